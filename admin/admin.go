@@ -0,0 +1,339 @@
+/*
+This package implements the admin API of EDIRO. It is the gRPC interface (see protobufferfile/admin.proto) through which
+an operator inspects and operates an edge node, e.g. with ediroctl, instead of reading its console output. It lists the
+edge nodes of the swarm with their load, dumps the IoT resource catalog and lists the client requests with the timings
+of their stages. It also injects IoT resources and client requests for testing, cancels client requests and triggers
+an anti-entropy sync of the catalog with the other edge nodes. It drains the edge nodes of the swarm taken down for
+maintenance and makes them active again. When the edge node has its credentials, the admin API is served over mutual
+TLS and only callers holding a certificate of the cluster CA are let in, e.g. a certificate issued by edirocert for the
+operator.
+
+*/
+
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientapi"
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+	"github.com/niketagrawal/EDIRO/taskinitiator"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+//API : The admin API of an edge node
+type API struct {
+	//Peers : addresses of the other edge nodes of the cluster
+	Peers []string
+	//Submit : hands a client request to the pipeline of the edge node
+	Submit func(ctx context.Context, s clientapi.Submission) error
+	//Cancel : withdraws a client from a request, or cancels it for all its clients when no client is given, and
+	//returns whether the request was cancelled
+	Cancel func(request string, client string) (bool, error)
+	//Offload : hands an IoT resource offloaded on an edge node to the resource manager
+	Offload func(ctx context.Context, resource resourcemanager.Newresource) error
+	//Sync : asks the other edge nodes for the IoT resources this edge node missed, returns the number added and the
+	//edge nodes that could not be reached
+	Sync func(ctx context.Context) (int, []string)
+	//Drain : starts the drain of an edge node of the swarm, Undrain : makes a drained edge node active again
+	Drain   func(d Drainorder) error
+	Undrain func(node string) error
+	//Usage : returns the bytes of IoT resources stored through this edge node on each edge node, nil if not tracked
+	Usage func() map[string]int64
+	//Quota : bytes of IoT resources each edge node keeps through this edge node, 0 for no limit
+	Quota int64
+	//Linearize : waits until the catalog applied every mutation committed before the call, nil if not supported
+	Linearize func(ctx context.Context) error
+	//Leader : returns the edge node leading the replicated log of the catalog, empty in the best-effort mode
+	Leader func() string
+
+	nodeID string
+	//credentials : certificate of this edge node and cluster CA, nil to serve the admin API in plain text
+	credentials *nodeidentity.Credentials
+	catalog     *resourcemanager.Catalog
+	records     *requestrecord.Store
+	runtime     *taskinitiator.Runtime
+	logger      *slog.Logger
+}
+
+//Drainorder : The drain of an edge node of the swarm taken down for maintenance
+type Drainorder struct {
+	Node string
+	//Replicate : copy the IoT resources held only by the edge node to the other edge nodes first
+	Replicate bool
+	//Migrate : move the workloads of the edge node to other edge nodes instead of letting them finish
+	Migrate bool
+	//Timeout : time given to the workloads to finish or move before the edge node departs, 0 for the default
+	Timeout time.Duration
+}
+
+//New : creates the admin API of an edge node inspecting the given catalog, request records and task initiator
+func New(nodeID string, creds *nodeidentity.Credentials, catalog *resourcemanager.Catalog,
+	records *requestrecord.Store, runtime *taskinitiator.Runtime, logger *slog.Logger) *API {
+	return &API{nodeID: nodeID, credentials: creds, catalog: catalog, records: records, runtime: runtime,
+		logger: logger}
+}
+
+/*
+Listenforadmins : This function runs the gRPC server of the admin API on the edge node. The server is stopped gracefully
+when ctx is cancelled.
+Input: context whose cancellation stops the server, listening address
+Output: Nil
+*/
+func (a *API) Listenforadmins(ctx context.Context, addr string) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		a.logger.Error("admin API could not listen", "addr", addr, "err", err)
+		return
+	}
+	var options []grpc.ServerOption
+	if a.credentials != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(a.credentials.Server())))
+	} else {
+		a.logger.Warn("no node certificate given, serving the admin API in plain text", "addr", addr)
+	}
+	s := grpc.NewServer(options...)
+	pb.RegisterAdminServer(s, &server{a: a})
+	go func() {
+		<-ctx.Done()
+		s.GracefulStop()
+	}()
+	a.logger.Info("launching admin API", "addr", lis.Addr())
+	if err := s.Serve(lis); err != nil {
+		a.logger.Error("admin API stopped", "err", err)
+		return
+	}
+	a.logger.Info("admin API stopped")
+}
+
+//server : the Admin service of an edge node
+type server struct {
+	a *API
+}
+
+//linearize : makes the reads of the catalog that follow linearizable if asked for
+func (s *server) linearize(ctx context.Context, asked bool) error {
+	if !asked || s.a.Linearize == nil {
+		return nil
+	}
+	if err := s.a.Linearize(ctx); err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return nil
+}
+
+func (s *server) Members(ctx context.Context, in *pb.MembersRequest) (*pb.MemberList, error) {
+	if err := s.linearize(ctx, in.Linearizable); err != nil {
+		return nil, err
+	}
+	members := map[string]*pb.Member{}
+	member := func(node string) *pb.Member {
+		if members[node] == nil {
+			members[node] = &pb.Member{Node: node, Capacity: int32(s.a.runtime.Nodecapacity)}
+		}
+		return members[node]
+	}
+	for node, resources := range s.a.catalog.Snapshot() {
+		m := member(node)
+		for _, resource := range resources {
+			if resource == resourcemanager.Used {
+				m.Used++
+			} else {
+				m.Resources++
+			}
+		}
+	}
+	for node, load := range s.a.runtime.Loads() {
+		m := member(node)
+		m.Running, m.Queued = int32(load.Running), int32(load.Queued)
+	}
+	states := s.a.catalog.States()
+	for node := range states {
+		member(node) //a departed edge node holds nothing anymore
+	}
+	if s.a.Usage != nil {
+		for node, stored := range s.a.Usage() {
+			member(node).Stored = stored
+		}
+	}
+	for node, m := range members {
+		m.State = resourcemanager.Active
+		if state, ok := states[node]; ok {
+			m.State = state
+		}
+		m.Quota = s.a.Quota
+	}
+
+	out := &pb.MemberList{ID: s.a.nodeID, Peers: s.a.Peers}
+	if s.a.Leader != nil {
+		out.Leader = s.a.Leader()
+	}
+	for _, m := range members {
+		out.Members = append(out.Members, m)
+	}
+	sort.Slice(out.Members, func(i, j int) bool { return out.Members[i].Node < out.Members[j].Node })
+	return out, nil
+}
+
+func (s *server) Catalog(ctx context.Context, in *pb.CatalogFilter) (*pb.CatalogEntries, error) {
+	if err := s.linearize(ctx, in.Linearizable); err != nil {
+		return nil, err
+	}
+	out := &pb.CatalogEntries{}
+	for _, e := range s.a.catalog.Entries() {
+		if (in.Node != "" && e.Node != in.Node) || !strings.HasPrefix(e.Resource, in.Resource) ||
+			(in.Type != "" && e.Descriptor.Type != in.Type) || (in.Available && !e.Available) {
+			continue
+		}
+		out.Entries = append(out.Entries, &pb.CatalogEntry{Resource: e.Resource, Node: e.Node, Available: e.Available,
+			Hash: e.Provenance.Hash, Contributor: e.Provenance.Contributor, Verified: e.Provenance.Verified,
+			Type: e.Descriptor.Type, Offloaded: nanos(e.Descriptor.Offloaded), Size: e.Descriptor.Size,
+			Origin: e.Provenance.Origin})
+	}
+	return out, nil
+}
+
+func (s *server) Requests(ctx context.Context, in *pb.RequestFilter) (*pb.RequestList, error) {
+	records := s.a.records.List()
+	sort.Slice(records, func(i, j int) bool { return records[i].Submitted.Before(records[j].Submitted) })
+	out := &pb.RequestList{}
+	for _, r := range records {
+		if (in.State != "" && r.State != in.State) || (in.Client != "" && !r.Has(in.Client)) {
+			continue
+		}
+		info := &pb.RequestInfo{Request: r.Request, State: r.State, Reason: r.Reason, Application: r.Application,
+			Node: r.Node, Service: r.Service, Priority: r.Priority, Clients: r.Clients, Submitted: nanos(r.Submitted),
+			Launched: nanos(r.Launched), Finished: nanos(r.Finished), Preemptions: int32(r.Preemptions),
+			Runs: int32(r.Runs)}
+		for _, stage := range r.Stages {
+			info.Stages = append(info.Stages, &pb.StageInfo{Name: stage.Name, Application: stage.Application,
+				Node: stage.Node, State: stage.State, Reason: stage.Reason, Launched: nanos(stage.Launched),
+				Finished: nanos(stage.Finished)})
+		}
+		out.Requests = append(out.Requests, info)
+	}
+	return out, nil
+}
+
+func (s *server) Offload(ctx context.Context, in *pb.OffloadRequest) (*pb.AdminReply, error) {
+	if in.Resource == "" || in.Node == "" {
+		return nil, status.Error(codes.InvalidArgument, "resource and node are required")
+	}
+	err := s.a.Offload(ctx, resourcemanager.Newresource{Resource: in.Resource, NodeID: in.Node, Hash: in.Hash,
+		Contributor: in.Contributor, Type: in.Type, Size: in.Size, Replicas: int(in.Replicas)})
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	s.a.logger.Info("IoT resource injected", logging.Resource, in.Resource, "holder", in.Node, "caller", caller(ctx))
+	return &pb.AdminReply{Detail: "offloaded"}, nil
+}
+
+func (s *server) Submit(ctx context.Context, in *pb.SubmitRequest) (*pb.AdminReply, error) {
+	if in.Request == "" {
+		return nil, status.Error(codes.InvalidArgument, "request is required")
+	}
+	if _, ok := library.Priorityrank[in.Priority]; in.Priority != "" && !ok {
+		return nil, status.Error(codes.InvalidArgument, "unknown priority class "+in.Priority)
+	}
+	for _, d := range []string{in.Within, in.Lifetime} {
+		if duration, err := time.ParseDuration(d); d != "" && (err != nil || duration <= 0) {
+			return nil, status.Error(codes.InvalidArgument, "invalid duration "+d)
+		}
+	}
+	err := s.a.Submit(ctx, clientapi.Submission{Request: in.Request, Priority: in.Priority, Within: in.Within,
+		Lifetime: in.Lifetime, Client: in.Client})
+	if rejection, ok := err.(*clientauth.Rejection); ok {
+		return nil, status.Error(codes.FailedPrecondition, rejection.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	s.a.logger.Info("client request injected", logging.Request, in.Request, "client", in.Client,
+		"caller", caller(ctx))
+	return &pb.AdminReply{Detail: requestrecord.Submitted}, nil
+}
+
+func (s *server) Cancel(ctx context.Context, in *pb.CancelRequest) (*pb.AdminReply, error) {
+	cancelled, err := s.a.Cancel(in.Request, in.Client)
+	switch err {
+	case nil:
+	case requestrecord.ErrUnknown:
+		return nil, status.Error(codes.NotFound, err.Error())
+	case requestrecord.ErrNotclient:
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.a.logger.Info("client request withdrawn by operator", logging.Request, in.Request, "client", in.Client,
+		"cancelled", cancelled, "caller", caller(ctx))
+	if !cancelled {
+		return &pb.AdminReply{Detail: clientapi.Detached}, nil
+	}
+	return &pb.AdminReply{Detail: requestrecord.Cancelled}, nil
+}
+
+func (s *server) Sync(ctx context.Context, in *pb.SyncRequest) (*pb.SyncReply, error) {
+	added, unreachable := s.a.Sync(ctx)
+	s.a.logger.Info("catalog synced by operator", "added", added, "unreachable", unreachable, "caller", caller(ctx))
+	return &pb.SyncReply{Added: int32(added), Unreachable: unreachable}, nil
+}
+
+func (s *server) Drain(ctx context.Context, in *pb.DrainRequest) (*pb.AdminReply, error) {
+	if in.Node == "" {
+		return nil, status.Error(codes.InvalidArgument, "node is required")
+	}
+	order := Drainorder{Node: in.Node, Replicate: in.Replicate, Migrate: in.Migrate}
+	if in.Timeout != "" {
+		timeout, err := time.ParseDuration(in.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid duration "+in.Timeout)
+		}
+		order.Timeout = timeout
+	}
+	if err := s.a.Drain(order); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.a.logger.Info("edge node drained by operator", "node", in.Node, "replicate", in.Replicate,
+		"migrate", in.Migrate, "caller", caller(ctx))
+	return &pb.AdminReply{Detail: resourcemanager.Draining}, nil
+}
+
+func (s *server) Undrain(ctx context.Context, in *pb.UndrainRequest) (*pb.AdminReply, error) {
+	if in.Node == "" {
+		return nil, status.Error(codes.InvalidArgument, "node is required")
+	}
+	if err := s.a.Undrain(in.Node); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.a.logger.Info("edge node made active by operator", "node", in.Node, "caller", caller(ctx))
+	return &pb.AdminReply{Detail: resourcemanager.Active}, nil
+}
+
+//caller : returns the identity of the operator calling the admin API, empty in plain text
+func caller(ctx context.Context) string {
+	identity, _ := nodeidentity.Caller(ctx)
+	return identity
+}
+
+//nanos : returns a time in unix nanoseconds, 0 for the zero time
+func nanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
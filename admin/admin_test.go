@@ -0,0 +1,289 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientapi"
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/metrics"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+	"github.com/niketagrawal/EDIRO/resultstore"
+	"github.com/niketagrawal/EDIRO/taskinitiator"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//newserver : returns the Admin service of the edge node n1 whose catalog holds a on n1 and n2 and b on n2, b being used
+func newserver() *server {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	catalog, records := resourcemanager.NewCatalog(logger), requestrecord.New()
+	catalog.Add("a", "n1", resourcemanager.Provenance{Hash: "h"})
+	catalog.Add("a", "n2", resourcemanager.Provenance{Hash: "h"})
+	catalog.Add("b", "n2", resourcemanager.Provenance{})
+	catalog.Describe("b", "n2", resourcemanager.Descriptor{Type: "hd_map"})
+	catalog.Claim("b", "")
+	runtime := taskinitiator.New("", 2, catalog, records, resultstore.New(), metrics.New(logger), logger)
+	return &server{a: New("n1", nil, catalog, records, runtime, logger)}
+}
+
+func TestMembers(t *testing.T) {
+	s := newserver()
+	s.a.Peers = []string{"n2:5001"}
+	s.a.catalog.Setstate("n3", resourcemanager.Departed)
+	s.a.Usage = func() map[string]int64 { return map[string]int64{"n1": 10} }
+	s.a.Quota = 100
+	out, err := s.Members(context.Background(), &pb.MembersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*pb.Member{{Node: "n1", Resources: 1, Capacity: 2, State: resourcemanager.Active, Stored: 10,
+		Quota: 100}, {Node: "n2", Resources: 1, Used: 1, Capacity: 2, State: resourcemanager.Active, Quota: 100},
+		{Node: "n3", Capacity: 2, State: resourcemanager.Departed, Quota: 100}}
+	if out.ID != "n1" || !reflect.DeepEqual(out.Peers, s.a.Peers) || len(out.Members) != len(want) {
+		t.Fatalf("members %v, want %v", out, want)
+	}
+	for i, m := range out.Members {
+		if m.Node != want[i].Node || m.Resources != want[i].Resources || m.Used != want[i].Used ||
+			m.Capacity != want[i].Capacity || m.State != want[i].State || m.Stored != want[i].Stored ||
+			m.Quota != want[i].Quota {
+			t.Errorf("member %v, want %v", m, want[i])
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *pb.CatalogFilter
+		//want : entries returned as resource@node
+		want []string
+	}{
+		{name: "all entries", filter: &pb.CatalogFilter{}, want: []string{"a@n1", "a@n2", "b@n2"}},
+		{name: "edge node", filter: &pb.CatalogFilter{Node: "n1"}, want: []string{"a@n1"}},
+		{name: "resource prefix", filter: &pb.CatalogFilter{Resource: "b"}, want: []string{"b@n2"}},
+		{name: "type", filter: &pb.CatalogFilter{Type: "hd_map"}, want: []string{"b@n2"}},
+		{name: "available", filter: &pb.CatalogFilter{Available: true}, want: []string{"a@n1", "a@n2"}},
+		{name: "no match", filter: &pb.CatalogFilter{Node: "n3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := newserver().Catalog(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range out.Entries {
+				got = append(got, e.Resource+"@"+e.Node)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequests(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		filter *pb.RequestFilter
+		want   []string
+	}{
+		{name: "all requests, the oldest first", filter: &pb.RequestFilter{}, want: []string{"r2", "r1"}},
+		{name: "state", filter: &pb.RequestFilter{State: requestrecord.Launched}, want: []string{"r1"}},
+		{name: "client", filter: &pb.RequestFilter{Client: "b"}, want: []string{"r2"}},
+		{name: "no match", filter: &pb.RequestFilter{Client: "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newserver()
+			s.a.records.Add(requestrecord.Record{Request: "r1", Clients: []string{"a"}, State: requestrecord.Launched,
+				Submitted: now})
+			s.a.records.Add(requestrecord.Record{Request: "r2", Clients: []string{"b"},
+				State: requestrecord.Completed, Submitted: now.Add(-time.Minute)})
+			out, err := s.Requests(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range out.Requests {
+				got = append(got, r.Request)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requests %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOffload(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          *pb.OffloadRequest
+		offloaderr  error
+		wantcode    codes.Code
+		wantoffload bool
+	}{
+		{name: "injected", in: &pb.OffloadRequest{Resource: "c", Node: "n1"}, wantcode: codes.OK, wantoffload: true},
+		{name: "no holder", in: &pb.OffloadRequest{Resource: "c"}, wantcode: codes.InvalidArgument},
+		{name: "resource manager stopping", in: &pb.OffloadRequest{Resource: "c", Node: "n1"},
+			offloaderr: errors.New("stopping"), wantcode: codes.Unavailable, wantoffload: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newserver()
+			offloaded := false
+			s.a.Offload = func(ctx context.Context, resource resourcemanager.Newresource) error {
+				offloaded = resource.Resource == tt.in.Resource && resource.NodeID == tt.in.Node
+				return tt.offloaderr
+			}
+			_, err := s.Offload(context.Background(), tt.in)
+			if status.Code(err) != tt.wantcode || offloaded != tt.wantoffload {
+				t.Errorf("Offload = %v, offloaded %v, want %v, %v", err, offloaded, tt.wantcode, tt.wantoffload)
+			}
+		})
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name          string
+		in            *pb.SubmitRequest
+		submiterr     error
+		wantcode      codes.Code
+		wantsubmitted bool
+	}{
+		{name: "injected", in: &pb.SubmitRequest{Request: "r", Client: "a", Lifetime: "10m"}, wantcode: codes.OK,
+			wantsubmitted: true},
+		{name: "no request", in: &pb.SubmitRequest{}, wantcode: codes.InvalidArgument},
+		{name: "unknown priority class", in: &pb.SubmitRequest{Request: "r", Priority: "urgent"},
+			wantcode: codes.InvalidArgument},
+		{name: "invalid duration", in: &pb.SubmitRequest{Request: "r", Within: "soon"},
+			wantcode: codes.InvalidArgument},
+		{name: "rejected", in: &pb.SubmitRequest{Request: "r"},
+			submiterr: &clientauth.Rejection{Reason: clientauth.Concurrencylimit}, wantcode: codes.FailedPrecondition,
+			wantsubmitted: true},
+		{name: "pipeline stopping", in: &pb.SubmitRequest{Request: "r"}, submiterr: errors.New("stopping"),
+			wantcode: codes.Unavailable, wantsubmitted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newserver()
+			submitted := false
+			s.a.Submit = func(ctx context.Context, submission clientapi.Submission) error {
+				submitted = submission.Request == tt.in.Request && submission.Client == tt.in.Client &&
+					submission.Lifetime == tt.in.Lifetime
+				return tt.submiterr
+			}
+			_, err := s.Submit(context.Background(), tt.in)
+			if status.Code(err) != tt.wantcode || submitted != tt.wantsubmitted {
+				t.Errorf("Submit = %v, submitted %v, want %v, %v", err, submitted, tt.wantcode, tt.wantsubmitted)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         *pb.CancelRequest
+		wantcode   codes.Code
+		wantdetail string
+	}{
+		{name: "cancelled for all clients", in: &pb.CancelRequest{Request: "r1"}, wantcode: codes.OK,
+			wantdetail: requestrecord.Cancelled},
+		{name: "client detached", in: &pb.CancelRequest{Request: "r1", Client: "a"}, wantcode: codes.OK,
+			wantdetail: clientapi.Detached},
+		{name: "unknown request", in: &pb.CancelRequest{Request: "r3"}, wantcode: codes.NotFound},
+		{name: "request of another client", in: &pb.CancelRequest{Request: "r1", Client: "c"},
+			wantcode: codes.PermissionDenied},
+		{name: "request finished", in: &pb.CancelRequest{Request: "r2"}, wantcode: codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newserver()
+			s.a.Cancel = func(request string, client string) (bool, error) {
+				if client == "" {
+					return true, s.a.records.Abort(request)
+				}
+				return s.a.records.Cancel(request, client)
+			}
+			s.a.records.Add(requestrecord.Record{Request: "r1", Clients: []string{"a", "b"},
+				State: requestrecord.Launched})
+			s.a.records.Add(requestrecord.Record{Request: "r2", State: requestrecord.Completed})
+			out, err := s.Cancel(context.Background(), tt.in)
+			if status.Code(err) != tt.wantcode || out.GetDetail() != tt.wantdetail {
+				t.Errorf("Cancel = %v, %v, want %v, %q", out, err, tt.wantcode, tt.wantdetail)
+			}
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        *pb.DrainRequest
+		drainerr  error
+		wantcode  codes.Code
+		wantorder *Drainorder
+	}{
+		{name: "drain", in: &pb.DrainRequest{Node: "n2", Replicate: true, Timeout: "1m"}, wantcode: codes.OK,
+			wantorder: &Drainorder{Node: "n2", Replicate: true, Timeout: time.Minute}},
+		{name: "default timeout", in: &pb.DrainRequest{Node: "n2", Migrate: true}, wantcode: codes.OK,
+			wantorder: &Drainorder{Node: "n2", Migrate: true}},
+		{name: "no node", in: &pb.DrainRequest{}, wantcode: codes.InvalidArgument},
+		{name: "invalid timeout", in: &pb.DrainRequest{Node: "n2", Timeout: "-1m"}, wantcode: codes.InvalidArgument},
+		{name: "already draining", in: &pb.DrainRequest{Node: "n2"}, drainerr: errors.New("already draining"),
+			wantcode: codes.FailedPrecondition, wantorder: &Drainorder{Node: "n2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newserver()
+			var order *Drainorder
+			s.a.Drain = func(d Drainorder) error {
+				order = &d
+				return tt.drainerr
+			}
+			if _, err := s.Drain(context.Background(), tt.in); status.Code(err) != tt.wantcode {
+				t.Errorf("Drain = %v, want %v", err, tt.wantcode)
+			}
+			if !reflect.DeepEqual(order, tt.wantorder) {
+				t.Errorf("drain %+v, want %+v", order, tt.wantorder)
+			}
+		})
+	}
+}
+
+func TestUndrain(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         *pb.UndrainRequest
+		undrainerr error
+		wantcode   codes.Code
+	}{
+		{name: "made active", in: &pb.UndrainRequest{Node: "n2"}, wantcode: codes.OK},
+		{name: "no node", in: &pb.UndrainRequest{}, wantcode: codes.InvalidArgument},
+		{name: "not drained", in: &pb.UndrainRequest{Node: "n2"}, undrainerr: errors.New("not drained"),
+			wantcode: codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newserver()
+			s.a.Undrain = func(node string) error {
+				return tt.undrainerr
+			}
+			if _, err := s.Undrain(context.Background(), tt.in); status.Code(err) != tt.wantcode {
+				t.Errorf("Undrain = %v, want %v", err, tt.wantcode)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+/*
+This package implements the admission control of EDIRO. A client request may carry a deadline by which its workload
+must have finished. Before the request enters the pipeline, the admission controller estimates when its workload would
+finish on each edge node holding the IoT resource it needs, from the number of workloads that would be served before it
+on that node (running and queued), the capacity of the node and the runtime of its application predicted for that node
+(see package predictor). The 90th percentile of the runtime is used so that a request is only admitted when it is
+likely to finish in time. The request is steered to the edge node finishing it first, and rejected right away when no
+edge node can finish it in time. Requests without a deadline, and requests whose application has no history yet, are
+admitted without estimate.
+
+*/
+
+package admission
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/predictor"
+)
+
+//Reasons for which a request is rejected or dropped because of its deadline
+const (
+	Deadlineunachievable = "deadline_unachievable"
+	Deadlinemissed       = "deadline_missed"
+)
+
+//Decision : The outcome of the admission of a request with a deadline
+type Decision struct {
+	//Node : edge node expected to finish the request first, empty if no estimate could be made
+	Node string
+	//Estimate : expected time until the workload of the request finishes on that node
+	Estimate time.Duration
+}
+
+//Controller : The admission controller of an edge node
+type Controller struct {
+	//Nodecapacity : number of workloads an edge node runs at the same time, 0 for no limit
+	Nodecapacity int
+
+	//holders : returns the edge nodes holding an available IoT resource
+	holders func(resource string) []string
+	//ahead : returns the number of workloads served before a request of a given priority rank on an edge node
+	ahead func(node string, rank int) int
+	//predictor : predicts the runtime of an application on an edge node
+	predictor *predictor.Predictor
+	logger    *slog.Logger
+}
+
+//New : creates the admission controller of an edge node, looking up the holders of a resource, the load of a node and
+//the predicted runtime of an application
+func New(nodecapacity int, holders func(resource string) []string, ahead func(node string, rank int) int,
+	p *predictor.Predictor, logger *slog.Logger) *Controller {
+	return &Controller{Nodecapacity: nodecapacity, holders: holders, ahead: ahead, predictor: p, logger: logger}
+}
+
+/*
+Estimate : Estimates on which edge node the workload of a request would finish first and when.
+Input: client request, its priority class
+Output: the decision, whether an estimate could be made
+*/
+func (c *Controller) Estimate(request string, priority string) (Decision, bool) {
+	application := library.RequesttoApp[request]
+	rank := library.Priorityrank[library.Priorityof(request, priority)]
+	var best Decision
+	found := false
+	for _, node := range c.holders(library.ApptoResource[application]) {
+		prediction, known := c.predictor.Predict(application, node, 0)
+		if !known {
+			continue
+		}
+		runtime := prediction.P90
+		estimate := runtime
+		if c.Nodecapacity > 0 {
+			//the workloads ahead are served in waves of the capacity of the node
+			if waiting := c.ahead(node, rank) - c.Nodecapacity + 1; waiting > 0 {
+				estimate += time.Duration((waiting+c.Nodecapacity-1)/c.Nodecapacity) * runtime
+			}
+		}
+		if !found || estimate < best.Estimate {
+			best, found = Decision{Node: node, Estimate: estimate}, true
+		}
+	}
+	return best, found
+}
+
+/*
+Admit : Decides whether a request can finish by its deadline.
+Input: client request, its priority class, its deadline, zero for none
+Output: the edge node to steer the request to, empty to leave the choice to resource discovery, a rejection if no edge
+node can finish the request in time
+*/
+func (c *Controller) Admit(request string, priority string, deadline time.Time) (Decision, error) {
+	if deadline.IsZero() {
+		return Decision{}, nil
+	}
+	if !time.Now().Before(deadline) {
+		return Decision{}, &clientauth.Rejection{Reason: Deadlinemissed, Err: fmt.Errorf("deadline %s has passed",
+			deadline.Format(time.RFC3339))}
+	}
+	decision, ok := c.Estimate(request, priority)
+	if !ok {
+		return Decision{}, nil
+	}
+	if finish := time.Now().Add(decision.Estimate); finish.After(deadline) {
+		c.logger.Info("rejecting request that cannot meet its deadline", logging.Request, request,
+			"estimate", decision.Estimate, "node", decision.Node, "deadline", deadline)
+		return Decision{}, &clientauth.Rejection{Reason: Deadlineunachievable,
+			Err: fmt.Errorf("expected to finish in %s at best, after the deadline %s",
+				decision.Estimate.Round(time.Millisecond), deadline.Format(time.RFC3339))}
+	}
+	return decision, nil
+}
@@ -0,0 +1,137 @@
+package admission
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/predictor"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+)
+
+func TestEstimate(t *testing.T) {
+	const request = "client_request_1"
+	application := library.RequesttoApp[request]
+	tests := []struct {
+		name     string
+		capacity int
+		runtimes map[string]time.Duration //observed runtime of the application by edge node
+		ahead    map[string]int
+		holders  []string
+		want     Decision
+		wantok   bool
+	}{
+		{
+			name:    "no history",
+			holders: []string{"n1"},
+		},
+		{
+			name:     "no capacity limit",
+			capacity: 0,
+			runtimes: map[string]time.Duration{"n1": 10 * time.Second},
+			ahead:    map[string]int{"n1": 5},
+			holders:  []string{"n1"},
+			want:     Decision{Node: "n1", Estimate: 10 * time.Second}, wantok: true,
+		},
+		{
+			name:     "room on the edge node",
+			capacity: 2,
+			runtimes: map[string]time.Duration{"n1": 10 * time.Second},
+			ahead:    map[string]int{"n1": 1},
+			holders:  []string{"n1"},
+			want:     Decision{Node: "n1", Estimate: 10 * time.Second}, wantok: true,
+		},
+		{
+			name:     "one wave ahead",
+			capacity: 2,
+			runtimes: map[string]time.Duration{"n1": 10 * time.Second},
+			ahead:    map[string]int{"n1": 2},
+			holders:  []string{"n1"},
+			want:     Decision{Node: "n1", Estimate: 20 * time.Second}, wantok: true,
+		},
+		{
+			name:     "waves ahead",
+			capacity: 2,
+			runtimes: map[string]time.Duration{"n1": 10 * time.Second},
+			ahead:    map[string]int{"n1": 5},
+			holders:  []string{"n1"},
+			want:     Decision{Node: "n1", Estimate: 30 * time.Second}, wantok: true,
+		},
+		{
+			name:     "edge node finishing first",
+			capacity: 1,
+			runtimes: map[string]time.Duration{"n1": 10 * time.Second, "n2": 20 * time.Second},
+			ahead:    map[string]int{"n1": 3},
+			holders:  []string{"n1", "n2"},
+			want:     Decision{Node: "n2", Estimate: 20 * time.Second}, wantok: true,
+		},
+		{
+			name:     "resource held by no edge node",
+			runtimes: map[string]time.Duration{"n1": 10 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			p := predictor.New(logger)
+			launched := time.Now()
+			for node, runtime := range tt.runtimes {
+				p.Observe(requestrecord.Record{Application: application, Node: node,
+					State: requestrecord.Completed, Launched: launched, Finished: launched.Add(runtime)})
+			}
+			c := New(tt.capacity, func(string) []string { return tt.holders },
+				func(node string, rank int) int { return tt.ahead[node] }, p, logger)
+			got, ok := c.Estimate(request, "")
+			if ok != tt.wantok || got != tt.want {
+				t.Errorf("Estimate = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantok)
+			}
+		})
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	const request = "client_request_1"
+	tests := []struct {
+		name       string
+		runtime    time.Duration //observed runtime of the application on n1, no history if zero
+		deadline   time.Duration //deadline after now, none if zero
+		wantnode   string
+		wantreason string
+	}{
+		{name: "no deadline", runtime: time.Hour},
+		{name: "deadline passed", deadline: -time.Second, wantreason: Deadlinemissed},
+		{name: "no history", deadline: time.Second},
+		{name: "deadline met", runtime: time.Second, deadline: time.Minute, wantnode: "n1"},
+		{name: "deadline cannot be met", runtime: time.Hour, deadline: time.Minute, wantreason: Deadlineunachievable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			p := predictor.New(logger)
+			if tt.runtime != 0 {
+				launched := time.Now()
+				p.Observe(requestrecord.Record{Application: library.RequesttoApp[request], Node: "n1",
+					State: requestrecord.Completed, Launched: launched, Finished: launched.Add(tt.runtime)})
+			}
+			c := New(0, func(string) []string { return []string{"n1"} }, func(string, int) int { return 0 }, p, logger)
+			var deadline time.Time
+			if tt.deadline != 0 {
+				deadline = time.Now().Add(tt.deadline)
+			}
+			decision, err := c.Admit(request, "", deadline)
+			reason := ""
+			var rejection *clientauth.Rejection
+			if errors.As(err, &rejection) {
+				reason = rejection.Reason
+			}
+			if decision.Node != tt.wantnode || reason != tt.wantreason {
+				t.Errorf("Admit = %+v, %v, want the node %q, rejected for %q", decision, err, tt.wantnode,
+					tt.wantreason)
+			}
+		})
+	}
+}
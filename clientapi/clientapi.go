@@ -0,0 +1,599 @@
+/*
+This package implements the client API of EDIRO. It is the HTTP interface through which clients submit requests to the
+edge node and fetch the results of their requests, and through which the workloads launched for a request hand their
+result back to EDIRO. It exposes the following endpoints:
+1. POST /requests : submits a client request, the body is of the form {"request": "client_request_1"} with an optional
+priority class, e.g. {"request": "client_request_1", "priority": "critical"}, and an optional deadline by which it
+must have finished, either absolute ("deadline": "2024-05-01T10:00:00Z") or relative ("within": "30s"). A vehicle
+may announce the edge nodes it will reach next ("route": [...]) for its IoT resource to be prefetched there, and ask
+for its workload to run on the first of them ("prelaunch": true), see package prefetch. Instead of the IoT resource of
+the request in the library, the workload can be given the IoT resources of a type overlapping an area and fresher than
+a given age, e.g. "where": {"type": "hd_map", "area": {"minlat": 48.1, "minlon": 11.5, "maxlat": 48.2, "maxlon": 11.6},
+"maxage": "30s"}. A submission with a lifetime ("lifetime": "10m") is a subscription whose workload delivers results
+until the lifetime ends, see package subscription.
+2. GET /results/<request> : returns the result of a request. With the query parameter 'wait' (e.g. ?wait=30s) the call
+blocks until the result is pushed or the wait time expires.
+3. POST /callback/<request>?token=<token> : called by a workload to deliver its result, the body is stored as the
+result. The token is the one handed to the workload in its callback URL (see package taskinitiator).
+4. POST /sessions : attaches the client to the edge node, e.g. when a vehicle reconnects at another edge node. Its
+session is handed over from the edge node that served it before (see package session). GET /sessions returns the
+session of the client. Both return the session along with the status of the requests of the client.
+5. GET /subscriptions/<request> : pushes every result of a subscription as a server-sent event until the subscription
+ends or the client goes away.
+6. DELETE /requests/<request> : withdraws the client from its request. A request shared with other clients keeps running
+for them, otherwise it is cancelled wherever it is in the pipeline and its workload is stopped.
+When the edge node has a list of clients, submissions must be authenticated and are subject to the quotas of the client
+(see package clientauth), and a client only reads the results and the subscriptions of the requests it submitted.
+Without a list of clients, a client may name itself with the header X-Client-ID to keep a session and may ask for no
+priority class above normal. A submission whose deadline cannot be met is rejected as well (see package admission). A
+rejected submission is answered with the reason of the rejection.
+
+*/
+
+package clientapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resultstore"
+	"github.com/niketagrawal/EDIRO/session"
+)
+
+//Clientidheader : header naming an anonymous client, used when the edge node has no list of clients
+const Clientidheader = "X-Client-ID"
+
+//maxresultsize : upper bound on the size of a result delivered through a callback
+const maxresultsize = 16 << 20
+
+//Submission : body of a request submission
+type Submission struct {
+	Request string `json:"request"`
+	//Priority : priority class asked for by the client, the class of the request in the library if empty
+	Priority string `json:"priority,omitempty"`
+	//Deadline : time by which the workload of the request must have finished, zero for no deadline
+	Deadline time.Time `json:"deadline,omitempty"`
+	//Within : deadline relative to the submission, e.g. 30s, the earlier of both applies when both are given
+	Within string `json:"within,omitempty"`
+	//Route : edge nodes the vehicle will reach next, in order, named as in the resource catalog
+	Route []string `json:"route,omitempty"`
+	//Prelaunch : run the workload on the first edge node of the route once the IoT resource is prefetched there
+	Prelaunch bool `json:"prelaunch,omitempty"`
+	//Where : location query selecting the IoT resources of the workload, nil for the resource of the request
+	Where *Locationquery `json:"where,omitempty"`
+	//Lifetime : lifetime of a subscription, e.g. 10m, empty for a request served once
+	Lifetime string `json:"lifetime,omitempty"`
+	//Client : client that submitted the request, empty for an anonymous client
+	Client string `json:"-"`
+}
+
+//Locationquery : The IoT resources of a type overlapping an area and fresher than a given age
+type Locationquery struct {
+	Type string  `json:"type"`
+	Area geo.Box `json:"area"`
+	//Maxage : e.g. 30s, empty for any age
+	Maxage string `json:"maxage,omitempty"`
+}
+
+//Query : returns the location query in the form resolved by the catalog
+func (l Locationquery) Query() geo.Query {
+	maxage, _ := time.ParseDuration(l.Maxage)
+	return geo.Query{Type: l.Type, Area: l.Area, Maxage: maxage}
+}
+
+//validate : returns an error if the location query is invalid
+func (l Locationquery) validate() error {
+	if l.Type == "" && l.Area.Empty() {
+		return errors.New("location query needs a type or an area")
+	}
+	if !l.Area.Empty() {
+		if err := l.Area.Validate(); err != nil {
+			return err
+		}
+	}
+	if maxage, err := time.ParseDuration(l.Maxage); l.Maxage != "" && (err != nil || maxage <= 0) {
+		return errors.New("invalid maxage " + l.Maxage)
+	}
+	return nil
+}
+
+//Due : returns the deadline of a submission made at a given time, zero for no deadline
+func (s Submission) Due(now time.Time) time.Time {
+	deadline := s.Deadline
+	if within, err := time.ParseDuration(s.Within); err == nil {
+		if due := now.Add(within); deadline.IsZero() || due.Before(deadline) {
+			deadline = due
+		}
+	}
+	return deadline
+}
+
+//Expiry : returns the end of the lifetime of a subscription made at a given time, zero for a request served once
+func (s Submission) Expiry(now time.Time) time.Time {
+	lifetime, err := time.ParseDuration(s.Lifetime)
+	if err != nil || lifetime <= 0 {
+		return time.Time{}
+	}
+	return now.Add(lifetime)
+}
+
+//Status : returned to the client as long as the result of its request is not available, or when it is rejected
+type Status struct {
+	Request string `json:"request"`
+	State   string `json:"state"`
+	Reason  string `json:"reason,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+	//Stages : state of each stage of the pipeline serving the request, if it has one
+	Stages []requestrecord.Stage `json:"stages,omitempty"`
+}
+
+//Rejected : state returned for a rejected submission, Unavailable : reason given when the edge node cannot take requests
+//Detached : state returned to a client withdrawn from a request that other clients share
+const (
+	Rejected    = "rejected"
+	Unavailable = "unavailable"
+	Detached    = "detached"
+)
+
+//API : The client API of an edge node
+type API struct {
+	//Submit : hands a client request submitted by a client to the pipeline of the edge node
+	Submit func(ctx context.Context, s Submission) error
+	//Sessions : the sessions of the clients attached to the edge node, nil to keep no sessions
+	Sessions *session.Manager
+	//Cancel : withdraws a client from a request and returns whether the request was cancelled, nil to refuse
+	//cancellations
+	Cancel func(request string, client string) (bool, error)
+
+	//auth : the clients allowed to submit requests, nil to accept anonymous submissions
+	auth *clientauth.Authenticator
+	//admission : makes the check of the quotas of a client and the count of its requests being submitted one step
+	admission sync.Mutex
+	//submitting : the requests of each client admitted and being submitted, keyed by client then request
+	submitting map[string]map[string]int
+	records    *requestrecord.Store
+	results    *resultstore.Store
+	logger     *slog.Logger
+}
+
+//New : creates the client API of an edge node serving the results and the request records of the given stores
+func New(submit func(ctx context.Context, s Submission) error, auth *clientauth.Authenticator,
+	records *requestrecord.Store, results *resultstore.Store, logger *slog.Logger) *API {
+	if auth == nil {
+		logger.Warn("no clients configured, accepting anonymous submissions without quotas up to the priority normal")
+	}
+	return &API{Submit: submit, auth: auth, submitting: map[string]map[string]int{}, records: records, results: results,
+		logger: logger}
+}
+
+/*
+Listenforclients : This function starts the HTTP server of the client API on the edge node.
+Input: context whose cancellation shuts the server down, listening address
+Output: Nil
+*/
+func (a *API) Listenforclients(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/requests", a.submitrequest)
+	mux.HandleFunc("/requests/", a.cancelrequest)
+	mux.HandleFunc("/results/", a.getresult)
+	mux.HandleFunc("/callback/", a.storeresult)
+	mux.HandleFunc("/sessions", a.attach)
+	mux.HandleFunc("/subscriptions/", a.stream)
+
+	a.logger.Info("launching client API", "addr", addr)
+	a.serve(ctx, &http.Server{Addr: addr, Handler: mux})
+}
+
+func (a *API) submitrequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var s Submission
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil || s.Request == "" {
+		http.Error(w, "body must be of the form {\"request\": \"<client request>\"}", http.StatusBadRequest)
+		return
+	}
+	if _, ok := library.Priorityrank[s.Priority]; s.Priority != "" && !ok {
+		http.Error(w, "unknown priority class "+s.Priority, http.StatusBadRequest)
+		return
+	}
+	if within, err := time.ParseDuration(s.Within); s.Within != "" && (err != nil || within <= 0) {
+		http.Error(w, "invalid within duration "+s.Within, http.StatusBadRequest)
+		return
+	}
+	if lifetime, err := time.ParseDuration(s.Lifetime); s.Lifetime != "" && (err != nil || lifetime <= 0) {
+		http.Error(w, "invalid lifetime "+s.Lifetime, http.StatusBadRequest)
+		return
+	}
+	if _, ok := library.RequesttoStages[s.Request]; ok && s.Lifetime != "" {
+		http.Error(w, "a request served by a pipeline cannot be subscribed to", http.StatusBadRequest)
+		return
+	}
+	if s.Where != nil {
+		if err := s.Where.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	client, err := a.identify(r)
+	if err != nil {
+		a.reject(w, r, s.Request, "", http.StatusUnauthorized, err)
+		return
+	}
+
+	//an anonymous client is held to the priority cap of a client without one
+	a.admission.Lock()
+	if a.auth != nil {
+		err = a.auth.Admit(client, a.active(client.ID), s.Priority)
+	} else {
+		err = client.Allows(s.Priority)
+	}
+	if err != nil {
+		a.admission.Unlock()
+		a.reject(w, r, s.Request, client.ID, http.StatusTooManyRequests, err)
+		return
+	}
+	if a.submitting[client.ID] == nil {
+		a.submitting[client.ID] = map[string]int{}
+	}
+	a.submitting[client.ID][s.Request]++
+	a.admission.Unlock()
+	s.Client = client.ID
+	err = a.Submit(r.Context(), s)
+	a.admission.Lock()
+	if a.submitting[client.ID][s.Request]--; a.submitting[client.ID][s.Request] == 0 {
+		delete(a.submitting[client.ID], s.Request)
+		if len(a.submitting[client.ID]) == 0 {
+			delete(a.submitting, client.ID)
+		}
+	}
+	a.admission.Unlock()
+	if err != nil {
+		if _, ok := err.(*clientauth.Rejection); !ok {
+			err = &clientauth.Rejection{Reason: Unavailable, Err: err}
+		}
+		a.reject(w, r, s.Request, client.ID, http.StatusServiceUnavailable, err)
+		return
+	}
+	a.logger.Info("client request submitted", logging.Request, s.Request, "client", client.ID, "priority", s.Priority,
+		"remote", r.RemoteAddr)
+	if a.Sessions != nil && client.ID != "" {
+		//the session is handed over in the background, the submission does not wait for the other edge nodes
+		go a.Sessions.Attach(context.Background(), client.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(Status{Request: s.Request, State: "submitted"})
+}
+
+func (a *API) cancelrequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.Cancel == nil {
+		http.Error(w, "requests cannot be cancelled on this edge node", http.StatusNotImplemented)
+		return
+	}
+	request := strings.TrimPrefix(r.URL.Path, "/requests/")
+	client, err := a.identify(r)
+	if err != nil {
+		a.reject(w, r, request, "", http.StatusUnauthorized, err)
+		return
+	}
+
+	cancelled, err := a.Cancel(request, client.ID)
+	switch err {
+	case nil:
+	case requestrecord.ErrUnknown:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case requestrecord.ErrNotclient:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	status := Status{Request: request, State: requestrecord.Cancelled}
+	if !cancelled {
+		status.State, status.Detail = Detached, "the request is shared with other clients and keeps running"
+	}
+	a.logger.Info("client withdrew request", logging.Request, request, "client", client.ID, "state", status.State,
+		"remote", r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+//identify : returns the client behind an HTTP request, an anonymous client has an empty ID unless it names itself
+func (a *API) identify(r *http.Request) (clientauth.Client, error) {
+	if a.auth != nil {
+		return a.auth.Authenticate(r)
+	}
+	return clientauth.Client{ID: r.Header.Get(Clientidheader)}, nil
+}
+
+//active : returns the number of active requests of a client, counting the requests being submitted that are not recorded
+//yet. admission must be held.
+func (a *API) active(client string) int {
+	n := a.records.Active(client)
+	for request := range a.submitting[client] {
+		if record, ok := a.records.Get(request); !ok || !record.Active() || !record.Has(client) {
+			n++
+		}
+	}
+	return n
+}
+
+//owns : checks that the client behind an HTTP request may read a request, i.e. is one of the clients that submitted it
+//when the edge node has a list of clients, and answers the call otherwise
+func (a *API) owns(w http.ResponseWriter, r *http.Request, request string) bool {
+	client, err := a.identify(r)
+	if err != nil {
+		a.reject(w, r, request, "", http.StatusUnauthorized, err)
+		return false
+	}
+	if a.auth == nil {
+		return true
+	}
+	record, known := a.records.Get(request)
+	if !known {
+		http.Error(w, "unknown request", http.StatusNotFound)
+		return false
+	}
+	if !record.Has(client.ID) {
+		a.logger.Warn("client denied request of another client", logging.Request, request, "client", client.ID,
+			"remote", r.RemoteAddr)
+		http.Error(w, requestrecord.ErrNotclient.Error(), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+//Sessionstatus : The session of a client and the status of its requests
+type Sessionstatus struct {
+	session.Session
+	Requests []Status `json:"requests"`
+}
+
+func (a *API) attach(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.Sessions == nil {
+		http.Error(w, "sessions are not kept by this edge node", http.StatusNotImplemented)
+		return
+	}
+	client, err := a.identify(r)
+	if err == nil && client.ID == "" {
+		err = &clientauth.Rejection{Reason: clientauth.Unauthenticated, Err: errors.New("no client ID given")}
+	}
+	if err != nil {
+		a.reject(w, r, "", "", http.StatusUnauthorized, err)
+		return
+	}
+
+	var status Sessionstatus
+	if r.Method == http.MethodPost {
+		status.Session = a.Sessions.Attach(r.Context(), client.ID)
+		a.logger.Info("client attached", "client", client.ID, "previous", status.Previous, "remote", r.RemoteAddr)
+	} else {
+		var known bool
+		if status.Session, known = a.Sessions.Get(client.ID); !known {
+			http.Error(w, "no session for client "+client.ID, http.StatusNotFound)
+			return
+		}
+	}
+	status.Requests = []Status{}
+	for _, record := range a.records.List() {
+		if record.Has(client.ID) {
+			status.Requests = append(status.Requests, Status{Request: record.Request, State: record.State,
+				Reason: record.Reason, Stages: record.Stages})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+//reject : answers a rejected submission with the reason of the rejection
+func (a *API) reject(w http.ResponseWriter, r *http.Request, request string, client string, code int, err error) {
+	status := Status{Request: request, State: Rejected, Detail: err.Error()}
+	if rejection, ok := err.(*clientauth.Rejection); ok {
+		status.Reason = rejection.Reason
+		if rejection.Err != nil {
+			status.Detail = rejection.Err.Error()
+		}
+	}
+	a.logger.Warn("client request rejected", logging.Request, request, "client", client, "reason", status.Reason,
+		"detail", status.Detail, "remote", r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+func (a *API) getresult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	request := strings.TrimPrefix(r.URL.Path, "/results/")
+	if !a.owns(w, r, request) {
+		return
+	}
+
+	result, ok := a.results.Get(request)
+	if !ok && r.URL.Query().Get("wait") != "" {
+		wait, err := time.ParseDuration(r.URL.Query().Get("wait"))
+		if err != nil {
+			http.Error(w, "invalid wait duration", http.StatusBadRequest)
+			return
+		}
+		ch := a.results.Subscribe(request)
+		select {
+		case result, ok = <-ch:
+		case <-time.After(wait):
+			a.results.Unsubscribe(request, ch)
+		case <-r.Context().Done():
+			a.results.Unsubscribe(request, ch)
+			return
+		}
+	}
+	if ok {
+		if result.ContentType != "" {
+			w.Header().Set("Content-Type", result.ContentType)
+		}
+		w.Write(result.Data)
+		return
+	}
+
+	record, known := a.records.Get(request)
+	if !known {
+		http.Error(w, "unknown request", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(Status{Request: request, State: record.State, Reason: record.Reason,
+		Stages: record.Stages})
+}
+
+//streamcheckinterval : interval at which the subscription of a stream is checked for its end
+const streamcheckinterval = time.Second
+
+//keepaliveinterval : time after which a comment is sent on an idle stream
+const keepaliveinterval = 15 * time.Second
+
+//Event : a result of a subscription as pushed to the client, Data is encoded in base64
+type Event struct {
+	Request     string    `json:"request"`
+	ContentType string    `json:"contenttype,omitempty"`
+	Data        []byte    `json:"data"`
+	Stored      time.Time `json:"stored"`
+}
+
+func (a *API) stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	request := strings.TrimPrefix(r.URL.Path, "/subscriptions/")
+	if !a.owns(w, r, request) {
+		return
+	}
+	if _, known := a.records.Get(request); !known {
+		http.Error(w, "unknown request", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := a.results.Watch(request)
+	defer a.results.Unwatch(request, ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	sent := time.Now()
+	send := func(event string, data []byte) {
+		w.Write([]byte("event: " + event + "\ndata: " + string(data) + "\n\n"))
+		flusher.Flush()
+		sent = time.Now()
+	}
+	push := func(result resultstore.Result) {
+		data, _ := json.Marshal(Event{Request: result.Request, ContentType: result.ContentType, Data: result.Data,
+			Stored: result.Stored})
+		send("result", data)
+	}
+	//the latest result is pushed first
+	if result, ok := a.results.Get(request); ok {
+		push(result)
+	} else {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case result := <-ch:
+			push(result)
+		case <-time.After(streamcheckinterval):
+			if record, _ := a.records.Get(request); !record.Active() {
+				for len(ch) > 0 {
+					push(<-ch)
+				}
+				status, _ := json.Marshal(Status{Request: request, State: record.State, Reason: record.Reason})
+				send("end", status)
+				return
+			}
+			if time.Since(sent) >= keepaliveinterval {
+				w.Write([]byte(": keepalive\n\n"))
+				flusher.Flush()
+				sent = time.Now()
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (a *API) storeresult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	request := strings.TrimPrefix(r.URL.Path, "/callback/")
+	record, known := a.records.Get(request)
+	if !known {
+		http.Error(w, "unknown request", http.StatusNotFound)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if record.Callbacktoken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(record.Callbacktoken)) != 1 {
+		a.logger.Warn("rejected callback without the token of the request", logging.Request, request)
+		http.Error(w, "invalid callback token", http.StatusForbidden)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxresultsize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	a.logger.Info("received result through callback", logging.Request, request, "bytes", len(data))
+	a.results.Put(resultstore.Result{Request: request, ContentType: r.Header.Get("Content-Type"),
+		Source: resultstore.Fromcallback, Data: data})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//shutdowntimeout : time given to the client API to complete the calls in progress when it is shut down
+const shutdowntimeout = 5 * time.Second
+
+//serve : runs the server until ctx is cancelled and then shuts it down gracefully
+func (a *API) serve(ctx context.Context, server *http.Server) {
+	go func() {
+		<-ctx.Done()
+		shutdownctx, cancel := context.WithTimeout(context.Background(), shutdowntimeout)
+		defer cancel()
+		server.Shutdown(shutdownctx)
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		a.logger.Error("client API stopped", "err", err)
+		return
+	}
+	a.logger.Info("client API stopped")
+}
@@ -0,0 +1,480 @@
+package clientapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/library"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resultstore"
+	"github.com/niketagrawal/EDIRO/session"
+)
+
+//newapi : returns a client API with empty stores whose pipeline accepts every request
+func newapi() *API {
+	return New(func(ctx context.Context, s Submission) error { return nil }, nil, requestrecord.New(),
+		resultstore.New(), slog.Default())
+}
+
+/*
+newauth : returns the clients of an edge node, the client a with the API key key_a, one active request at most and high
+priority requests at most
+*/
+func newauth(t *testing.T) *clientauth.Authenticator {
+	sum := sha256.Sum256([]byte("key_a"))
+	data, err := json.Marshal(clientauth.Authenticator{Clients: []clientauth.Client{{ID: "a",
+		Apikeysha256: hex.EncodeToString(sum[:]), Maxconcurrent: 1, Maxpriority: library.High}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clients.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := clientauth.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth
+}
+
+func TestSubmitrequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		auth        bool   //whether the edge node has a list of clients
+		apikey      string //API key sent with the submission
+		active      bool   //whether the client a has an active request
+		submiterr   error  //error returned by the pipeline
+		wantstatus  int
+		wantrequest string
+		wantclient  string
+		//wantpriority : priority class handed to the pipeline, wantreason : reason of a rejection
+		wantpriority, wantreason string
+	}{
+		{name: "submitted", method: "POST", body: `{"request": "client_request_1"}`, wantstatus: http.StatusAccepted,
+			wantrequest: "client_request_1"},
+		{name: "pipeline stopping", method: "POST", body: `{"request": "client_request_1"}`,
+			submiterr: errors.New("orchestrator is stopping"), wantstatus: http.StatusServiceUnavailable,
+			wantrequest: "client_request_1", wantreason: Unavailable},
+		{name: "malformed body", method: "POST", body: `client_request_1`, wantstatus: http.StatusBadRequest},
+		{name: "no request", method: "POST", body: `{}`, wantstatus: http.StatusBadRequest},
+		{name: "wrong method", method: "GET", wantstatus: http.StatusMethodNotAllowed},
+		{name: "authenticated client", method: "POST", body: `{"request": "client_request_1"}`, auth: true,
+			apikey: "key_a", wantstatus: http.StatusAccepted, wantrequest: "client_request_1", wantclient: "a"},
+		{name: "unauthenticated client", method: "POST", body: `{"request": "client_request_1"}`, auth: true,
+			wantstatus: http.StatusUnauthorized, wantreason: clientauth.Unauthenticated},
+		{name: "priority class", method: "POST", body: `{"request": "client_request_1", "priority": "low"}`,
+			wantstatus: http.StatusAccepted, wantrequest: "client_request_1", wantpriority: library.Low},
+		{name: "unknown priority class", method: "POST", body: `{"request": "client_request_1", "priority": "urgent"}`,
+			wantstatus: http.StatusBadRequest},
+		{name: "priority class allowed to the client", method: "POST", auth: true, apikey: "key_a",
+			body: `{"request": "client_request_1", "priority": "high"}`, wantstatus: http.StatusAccepted,
+			wantrequest: "client_request_1", wantclient: "a", wantpriority: library.High},
+		{name: "priority class above the cap of the client", method: "POST", auth: true, apikey: "key_a",
+			body: `{"request": "client_request_1", "priority": "critical"}`, wantstatus: http.StatusTooManyRequests,
+			wantreason: clientauth.Prioritynotallowed},
+		{name: "priority class above the cap of an anonymous client", method: "POST",
+			body: `{"request": "client_request_1", "priority": "critical"}`, wantstatus: http.StatusTooManyRequests,
+			wantreason: clientauth.Prioritynotallowed},
+		{name: "invalid relative deadline", method: "POST", body: `{"request": "client_request_1", "within": "soon"}`,
+			wantstatus: http.StatusBadRequest},
+		{name: "subscription", method: "POST", body: `{"request": "client_request_1", "lifetime": "10m"}`,
+			wantstatus: http.StatusAccepted, wantrequest: "client_request_1"},
+		{name: "invalid lifetime", method: "POST", body: `{"request": "client_request_1", "lifetime": "-1m"}`,
+			wantstatus: http.StatusBadRequest},
+		{name: "subscription to a pipeline", method: "POST", body: `{"request": "client_request_5", "lifetime": "10m"}`,
+			wantstatus: http.StatusBadRequest},
+		{name: "quota of the client exceeded", method: "POST", body: `{"request": "client_request_1"}`, auth: true,
+			apikey: "key_a", active: true, wantstatus: http.StatusTooManyRequests,
+			wantreason: clientauth.Concurrencylimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var submitted Submission
+			var auth *clientauth.Authenticator
+			if tt.auth {
+				auth = newauth(t)
+			}
+			a := New(func(ctx context.Context, s Submission) error {
+				submitted = s
+				return tt.submiterr
+			}, auth, requestrecord.New(), resultstore.New(), slog.Default())
+			if tt.active {
+				a.records.Submit(requestrecord.Record{Request: "client_request_2", Clients: []string{"a"}})
+			}
+			r := httptest.NewRequest(tt.method, "/requests", strings.NewReader(tt.body))
+			if tt.apikey != "" {
+				r.Header.Set(clientauth.Apikeyheader, tt.apikey)
+			}
+			w := httptest.NewRecorder()
+			a.submitrequest(w, r)
+			if w.Code != tt.wantstatus || submitted.Request != tt.wantrequest || submitted.Client != tt.wantclient ||
+				submitted.Priority != tt.wantpriority {
+				t.Errorf("status %d submitting %+v, want %d submitting %q for %q with priority %q", w.Code, submitted,
+					tt.wantstatus, tt.wantrequest, tt.wantclient, tt.wantpriority)
+			}
+			var status Status
+			json.Unmarshal(w.Body.Bytes(), &status)
+			if status.Reason != tt.wantreason {
+				t.Errorf("rejected for %q, want %q", status.Reason, tt.wantreason)
+			}
+		})
+	}
+}
+
+func TestSubmitrequestConcurrent(t *testing.T) {
+	tests := []struct {
+		name       string
+		apikey     string //API key of the submissions, none for anonymous submissions
+		wantstatus int    //status of the second submission while the first one is being submitted
+	}{
+		//the admission is not held while the pipeline takes the first submission
+		{name: "anonymous clients", wantstatus: http.StatusAccepted},
+		//the submission in progress counts against the quota of the client
+		{name: "client with a quota", apikey: "key_a", wantstatus: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var auth *clientauth.Authenticator
+			if tt.apikey != "" {
+				auth = newauth(t)
+			}
+			entered, unblock := make(chan struct{}), make(chan struct{})
+			a := New(func(ctx context.Context, s Submission) error {
+				if s.Request == "client_request_1" {
+					close(entered)
+					<-unblock
+				}
+				return nil
+			}, auth, requestrecord.New(), resultstore.New(), slog.Default())
+			submit := func(request string, apikey string) int {
+				r := httptest.NewRequest("POST", "/requests", strings.NewReader(`{"request": "`+request+`"}`))
+				if apikey != "" {
+					r.Header.Set(clientauth.Apikeyheader, apikey)
+				}
+				w := httptest.NewRecorder()
+				a.submitrequest(w, r)
+				return w.Code
+			}
+			first := make(chan int)
+			go func() { first <- submit("client_request_1", tt.apikey) }()
+			<-entered
+			if code := submit("client_request_2", tt.apikey); code != tt.wantstatus {
+				t.Errorf("second submission answered %d, want %d", code, tt.wantstatus)
+			}
+			close(unblock)
+			if code := <-first; code != http.StatusAccepted {
+				t.Errorf("first submission answered %d", code)
+			}
+			if len(a.submitting) != 0 {
+				t.Errorf("submissions still counted once submitted: %v", a.submitting)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		submission Submission
+		want       time.Time
+	}{
+		{name: "no deadline"},
+		{name: "absolute", submission: Submission{Deadline: now.Add(time.Minute)}, want: now.Add(time.Minute)},
+		{name: "relative", submission: Submission{Within: "30s"}, want: now.Add(30 * time.Second)},
+		{name: "relative earlier", submission: Submission{Deadline: now.Add(time.Minute), Within: "30s"},
+			want: now.Add(30 * time.Second)},
+		{name: "absolute earlier", submission: Submission{Deadline: now.Add(time.Second), Within: "30s"},
+			want: now.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.submission.Due(now); !got.Equal(tt.want) {
+				t.Errorf("Due = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		lifetime string
+		want     time.Time
+	}{
+		{name: "served once"},
+		{name: "subscription", lifetime: "10m", want: now.Add(10 * time.Minute)},
+		{name: "invalid lifetime", lifetime: "forever"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Submission{Lifetime: tt.lifetime}).Expiry(now); !got.Equal(tt.want) {
+				t.Errorf("Expiry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetresult(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     bool   //whether the request is known
+		result     string //result stored for the request, none if empty
+		query      string
+		auth       bool     //whether the edge node has a list of clients
+		apikey     string   //API key of the caller
+		clients    []string //clients that submitted the request
+		wantstatus int
+		wantbody   string
+	}{
+		{name: "result available", record: true, result: "42", wantstatus: http.StatusOK, wantbody: "42"},
+		{name: "result pending", record: true, wantstatus: http.StatusAccepted,
+			wantbody: `{"request":"req","state":"launched"}`},
+		{name: "wait expired", record: true, query: "?wait=10ms", wantstatus: http.StatusAccepted,
+			wantbody: `{"request":"req","state":"launched"}`},
+		{name: "invalid wait", record: true, query: "?wait=soon", wantstatus: http.StatusBadRequest},
+		{name: "unknown request", wantstatus: http.StatusNotFound},
+		{name: "result of the client", record: true, result: "42", auth: true, apikey: "key_a",
+			clients: []string{"a"}, wantstatus: http.StatusOK, wantbody: "42"},
+		{name: "result of another client", record: true, result: "42", auth: true, apikey: "key_a",
+			clients: []string{"b"}, wantstatus: http.StatusForbidden},
+		{name: "unauthenticated client", record: true, result: "42", auth: true, clients: []string{"a"},
+			wantstatus: http.StatusUnauthorized},
+		{name: "unknown request of a client", auth: true, apikey: "key_a", wantstatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newapi()
+			if tt.auth {
+				a.auth = newauth(t)
+			}
+			if tt.record {
+				a.records.Add(requestrecord.Record{Request: "req", Clients: tt.clients, State: requestrecord.Launched})
+			}
+			if tt.result != "" {
+				a.results.Put(resultstore.Result{Request: "req", Data: []byte(tt.result)})
+			}
+			r := httptest.NewRequest("GET", "/results/req"+tt.query, nil)
+			if tt.apikey != "" {
+				r.Header.Set(clientauth.Apikeyheader, tt.apikey)
+			}
+			w := httptest.NewRecorder()
+			a.getresult(w, r)
+			if body := strings.TrimSpace(w.Body.String()); w.Code != tt.wantstatus ||
+				(tt.wantbody != "" && body != tt.wantbody) {
+				t.Errorf("status %d with %q, want %d with %q", w.Code, body, tt.wantstatus, tt.wantbody)
+			}
+		})
+	}
+}
+
+func TestGetresultWait(t *testing.T) {
+	a := newapi()
+	a.records.Add(requestrecord.Record{Request: "req", State: requestrecord.Launched})
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		a.getresult(w, httptest.NewRequest("GET", "/results/req?wait=1m", nil))
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond) //the result is found whether the call is waiting already or not
+	a.results.Put(resultstore.Result{Request: "req", Data: []byte("42")})
+	<-done
+	if w.Code != http.StatusOK || w.Body.String() != "42" {
+		t.Errorf("status %d with %q, want the pushed result", w.Code, w.Body.String())
+	}
+}
+
+func TestStoreresult(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		record     bool
+		token      string //token of the request, none if empty
+		target     string
+		wantstatus int
+		wantstored bool
+	}{
+		{name: "result delivered", method: "POST", record: true, token: "t0k", target: "/callback/req?token=t0k",
+			wantstatus: http.StatusNoContent, wantstored: true},
+		{name: "unknown request", method: "POST", target: "/callback/req?token=t0k",
+			wantstatus: http.StatusNotFound},
+		{name: "wrong method", method: "GET", record: true, token: "t0k", target: "/callback/req?token=t0k",
+			wantstatus: http.StatusMethodNotAllowed},
+		{name: "no token", method: "POST", record: true, token: "t0k", target: "/callback/req",
+			wantstatus: http.StatusForbidden},
+		{name: "wrong token", method: "POST", record: true, token: "t0k", target: "/callback/req?token=other",
+			wantstatus: http.StatusForbidden},
+		{name: "request not launched", method: "POST", record: true, target: "/callback/req?token=",
+			wantstatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newapi()
+			if tt.record {
+				a.records.Add(requestrecord.Record{Request: "req", State: requestrecord.Launched,
+					Callbacktoken: tt.token})
+			}
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader("42"))
+			r.Header.Set("Content-Type", "text/plain")
+			w := httptest.NewRecorder()
+			a.storeresult(w, r)
+			result, stored := a.results.Get("req")
+			if w.Code != tt.wantstatus || stored != tt.wantstored {
+				t.Errorf("status %d, stored %v, want %d, %v", w.Code, stored, tt.wantstatus, tt.wantstored)
+			}
+			if stored && (string(result.Data) != "42" || result.Source != resultstore.Fromcallback ||
+				result.ContentType != "text/plain") {
+				t.Errorf("stored %+v", result)
+			}
+		})
+	}
+}
+
+//alone : inter edge communication of an edge node without other edge nodes
+type alone struct{}
+
+func (alone) Handover(ctx context.Context, in *pb.HandoverRequest) (string, *pb.HandoverReply, bool) {
+	return "", nil, false
+}
+
+func (alone) Fetchresult(ctx context.Context, peer string, in *pb.FetchRequest) (*pb.RequestState, error) {
+	return nil, errors.New("no other edge node")
+}
+
+func TestAttach(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		sessions   bool   //whether the edge node keeps sessions
+		client     string //client ID sent with the call
+		attached   bool   //whether the client is already attached
+		wantstatus int
+		//wantrequests : requests whose status is returned with the session
+		wantrequests []string
+	}{
+		{name: "attach", method: "POST", sessions: true, client: "a", wantstatus: http.StatusOK,
+			wantrequests: []string{"client_request_1"}},
+		{name: "session of an attached client", method: "GET", sessions: true, client: "a", attached: true,
+			wantstatus: http.StatusOK, wantrequests: []string{"client_request_1"}},
+		{name: "unknown session", method: "GET", sessions: true, client: "a", wantstatus: http.StatusNotFound},
+		{name: "no client ID", method: "POST", sessions: true, wantstatus: http.StatusUnauthorized},
+		{name: "no sessions", method: "POST", client: "a", wantstatus: http.StatusNotImplemented},
+		{name: "wrong method", method: "DELETE", sessions: true, client: "a", wantstatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newapi()
+			if tt.sessions {
+				a.Sessions = session.New("n1", alone{}, a.records, a.results, slog.Default())
+			}
+			if tt.attached {
+				a.Sessions.Attach(context.Background(), tt.client)
+			}
+			a.records.Add(requestrecord.Record{Request: "client_request_1", Clients: []string{"a"},
+				State: requestrecord.Launched})
+			a.records.Add(requestrecord.Record{Request: "client_request_2", Clients: []string{"b"},
+				State: requestrecord.Launched})
+			r := httptest.NewRequest(tt.method, "/sessions", nil)
+			if tt.client != "" {
+				r.Header.Set(Clientidheader, tt.client)
+			}
+			w := httptest.NewRecorder()
+			a.attach(w, r)
+			if w.Code != tt.wantstatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantstatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var status Sessionstatus
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatal(err)
+			}
+			var requests []string
+			for _, request := range status.Requests {
+				requests = append(requests, request.Request)
+			}
+			if status.Client != tt.client || status.Node != "n1" || strings.Join(requests, ",") !=
+				strings.Join(tt.wantrequests, ",") {
+				t.Errorf("session %+v, want the client %s attached to n1 with the requests %v", status, tt.client,
+					tt.wantrequests)
+			}
+		})
+	}
+}
+
+func TestCancelrequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		cancel     bool   //whether the edge node accepts cancellations
+		client     string //client ID sent with the call
+		request    string
+		wantstatus int
+		wantstate  string
+	}{
+		{name: "cancelled", method: "DELETE", cancel: true, client: "a", request: "client_request_1",
+			wantstatus: http.StatusOK, wantstate: requestrecord.Cancelled},
+		{name: "shared request", method: "DELETE", cancel: true, client: "b", request: "client_request_2",
+			wantstatus: http.StatusOK, wantstate: Detached},
+		{name: "request of another client", method: "DELETE", cancel: true, client: "b", request: "client_request_1",
+			wantstatus: http.StatusForbidden},
+		{name: "unknown request", method: "DELETE", cancel: true, client: "a", request: "client_request_3",
+			wantstatus: http.StatusNotFound},
+		{name: "request finished", method: "DELETE", cancel: true, client: "a", request: "client_request_4",
+			wantstatus: http.StatusConflict},
+		{name: "no cancellations", method: "DELETE", client: "a", request: "client_request_1",
+			wantstatus: http.StatusNotImplemented},
+		{name: "wrong method", method: "PUT", cancel: true, client: "a", request: "client_request_1",
+			wantstatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newapi()
+			if tt.cancel {
+				a.Cancel = a.records.Cancel
+			}
+			a.records.Add(requestrecord.Record{Request: "client_request_1", Clients: []string{"a"},
+				State: requestrecord.Launched})
+			a.records.Add(requestrecord.Record{Request: "client_request_2", Clients: []string{"a", "b"},
+				State: requestrecord.Submitted})
+			a.records.Add(requestrecord.Record{Request: "client_request_4", Clients: []string{"a"},
+				State: requestrecord.Completed})
+			r := httptest.NewRequest(tt.method, "/requests/"+tt.request, nil)
+			r.Header.Set(Clientidheader, tt.client)
+			w := httptest.NewRecorder()
+			a.cancelrequest(w, r)
+			if w.Code != tt.wantstatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantstatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var status Status
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatal(err)
+			}
+			if status.Request != tt.request || status.State != tt.wantstate {
+				t.Errorf("status %+v, want %s for %s", status, tt.wantstate, tt.request)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+/*
+This package implements the authentication of the clients of EDIRO and the quotas applied to them on request
+submission. The clients known to an edge node are listed in a file along with their quotas. A client authenticates
+either with an API key, of which only the SHA-256 hash is stored on the edge node, or with a JWT signed with HS256 by
+a secret shared with the edge node, whose subject is the client ID. Both are verified locally. Every client is given a
+rate limit on its submissions and a cap on the number of its requests active on the edge cluster at the same time. The
+priority class a client may ask for is capped as well, e.g. only emergency vehicles may submit critical requests.
+A rejected submission comes with the reason of the rejection.
+
+Example of a clients file:
+	{
+	  "jwtsecret": "<shared secret>",
+	  "clients": [
+	    {"id": "fleet_a", "apikeysha256": "<hex of sha256(api key)>", "rate": 1, "burst": 5, "maxconcurrent": 3, "maxpriority": "high"}
+	  ]
+	}
+
+*/
+
+package clientauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/library"
+)
+
+//Reasons for which a submission is rejected
+const (
+	Unauthenticated    = "unauthenticated"
+	Unknownclient      = "unknown_client"
+	Ratelimited        = "rate_limited"
+	Concurrencylimit   = "concurrency_limit"
+	Prioritynotallowed = "priority_not_allowed"
+)
+
+//Apikeyheader : header carrying the API key of a client, the key can also be sent as a bearer token
+const Apikeyheader = "X-API-Key"
+
+//Client : A client of the edge node and its quotas
+type Client struct {
+	ID string `json:"id"`
+	//Apikeysha256 : hex encoded SHA-256 hash of the API key of the client, empty if it only uses JWTs
+	Apikeysha256 string `json:"apikeysha256"`
+	//Rate : number of submissions per second the client is allowed on average, 0 for no limit
+	Rate float64 `json:"rate"`
+	//Burst : number of submissions the client is allowed at once, at least 1
+	Burst int `json:"burst"`
+	//Maxconcurrent : number of requests of the client that can be active at the same time, 0 for no limit
+	Maxconcurrent int `json:"maxconcurrent"`
+	//Maxpriority : highest priority class the client may ask for, normal if empty. Requests submitted without a
+	//priority class get the class of the request in the library whatever this cap.
+	Maxpriority string `json:"maxpriority"`
+}
+
+//Rejection : The error returned when a submission is rejected
+type Rejection struct {
+	Reason string
+	Err    error
+}
+
+func (r *Rejection) Error() string {
+	if r.Err == nil {
+		return r.Reason
+	}
+	return r.Reason + ": " + r.Err.Error()
+}
+
+//Authenticator : The clients known to an edge node and their quotas
+type Authenticator struct {
+	Jwtsecret string             `json:"jwtsecret"`
+	Clients   []Client           `json:"clients"`
+	clients   map[string]*Client //clients keyed by their ID
+	buckets   map[string]*bucket //rate limit of each client
+	mux       sync.Mutex
+}
+
+/*
+Load : Reads the clients of the edge node from a file.
+Input: path of the clients file
+Output: the authenticator, error if the file could not be read or is invalid
+*/
+func Load(path string) (*Authenticator, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Authenticator{}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, err
+	}
+	a.clients = map[string]*Client{}
+	a.buckets = map[string]*bucket{}
+	for i := range a.Clients {
+		c := &a.Clients[i]
+		if c.ID == "" {
+			return nil, errors.New("client without id")
+		}
+		if _, ok := a.clients[c.ID]; ok {
+			return nil, fmt.Errorf("client %s listed twice", c.ID)
+		}
+		c.Apikeysha256 = strings.ToLower(c.Apikeysha256)
+		if c.Maxpriority == "" {
+			c.Maxpriority = library.Normal
+		}
+		if _, ok := library.Priorityrank[c.Maxpriority]; !ok {
+			return nil, fmt.Errorf("client %s has unknown priority class %s", c.ID, c.Maxpriority)
+		}
+		a.clients[c.ID] = c
+		if c.Rate > 0 {
+			a.buckets[c.ID] = newbucket(c.Rate, c.Burst)
+		}
+	}
+	return a, nil
+}
+
+/*
+Authenticate : Identifies the client behind an HTTP request from its API key or its JWT.
+Input: HTTP request carrying the header X-API-Key or Authorization: Bearer <API key or JWT>
+Output: the client, a Rejection if the client could not be authenticated
+*/
+func (a *Authenticator) Authenticate(r *http.Request) (Client, error) {
+	credential := r.Header.Get(Apikeyheader)
+	if credential == "" {
+		credential = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if credential == r.Header.Get("Authorization") {
+			credential = ""
+		}
+	}
+	if credential == "" {
+		return Client{}, &Rejection{Reason: Unauthenticated, Err: errors.New("no API key or token given")}
+	}
+
+	if strings.Count(credential, ".") == 2 {
+		id, err := a.verifyjwt(credential, time.Now())
+		if err != nil {
+			return Client{}, &Rejection{Reason: Unauthenticated, Err: err}
+		}
+		c, ok := a.clients[id]
+		if !ok {
+			return Client{}, &Rejection{Reason: Unknownclient, Err: fmt.Errorf("client %s is not known", id)}
+		}
+		return *c, nil
+	}
+
+	sum := sha256.Sum256([]byte(credential))
+	hash := hex.EncodeToString(sum[:])
+	for _, c := range a.clients {
+		if c.Apikeysha256 != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(c.Apikeysha256)) == 1 {
+			return *c, nil
+		}
+	}
+	return Client{}, &Rejection{Reason: Unauthenticated, Err: errors.New("invalid API key")}
+}
+
+//Allows : returns a Rejection if a client may not ask for a priority class, the class normal being the cap of a client
+//without one, e.g. an anonymous client
+func (c Client) Allows(priority string) error {
+	max := c.Maxpriority
+	if max == "" {
+		max = library.Normal
+	}
+	if priority != "" && library.Priorityrank[priority] > library.Priorityrank[max] {
+		return &Rejection{Reason: Prioritynotallowed,
+			Err: fmt.Errorf("priority %s is above the %s allowed to the client", priority, max)}
+	}
+	return nil
+}
+
+/*
+Admit : Applies the quotas of a client to a new submission. A submission within the quotas consumes one unit of the
+rate limit of the client.
+Input: client, number of requests of the client currently active, priority class asked for by the client, if any
+Output: a Rejection if the submission exceeds a quota of the client
+*/
+func (a *Authenticator) Admit(c Client, active int, priority string) error {
+	if err := c.Allows(priority); err != nil {
+		return err
+	}
+	if c.Maxconcurrent > 0 && active >= c.Maxconcurrent {
+		return &Rejection{Reason: Concurrencylimit,
+			Err: fmt.Errorf("%d of %d concurrent requests in use", active, c.Maxconcurrent)}
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if b, ok := a.buckets[c.ID]; ok && !b.take(time.Now()) {
+		return &Rejection{Reason: Ratelimited, Err: fmt.Errorf("limit of %g submissions per second exceeded", c.Rate)}
+	}
+	return nil
+}
+
+//claims : the claims of a JWT used by EDIRO
+type claims struct {
+	Subject   string `json:"sub"`
+	Expiry    int64  `json:"exp"`
+	Notbefore int64  `json:"nbf"`
+}
+
+/*
+verifyjwt : Verifies a JWT signed with HS256 by the shared secret.
+Input: the JWT, current time
+Output: the subject of the token, i.e. the client ID, error if the token is invalid or expired
+*/
+func (a *Authenticator) verifyjwt(token string, now time.Time) (string, error) {
+	if a.Jwtsecret == "" {
+		return "", errors.New("tokens are not accepted by this edge node")
+	}
+	parts := strings.Split(token, ".")
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errors.New("malformed token")
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return "", errors.New("token must be signed with HS256")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed token")
+	}
+	mac := hmac.New(sha256.New, []byte(a.Jwtsecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errors.New("invalid token signature")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("malformed token")
+	}
+	var c claims
+	if err := json.Unmarshal(body, &c); err != nil {
+		return "", errors.New("malformed token")
+	}
+	if c.Expiry != 0 && now.Unix() >= c.Expiry {
+		return "", errors.New("token expired")
+	}
+	if c.Notbefore != 0 && now.Unix() < c.Notbefore {
+		return "", errors.New("token not valid yet")
+	}
+	if c.Subject == "" {
+		return "", errors.New("token has no subject")
+	}
+	return c.Subject, nil
+}
+
+//bucket : token bucket limiting the rate of the submissions of a client
+type bucket struct {
+	rate, burst, tokens float64
+	last                time.Time
+}
+
+func newbucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+//take : refills the bucket for the time elapsed and takes a token from it if one is left
+func (b *bucket) take(now time.Time) bool {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
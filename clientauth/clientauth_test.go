@@ -0,0 +1,245 @@
+package clientauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/library"
+)
+
+const secret = "shared secret"
+
+//load : writes a clients file and loads it
+func load(t *testing.T, a *Authenticator) (*Authenticator, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clients.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+//hash : returns the hex encoded SHA-256 hash of an API key
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+//token : returns a JWT with the given claims signed by a secret with an algorithm
+func token(alg string, c claims, key string) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	body, _ := json.Marshal(c)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//bearer : returns the header carrying a bearer token
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+//reason : returns the reason of a rejection, empty for no error
+func reason(err error) string {
+	var r *Rejection
+	if errors.As(err, &r) {
+		return r.Reason
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name            string
+		clients         []Client
+		wanterr         bool
+		wantmaxpriority string
+	}{
+		{name: "valid", clients: []Client{{ID: "a", Apikeysha256: "ABC", Maxpriority: library.High}, {ID: "b"}},
+			wantmaxpriority: library.High},
+		{name: "normal priority by default", clients: []Client{{ID: "a"}}, wantmaxpriority: library.Normal},
+		{name: "client without id", clients: []Client{{}}, wanterr: true},
+		{name: "client listed twice", clients: []Client{{ID: "a"}, {ID: "a"}}, wanterr: true},
+		{name: "unknown priority class", clients: []Client{{ID: "a", Maxpriority: "urgent"}}, wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := load(t, &Authenticator{Clients: tt.clients})
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("Load = %v, want error %v", err, tt.wanterr)
+			}
+			if err != nil {
+				return
+			}
+			if len(a.clients) != len(tt.clients) ||
+				a.clients["a"].Apikeysha256 != strings.ToLower(tt.clients[0].Apikeysha256) {
+				t.Errorf("clients %v, want %v with the hash of the API key in lower case", a.clients, tt.clients)
+			}
+			if a.clients["a"].Maxpriority != tt.wantmaxpriority {
+				t.Errorf("maximum priority %q, want %q", a.clients["a"].Maxpriority, tt.wantmaxpriority)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name       string
+		jwtsecret  string
+		headers    map[string]string
+		wantclient string
+		wantreason string
+	}{
+		{name: "API key", jwtsecret: secret, headers: map[string]string{Apikeyheader: "key_a"}, wantclient: "a"},
+		{name: "API key as bearer token", jwtsecret: secret,
+			headers: map[string]string{"Authorization": "Bearer key_a"}, wantclient: "a"},
+		{name: "invalid API key", jwtsecret: secret, headers: map[string]string{Apikeyheader: "key_b"},
+			wantreason: Unauthenticated},
+		{name: "nothing given", jwtsecret: secret, wantreason: Unauthenticated},
+		{name: "authorization without bearer", jwtsecret: secret, headers: map[string]string{"Authorization": "key_a"},
+			wantreason: Unauthenticated},
+		{name: "JWT", jwtsecret: secret,
+			headers: bearer(token("HS256", claims{Subject: "b", Expiry: now + 60}, secret)), wantclient: "b"},
+		{name: "JWT of an unknown client", jwtsecret: secret,
+			headers: bearer(token("HS256", claims{Subject: "c"}, secret)), wantreason: Unknownclient},
+		{name: "JWT signed by another secret", jwtsecret: secret,
+			headers: bearer(token("HS256", claims{Subject: "b"}, "other")), wantreason: Unauthenticated},
+		{name: "JWT of another algorithm", jwtsecret: secret,
+			headers: bearer(token("none", claims{Subject: "b"}, secret)), wantreason: Unauthenticated},
+		{name: "JWT expired", jwtsecret: secret, wantreason: Unauthenticated,
+			headers: bearer(token("HS256", claims{Subject: "b", Expiry: now - 1}, secret))},
+		{name: "JWT not valid yet", jwtsecret: secret, wantreason: Unauthenticated,
+			headers: bearer(token("HS256", claims{Subject: "b", Notbefore: now + 60}, secret))},
+		{name: "JWT without subject", jwtsecret: secret,
+			headers: bearer(token("HS256", claims{}, secret)), wantreason: Unauthenticated},
+		{name: "JWT not accepted", wantreason: Unauthenticated,
+			headers: bearer(token("HS256", claims{Subject: "b"}, ""))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := load(t, &Authenticator{Jwtsecret: tt.jwtsecret,
+				Clients: []Client{{ID: "a", Apikeysha256: hash("key_a")}, {ID: "b"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest("POST", "/submit", nil)
+			for header, value := range tt.headers {
+				r.Header.Set(header, value)
+			}
+			c, err := a.Authenticate(r)
+			if c.ID != tt.wantclient || reason(err) != tt.wantreason {
+				t.Errorf("Authenticate = %q, %v, want %q, %q", c.ID, err, tt.wantclient, tt.wantreason)
+			}
+		})
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	type submission struct {
+		active     int
+		priority   string
+		wantreason string
+	}
+	tests := []struct {
+		name        string
+		client      Client
+		submissions []submission
+	}{
+		{
+			name:        "no quota",
+			client:      Client{ID: "a"},
+			submissions: []submission{{active: 100}, {}, {priority: library.Normal}},
+		},
+		{
+			name:   "priority above the cap",
+			client: Client{ID: "a", Maxpriority: library.High},
+			submissions: []submission{{priority: library.High}, {priority: library.Low},
+				{priority: library.Critical, wantreason: Prioritynotallowed}},
+		},
+		{
+			name:        "priority capped to normal by default",
+			client:      Client{ID: "a"},
+			submissions: []submission{{priority: library.High, wantreason: Prioritynotallowed}},
+		},
+		{
+			name:        "concurrency limit",
+			client:      Client{ID: "a", Maxconcurrent: 2},
+			submissions: []submission{{active: 1}, {active: 2, wantreason: Concurrencylimit}},
+		},
+		{
+			name:        "burst",
+			client:      Client{ID: "a", Rate: 0.001, Burst: 2},
+			submissions: []submission{{}, {}, {wantreason: Ratelimited}},
+		},
+		{
+			name:        "burst of at least one",
+			client:      Client{ID: "a", Rate: 0.001},
+			submissions: []submission{{}, {wantreason: Ratelimited}},
+		},
+		{
+			name:   "rejected submissions do not consume the rate limit",
+			client: Client{ID: "a", Rate: 0.001, Maxconcurrent: 1},
+			submissions: []submission{{active: 1, wantreason: Concurrencylimit}, {},
+				{wantreason: Ratelimited}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := load(t, &Authenticator{Clients: []Client{tt.client}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := *a.clients[tt.client.ID]
+			for i, s := range tt.submissions {
+				if err := a.Admit(c, s.active, s.priority); reason(err) != s.wantreason {
+					t.Errorf("submission %d = %v, want %q", i, err, s.wantreason)
+				}
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		takes []time.Duration //time of each take after the start
+		want  []bool
+	}{
+		{name: "burst", rate: 1, burst: 3, takes: []time.Duration{0, 0, 0, 0}, want: []bool{true, true, true, false}},
+		{name: "refill", rate: 1, burst: 1, takes: []time.Duration{0, 500 * time.Millisecond, time.Second},
+			want: []bool{true, false, true}},
+		{name: "refill capped by the burst", rate: 1, burst: 2,
+			takes: []time.Duration{0, 0, time.Hour, time.Hour, time.Hour}, want: []bool{true, true, true, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newbucket(tt.rate, tt.burst)
+			for i, after := range tt.takes {
+				if got := b.take(start.Add(after)); got != tt.want[i] {
+					t.Errorf("take %d after %s = %v, want %v", i, after, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
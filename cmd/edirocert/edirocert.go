@@ -0,0 +1,169 @@
+/*
+edirocert issues and rotates the certificates with which the edge nodes of an EDIRO cluster authenticate each other. It
+works offline on files and never talks to the nodes. A running node picks up a rotated certificate on its next
+connection, so the files of a node can be rotated in place.
+
+Usage:
+	edirocert ca -dir <dir> [-cluster name] [-validity 87600h]
+		creates the cluster CA as ca.pem and ca-key.pem in dir
+	edirocert issue -ca <dir> -node <node ID> -hosts <host,ip,...> -dir <dir> [-validity 8760h]
+		issues the certificate of a node as node.pem and node-key.pem in dir
+	edirocert rotate -ca <dir> -dir <dir> [-validity 8760h]
+		issues a new certificate for the node whose certificate is in dir, keeping its node ID and hosts
+	edirocert show -dir <dir>
+		prints the node ID, the hosts and the expiry of the certificate in dir
+
+The key of the CA should be kept off the edge nodes, each node only needs ca.pem, node.pem and node-key.pem.
+
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+)
+
+//Names of the files written by edirocert
+const (
+	Cacert   = "ca.pem"
+	Cakey    = "ca-key.pem"
+	Nodecert = "node.pem"
+	Nodekey  = "node-key.pem"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "ca":
+		err = ca(os.Args[2:])
+	case "issue":
+		err = issue(os.Args[2:])
+	case "rotate":
+		err = rotate(os.Args[2:])
+	case "show":
+		err = show(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "edirocert:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: edirocert ca|issue|rotate|show [flags], see edirocert <command> -h")
+	os.Exit(2)
+}
+
+//ca : creates the cluster CA
+func ca(args []string) error {
+	fs := flag.NewFlagSet("ca", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory the CA is written to")
+	cluster := fs.String("cluster", "EDIRO", "name of the cluster")
+	validity := fs.Duration("validity", 10*365*24*time.Hour, "validity of the CA certificate")
+	fs.Parse(args)
+
+	if _, err := os.Stat(filepath.Join(*dir, Cakey)); err == nil {
+		return fmt.Errorf("%s already exists, refusing to replace the CA", filepath.Join(*dir, Cakey))
+	}
+	certpem, keypem, err := nodeidentity.Newca(*cluster, *validity)
+	if err != nil {
+		return err
+	}
+	if err := nodeidentity.Writefile(filepath.Join(*dir, Cakey), keypem, 0600); err != nil {
+		return err
+	}
+	return nodeidentity.Writefile(filepath.Join(*dir, Cacert), certpem, 0644)
+}
+
+//issue : issues the certificate of a node
+func issue(args []string) error {
+	fs := flag.NewFlagSet("issue", flag.ExitOnError)
+	cadir := fs.String("ca", ".", "directory of the CA")
+	node := fs.String("node", "", "ID of the edge node")
+	hosts := fs.String("hosts", "", "comma separated host names and IP addresses on which the node is reached")
+	dir := fs.String("dir", ".", "directory the certificate is written to")
+	validity := fs.Duration("validity", 365*24*time.Hour, "validity of the certificate")
+	fs.Parse(args)
+
+	var hostlist []string
+	for _, host := range strings.Split(*hosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hostlist = append(hostlist, host)
+		}
+	}
+	return write(*cadir, *dir, *node, hostlist, *validity)
+}
+
+//rotate : issues a new certificate for a node keeping its identity
+func rotate(args []string) error {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	cadir := fs.String("ca", ".", "directory of the CA")
+	dir := fs.String("dir", ".", "directory of the certificate to rotate")
+	validity := fs.Duration("validity", 365*24*time.Hour, "validity of the new certificate")
+	fs.Parse(args)
+
+	certpem, err := ioutil.ReadFile(filepath.Join(*dir, Nodecert))
+	if err != nil {
+		return err
+	}
+	node, err := nodeidentity.Describe(certpem)
+	if err != nil {
+		return err
+	}
+	return write(*cadir, *dir, node.ID, node.Hosts, *validity)
+}
+
+//show : prints the identity carried by a node certificate
+func show(args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory of the certificate")
+	fs.Parse(args)
+
+	certpem, err := ioutil.ReadFile(filepath.Join(*dir, Nodecert))
+	if err != nil {
+		return err
+	}
+	node, err := nodeidentity.Describe(certpem)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("node:    %s\nhosts:   %s\nexpires: %s\n", node.ID, strings.Join(node.Hosts, ","),
+		node.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+/*
+write : Issues a node certificate with the CA in cadir and writes it to dir. The key is written before the certificate,
+a running node reloads both once the certificate changes.
+*/
+func write(cadir string, dir string, nodeID string, hosts []string, validity time.Duration) error {
+	capem, err := ioutil.ReadFile(filepath.Join(cadir, Cacert))
+	if err != nil {
+		return err
+	}
+	cakeypem, err := ioutil.ReadFile(filepath.Join(cadir, Cakey))
+	if err != nil {
+		return err
+	}
+	certpem, keypem, err := nodeidentity.Issue(capem, cakeypem, nodeID, hosts, validity)
+	if err != nil {
+		return err
+	}
+	if err := nodeidentity.Writefile(filepath.Join(dir, Nodekey), keypem, 0600); err != nil {
+		return err
+	}
+	return nodeidentity.Writefile(filepath.Join(dir, Nodecert), certpem, 0644)
+}
@@ -0,0 +1,404 @@
+/*
+ediroctl inspects and operates a running EDIRO edge node through its admin API (see package admin).
+
+Usage:
+	ediroctl members [-linearizable] [flags]
+		lists the edge nodes of the swarm known to the edge node with their IoT resources, storage and load, its
+		peers and the leader of the replicated log with -consistency raft
+	ediroctl catalog [-node <node>] [-resource <prefix>] [-type <type>] [-available] [-linearizable] [flags]
+		dumps the IoT resource catalog of the edge node, once it caught up with the replicated log with -linearizable
+	ediroctl requests [-state <state>] [-client <client>] [flags]
+		lists the client requests with the time they waited and ran, and the timings of the stages of pipelines, a stage
+		waiting from the launch of its pipeline
+	ediroctl offload -resource <resource> -node <node> [-type <type>] [-hash <hash>] [-contributor <id>] [-size <bytes>] [-replicas <n>] [flags]
+		injects a test IoT resource held by a node
+	ediroctl submit -request <request> [-priority <class>] [-client <client>] [-within 30s] [-lifetime 10m] [flags]
+		injects a test client request
+	ediroctl cancel -request <request> [-client <client>] [flags]
+		withdraws a client from a request, or cancels the request for all its clients without -client
+	ediroctl sync [flags]
+		reloads the IoT resources the edge node missed from the other edge nodes (anti-entropy)
+	ediroctl drain -node <node> [-replicate] [-migrate] [-timeout 5m] [flags]
+		stops placing workloads on a node taken down for maintenance, copies the IoT resources only it holds to the
+		other nodes with -replicate, moves its workloads with -migrate or lets them finish, then announces its departure
+	ediroctl undrain -node <node> [flags]
+		makes a drained node active again
+
+Every command takes -addr, the address of the admin API (localhost:9090 by default). When the edge node runs with its
+certificate, the admin API is reached over mutual TLS with -tls-cert, -tls-key and -tls-ca, e.g. with a certificate
+issued by edirocert for the operator.
+
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+//calltimeout : time given to a call of the admin API
+const calltimeout = 10 * time.Second
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "members":
+		err = members(os.Args[2:])
+	case "catalog":
+		err = catalog(os.Args[2:])
+	case "requests":
+		err = requests(os.Args[2:])
+	case "offload":
+		err = offload(os.Args[2:])
+	case "submit":
+		err = submit(os.Args[2:])
+	case "cancel":
+		err = cancel(os.Args[2:])
+	case "sync":
+		err = sync(os.Args[2:])
+	case "drain":
+		err = drain(os.Args[2:])
+	case "undrain":
+		err = undrain(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ediroctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ediroctl members|catalog|requests|offload|submit|cancel|sync|drain|undrain [flags], see ediroctl <command> -h")
+	os.Exit(2)
+}
+
+//target : the admin API of the edge node a command talks to
+type target struct {
+	addr, cert, key, ca *string
+}
+
+//flags : returns the flag set of a command with the flags naming the admin API
+func flags(name string) (*flag.FlagSet, *target) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	t := &target{
+		addr: fs.String("addr", "localhost:9090", "address of the admin API of the edge node"),
+		cert: fs.String("tls-cert", "", "certificate of the operator, enables mutual TLS"),
+		key:  fs.String("tls-key", "", "key of the certificate of the operator"),
+		ca:   fs.String("tls-ca", "", "certificate of the cluster CA"),
+	}
+	return fs, t
+}
+
+//dial : connects to the admin API, the connection is to be closed by the caller
+func (t *target) dial() (pb.AdminClient, *grpc.ClientConn, error) {
+	option := grpc.WithTransportCredentials(insecure.NewCredentials())
+	if *t.cert != "" {
+		creds, err := nodeidentity.Load(*t.cert, *t.key, *t.ca)
+		if err != nil {
+			return nil, nil, err
+		}
+		option = grpc.WithTransportCredentials(credentials.NewTLS(creds.Client()))
+	}
+	conn, err := grpc.Dial(*t.addr, option)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pb.NewAdminClient(conn), conn, nil
+}
+
+//members : lists the edge nodes of the swarm with their load
+func members(args []string) error {
+	fs, t := flags("members")
+	in := &pb.MembersRequest{}
+	fs.BoolVar(&in.Linearizable, "linearizable", false, "read the catalog once it applied every mutation committed before, with -consistency raft")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	list, err := client.Members(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("edge node: %s\npeers:     %s\n", list.ID, strings.Join(list.Peers, ","))
+	if list.Leader != "" {
+		fmt.Printf("leader:    %s\n", list.Leader)
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTATE\tRESOURCES\tUSED\tSTORED\tRUNNING\tQUEUED\tCAPACITY")
+	for _, m := range list.Members {
+		capacity := "-"
+		if m.Capacity > 0 {
+			capacity = fmt.Sprint(m.Capacity)
+		}
+		stored := fmt.Sprint(m.Stored)
+		if m.Quota > 0 {
+			stored += "/" + fmt.Sprint(m.Quota)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%d\t%d\t%s\n", m.Node, m.State, m.Resources, m.Used, stored, m.Running,
+			m.Queued, capacity)
+	}
+	return w.Flush()
+}
+
+//catalog : dumps the IoT resource catalog
+func catalog(args []string) error {
+	fs, t := flags("catalog")
+	filter := &pb.CatalogFilter{}
+	fs.StringVar(&filter.Node, "node", "", "only the IoT resources held by this node")
+	fs.StringVar(&filter.Resource, "resource", "", "only the IoT resources whose name starts with this prefix")
+	fs.StringVar(&filter.Type, "type", "", "only the IoT resources of this type")
+	fs.BoolVar(&filter.Available, "available", false, "only the IoT resources not used by a workload yet")
+	fs.BoolVar(&filter.Linearizable, "linearizable", false, "read the catalog once it applied every mutation committed before, with -consistency raft")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	entries, err := client.Catalog(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tNODE\tAVAILABLE\tTYPE\tSIZE\tOFFLOADED\tORIGIN\tCONTRIBUTOR\tVERIFIED\tHASH")
+	for _, e := range entries.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%d\t%s\t%s\t%s\t%t\t%s\n", e.Resource, e.Node, e.Available, dash(e.Type),
+			e.Size, timestamp(e.Offloaded), dash(e.Origin), dash(e.Contributor), e.Verified, dash(e.Hash))
+	}
+	return w.Flush()
+}
+
+//requests : lists the client requests and the timings of their stages
+func requests(args []string) error {
+	fs, t := flags("requests")
+	filter := &pb.RequestFilter{}
+	fs.StringVar(&filter.State, "state", "", "only the requests in this state, e.g. launched")
+	fs.StringVar(&filter.Client, "client", "", "only the requests of this client")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	list, err := client.Requests(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REQUEST\tSTATE\tREASON\tPRIORITY\tNODE\tSUBMITTED\tWAITED\tRAN\tCLIENTS")
+	for _, r := range list.Requests {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Request, r.State, dash(r.Reason), dash(r.Priority),
+			dash(r.Node), timestamp(r.Submitted), elapsed(r.Submitted, r.Launched), elapsed(r.Launched, r.Finished),
+			dash(strings.Join(r.Clients, ",")))
+		for _, stage := range r.Stages {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t-\t%s\t-\t%s\t%s\t-\n", stage.Name, stage.State, dash(stage.Reason),
+				dash(stage.Node), elapsed(r.Launched, stage.Launched), elapsed(stage.Launched, stage.Finished))
+		}
+	}
+	return w.Flush()
+}
+
+//offload : injects a test IoT resource
+func offload(args []string) error {
+	fs, t := flags("offload")
+	in := &pb.OffloadRequest{}
+	fs.StringVar(&in.Resource, "resource", "", "name of the IoT resource")
+	fs.StringVar(&in.Node, "node", "", "node holding the IoT resource")
+	fs.StringVar(&in.Type, "type", "", "type of the IoT resource, e.g. hd_map")
+	fs.StringVar(&in.Hash, "hash", "", "content hash of the IoT resource, computed by the edge node if empty")
+	fs.StringVar(&in.Contributor, "contributor", "", "vehicle or device that contributed the data")
+	fs.Int64Var(&in.Size, "size", 0, "size of the data in bytes, read by the edge node if 0")
+	replicas := fs.Int("replicas", 0, "number of nodes that should hold the IoT resource, the factor of its type if 0")
+	fs.Parse(args)
+	in.Replicas = int32(*replicas)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	reply, err := client.Offload(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Println(in.Resource, reply.Detail)
+	return nil
+}
+
+//submit : injects a test client request
+func submit(args []string) error {
+	fs, t := flags("submit")
+	in := &pb.SubmitRequest{}
+	fs.StringVar(&in.Request, "request", "", "client request, e.g. client_request_1")
+	fs.StringVar(&in.Priority, "priority", "", "priority class, the class of the request in the library if empty")
+	fs.StringVar(&in.Client, "client", "", "client the request is submitted for, anonymous if empty")
+	fs.StringVar(&in.Within, "within", "", "deadline relative to the submission, e.g. 30s")
+	fs.StringVar(&in.Lifetime, "lifetime", "", "lifetime of a subscription, e.g. 10m")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	reply, err := client.Submit(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Println(in.Request, reply.Detail)
+	return nil
+}
+
+//cancel : withdraws a client from a request or cancels it
+func cancel(args []string) error {
+	fs, t := flags("cancel")
+	in := &pb.CancelRequest{}
+	fs.StringVar(&in.Request, "request", "", "client request to withdraw")
+	fs.StringVar(&in.Client, "client", "", "client withdrawn from the request, all its clients if empty")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, stop := context.WithTimeout(context.Background(), calltimeout)
+	defer stop()
+	reply, err := client.Cancel(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Println(in.Request, reply.Detail)
+	return nil
+}
+
+//sync : triggers an anti-entropy sync of the catalog
+func sync(args []string) error {
+	fs, t := flags("sync")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	reply, err := client.Sync(ctx, &pb.SyncRequest{})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("added %d IoT resources\n", reply.Added)
+	if len(reply.Unreachable) > 0 {
+		fmt.Printf("unreachable: %s\n", strings.Join(reply.Unreachable, ","))
+	}
+	return nil
+}
+
+//drain : starts the drain of a node
+func drain(args []string) error {
+	fs, t := flags("drain")
+	in := &pb.DrainRequest{}
+	fs.StringVar(&in.Node, "node", "", "node taken down for maintenance")
+	fs.BoolVar(&in.Replicate, "replicate", false, "copy the IoT resources only the node holds to the other nodes first")
+	fs.BoolVar(&in.Migrate, "migrate", false, "move the workloads of the node instead of letting them finish")
+	fs.StringVar(&in.Timeout, "timeout", "", "time given to the workloads before the node departs, e.g. 5m")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	reply, err := client.Drain(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Println(in.Node, reply.Detail)
+	return nil
+}
+
+//undrain : makes a drained node active again
+func undrain(args []string) error {
+	fs, t := flags("undrain")
+	in := &pb.UndrainRequest{}
+	fs.StringVar(&in.Node, "node", "", "drained node")
+	fs.Parse(args)
+
+	client, conn, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), calltimeout)
+	defer cancel()
+	reply, err := client.Undrain(ctx, in)
+	if err != nil {
+		return err
+	}
+	fmt.Println(in.Node, reply.Detail)
+	return nil
+}
+
+//dash : returns a value to print, - if empty
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+//timestamp : returns a time in unix nanoseconds to print, - if 0
+func timestamp(nanos int64) string {
+	if nanos == 0 {
+		return "-"
+	}
+	return time.Unix(0, nanos).Format(time.RFC3339)
+}
+
+//elapsed : returns the time between two times in unix nanoseconds to print, - if either is 0
+func elapsed(from int64, to int64) string {
+	if from == 0 || to == 0 {
+		return "-"
+	}
+	return time.Duration(to - from).Round(time.Millisecond).String()
+}
@@ -0,0 +1,275 @@
+/*
+This package implements the replicated log of the consistent mode of the catalog (see resourcemanager/consensus.go)
+with Raft. EDIRO runs in one of two modes:
+1. eventual - the default, the IoT resources are broadcast on a best-effort basis and the reservations are local
+2. raft - the mutations of the catalog and the reservations of the IoT resources are committed to a Raft log
+replicated across the edge cluster, at the cost of a round trip to a majority of the edge nodes for each of them
+Both modes run on the same pipeline so that the latency cost of the consistency can be measured on the same testbed.
+Each edge node of the cluster is a Raft server identified by the listening address the other edge nodes reach it on,
+and talks Raft on an address of its own. The cluster is bootstrapped with all edge nodes of the configuration. The log
+is kept in memory, or in a directory to survive restarts. When the edge node is given its credentials, Raft runs over
+mutual TLS like the other inter edge communication.
+
+*/
+
+package consensus
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+)
+
+//Consistency modes of the catalog
+const (
+	Eventual = "eventual"
+	Raft     = "raft"
+)
+
+//applypoll : interval at which the index applied to the catalog is checked while waiting for it
+const applypoll = 5 * time.Millisecond
+
+//Config : The Raft server of an edge node
+type Config struct {
+	//ID : listening address of the edge node as the other edge nodes reach it, i.e. as listed in their peers
+	ID string
+	//Address : address the edge node talks Raft on, reachable by the other edge nodes
+	Address string
+	//Peers : Raft address of each other edge node of the cluster, keyed by its listening address
+	Peers map[string]string
+	//Dir : directory the log and the snapshots are kept in, empty to keep them in memory
+	Dir string
+	//Credentials : certificate of the edge node and cluster CA for mutual TLS with the other edge nodes, nil for plain
+	//text
+	Credentials *nodeidentity.Credentials
+}
+
+//Node : The Raft server replicating the catalog of an edge node
+type Node struct {
+	raft *raft.Raft
+	//closers : the stores and the transport, closed once Raft is shut down
+	closers []io.Closer
+	logger  *slog.Logger
+}
+
+/*
+New : Starts the Raft server of an edge node applying the log to its catalog. The cluster is bootstrapped with all
+edge nodes of the configuration unless the log was restored from the directory.
+Input: the configuration, the catalog of the edge node, logger
+Output: the Raft server, error if the stores or the Raft address could not be opened
+*/
+func New(cfg Config, catalog *resourcemanager.Catalog, logger *slog.Logger) (*Node, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("consistency mode raft requires a Raft address")
+	}
+	n := &Node{logger: logger}
+	hclogger := hclog.FromStandardLogger(slog.NewLogLogger(logger.Handler(), slog.LevelInfo),
+		&hclog.LoggerOptions{Name: "raft", Level: hclog.Info, DisableTime: true})
+
+	var logs raft.LogStore
+	var stable raft.StableStore
+	var snapshots raft.SnapshotStore
+	if cfg.Dir == "" {
+		store := raft.NewInmemStore()
+		logs, stable, snapshots = store, store, raft.NewInmemSnapshotStore()
+	} else {
+		if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+			return nil, err
+		}
+		store, err := raftboltdb.NewBoltStore(filepath.Join(cfg.Dir, "raft.db"))
+		if err != nil {
+			return nil, fmt.Errorf("could not open the Raft log: %v", err)
+		}
+		n.closers = append(n.closers, store)
+		logs, stable = store, store
+		if snapshots, err = raft.NewFileSnapshotStoreWithLogger(cfg.Dir, 2, hclogger); err != nil {
+			n.close()
+			return nil, fmt.Errorf("could not open the Raft snapshots: %v", err)
+		}
+	}
+
+	transport, err := newtransport(cfg, hclogger)
+	if err != nil {
+		n.close()
+		return nil, fmt.Errorf("could not listen on the Raft address: %v", err)
+	}
+	n.closers = append(n.closers, transport)
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(cfg.ID)
+	config.Logger = hclogger
+	n.raft, err = raft.NewRaft(config, &fsm{catalog: catalog, logger: logger}, logs, stable, snapshots, transport)
+	if err != nil {
+		n.close()
+		return nil, err
+	}
+
+	existing, err := raft.HasExistingState(logs, stable, snapshots)
+	if err != nil {
+		n.Shutdown()
+		return nil, err
+	}
+	if !existing {
+		servers := []raft.Server{{ID: raft.ServerID(cfg.ID), Address: raft.ServerAddress(cfg.Address)}}
+		for id, address := range cfg.Peers {
+			servers = append(servers, raft.Server{ID: raft.ServerID(id), Address: raft.ServerAddress(address)})
+		}
+		//every edge node bootstraps the same configuration, only the first one to do so counts
+		err := n.raft.BootstrapCluster(raft.Configuration{Servers: servers}).Error()
+		if err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+			n.Shutdown()
+			return nil, err
+		}
+	}
+	logger.Info("raft server started", "id", cfg.ID, "addr", cfg.Address, "peers", len(cfg.Peers),
+		"restored", existing, "dir", cfg.Dir)
+	return n, nil
+}
+
+//newtransport : returns the Raft transport of an edge node, over mutual TLS when it has its credentials
+func newtransport(cfg Config, logger hclog.Logger) (*raft.NetworkTransport, error) {
+	if cfg.Credentials == nil {
+		return raft.NewTCPTransportWithLogger(cfg.Address, nil, 3, 10*time.Second, logger)
+	}
+	listener, err := tls.Listen("tcp", cfg.Address, cfg.Credentials.Server())
+	if err != nil {
+		return nil, err
+	}
+	stream := &tlsstream{Listener: listener, client: cfg.Credentials.Client()}
+	return raft.NewNetworkTransportWithLogger(stream, 3, 10*time.Second, logger), nil
+}
+
+//tlsstream : the stream layer of the Raft transport over mutual TLS
+type tlsstream struct {
+	net.Listener
+	client *tls.Config
+}
+
+func (s *tlsstream) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", string(address), s.client)
+}
+
+//close : closes the stores and the transport
+func (n *Node) close() {
+	for _, c := range n.closers {
+		c.Close()
+	}
+}
+
+//Shutdown : stops the Raft server, the catalog is no longer updated
+func (n *Node) Shutdown() {
+	if err := n.raft.Shutdown().Error(); err != nil {
+		n.logger.Warn("could not shut down the raft server", "err", err)
+	}
+	n.close()
+	n.logger.Info("raft server stopped")
+}
+
+//Leading : returns whether this edge node leads the log
+func (n *Node) Leading() bool {
+	return n.raft.State() == raft.Leader
+}
+
+//Leader : returns the listening address of the edge node leading the log, empty if unknown
+func (n *Node) Leader() string {
+	_, id := n.raft.LeaderWithID()
+	return string(id)
+}
+
+//Apply : appends a command to the log, on the leader only, and returns its result and index once applied
+func (n *Node) Apply(command []byte, timeout time.Duration) (resourcemanager.Result, uint64, error) {
+	f := n.raft.Apply(command, timeout)
+	if err := f.Error(); err != nil {
+		return resourcemanager.Result{}, 0, leadererror(err)
+	}
+	result, _ := f.Response().(resourcemanager.Result)
+	return result, f.Index(), nil
+}
+
+//Barrier : returns, on the leader only, the index of the log once every command before it was applied
+func (n *Node) Barrier(timeout time.Duration) (uint64, error) {
+	if err := n.raft.Barrier(timeout).Error(); err != nil {
+		return 0, leadererror(err)
+	}
+	return n.raft.AppliedIndex(), nil
+}
+
+//Wait : waits until the catalog of this edge node applied the log up to an index
+func (n *Node) Wait(ctx context.Context, index uint64) error {
+	for n.raft.AppliedIndex() < index {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("catalog did not apply the log up to %d: %v", index, ctx.Err())
+		case <-time.After(applypoll):
+		}
+	}
+	return nil
+}
+
+//leadererror : returns resourcemanager.ErrNotleader for the errors of a server that does not lead the log
+func leadererror(err error) error {
+	if errors.Is(err, raft.ErrNotLeader) {
+		return resourcemanager.ErrNotleader
+	}
+	return err
+}
+
+//fsm : applies the log to the catalog of the edge node
+type fsm struct {
+	catalog *resourcemanager.Catalog
+	logger  *slog.Logger
+}
+
+func (f *fsm) Apply(l *raft.Log) interface{} {
+	var cmd resourcemanager.Command
+	if err := json.Unmarshal(l.Data, &cmd); err != nil {
+		f.logger.Error("could not decode command of the log", "index", l.Index, "err", err)
+		return resourcemanager.Result{}
+	}
+	return f.catalog.Execute(cmd)
+}
+
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	state, err := f.catalog.Save()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(state), nil
+}
+
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	state, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return f.catalog.Restore(state)
+}
+
+//snapshot : the catalog saved in a snapshot of the log
+type snapshot []byte
+
+func (s snapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := sink.Write(s); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s snapshot) Release() {}
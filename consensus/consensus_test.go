@@ -0,0 +1,129 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+
+	"github.com/hashicorp/raft"
+)
+
+func newcatalog() *resourcemanager.Catalog {
+	return resourcemanager.NewCatalog(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func encode(t *testing.T, cmd resourcemanager.Command) []byte {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+//sink : a snapshot sink keeping the snapshot in memory
+type sink struct {
+	bytes.Buffer
+	cancelled bool
+}
+
+func (s *sink) ID() string    { return "snapshot" }
+func (s *sink) Close() error  { return nil }
+func (s *sink) Cancel() error { s.cancelled = true; return nil }
+
+func TestFSM(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want resourcemanager.Result
+	}{
+		{name: "record", data: encode(t, resourcemanager.Command{Op: resourcemanager.Recordop, Resource: "a",
+			Node: "n2"}), want: resourcemanager.Result{Holderresources: 1, Holders: 2}},
+		{name: "claim", data: encode(t, resourcemanager.Command{Op: resourcemanager.Claimop, Resource: "r",
+			Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}), want: resourcemanager.Result{Node: "n1", Ok: true}},
+		{name: "corrupted command", data: []byte("{")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			c.Execute(resourcemanager.Command{Op: resourcemanager.Recordop, Resource: "r", Node: "n1"})
+			f := &fsm{catalog: c, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+			if got := f.Apply(&raft.Log{Index: 1, Data: tt.data}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply = %+v, want %+v", got, tt.want)
+			}
+
+			//the catalog survives a snapshot
+			snap, err := f.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &sink{}
+			if err := snap.Persist(s); err != nil || s.cancelled {
+				t.Fatalf("Persist = %v, cancelled %v", err, s.cancelled)
+			}
+			restored := &fsm{catalog: newcatalog(), logger: f.logger}
+			if err := restored.Restore(io.NopCloser(&s.Buffer)); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := restored.catalog.Entries(), c.Entries(); !reflect.DeepEqual(got, want) {
+				t.Errorf("restored %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+//freeaddress : returns a local address no one listens on
+func freeaddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestNode(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if _, err := New(Config{ID: "n1"}, newcatalog(), logger); err == nil {
+		t.Fatal("New without a Raft address succeeded")
+	}
+
+	for _, dir := range []string{"", t.TempDir()} {
+		c := newcatalog()
+		n, err := New(Config{ID: "127.0.0.1:5000", Address: freeaddress(t), Dir: dir}, c, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		for !n.Leading() && ctx.Err() == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !n.Leading() || n.Leader() != "127.0.0.1:5000" {
+			t.Fatalf("single edge node not leading, leader %q", n.Leader())
+		}
+
+		result, index, err := n.Apply(encode(t, resourcemanager.Command{Op: resourcemanager.Recordop, Resource: "r",
+			Node: "n1"}), time.Second)
+		if err != nil || result.Holders != 1 {
+			t.Fatalf("Apply = %+v, %v", result, err)
+		}
+		if err := n.Wait(ctx, index); err != nil {
+			t.Fatal(err)
+		}
+		if !c.Has("r") {
+			t.Error("command not applied to the catalog")
+		}
+		if barrier, err := n.Barrier(time.Second); err != nil || barrier < index {
+			t.Errorf("Barrier = %d, %v, want at least %d", barrier, err, index)
+		}
+		cancel()
+		n.Shutdown()
+	}
+}
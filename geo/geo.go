@@ -0,0 +1,175 @@
+/*
+This package implements the geographic footprint of the IoT resources and the geohash cells used to index them. IoT
+resources such as map tiles or road sensor readings cover an area, given as a bounding box in degrees of latitude and
+longitude. A footprint is indexed under the geohash cells it overlaps, at the finest precision keeping the number of
+cells small, so that the resources overlapping an area are found by looking up the cells of that area.
+
+*/
+
+package geo
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+//base32 : alphabet of the geohashes
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+//Maxprecision : number of characters of the finest geohash cells used, about 5 km wide
+const Maxprecision = 5
+
+//Box : A bounding box in degrees
+type Box struct {
+	Minlat float64 `json:"minlat"`
+	Minlon float64 `json:"minlon"`
+	Maxlat float64 `json:"maxlat"`
+	Maxlon float64 `json:"maxlon"`
+}
+
+//Query : IoT resources selected by type, area and freshness
+type Query struct {
+	//Type : type of the IoT resources, e.g. hd_map, empty for any
+	Type string
+	//Area : area the IoT resources must overlap
+	Area Box
+	//Maxage : age beyond which an IoT resource is too old, 0 for any age
+	Maxage time.Duration
+}
+
+//Empty : returns whether the box is the zero box, i.e. no footprint was given
+func (b Box) Empty() bool {
+	return b == Box{}
+}
+
+//Validate : returns an error if the box is not a valid bounding box
+func (b Box) Validate() error {
+	if b.Minlat < -90 || b.Maxlat > 90 || b.Minlon < -180 || b.Maxlon > 180 {
+		return fmt.Errorf("box %v is out of range", b)
+	}
+	if b.Minlat > b.Maxlat || b.Minlon > b.Maxlon {
+		return fmt.Errorf("box %v has its minimum above its maximum", b)
+	}
+	return nil
+}
+
+//Intersects : returns whether two boxes overlap, boxes sharing an edge overlap
+func (b Box) Intersects(o Box) bool {
+	return b.Minlat <= o.Maxlat && o.Minlat <= b.Maxlat && b.Minlon <= o.Maxlon && o.Minlon <= b.Maxlon
+}
+
+//Centre : returns the latitude and longitude of the centre of the box
+func (b Box) Centre() (float64, float64) {
+	return (b.Minlat + b.Maxlat) / 2, (b.Minlon + b.Maxlon) / 2
+}
+
+/*
+Distance : Returns the great circle distance between two points.
+Input: latitude and longitude of both points in degrees
+Output: the distance in km
+*/
+func Distance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	const earthradius = 6371.0
+	rad := math.Pi / 180
+	dlat, dlon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthradius * math.Asin(math.Sqrt(h))
+}
+
+/*
+Encode : Returns the geohash of a point.
+Input: latitude and longitude in degrees, number of characters of the geohash
+Output: the geohash
+*/
+func Encode(lat float64, lon float64, precision int) string {
+	latrange, lonrange := [2]float64{-90, 90}, [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	even := true
+	var bits, ch int
+	for len(hash) < precision {
+		r, v := &latrange, lat
+		if even {
+			r, v = &lonrange, lon
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash = append(hash, base32[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+//cellsize : returns the height and width in degrees of the geohash cells of a precision
+func cellsize(precision int) (float64, float64) {
+	lonbits := (5*precision + 1) / 2
+	latbits := 5 * precision / 2
+	return 180 / math.Exp2(float64(latbits)), 360 / math.Exp2(float64(lonbits))
+}
+
+//cell : returns the index of the cell of a size holding a coordinate, counted from the lower bound of the range of the
+//coordinate. The upper bound of the range, e.g. the latitude 90, is in the last cell.
+func cell(v float64, lower float64, extent float64, size float64) int {
+	i := int(math.Floor((v - lower) / size))
+	if last := int(math.Round(extent/size)) - 1; i > last {
+		return last
+	}
+	return i
+}
+
+//span : returns the number of geohash cells of a precision along the latitude and the longitude of a box
+func span(b Box, precision int) (int, int) {
+	height, width := cellsize(precision)
+	rows := cell(b.Maxlat, -90, 180, height) - cell(b.Minlat, -90, 180, height) + 1
+	columns := cell(b.Maxlon, -180, 360, width) - cell(b.Minlon, -180, 360, width) + 1
+	return rows, columns
+}
+
+//Count : returns the number of geohash cells of a precision overlapped by a box
+func Count(b Box, precision int) int {
+	rows, columns := span(b, precision)
+	return rows * columns
+}
+
+/*
+Precision : Returns the finest precision at which a box overlaps at most a given number of geohash cells.
+Input: box, maximum number of cells
+Output: precision between 1 and Maxprecision
+*/
+func Precision(b Box, maxcells int) int {
+	for precision := Maxprecision; precision > 1; precision-- {
+		if Count(b, precision) <= maxcells {
+			return precision
+		}
+	}
+	return 1
+}
+
+/*
+Cells : Returns the geohash cells of a precision overlapped by a box.
+Input: box, precision
+Output: the geohashes of the cells
+*/
+func Cells(b Box, precision int) []string {
+	height, width := cellsize(precision)
+	rows, columns := span(b, precision)
+	//centre of the cell of the lower corner of the box
+	lat0 := (float64(cell(b.Minlat, -90, 180, height))+0.5)*height - 90
+	lon0 := (float64(cell(b.Minlon, -180, 360, width))+0.5)*width - 180
+	cells := make([]string, 0, rows*columns)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < columns; j++ {
+			cells = append(cells, Encode(lat0+float64(i)*height, lon0+float64(j)*width, precision))
+		}
+	}
+	return cells
+}
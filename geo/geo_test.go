@@ -0,0 +1,164 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// munich : a box of about 7 by 11 km
+var munich = Box{Minlat: 48.1, Minlon: 11.5, Maxlat: 48.2, Maxlon: 11.6}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		box     Box
+		wanterr bool
+	}{
+		{name: "valid", box: munich},
+		{name: "point", box: Box{Minlat: 48.1, Minlon: 11.5, Maxlat: 48.1, Maxlon: 11.5}},
+		{name: "whole world", box: Box{Minlat: -90, Minlon: -180, Maxlat: 90, Maxlon: 180}},
+		{name: "latitude out of range", box: Box{Minlat: -91, Maxlat: 10}, wanterr: true},
+		{name: "longitude out of range", box: Box{Minlon: 10, Maxlon: 181}, wanterr: true},
+		{name: "minimum above maximum", box: Box{Minlat: 48.2, Minlon: 11.5, Maxlat: 48.1, Maxlon: 11.6},
+			wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.box.Validate(); (err != nil) != tt.wanterr {
+				t.Errorf("Validate = %v, want error %v", err, tt.wanterr)
+			}
+		})
+	}
+}
+
+func TestIntersects(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Box
+		want bool
+	}{
+		{name: "same box", a: munich, b: munich, want: true},
+		{name: "overlap", a: munich, b: Box{Minlat: 48.15, Minlon: 11.55, Maxlat: 48.3, Maxlon: 11.7}, want: true},
+		{name: "contained", a: munich, b: Box{Minlat: 48.12, Minlon: 11.52, Maxlat: 48.13, Maxlon: 11.53},
+			want: true},
+		{name: "shared edge", a: munich, b: Box{Minlat: 48.2, Minlon: 11.5, Maxlat: 48.3, Maxlon: 11.6}, want: true},
+		{name: "apart in latitude", a: munich, b: Box{Minlat: 48.3, Minlon: 11.5, Maxlat: 48.4, Maxlon: 11.6}},
+		{name: "apart in longitude", a: munich, b: Box{Minlat: 48.1, Minlon: 11.7, Maxlat: 48.2, Maxlon: 11.8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Intersects(tt.b); got != tt.want {
+				t.Errorf("Intersects = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.Intersects(tt.a); got != tt.want {
+				t.Errorf("Intersects the other way = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{lat: 57.64911, lon: 10.40744, precision: 11, want: "u4pruydqqvj"},
+		{lat: 57.64911, lon: 10.40744, precision: 5, want: "u4pru"},
+		{lat: 48.1374, lon: 11.5755, precision: 5, want: "u281z"},
+		{lat: -90, lon: -180, precision: 3, want: "000"},
+		{lat: 90, lon: 180, precision: 3, want: "zzz"},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.lat, tt.lon, tt.precision); got != tt.want {
+			t.Errorf("Encode(%v, %v, %d) = %q, want %q", tt.lat, tt.lon, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestCells(t *testing.T) {
+	tests := []struct {
+		name      string
+		box       Box
+		precision int
+		wantcount int
+	}{
+		{name: "point", box: Box{Minlat: 48.14, Minlon: 11.58, Maxlat: 48.14, Maxlon: 11.58}, precision: 5,
+			wantcount: 1},
+		{name: "box at the finest precision", box: munich, precision: 5, wantcount: 9},
+		{name: "box at a coarse precision", box: munich, precision: 3, wantcount: 1},
+		{name: "whole world", box: Box{Minlat: -90, Minlon: -180, Maxlat: 90, Maxlon: 180}, precision: 1,
+			wantcount: 32},
+		{name: "upper corner of the world", box: Box{Minlat: 90, Minlon: 180, Maxlat: 90, Maxlon: 180},
+			precision: 2, wantcount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if count := Count(tt.box, tt.precision); count != tt.wantcount {
+				t.Errorf("Count = %d, want %d", count, tt.wantcount)
+			}
+			cells := Cells(tt.box, tt.precision)
+			distinct := map[string]bool{}
+			for _, cell := range cells {
+				if len(cell) != tt.precision {
+					t.Errorf("cell %q of precision %d", cell, tt.precision)
+				}
+				distinct[cell] = true
+			}
+			if len(cells) != tt.wantcount || len(distinct) != tt.wantcount {
+				t.Errorf("%d cells, %d distinct, want %d", len(cells), len(distinct), tt.wantcount)
+			}
+			//the corners of the box are in its cells
+			for _, corner := range [][2]float64{{tt.box.Minlat, tt.box.Minlon}, {tt.box.Maxlat, tt.box.Maxlon}} {
+				if cell := Encode(corner[0], corner[1], tt.precision); !distinct[cell] {
+					t.Errorf("cell %q of corner %v not among the cells %v", cell, corner, cells)
+				}
+			}
+		})
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		box      Box
+		maxcells int
+		want     int
+	}{
+		{name: "point", box: Box{Minlat: 48.14, Minlon: 11.58, Maxlat: 48.14, Maxlon: 11.58}, maxcells: 1,
+			want: Maxprecision},
+		{name: "box within the limit", box: munich, maxcells: 16, want: Maxprecision},
+		{name: "box above the limit", box: munich, maxcells: 4, want: 4},
+		{name: "whole world", box: Box{Minlat: -90, Minlon: -180, Maxlat: 90, Maxlon: 180}, maxcells: 16, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Precision(tt.box, tt.maxcells)
+			if got != tt.want {
+				t.Errorf("Precision = %d, want %d", got, tt.want)
+			}
+			if got > 1 && Count(tt.box, got) > tt.maxcells {
+				t.Errorf("%d cells at precision %d, above %d", Count(tt.box, got), got, tt.maxcells)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64 //km
+	}{
+		{name: "same point", lat1: 48.14, lon1: 11.58, lat2: 48.14, lon2: 11.58, want: 0},
+		{name: "one degree of latitude", lat1: 0, lon1: 0, lat2: 1, lon2: 0, want: 111.19},
+		{name: "Munich to Berlin", lat1: 48.1374, lon1: 11.5755, lat2: 52.5200, lon2: 13.4050, want: 504.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(got-tt.want) > 0.5 {
+				t.Errorf("Distance = %.2f km, want %.2f km", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,11 @@ Author : Niket Agrawal
 
 package library
 
+import (
+	"fmt"
+	"time"
+)
+
 /*
   RequesttoApp : Maps incoming client request to the corresponding application that needs to be deployed to
    fullfil that request. Specify the name of the desired application image below
@@ -17,6 +22,7 @@ var RequesttoApp = map[string]string{
 	"client_request_1": "application_image_1",
 	"client_request_2": "application_image_2",
 	"client_request_3": "application_image_3",
+	"client_request_4": "application_image_4",
 }
 
 /*
@@ -27,4 +33,188 @@ var ApptoResource = map[string]string{
 	"application_image_1": "IoT_resource_1",
 	"application_image_2": "IoT_resource_2",
 	"application_image_3": "IoT_resource_3",
+	"application_image_5": "IoT_resource_5",
+}
+
+//Input : An input required by an application, i.e. one named IoT resource or a number of IoT resources of a type
+type Input struct {
+	//Name : name of the input, the workload finds its IoT resources in the environment variable EDIRO_INPUT_<NAME>
+	Name string
+	//Resource : name of the IoT resource, or Type : type of the IoT resources, e.g. hd_map (see package geo)
+	Resource, Type string
+	//Min and Max : number of IoT resources of the input, the freshest are taken. Max is Min if 0.
+	Min, Max int
+	//Maxage : age beyond which an IoT resource is too old for the input, 0 for any age
+	Maxage time.Duration
+}
+
+/*
+  ApptoInputs : Maps the applications fusing several IoT resources to the inputs they require. The inputs of an
+   application listed here replace its IoT resource in ApptoResource. The IoT resources of a typed input overlap the
+   area of the location query of the request, if any.
+*/
+var ApptoInputs = map[string][]Input{
+	"application_image_4": {
+		{Name: "map", Type: "hd_map", Min: 1},
+		{Name: "camera", Type: "camera_frame", Min: 2, Max: 4, Maxage: 30 * time.Second},
+		{Name: "lidar", Type: "lidar", Min: 1, Max: 3, Maxage: 30 * time.Second},
+	},
+}
+
+//Inputsof : returns the inputs of an application, the single IoT resource of ApptoResource if it has no inputs listed
+func Inputsof(application string) []Input {
+	if inputs, ok := ApptoInputs[application]; ok {
+		return inputs
+	}
+	return []Input{{Name: "resource", Resource: ApptoResource[application], Min: 1}}
+}
+
+//Wanted : returns the number of IoT resources taken for an input
+func (i Input) Wanted() int {
+	if i.Max < i.Min {
+		return i.Min
+	}
+	return i.Max
+}
+
+//Stage : A stage of the pipeline of applications serving a client request
+type Stage struct {
+	//Name : name of the stage, unique within the pipeline
+	Name string
+	//Application : application run by the stage, its IoT resources are found in ApptoResource and ApptoInputs
+	Application string
+	//After : stages whose output the stage consumes, it starts once they all completed
+	After []string
+}
+
+/*
+  RequesttoStages : Maps a client request to the pipeline of applications serving it, a directed acyclic graph of stages
+   passing their output to the stages after them. A request listed here is not looked up in RequesttoApp.
+*/
+var RequesttoStages = map[string][]Stage{
+	"client_request_5": {
+		{Name: "downsample", Application: "application_image_5"},
+		{Name: "inference", Application: "application_image_6", After: []string{"downsample"}},
+	},
+}
+
+/*
+Order : Orders the stages of a pipeline so that every stage comes after the stages it consumes the output of.
+Input: stages of the pipeline
+Output: the stages in order, error if a stage is named twice, consumes an unknown stage or the pipeline has a cycle
+*/
+func Order(stages []Stage) ([]Stage, error) {
+	index := map[string]int{}
+	for i, s := range stages {
+		if s.Name == "" {
+			return nil, fmt.Errorf("stage %d has no name", i)
+		}
+		if _, ok := index[s.Name]; ok {
+			return nil, fmt.Errorf("stage %s is named twice", s.Name)
+		}
+		index[s.Name] = i
+	}
+	waiting := make([]int, len(stages))
+	next := make([][]int, len(stages))
+	for i, s := range stages {
+		for _, after := range s.After {
+			j, ok := index[after]
+			if !ok {
+				return nil, fmt.Errorf("stage %s comes after unknown stage %s", s.Name, after)
+			}
+			waiting[i]++
+			next[j] = append(next[j], i)
+		}
+	}
+	var ordered []Stage
+	var ready []int
+	for i := range stages {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, stages[i])
+		for _, j := range next[i] {
+			if waiting[j]--; waiting[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(ordered) < len(stages) {
+		return nil, fmt.Errorf("pipeline has a cycle")
+	}
+	return ordered, nil
+}
+
+//Checkstages : returns an error naming the first client request whose pipeline is not a directed acyclic graph
+func Checkstages() error {
+	for request, stages := range RequesttoStages {
+		if _, err := Order(stages); err != nil {
+			return fmt.Errorf("pipeline of %s: %v", request, err)
+		}
+	}
+	return nil
+}
+
+//Priority classes of the client requests, from the most to the least urgent
+const (
+	Critical = "critical"
+	High     = "high"
+	Normal   = "normal"
+	Low      = "low"
+)
+
+/*
+  Priorityrank : Rank of each priority class. Requests of a higher rank are admitted first, and a critical request may
+   preempt the workloads of a lower rank on a saturated edge node.
+*/
+var Priorityrank = map[string]int{
+	Critical: 3,
+	High:     2,
+	Normal:   1,
+	Low:      0,
+}
+
+/*
+  RequesttoPriority : Maps a client request to its priority class when the client does not give one. Requests that are
+   not listed are of the class normal.
+*/
+var RequesttoPriority = map[string]string{
+	"client_request_1": Critical,
+	"client_request_2": Normal,
+	"client_request_3": Low,
+}
+
+//Priorityof : returns the priority class of a client request given the class requested by the client, if any
+func Priorityof(request string, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if priority, ok := RequesttoPriority[request]; ok {
+		return priority
+	}
+	return Normal
+}
+
+/*
+  Typereplication : Number of edge nodes of the swarm that should hold each IoT resource of a type, the edge node it is
+   offloaded on included, so that the requests needing it do not stall when an edge node fails. IoT resources of a type
+   that is not listed are only held by the edge node they are offloaded on, unless their contributor asks for more.
+*/
+var Typereplication = map[string]int{
+	"hd_map": 2,
+}
+
+//Replicationof : returns the replication factor of an IoT resource of a type given the factor asked by its contributor, if any
+func Replicationof(resourcetype string, requested int) int {
+	if requested > 0 {
+		return requested
+	}
+	if factor, ok := Typereplication[resourcetype]; ok {
+		return factor
+	}
+	return 1
 }
@@ -0,0 +1,68 @@
+package library
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		stages  []Stage
+		want    []string //names of the stages in order
+		wanterr bool
+	}{
+		{name: "single stage", stages: []Stage{{Name: "a"}}, want: []string{"a"}},
+		{name: "chain listed backwards", stages: []Stage{{Name: "c", After: []string{"b"}},
+			{Name: "b", After: []string{"a"}}, {Name: "a"}}, want: []string{"a", "b", "c"}},
+		{name: "fan in", stages: []Stage{{Name: "fuse", After: []string{"left", "right"}}, {Name: "left"},
+			{Name: "right"}}, want: []string{"left", "right", "fuse"}},
+		{name: "stage without name", stages: []Stage{{Name: "a"}, {}}, wanterr: true},
+		{name: "stage named twice", stages: []Stage{{Name: "a"}, {Name: "a"}}, wanterr: true},
+		{name: "unknown stage", stages: []Stage{{Name: "a", After: []string{"b"}}}, wanterr: true},
+		{name: "cycle", stages: []Stage{{Name: "a", After: []string{"b"}}, {Name: "b", After: []string{"a"}}},
+			wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := Order(tt.stages)
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("Order = %v, want error %v", err, tt.wanterr)
+			}
+			var names []string
+			for _, s := range ordered {
+				names = append(names, s.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("ordered %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckstages(t *testing.T) {
+	if err := Checkstages(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReplicationof(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourcetype string
+		requested    int
+		want         int
+	}{
+		{name: "type replicated", resourcetype: "hd_map", want: 2},
+		{name: "type not listed", resourcetype: "camera_frame", want: 1},
+		{name: "asked by the contributor", resourcetype: "camera_frame", requested: 3, want: 3},
+		{name: "asked below the factor of the type", resourcetype: "hd_map", requested: 1, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Replicationof(tt.resourcetype, tt.requested); got != tt.want {
+				t.Errorf("Replicationof = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+This package implements the structured logging of EDIRO on top of log/slog. Every module logs through its own logger
+obtained from For(), which tags each line with the name of the module (subsystem). The orchestrator adds the name of the
+edge node to the loggers of its modules. The level of each subsystem can be set separately and the output is either
+human readable text or JSON to be parsed by machines.
+Lines about a client request or an IoT resource carry the fields 'request' and 'resource' so that all lines about one
+request can be correlated across modules and nodes.
+
+*/
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+//Keys of the fields used to correlate log lines
+const (
+	Node      = "node"
+	Subsystem = "subsystem"
+	Request   = "request"
+	Resource  = "resource"
+)
+
+var (
+	mux          sync.RWMutex
+	base         slog.Handler = slog.Default().Handler()
+	defaultlevel              = slog.LevelInfo
+	levels                    = map[string]slog.Level{}
+)
+
+/*
+Setup : Configures the output of all loggers. It can be called after the loggers have been obtained with For().
+Input: writer to log to, format of the lines (text or json), levels given as a comma separated list of an optional
+default level and subsystem=level pairs, e.g. "info,resourcemanager=debug,parser=warn"
+Output: error if the format or a level is invalid
+*/
+func Setup(w io.Writer, format string, levelspec string) error {
+	var h slog.Handler
+	options := &slog.HandlerOptions{Level: slog.LevelDebug} //filtering is done per subsystem by the loggers
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, options)
+	case "text":
+		h = slog.NewTextHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	for _, entry := range strings.Split(levelspec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, found := strings.Cut(entry, "=")
+		var level slog.Level
+		if !found {
+			if err := level.UnmarshalText([]byte(name)); err != nil {
+				return err
+			}
+			defaultlevel = level
+			continue
+		}
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+		levels[name] = level
+	}
+	base = h
+	return nil
+}
+
+//For : returns the logger of a subsystem
+func For(subsystem string) *slog.Logger {
+	return slog.New(&handler{subsystem: subsystem})
+}
+
+/*
+handler : hands the records of a subsystem to the handler configured by Setup, which may change after the logger has
+been created. Attributes and groups added to the logger are replayed on the configured handler.
+*/
+type handler struct {
+	subsystem string
+	with      []func(slog.Handler) slog.Handler
+}
+
+//level : returns the level set for the subsystem, or the default level if none is set
+func (h *handler) level() slog.Level {
+	mux.RLock()
+	defer mux.RUnlock()
+	if level, ok := levels[h.subsystem]; ok {
+		return level
+	}
+	return defaultlevel
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	mux.RLock()
+	target := base
+	mux.RUnlock()
+	target = target.WithAttrs([]slog.Attr{slog.String(Subsystem, h.subsystem)})
+	for _, with := range h.with {
+		target = with(target)
+	}
+	return target.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.extend(func(target slog.Handler) slog.Handler { return target.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.extend(func(target slog.Handler) slog.Handler { return target.WithGroup(name) })
+}
+
+func (h *handler) extend(with func(slog.Handler) slog.Handler) slog.Handler {
+	extended := &handler{subsystem: h.subsystem, with: make([]func(slog.Handler) slog.Handler, 0, len(h.with)+1)}
+	extended.with = append(append(extended.with, h.with...), with)
+	return extended
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		levelspec string
+		wanterr   bool
+		//wantlogged : subsystems whose info line is logged
+		wantlogged []string
+	}{
+		{name: "default level", format: "text", wantlogged: []string{"parser", "taskinitiator"}},
+		{name: "raised default level", format: "text", levelspec: "warn"},
+		{name: "level of a subsystem", format: "json", levelspec: "warn,parser=info", wantlogged: []string{"parser"}},
+		{name: "level of a subsystem only", format: "json", levelspec: " taskinitiator=error ",
+			wantlogged: []string{"parser"}},
+		{name: "unknown format", format: "xml", wanterr: true},
+		{name: "unknown level", format: "text", levelspec: "loud", wanterr: true},
+		{name: "unknown level of a subsystem", format: "text", levelspec: "parser=loud", wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultlevel, levels = slog.LevelInfo, map[string]slog.Level{}
+			var out bytes.Buffer
+			if err := Setup(&out, tt.format, tt.levelspec); (err != nil) != tt.wanterr {
+				t.Fatalf("Setup = %v, want error %v", err, tt.wanterr)
+			}
+			if tt.wanterr {
+				return
+			}
+			var logged []string
+			for _, subsystem := range []string{"parser", "taskinitiator"} {
+				out.Reset()
+				For(subsystem).Info("request parsed")
+				if out.Len() > 0 {
+					logged = append(logged, subsystem)
+				}
+			}
+			if strings.Join(logged, ",") != strings.Join(tt.wantlogged, ",") {
+				t.Errorf("logged by %v, want %v", logged, tt.wantlogged)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	defaultlevel, levels = slog.LevelInfo, map[string]slog.Level{}
+	//the logger is obtained before the output is configured
+	logger := For("resourcemanager").With(Node, "edge_node_1", Resource, "hd_map_1")
+	var out bytes.Buffer
+	if err := Setup(&out, "json", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	logger.WithGroup("update").Debug("resource offloaded", Request, "client_request_1")
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("%v: %s", err, out.Bytes())
+	}
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{key: Node, want: "edge_node_1"},
+		{key: Subsystem, want: "resourcemanager"},
+		{key: Resource, want: "hd_map_1"},
+		{key: "level", want: "DEBUG"},
+		{key: "msg", want: "resource offloaded"},
+		{key: "update", want: map[string]any{Request: "client_request_1"}},
+	}
+	for _, tt := range tests {
+		if got, _ := json.Marshal(line[tt.key]); !bytes.Equal(got, mustmarshal(t, tt.want)) {
+			t.Errorf("field %s = %s, want %s", tt.key, got, mustmarshal(t, tt.want))
+		}
+	}
+}
+
+//mustmarshal : returns the JSON encoding of a value
+func mustmarshal(t *testing.T, v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,125 @@
+/*
+This package implements the metrics of EDIRO. The metrics are exposed in the Prometheus format on the /metrics endpoint
+of an HTTP server so that the edge nodes of a testbed can be scraped instead of reading their console output. Besides
+the Go runtime and process statistics collected by the Prometheus client, the following is exposed:
+1. Latency of each stage a client request goes through (parse, discovery, launch, run) and of the whole pipeline up to
+the launch of the workload
+2. Time taken to spread the information about a new IoT resource to each other edge node
+3. Number of items waiting in each channel of the pipeline
+4. Number of failed broadcasts to each other edge node
+5. Time taken to commit each mutation of the catalog to the replicated log, in the consistent mode
+Every edge node has its own registry so that several edge nodes can run in one process.
+
+*/
+
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//Stages of a client request for which the latency is measured
+const (
+	Parse     = "parse"
+	Discovery = "discovery"
+	Launch    = "launch"
+	Run       = "run"
+)
+
+//Metrics : The metrics of an edge node and the registry they are exposed from
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	//Stagelatency : latency of each stage of a client request
+	Stagelatency *prometheus.HistogramVec
+	//Pipelinelatency : time from the arrival of a client request until the launch of its workload is issued
+	Pipelinelatency prometheus.Histogram
+	//Propagationtime : time from the upload of an IoT resource on this node until another edge node acknowledges it
+	Propagationtime *prometheus.HistogramVec
+	//Broadcastfailures : number of failed attempts to spread a resource update to another edge node
+	Broadcastfailures *prometheus.CounterVec
+	//Commitlatency : time from the submission of a mutation of the catalog until this edge node applied it
+	Commitlatency *prometheus.HistogramVec
+
+	logger *slog.Logger
+}
+
+//New : creates the metrics of an edge node in a registry of their own, along with the Go runtime and process statistics
+func New(logger *slog.Logger) *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		Stagelatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ediro_request_stage_duration_seconds",
+			Help:    "Time spent by a client request in each stage of the pipeline.",
+			Buckets: []float64{.0001, .001, .01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+		}, []string{"stage"}),
+		Pipelinelatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ediro_request_pipeline_duration_seconds",
+			Help:    "Time from the arrival of a client request until the launch of its workload is issued.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		Propagationtime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ediro_resource_propagation_seconds",
+			Help:    "Time from the upload of an IoT resource until its availability is acknowledged by a peer.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"peer"}),
+		Broadcastfailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ediro_broadcast_failures_total",
+			Help: "Number of resource updates that could not be delivered to a peer.",
+		}, []string{"peer"}),
+		Commitlatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ediro_catalog_commit_seconds",
+			Help:    "Time from the submission of a mutation of the catalog until it is applied through the replicated log.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"op"}),
+		logger: logger,
+	}
+	m.Registry.MustRegister(m.Stagelatency, m.Pipelinelatency, m.Propagationtime, m.Broadcastfailures, m.Commitlatency,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+//Observestage : records the time spent by a client request in a stage that started at 'since'
+func (m *Metrics) Observestage(stage string, since time.Time) {
+	m.Stagelatency.WithLabelValues(stage).Observe(time.Since(since).Seconds())
+}
+
+/*
+Registerqueue : exposes the number of items waiting in a channel of the pipeline.
+Input: name of the queue, function returning the current length of the channel
+Output: Nil
+*/
+func (m *Metrics) Registerqueue(name string, length func() int) {
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "ediro_queue_depth",
+		Help:        "Number of items waiting in a channel of the pipeline.",
+		ConstLabels: prometheus.Labels{"queue": name},
+	}, func() float64 { return float64(length()) }))
+}
+
+/*
+Listenformetrics : This function starts the HTTP server exposing the metrics on /metrics.
+Input: context whose cancellation shuts the server down, listening address
+Output: Nil
+*/
+func (m *Metrics) Listenformetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}))
+
+	m.logger.Info("launching metrics endpoint", "addr", addr)
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		m.logger.Error("metrics endpoint stopped", "err", err)
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//scrape : returns the metrics of the registry exposed on /metrics
+func scrape(t *testing.T, m *Metrics) string {
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		observe func(m *Metrics)
+		want    string //line expected on /metrics
+	}{
+		{
+			name:    "stage latency",
+			observe: func(m *Metrics) { m.Observestage(Discovery, time.Now().Add(-time.Second)) },
+			want:    `ediro_request_stage_duration_seconds_bucket{stage="discovery",le="1"} 0`,
+		},
+		{
+			name:    "stage count",
+			observe: func(m *Metrics) { m.Observestage(Launch, time.Now()) },
+			want:    `ediro_request_stage_duration_seconds_count{stage="launch"} 1`,
+		},
+		{
+			name:    "pipeline latency",
+			observe: func(m *Metrics) { m.Pipelinelatency.Observe(0.2) },
+			want:    `ediro_request_pipeline_duration_seconds_bucket{le="0.25"} 1`,
+		},
+		{
+			name:    "propagation time",
+			observe: func(m *Metrics) { m.Propagationtime.WithLabelValues("edge_node_2").Observe(0.02) },
+			want:    `ediro_resource_propagation_seconds_bucket{peer="edge_node_2",le="0.025"} 1`,
+		},
+		{
+			name:    "broadcast failures",
+			observe: func(m *Metrics) { m.Broadcastfailures.WithLabelValues("edge_node_3").Add(2) },
+			want:    `ediro_broadcast_failures_total{peer="edge_node_3"} 2`,
+		},
+		{
+			name:    "queue depth",
+			observe: func(m *Metrics) { m.Registerqueue("chanparseroutput", func() int { return 3 }) },
+			want:    `ediro_queue_depth{queue="chanparseroutput"} 3`,
+		},
+		{
+			name:    "runtime statistics",
+			observe: func(m *Metrics) {},
+			want:    `go_goroutines `,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(slog.Default())
+			tt.observe(m)
+			if body := scrape(t, m); !strings.Contains(body, tt.want) {
+				t.Errorf("%q not exposed", tt.want)
+			}
+		})
+	}
+}
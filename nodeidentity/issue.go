@@ -0,0 +1,164 @@
+/*
+This file implements the issuing of the cluster CA and of the node certificates. It works offline on PEM files and is
+used by the edirocert tool. A node certificate is valid both to serve and to dial other edge nodes, binds to the node ID
+through the common name of its subject and lists the addresses on which the node is reached by the other nodes.
+
+*/
+
+package nodeidentity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+//clockskew : the certificates are made valid slightly before their issuing to tolerate clocks of the nodes running behind
+const clockskew = 5 * time.Minute
+
+//Node : The identity carried by a node certificate, as returned by Describe
+type Node struct {
+	ID       string
+	Hosts    []string
+	NotAfter time.Time
+}
+
+/*
+Newca : Creates the key and the self signed certificate of a cluster CA.
+Input: name of the cluster, validity of the CA certificate
+Output: PEM encoded certificate and key of the CA, error if they could not be generated
+*/
+func Newca(cluster string, validity time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newtemplate(cluster+" CA", validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encode(der, key)
+}
+
+/*
+Issue : Issues the certificate of an edge node signed by the cluster CA.
+Input: PEM encoded certificate and key of the CA, node ID, host names and IP addresses of the node, validity
+Output: PEM encoded certificate and key of the node, error if the CA could not be read or the certificate signed
+*/
+func Issue(capem []byte, cakeypem []byte, nodeID string, hosts []string, validity time.Duration) ([]byte, []byte, error) {
+	if nodeID == "" {
+		return nil, nil, errors.New("node ID is empty")
+	}
+	ca, err := parsecertificate(capem)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(cakeypem)
+	if block == nil {
+		return nil, nil, errors.New("no CA key found")
+	}
+	cakey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template, err := newtemplate(nodeID, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, cakey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encode(der, key)
+}
+
+//Describe : returns the node ID, the hosts and the expiry of a PEM encoded node certificate
+func Describe(certpem []byte) (Node, error) {
+	cert, err := parsecertificate(certpem)
+	if err != nil {
+		return Node{}, err
+	}
+	n := Node{ID: cert.Subject.CommonName, Hosts: append([]string(nil), cert.DNSNames...), NotAfter: cert.NotAfter}
+	for _, ip := range cert.IPAddresses {
+		n.Hosts = append(n.Hosts, ip.String())
+	}
+	return n, nil
+}
+
+/*
+Writefile : Writes a PEM file, replacing any earlier file atomically so that a running node never reads a half written
+certificate.
+Input: path of the file, content, permissions
+Output: error if the file could not be written
+*/
+func Writefile(path string, data []byte, perm os.FileMode) error {
+	if err := ioutil.WriteFile(path+".tmp", data, perm); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+//newtemplate : returns a certificate template with a random serial number
+func newtemplate(commonname string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonname, Organization: []string{"EDIRO"}},
+		NotBefore:    now.Add(-clockskew),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+//parsecertificate : parses the first certificate of a PEM block
+func parsecertificate(certpem []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certpem)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+//encode : PEM encodes a certificate and its key
+func encode(der []byte, key *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	keyder, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyder}), nil
+}
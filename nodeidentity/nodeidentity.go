@@ -0,0 +1,153 @@
+/*
+This package implements the identity of the edge nodes of an EDIRO cluster. The edge nodes talk to each other over
+mutual TLS with certificates issued by a local cluster CA. Each certificate binds to the ID of one edge node, carried as
+the common name of its subject, so that an edge node can tell which node an update comes from and reject updates a node
+makes on behalf of another. Certificates are issued and rotated offline with the edirocert tool (see issue.go).
+The certificate and key of a node are read again from disk when they change, so a rotated certificate is picked up
+without restarting the node. Changing the cluster CA requires a restart.
+
+*/
+
+package nodeidentity
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+//ErrNoIdentity : returned when the caller of a gRPC call did not present a verified certificate
+var ErrNoIdentity = errors.New("caller presented no verified node certificate")
+
+//Credentials : The certificate of an edge node and the cluster CA it trusts
+type Credentials struct {
+	Certfile, Keyfile, CAfile string
+
+	pool    *x509.CertPool
+	cert    *tls.Certificate
+	modtime time.Time
+	mux     sync.Mutex
+}
+
+/*
+Load : Reads the certificate and key of an edge node and the certificate of the cluster CA.
+Input: paths of the PEM encoded node certificate, node key and CA certificate
+Output: credentials of the node, error if a file could not be read or parsed
+*/
+func Load(certfile string, keyfile string, cafile string) (*Credentials, error) {
+	capem, err := ioutil.ReadFile(cafile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(capem) {
+		return nil, fmt.Errorf("no CA certificate found in %s", cafile)
+	}
+	c := &Credentials{Certfile: certfile, Keyfile: keyfile, CAfile: cafile, pool: pool}
+	if _, err := c.certificate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//certificate : returns the certificate of the node, reading it again from disk if it was rotated
+func (c *Credentials) certificate() (*tls.Certificate, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	info, err := os.Stat(c.Certfile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil //keep the certificate in use while the file is being replaced
+		}
+		return nil, err
+	}
+	if c.cert != nil && !info.ModTime().After(c.modtime) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.Certfile, c.Keyfile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	c.cert, c.modtime = &cert, info.ModTime()
+	return c.cert, nil
+}
+
+//ID : returns the node ID bound to the certificate of the node, empty if the certificate cannot be read
+func (c *Credentials) ID() string {
+	cert, err := c.certificate()
+	if err != nil || len(cert.Certificate) == 0 {
+		return ""
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return ""
+	}
+	return leaf.Subject.CommonName
+}
+
+//Server : returns the TLS configuration of the listening server, which requires a certificate of the cluster CA
+func (c *Credentials) Server() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  c.pool,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return c.certificate()
+		},
+	}
+}
+
+//Client : returns the TLS configuration used to dial other edge nodes
+func (c *Credentials) Client() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    c.pool,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return c.certificate()
+		},
+	}
+}
+
+/*
+Caller : Returns the node ID bound to the certificate presented by the caller of a gRPC call.
+Input: context of the gRPC call
+Output: node ID of the caller, ErrNoIdentity if the call did not come over mutual TLS
+*/
+func Caller(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", ErrNoIdentity
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", ErrNoIdentity
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+/*
+Matches : Returns whether a node ID claimed in an update belongs to the node with the given certificate identity. The
+claim is either the node ID itself or a swarm placement constraint on it, e.g. node.labels.device==edge_node_1 for the
+node edge_node_1, which is how the holder of an IoT resource is given in input.json.
+Input: claimed node ID, node ID of the certificate
+Output: whether the claim matches
+*/
+func Matches(claimed string, identity string) bool {
+	if claimed == identity {
+		return true
+	}
+	return strings.HasPrefix(claimed, "node.") && strings.HasSuffix(claimed, "=="+identity)
+}
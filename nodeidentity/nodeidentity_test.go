@@ -0,0 +1,162 @@
+package nodeidentity
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+//newcredentials : issues the certificate of a node by a cluster CA in a temporary directory and loads it
+func newcredentials(t *testing.T, capem []byte, cakeypem []byte, nodeID string) *Credentials {
+	certpem, keypem, err := Issue(capem, cakeypem, nodeID, []string{"localhost"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	paths := map[string][]byte{"node.crt": certpem, "node.key": keypem, "ca.crt": capem}
+	for name, data := range paths {
+		if err := Writefile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := Load(filepath.Join(dir, "node.crt"), filepath.Join(dir, "node.key"), filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+//newca : creates a cluster CA
+func newca(t *testing.T, cluster string) ([]byte, []byte) {
+	capem, cakeypem, err := Newca(cluster, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return capem, cakeypem
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		claimed, identity string
+		want              bool
+	}{
+		{claimed: "edge_node_1", identity: "edge_node_1", want: true},
+		{claimed: "edge_node_2", identity: "edge_node_1"},
+		{claimed: "node.labels.device==edge_node_1", identity: "edge_node_1", want: true},
+		{claimed: "node.hostname==edge_node_1", identity: "edge_node_1", want: true},
+		{claimed: "node.labels.device==edge_node_2", identity: "edge_node_1"},
+		{claimed: "node.labels.device==other_edge_node_1", identity: "edge_node_1"},
+		{claimed: "labels.device==edge_node_1", identity: "edge_node_1"},
+		{claimed: "", identity: "edge_node_1"},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.claimed, tt.identity); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.claimed, tt.identity, got, tt.want)
+		}
+	}
+}
+
+func TestIssue(t *testing.T) {
+	capem, cakeypem := newca(t, "test")
+	tests := []struct {
+		name      string
+		nodeID    string
+		hosts     []string
+		wanterr   bool
+		wanthosts []string
+	}{
+		{name: "host names and addresses", nodeID: "edge_node_1", hosts: []string{"10.0.0.1", "edge1.local"},
+			wanthosts: []string{"edge1.local", "10.0.0.1"}},
+		{name: "no hosts", nodeID: "edge_node_1"},
+		{name: "empty node ID", wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certpem, _, err := Issue(capem, cakeypem, tt.nodeID, tt.hosts, time.Hour)
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("Issue = %v, want error %v", err, tt.wanterr)
+			}
+			if err != nil {
+				return
+			}
+			n, err := Describe(certpem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.ID != tt.nodeID || !reflect.DeepEqual(n.Hosts, tt.wanthosts) {
+				t.Errorf("Describe = %+v, want node ID %s and hosts %v", n, tt.nodeID, tt.wanthosts)
+			}
+		})
+	}
+}
+
+func TestCaller(t *testing.T) {
+	capem, cakeypem := newca(t, "test")
+	othercapem, othercakeypem := newca(t, "other")
+	server := newcredentials(t, capem, cakeypem, "edge_node_1")
+	tests := []struct {
+		name      string
+		client    *Credentials //credentials the caller dials with, nil for a call without TLS
+		wantid    string
+		wanterr   bool
+		wantfails bool //whether the handshake fails
+	}{
+		{name: "node of the cluster", client: newcredentials(t, capem, cakeypem, "edge_node_2"),
+			wantid: "edge_node_2"},
+		{name: "node of another cluster", client: newcredentials(t, othercapem, othercakeypem, "edge_node_2"),
+			wantfails: true},
+		{name: "no TLS", wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{})
+			if tt.client != nil {
+				serverconn, clientconn := net.Pipe()
+				defer serverconn.Close()
+				defer clientconn.Close()
+				clienttls := tt.client.Client()
+				clienttls.ServerName = "localhost"
+				go tls.Client(clientconn, clienttls).Handshake()
+				conn := tls.Server(serverconn, server.Server())
+				if err := conn.Handshake(); (err != nil) != tt.wantfails {
+					t.Fatalf("handshake = %v, want failure %v", err, tt.wantfails)
+				}
+				if tt.wantfails {
+					return
+				}
+				ctx = peer.NewContext(context.Background(),
+					&peer.Peer{AuthInfo: credentials.TLSInfo{State: conn.ConnectionState()}})
+			}
+			id, err := Caller(ctx)
+			if id != tt.wantid || (err != nil) != tt.wanterr {
+				t.Errorf("Caller = %q, %v, want %q with error %v", id, err, tt.wantid, tt.wanterr)
+			}
+		})
+	}
+}
+
+func TestID(t *testing.T) {
+	capem, cakeypem := newca(t, "test")
+	tests := []struct {
+		name   string
+		c      *Credentials
+		wantid string
+	}{
+		{name: "certificate of the node", c: newcredentials(t, capem, cakeypem, "edge_node_1"), wantid: "edge_node_1"},
+		{name: "no certificate", c: &Credentials{Certfile: filepath.Join(t.TempDir(), "node.crt")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id := tt.c.ID(); id != tt.wantid {
+				t.Errorf("ID = %q, want %q", id, tt.wantid)
+			}
+		})
+	}
+}
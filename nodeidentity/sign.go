@@ -0,0 +1,68 @@
+/*
+This file implements the signing of the data an edge node announces to the other edge nodes. An announcement is signed
+with the key of the node certificate and carries that certificate, so that any edge node trusting the cluster CA can
+verify where the announcement originates from and that it was not altered, even when it was relayed by other nodes.
+
+*/
+
+package nodeidentity
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+/*
+Sign : Signs an announcement with the key of the node certificate.
+Input: the bytes announced
+Output: signature, certificate of the node (DER) to be sent along, error if the key cannot sign
+*/
+func (c *Credentials) Sign(payload []byte) ([]byte, []byte, error) {
+	cert, err := c.certificate()
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("node key cannot sign")
+	}
+	digest := sha256.Sum256(payload)
+	signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signature, cert.Certificate[0], nil
+}
+
+/*
+Verify : Verifies that an announcement was signed by a node certificate issued by the cluster CA.
+Input: certificate sent along (DER), the bytes announced, signature
+Output: node ID of the signing node, error if the certificate is not trusted or the signature does not match
+*/
+func (c *Credentials) Verify(certder []byte, payload []byte, signature []byte) (string, error) {
+	cert, err := x509.ParseCertificate(certder)
+	if err != nil {
+		return "", err
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: c.pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		return "", err
+	}
+	var algorithm x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.ECDSA:
+		algorithm = x509.ECDSAWithSHA256
+	case x509.RSA:
+		algorithm = x509.SHA256WithRSA
+	default:
+		return "", fmt.Errorf("unsupported key algorithm %s", cert.PublicKeyAlgorithm)
+	}
+	if err := cert.CheckSignature(algorithm, payload, signature); err != nil {
+		return "", err
+	}
+	return cert.Subject.CommonName, nil
+}
@@ -0,0 +1,58 @@
+package nodeidentity
+
+import (
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	capem, cakeypem := newca(t, "test")
+	othercapem, othercakeypem := newca(t, "other")
+	verifier := newcredentials(t, capem, cakeypem, "edge_node_1")
+	payload := []byte("resource r on edge_node_2")
+	tests := []struct {
+		name     string
+		signer   *Credentials
+		verified []byte //bytes given to Verify, the payload if nil
+		certder  []byte //certificate given to Verify, the one of the signer if nil
+		wantnode string
+		wanterr  bool
+	}{
+		{name: "own announcement", signer: verifier, wantnode: "edge_node_1"},
+		{name: "announcement of another node", signer: newcredentials(t, capem, cakeypem, "edge_node_2"),
+			wantnode: "edge_node_2"},
+		{name: "altered announcement", signer: verifier, verified: []byte("resource r on edge_node_3"),
+			wanterr: true},
+		{name: "node of another cluster", signer: newcredentials(t, othercapem, othercakeypem, "edge_node_2"),
+			wanterr: true},
+		{name: "certificate swapped", signer: newcredentials(t, capem, cakeypem, "edge_node_2"),
+			certder: mustsign(t, verifier), wanterr: true},
+		{name: "malformed certificate", signer: verifier, certder: []byte("certificate"), wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signature, certder, err := tt.signer.Sign(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.verified == nil {
+				tt.verified = payload
+			}
+			if tt.certder != nil {
+				certder = tt.certder
+			}
+			node, err := verifier.Verify(certder, tt.verified, signature)
+			if node != tt.wantnode || (err != nil) != tt.wanterr {
+				t.Errorf("Verify = %q, %v, want %q, error %v", node, err, tt.wantnode, tt.wanterr)
+			}
+		})
+	}
+}
+
+//mustsign : returns the certificate a node sends along its announcements
+func mustsign(t *testing.T, c *Credentials) []byte {
+	_, certder, err := c.Sign(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certder
+}
@@ -4,7 +4,8 @@ The main() function in this file is the entry point of the executable program fo
 This program performs the following tasks upon execution:
 1. Starts the server and client functionality on the edge node that runs indefinitely awaiting inputs to be processed
 2. Parse client requests and IoT resources uploads captured in a file.
-3. Triggers the core modules of EDIRO as go routines
+3. Runs the edge node, see package orchestrator
+4. Shuts the edge node down gracefully on SIGINT or SIGTERM
 
 Author : Niket Agrawal
 
@@ -13,19 +14,27 @@ Author : Niket Agrawal
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"net"
 	_ "net/http/pprof"
 	"os"
-	"runtime"
-	"sync"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/niketagrawal/EDIRO/parser"
-	"github.com/niketagrawal/EDIRO/resourcediscovery"
+	"github.com/niketagrawal/EDIRO/clientapi"
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	"github.com/niketagrawal/EDIRO/orchestrator"
 	"github.com/niketagrawal/EDIRO/resourcemanager"
-	"github.com/niketagrawal/EDIRO/taskinitiator"
+	"github.com/niketagrawal/EDIRO/tracing"
 )
 
 //IoTResources : A struct that contains array of IoT resources as stored in the input json file
@@ -33,96 +42,175 @@ type IoTResources struct {
 	IoTResourcearray []resourcemanager.Newresource `json:"iotresources"`
 }
 
-//MonitorMem : This function monitors the run time memory usage by the program and prints out the statistics
-//Source: https://scene-si.org/2018/08/06/basic-monitoring-of-go-apps-with-the-runtime-package/
-func MonitorMem(duration int) {
-
-	var m runtime.MemStats
-	var interval = time.Duration(duration) * time.Second
-	for {
-		<-time.After(interval)
-
-		// Read full mem stats
-		runtime.ReadMemStats(&m)
-		Numgoroutine := runtime.NumGoroutine()
-
-		fmt.Printf("\tTotalAlloc : %v", m.TotalAlloc/1024/1024)
-		fmt.Printf("\tSys : %v", m.Sys/1024/1024)
-		fmt.Printf("\tHeapSys : %v", m.HeapSys/1024)
-		fmt.Printf("\tStackInuse : %v", m.StackInuse/1024)
-		fmt.Printf("\tHeapAlloc : %v", m.HeapAlloc/1024/1024)
-		fmt.Printf("\tNextGC : %v", m.NextGC)
-		fmt.Printf("\tNumGC : %v", m.NumGC)
-		fmt.Printf("\tNumGoroutines : %v\n", Numgoroutine)
-
-	}
-}
+var logger = logging.For("main")
 
 /*
 parseiotresources : This function parses IoT resources from the input json file in which the resources are stored in an
-array of structures and writes to the new IoT resource arrival channel.
-Input: unmarshalled struct converted from json, New IoT resource arrival channel
+array of structures and offloads them on the edge node. When the edge node has its credentials, the IoT resources held
+by the other edge nodes are left to them, so that every edge node can be given the same file.
+Input: context whose cancellation stops the parsing, unmarshalled struct converted from json, edge node
 Output: Nil
 */
-func parseiotresources(iotresources IoTResources, ch chan resourcemanager.Newresource) {
+func parseiotresources(ctx context.Context, iotresources IoTResources, o *orchestrator.Orchestrator) {
 	for i := 0; i < len(iotresources.IoTResourcearray); i++ {
-		fmt.Println("Resource: " + iotresources.IoTResourcearray[i].Resource)
-		fmt.Println("NodeID: " + iotresources.IoTResourcearray[i].NodeID)
-		var resource = resourcemanager.Newresource{Resource: iotresources.IoTResourcearray[i].Resource,
-			NodeID: iotresources.IoTResourcearray[i].NodeID}
-		ch <- resource
+		err := o.OffloadResource(ctx, iotresources.IoTResourcearray[i])
+		if errors.Is(err, orchestrator.ErrNotholder) {
+			logger.Debug("IoT resource left to its holder", logging.Resource,
+				iotresources.IoTResourcearray[i].Resource, "holder", iotresources.IoTResourcearray[i].NodeID)
+			continue
+		}
+		if err != nil {
+			return
+		}
+		logger.Debug("IoT resource offloaded", logging.Resource, iotresources.IoTResourcearray[i].Resource,
+			"holder", iotresources.IoTResourcearray[i].NodeID)
 	}
 }
 
 /*
 parseclientrequests : This function parses client requests from the input json file in which the requests
-are stored as array of strings and writes to the new client request channel of type string
-Input: unmarshalled struct converted from json, new client request channel
+are stored as array of strings and submits them to the edge node
+Input: context whose cancellation stops the parsing, unmarshalled struct converted from json, edge node
 Output: Nil
 */
-func parseclientrequests(clientrequests []string, ch chan string) {
+func parseclientrequests(ctx context.Context, clientrequests []string, o *orchestrator.Orchestrator) {
 	for i := 0; i < len(clientrequests); i++ {
-		fmt.Println("Client Request: " + clientrequests[i])
-		ch <- clientrequests[i]
-		time.Sleep(3 * time.Second) //inter-arrival time between two consecutive client requests, use as desired
+		logger.Debug("client request arrived", logging.Request, clientrequests[i])
+		if err := o.SubmitRequest(ctx, clientapi.Submission{Request: clientrequests[i]}); err != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(3 * time.Second): //inter-arrival time between two consecutive client requests, use as desired
+		}
 	}
 }
 
 func main() {
 
-	go MonitorMem(1) //collect and print run time memory usage statistics every 1 second
-
-	go resourcemanager.Init()
-
-	<-resourcemanager.Done1 // waiting for the Init() goroutine to start the server in the background
-
-	time.Sleep(4 * time.Second) // sufficient time for servers to setup first so that incoming client requests will be served surely
-
-	var mux sync.Mutex
+	cfg := orchestrator.Defaultconfig()
+	listenaddr := flag.String("listen-addr", cfg.Listenaddress, "listening address for updates from the other edge nodes")
+	flag.StringVar(&cfg.Advertiseaddress, "advertise-addr", "", "address the other edge nodes reach -listen-addr at, as listed in their -peers, defaults to -listen-addr")
+	peers := flag.String("peers", strings.Join(cfg.Peers, ","), "comma separated addresses of the other edge nodes")
+	tlscert := flag.String("tls-cert", "", "certificate of this edge node, enables mutual TLS with the other edge nodes")
+	tlskey := flag.String("tls-key", "", "key of the certificate of this edge node")
+	tlsca := flag.String("tls-ca", "", "certificate of the cluster CA")
+	flag.IntVar(&cfg.Nodecapacity, "node-capacity", 0, "number of workloads an edge node runs at the same time, 0 for no limit")
+	flag.DurationVar(&cfg.Retention, "retention", cfg.Retention, "time a finished service is kept in the swarm before it is removed")
+	flag.DurationVar(&cfg.Reapinterval, "reap-interval", cfg.Reapinterval, "interval between two passes of the service reaper")
+	flag.StringVar(&cfg.Clientaddress, "client-addr", cfg.Clientaddress, "listening address of the client API")
+	clientsfile := flag.String("clients", "", "file listing the clients allowed to submit requests and their quotas, anonymous submissions are accepted without it")
+	callbackurl := flag.String("callback-url", "", "base URL of the client API as reachable from the workloads, defaults to http://<hostname>:<client port>")
+	flag.StringVar(&cfg.Metricsaddress, "metrics-addr", cfg.Metricsaddress, "listening address of the Prometheus /metrics endpoint")
+	flag.StringVar(&cfg.Adminaddress, "admin-addr", cfg.Adminaddress, "listening address of the admin API used by ediroctl, empty to run without admin API")
+	flag.DurationVar(&cfg.Resultttl, "result-ttl", cfg.Resultttl, "time the result of a request is kept for the client to fetch it")
+	flag.StringVar(&cfg.NodeID, "node-id", "", "name of this edge node in logs and traces, defaults to the node ID of its certificate with -tls-cert or else to the hostname")
+	traceexporter := flag.String("trace-exporter", tracing.None, "exporter of the spans: none, otlp or file")
+	traceendpoint := flag.String("trace-endpoint", "localhost:4317", "address of the OTLP collector, or path of the file the spans are written to")
+	logformat := flag.String("log-format", "text", "format of the log lines: text or json")
+	loglevel := flag.String("log-level", "info", "default level and per subsystem levels, e.g. info,resourcemanager=debug,parser=warn")
+	flag.DurationVar(&cfg.Draintimeout, "drain-timeout", cfg.Draintimeout, "time given on shutdown to the requests in the pipeline, to the running workloads with -wait-workloads and to the workloads of a drained node")
+	flag.BoolVar(&cfg.Waitworkloads, "wait-workloads", false, "wait on shutdown for the running workloads to finish instead of leaving them running in the swarm")
+	flag.StringVar(&cfg.Statefile, "state-file", cfg.Statefile, "file the request records are persisted to on shutdown and restored from on start")
+	flag.StringVar(&cfg.Prefetchimage, "prefetch-image", cfg.Prefetchimage, "image of the service copying an IoT resource to the edge nodes on the route of a vehicle, empty to only plan the prefetching")
+	flag.IntVar(&cfg.Prefetchhorizon, "prefetch-horizon", cfg.Prefetchhorizon, "number of the next edge nodes of the route of a vehicle the IoT resources are prefetched to")
+	flag.DurationVar(&cfg.Replicationinterval, "replication-interval", cfg.Replicationinterval, "interval at which the replicas of the IoT resources offloaded through this edge node are checked, 0 to only replicate them when they are offloaded")
+	flag.Int64Var(&cfg.Storagequota, "storage-quota", cfg.Storagequota, "bytes of IoT resources each edge node keeps through this edge node, 0 for no limit")
+	flag.StringVar(&cfg.Evictionpolicy, "eviction-policy", cfg.Evictionpolicy, "policy picking the IoT resources evicted from an edge node: lru, oldest or ttl")
+	flag.DurationVar(&cfg.Resourcettl, "resource-ttl", cfg.Resourcettl, "age beyond which an IoT resource is evicted with the ttl eviction policy")
+	flag.StringVar(&cfg.Storagedir, "storage-dir", cfg.Storagedir, "directory the data of the IoT resources offloaded on this edge node is kept in, empty to leave the data of evicted resources in place")
+	flag.StringVar(&cfg.Consistency, "consistency", cfg.Consistency, "consistency mode of the catalog: eventual for best-effort broadcasts or raft for a replicated log")
+	flag.StringVar(&cfg.Raftaddress, "raft-addr", "", "address this edge node talks Raft on with -consistency raft, e.g. 10.0.0.1:7000")
+	raftpeers := flag.String("raft-peers", "", "comma separated Raft addresses of the other edge nodes with -consistency raft, each as <address in -peers>=<raft address>")
+	flag.StringVar(&cfg.Raftdir, "raft-dir", "", "directory the Raft log is kept in, empty to keep it in memory")
+	flag.DurationVar(&cfg.Predictioninterval, "prediction-interval", cfg.Predictioninterval, "interval at which the runtime statistics of the applications are shared with the other edge nodes, 0 to keep them local")
+	flag.Parse()
+
+	cfg.Listenaddress = *listenaddr
+	cfg.Peers = nil
+	for _, peer := range strings.Split(*peers, ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			cfg.Peers = append(cfg.Peers, peer)
+		}
+	}
+	cfg.Raftpeers = map[string]string{}
+	for _, peer := range strings.Split(*raftpeers, ",") {
+		if peer = strings.TrimSpace(peer); peer == "" {
+			continue
+		}
+		address, raftaddress, ok := strings.Cut(peer, "=")
+		if !ok {
+			fmt.Fprintln(os.Stderr, "invalid Raft peer, expected <address>=<raft address>:", peer)
+			os.Exit(1)
+		}
+		cfg.Raftpeers[address] = raftaddress
+	}
+	if err := logging.Setup(os.Stderr, *logformat, *loglevel); err != nil {
+		fmt.Fprintln(os.Stderr, "could not set up logging:", err)
+		os.Exit(1)
+	}
 
-	chanparseroutput := make(chan parser.Parseroutput, 10)
+	if *tlscert != "" {
+		var err error
+		cfg.Credentials, err = nodeidentity.Load(*tlscert, *tlskey, *tlsca)
+		if err != nil {
+			logger.Error("could not load the node certificate", "err", err)
+			os.Exit(1)
+		}
+		//the other edge nodes check the node ID of the updates against the certificate
+		if cfg.NodeID == "" {
+			cfg.NodeID = cfg.Credentials.ID()
+		} else if cfg.NodeID != cfg.Credentials.ID() {
+			logger.Error("node ID does not match the node certificate", "node", cfg.NodeID,
+				"certificate", cfg.Credentials.ID())
+			os.Exit(1)
+		}
+	}
+	if cfg.NodeID == "" {
+		cfg.NodeID, _ = os.Hostname()
+	}
+	logger = logger.With(logging.Node, cfg.NodeID)
+	shutdowntracing, err := tracing.Init(*traceexporter, *traceendpoint, cfg.NodeID)
+	if err != nil {
+		logger.Error("could not set up tracing", "err", err)
+		os.Exit(1)
+	}
+	defer shutdowntracing(context.Background()) //flush the spans still buffered when main() exits
+
+	if *clientsfile != "" {
+		cfg.Clients, err = clientauth.Load(*clientsfile)
+		if err != nil {
+			logger.Error("could not load the clients", "file", *clientsfile, "err", err)
+			os.Exit(1)
+		}
+	}
 
-	chandiscovery := make(chan resourcediscovery.Resourcediscoveryoutput, 10)
+	cfg.Callbackaddress = *callbackurl
+	if cfg.Callbackaddress == "" {
+		hostname, _ := os.Hostname()
+		_, clientport, _ := net.SplitHostPort(cfg.Clientaddress)
+		cfg.Callbackaddress = "http://" + net.JoinHostPort(hostname, clientport)
+	}
 
-	/* chanNewIotResourceArrival - The input side of the resource manager, ie, facing the outside world. The information about
-	arrival of new IoT resources is parsed by 'parseiotresources()', packaged into a struct and written to this channel
-	*/
-	chanNewIotResourceArrival := make(chan resourcemanager.Newresource, 10)
+	//the edge node is stopped gracefully on SIGINT or SIGTERM
+	signalctx, stopsignal := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopsignal()
 
-	/*chanNewIoTResourceUpdate - Corresponds to the output side of the resource manager which talks to other modules, ie, facing the other modules
-	in the orchestrator. The information about new client requests ia parsed by 'parseclientrequests()' and written to this channel.
-	The data from this channel is then fetched by 'Newresourceupdate()'
-	*/
-	chanNewIoTResourceUpdate := make(chan resourcemanager.Newresource, 10)
+	o := orchestrator.New(cfg)
+	if err := o.Start(context.Background()); err != nil {
+		logger.Error("failed to listen", "err", err)
+		os.Exit(1)
+	}
 
-	//Channel to store the new client requests arriving at the system. Data from this channel is consumed by the parser.
-	chanNewClientRequest := make(chan string, 10)
+	time.Sleep(4 * time.Second) // sufficient time for servers to setup first so that incoming client requests will be served surely
 
 	IotResourcelist, err := os.Open("input.json")
 	if err != nil {
-		fmt.Println(err)
+		logger.Error("could not open input.json", "err", err)
+	} else {
+		logger.Info("successfully opened input.json")
 	}
-	fmt.Println("Successfully Opened input.json")
 	// defer the closing of our jsonFile so that we can parse it later on
 	defer IotResourcelist.Close()
 	byteValue, _ := ioutil.ReadAll(IotResourcelist)
@@ -132,9 +220,10 @@ func main() {
 
 	ClientRequestslist, errr := os.Open("clientrequest.json")
 	if errr != nil {
-		fmt.Println(errr)
+		logger.Error("could not open clientrequest.json", "err", errr)
+	} else {
+		logger.Info("successfully opened clientrequest.json")
 	}
-	fmt.Println("Successfully Opened clientrequests.json")
 	// defer the closing of our jsonFile so that we can parse it later on
 	defer ClientRequestslist.Close()
 	byteValuee, _ := ioutil.ReadAll(ClientRequestslist)
@@ -142,22 +231,15 @@ func main() {
 	var clientrequests []string
 	json.Unmarshal(byteValuee, &clientrequests)
 
-	/*Starting all the gorouotines here at once. They will start processing the data as and when it arrives on the respective
-	/channels they consume from */
-	//go resourcediscovery.DetectDuplicateApp(chanparseroutput, chanduplicate)
-	//go resourcediscovery.Discoverresource(chanduplicate, chandiscovery, &mux)
-	go resourcediscovery.Discoverresource(chanparseroutput, chandiscovery, &mux)
-	go taskinitiator.Createlaunchcommand(chandiscovery)
-	go resourcemanager.Newresourceupdate(chanNewIotResourceArrival, &mux, chanNewIoTResourceUpdate)
-	go parser.Parseinput(chanNewClientRequest, chanparseroutput)
-
 	//Parse IoT resouces uploaded
-	go parseiotresources(iotresources, chanNewIotResourceArrival)
+	go parseiotresources(signalctx, iotresources, o)
 
 	time.Sleep(2 * time.Second) //added to make sure all the resources are uploaded before taking in client requests
 
 	//Parse client requests in parallel
-	go parseclientrequests(clientrequests, chanNewClientRequest)
+	go parseclientrequests(signalctx, clientrequests, o)
 
-	<-resourcemanager.Done // to ensure we wait for server to shut down and only then the main() exists
+	<-signalctx.Done()
+	stopsignal() //a second signal terminates the program right away
+	o.Stop()
 }
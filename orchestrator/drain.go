@@ -0,0 +1,160 @@
+/*
+This file implements the drain of an edge node of the swarm taken down for maintenance, e.g. a traffic light. The edge
+node is announced as draining to the whole cluster so that no new workload is placed on it. The IoT resources it holds
+and no other edge node does may then be replicated to the active edge node holding the fewest resources. Its workloads
+are given a timeout to finish, or are migrated to the other edge nodes holding their resource, before the edge node is
+announced as departed and the cluster forgets the resources it held.
+*/
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/admin"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//draining : A drain in progress, cancel stops it when the edge node is made active again
+type draining struct {
+	cancel context.CancelFunc
+}
+
+/*
+Drain : Starts the drain of an edge node of the swarm. The drain runs in the background and ends with the departure of
+the edge node, unless Undrain is called first.
+Input: the edge node, whether to replicate its resources and to migrate its workloads, the time given to its workloads,
+0 for the drain timeout of the configuration
+Output: ErrStopped if the edge node is stopping, an error if the edge node is already draining
+*/
+func (o *Orchestrator) Drain(d admin.Drainorder) error {
+	if o.ingress.Err() != nil {
+		return ErrStopped
+	}
+	if d.Timeout == 0 {
+		d.Timeout = o.Config.Draintimeout
+	}
+	o.drainmux.Lock()
+	defer o.drainmux.Unlock()
+	if _, ok := o.drains[d.Node]; ok || o.Catalog.State(d.Node) == resourcemanager.Draining {
+		return fmt.Errorf("edge node %s is already draining", d.Node)
+	}
+	ctx, cancel := context.WithCancel(o.pipeline)
+	drain := &draining{cancel: cancel}
+	o.drains[d.Node] = drain
+	go func() {
+		o.drain(ctx, d)
+		o.drainmux.Lock()
+		if o.drains[d.Node] == drain {
+			delete(o.drains, d.Node)
+		}
+		o.drainmux.Unlock()
+		cancel()
+	}()
+	return nil
+}
+
+/*
+Undrain : Stops the drain of an edge node, if it is in progress, and announces the edge node as active again. The
+IoT resources of a departed edge node are not restored, they are offloaded again or reloaded with a sync.
+Input: the edge node
+Output: an error if the edge node is neither draining nor departed
+*/
+func (o *Orchestrator) Undrain(node string) error {
+	o.drainmux.Lock()
+	drain, ok := o.drains[node]
+	delete(o.drains, node)
+	o.drainmux.Unlock()
+	if ok {
+		drain.cancel()
+	} else if o.Catalog.State(node) == resourcemanager.Active {
+		return fmt.Errorf("edge node %s is not drained", node)
+	}
+	unreachable := o.Transport.Announcestate(o.transport, node, resourcemanager.Active)
+	o.logger.Info("edge node active again", "node", node, "unreachable", unreachable)
+	return nil
+}
+
+/*
+drain : Drains an edge node: announces it as draining, replicates its resources if asked, waits for its workloads to
+finish or migrates them, then announces its departure. The departure is announced even if workloads are left on the
+edge node at the timeout, they keep running in the swarm and are still tracked.
+Input: context whose cancellation stops the drain, the drain to run
+Output: Nil
+*/
+func (o *Orchestrator) drain(ctx context.Context, d admin.Drainorder) {
+	unreachable := o.Transport.Announcestate(ctx, d.Node, resourcemanager.Draining)
+	o.logger.Info("draining edge node", "node", d.Node, "replicate", d.Replicate, "migrate", d.Migrate,
+		"timeout", d.Timeout, "unreachable", unreachable)
+	if d.Replicate {
+		o.replicate(ctx, d.Node)
+	}
+
+	drained := waituntil(time.Now().Add(d.Timeout), func() bool {
+		if ctx.Err() != nil {
+			return true
+		}
+		if d.Migrate {
+			o.Runtime.Migrate(d.Node)
+		}
+		load := o.Runtime.Loads()[d.Node]
+		return load.Running == 0 && load.Queued == 0
+	})
+	if ctx.Err() != nil {
+		o.logger.Info("drain of edge node stopped", "node", d.Node)
+		return
+	}
+	if !drained {
+		load := o.Runtime.Loads()[d.Node]
+		o.logger.Warn("workloads left on draining edge node at the timeout", "node", d.Node, "running", load.Running,
+			"queued", load.Queued)
+	}
+	unreachable = o.Transport.Announcestate(ctx, d.Node, resourcemanager.Departed)
+	o.logger.Info("edge node departed", "node", d.Node, "unreachable", unreachable)
+}
+
+/*
+replicate : Copies the IoT resources held by a draining edge node and by no other active edge node to the active edge
+node holding the fewest resources.
+Input: context whose cancellation stops the copies, the draining edge node
+Output: Nil
+*/
+func (o *Orchestrator) replicate(ctx context.Context, node string) {
+	table, states := o.Catalog.Snapshot(), o.Catalog.States()
+	entries := o.Catalog.Entries()
+	elsewhere := map[string]bool{}
+	for _, e := range entries {
+		if e.Node != node && states[e.Node] == "" {
+			elsewhere[e.Resource] = true
+		}
+	}
+	for _, e := range entries {
+		if e.Node != node || elsewhere[e.Resource] {
+			continue
+		}
+		to := ""
+		for candidate, resources := range table {
+			if candidate != node && states[candidate] == "" && (to == "" || len(resources) < len(table[to])) {
+				to = candidate
+			}
+		}
+		if to == "" {
+			o.logger.Warn("no active edge node to replicate the resources of the draining edge node to", "node", node)
+			return
+		}
+		err := o.Prefetch.Replicate(ctx, prefetch.Transfer{Resource: e.Resource, From: node, To: to,
+			Provenance: e.Provenance, Descriptor: e.Descriptor})
+		if err != nil {
+			o.logger.Warn("could not replicate resource of draining edge node", logging.Resource, e.Resource,
+				"node", node, "to", to, "err", err)
+			continue
+		}
+		table[to] = append(table[to], e.Resource)
+		elsewhere[e.Resource] = true
+		o.logger.Info("resource of draining edge node replicated", logging.Resource, e.Resource, "node", node, "to", to)
+	}
+}
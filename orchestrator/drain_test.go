@@ -0,0 +1,73 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/admin"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   string //state of the edge node before the drain
+		stopped bool   //whether the edge node draining the other is stopped
+		wanterr bool
+		//wantstate : state of the drained edge node afterwards, wantheld : whether the cluster still knows its resource
+		wantstate string
+		wantheld  bool
+	}{
+		{name: "edge node departs", state: resourcemanager.Active, wantstate: resourcemanager.Departed},
+		{name: "already draining", state: resourcemanager.Draining, wanterr: true,
+			wantstate: resourcemanager.Draining, wantheld: true},
+		{name: "stopped", state: resourcemanager.Active, stopped: true, wanterr: true,
+			wantstate: resourcemanager.Active, wantheld: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := New(testconfig(t, "edge_node_1"))
+			o.Catalog.Add("IoT_resource_1", "edge_node_2", resourcemanager.Provenance{})
+			o.Catalog.Setstate("edge_node_2", tt.state)
+			if tt.stopped {
+				o.stopingress()
+			}
+			err := o.Drain(admin.Drainorder{Node: "edge_node_2", Timeout: time.Second})
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("Drain = %v, want error %v", err, tt.wanterr)
+			}
+			if !waituntil(time.Now().Add(5*time.Second), func() bool {
+				return o.Catalog.State("edge_node_2") == tt.wantstate
+			}) {
+				t.Fatalf("edge node %s, want %s", o.Catalog.State("edge_node_2"), tt.wantstate)
+			}
+			if held := len(o.Catalog.Snapshot()["edge_node_2"]) > 0; held != tt.wantheld {
+				t.Errorf("resource of the edge node kept %v, want %v", held, tt.wantheld)
+			}
+		})
+	}
+}
+
+func TestUndrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   string
+		wanterr bool
+	}{
+		{name: "draining", state: resourcemanager.Draining},
+		{name: "departed", state: resourcemanager.Departed},
+		{name: "active", state: resourcemanager.Active, wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := New(testconfig(t, "edge_node_1"))
+			o.Catalog.Setstate("edge_node_2", tt.state)
+			if err := o.Undrain("edge_node_2"); (err != nil) != tt.wanterr {
+				t.Errorf("Undrain = %v, want error %v", err, tt.wanterr)
+			}
+			if state := o.Catalog.State("edge_node_2"); state != resourcemanager.Active {
+				t.Errorf("edge node %s, want %s", state, resourcemanager.Active)
+			}
+		})
+	}
+}
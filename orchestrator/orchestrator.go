@@ -0,0 +1,599 @@
+/*
+This package implements the Orchestrator, i.e. one EDIRO edge node. An Orchestrator is built from a Config and owns all
+the state of the edge node: its IoT resource catalog, its inter edge communication (transport), its task initiator
+(runtime) and the channels connecting the modules of its pipeline. As no state is shared between Orchestrators,
+several edge nodes can run in one process and EDIRO can be embedded in other programs, e.g. a vehicle simulation.
+
+*/
+
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/admin"
+	"github.com/niketagrawal/EDIRO/admission"
+	"github.com/niketagrawal/EDIRO/clientapi"
+	"github.com/niketagrawal/EDIRO/clientauth"
+	"github.com/niketagrawal/EDIRO/consensus"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	"github.com/niketagrawal/EDIRO/parser"
+	"github.com/niketagrawal/EDIRO/predictor"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/replication"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcediscovery"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+	"github.com/niketagrawal/EDIRO/resultstore"
+	"github.com/niketagrawal/EDIRO/session"
+	"github.com/niketagrawal/EDIRO/storage"
+	"github.com/niketagrawal/EDIRO/subscription"
+	"github.com/niketagrawal/EDIRO/taskinitiator"
+)
+
+//ErrStopped : returned when a client request or an IoT resource is handed to an Orchestrator that is stopping
+var ErrStopped = errors.New("orchestrator is stopping")
+
+//ErrNotholder : returned when an IoT resource held by another edge node is offloaded on an edge node with credentials
+var ErrNotholder = errors.New("IoT resource is held by another edge node")
+
+//Config : The configuration of an edge node
+type Config struct {
+	//NodeID : name of the edge node in logs and traces and in the updates sent to the other edge nodes, it must be the
+	//node ID of the certificate when the edge node has its credentials
+	NodeID string
+	//Listenaddress : address on which the edge node listens for updates from other edge nodes
+	Listenaddress string
+	//Advertiseaddress : address the other edge nodes reach the listening address at, i.e. as listed in their peers,
+	//Listenaddress if empty. It identifies the edge node in the raft mode and the commands are forwarded to it.
+	Advertiseaddress string
+	//Peers : addresses of the other edge nodes of the cluster
+	Peers []string
+	//Credentials : certificate of the edge node and cluster CA for mutual TLS with the other edge nodes, nil for plain text
+	Credentials *nodeidentity.Credentials
+	//Clientaddress : listening address of the client API, empty to run without client API
+	Clientaddress string
+	//Clients : clients allowed to submit requests through the client API and their quotas, nil to accept anonymous
+	//submissions
+	Clients *clientauth.Authenticator
+	//Callbackaddress : base URL of the client API as reachable from the workloads
+	Callbackaddress string
+	//Metricsaddress : listening address of the /metrics endpoint, empty to run without endpoint
+	Metricsaddress string
+	//Adminaddress : listening address of the admin API, empty to run without admin API
+	Adminaddress string
+	//Nodecapacity : number of workloads an edge node of the swarm runs at the same time, 0 for no limit. Requests wait
+	//for admission by priority class and critical requests may preempt others on saturated edge nodes.
+	Nodecapacity int
+	//Retention : time a finished service is kept in the swarm before it is removed
+	Retention time.Duration
+	//Reapinterval : interval between two passes of the service reaper
+	Reapinterval time.Duration
+	//Resultttl : time the result of a request is kept for the client to fetch it
+	Resultttl time.Duration
+	//Draintimeout : time given on Stop to the requests in the pipeline and, with Waitworkloads, to the running workloads.
+	//It is also the time given to the workloads of a drained edge node of the swarm when the drain does not give one.
+	Draintimeout time.Duration
+	//Waitworkloads : wait on Stop for the running workloads to finish instead of leaving them running in the swarm
+	Waitworkloads bool
+	//Statefile : file the request records are persisted to on Stop and restored from on Start, empty to disable
+	Statefile string
+	//Prefetchimage : image of the service copying an IoT resource to an edge node on the route of a vehicle, empty to
+	//only plan the prefetching
+	Prefetchimage string
+	//Prefetchhorizon : number of the next edge nodes of the route of a vehicle the IoT resources are prefetched to
+	Prefetchhorizon int
+	//Predictioninterval : interval at which the runtime statistics of the applications are shared with the other edge
+	//nodes, 0 to keep them local
+	Predictioninterval time.Duration
+	//Replicationinterval : interval at which the replicas of the IoT resources offloaded through the edge node are
+	//checked, 0 to only replicate them when they are offloaded
+	Replicationinterval time.Duration
+	//Storagequota : bytes of IoT resources each edge node of the swarm keeps through this edge node, 0 for no limit
+	Storagequota int64
+	//Evictionpolicy : policy picking the IoT resources evicted from an edge node, lru, oldest or ttl
+	Evictionpolicy string
+	//Resourcettl : age beyond which an IoT resource is evicted with the ttl policy
+	Resourcettl time.Duration
+	//Storagedir : directory the data of the IoT resources offloaded on the edge node is kept in and hashed from, empty
+	//if the IoT resources are the paths of their data, which is then left in place on eviction
+	Storagedir string
+	//Consistency : consistency mode of the catalog, eventual for best-effort broadcasts or raft for a replicated log
+	Consistency string
+	//Raftaddress : address the edge node talks Raft on in the raft mode
+	Raftaddress string
+	//Raftpeers : Raft address of each other edge node of the cluster in the raft mode, keyed by its address in Peers
+	Raftpeers map[string]string
+	//Raftdir : directory the Raft log is kept in, empty to keep it in memory
+	Raftdir string
+}
+
+//advertised : returns the address the other edge nodes reach the listening address of the edge node at
+func (c Config) advertised() string {
+	if c.Advertiseaddress != "" {
+		return c.Advertiseaddress
+	}
+	return c.Listenaddress
+}
+
+//Defaultconfig : returns the configuration used when nothing else is specified
+func Defaultconfig() Config {
+	return Config{
+		Listenaddress:   "1.1.1.1:1",
+		Peers:           []string{"2.2.2.2:2"},
+		Clientaddress:   ":8080",
+		Callbackaddress: "http://localhost:8080",
+		Metricsaddress:  ":2112",
+		Adminaddress:    "localhost:9090",
+		Retention:       10 * time.Minute,
+		Reapinterval:    30 * time.Second,
+		Resultttl:       10 * time.Minute,
+		Draintimeout:    30 * time.Second,
+		Statefile:       "ediro-state.json",
+
+		Predictioninterval:  30 * time.Second,
+		Replicationinterval: 30 * time.Second,
+		Prefetchhorizon:     2,
+		Evictionpolicy:      storage.LRU,
+		Storagedir:          ".",
+		Consistency:         consensus.Eventual,
+	}
+}
+
+//Orchestrator : One EDIRO edge node
+type Orchestrator struct {
+	Config Config
+
+	Catalog       *resourcemanager.Catalog
+	Transport     *resourcemanager.Transport
+	Runtime       *taskinitiator.Runtime
+	Records       *requestrecord.Store
+	Results       *resultstore.Store
+	Metrics       *metrics.Metrics
+	Admission     *admission.Controller
+	Predictor     *predictor.Predictor
+	Sessions      *session.Manager
+	Prefetch      *prefetch.Planner
+	Replication   *replication.Manager
+	Storage       *storage.Store
+	Consensus     *consensus.Node
+	Subscriptions *subscription.Manager
+
+	parser    *parser.Parser
+	discovery *resourcediscovery.Discovery
+	clientapi *clientapi.API
+	admin     *admin.API
+
+	//drains : the drains in progress keyed by edge node, see drain.go
+	drains   map[string]*draining
+	drainmux sync.Mutex
+
+	//Channels of the pipeline
+	chanNewClientRequest      chan string
+	chanparseroutput          chan parser.Parseroutput
+	chandiscovery             chan resourcediscovery.Resourcediscoveryoutput
+	chanNewIotResourceArrival chan resourcemanager.Newresource
+	chanNewIoTResourceUpdate  chan resourcemanager.Newresource
+
+	/* The edge node is stopped in three steps, each with its own context:
+	ingress - no new client requests or IoT resources are accepted
+	pipeline - the modules processing the requests and resources stop once the requests in flight are drained
+	transport - the listening servers stop once nothing is left to be processed
+	*/
+	ingress, pipeline, transport             context.Context
+	stopingress, stoppipeline, stoptransport context.CancelFunc
+
+	stopping sync.Once
+	done     chan struct{}
+	logger   *slog.Logger
+}
+
+//New : builds an edge node from its configuration. Nothing is started before Start is called.
+func New(cfg Config) *Orchestrator {
+	o := &Orchestrator{Config: cfg, drains: map[string]*draining{}, done: make(chan struct{}),
+		logger: nodelogger(cfg, "orchestrator")}
+
+	o.Metrics = metrics.New(nodelogger(cfg, "metrics"))
+	o.Catalog = resourcemanager.NewCatalog(nodelogger(cfg, "resourcemanager"))
+	o.Transport = resourcemanager.NewTransport(cfg.Listenaddress, cfg.Peers, cfg.Credentials, o.Catalog, o.Metrics,
+		nodelogger(cfg, "resourcemanager"))
+	o.Records = requestrecord.New()
+	o.Results = resultstore.New()
+	o.Runtime = taskinitiator.New(cfg.Callbackaddress, cfg.Nodecapacity, o.Catalog, o.Records, o.Results, o.Metrics,
+		nodelogger(cfg, "taskinitiator"))
+	o.Predictor = predictor.New(nodelogger(cfg, "predictor"))
+	o.Runtime.Observer = o.Predictor.Observe
+	o.Transport.Onpredictions = o.Predictor.Merge
+	o.Admission = admission.New(cfg.Nodecapacity, o.Catalog.Holders, o.Runtime.Ahead, o.Predictor,
+		nodelogger(cfg, "admission"))
+	o.parser = parser.New(o.Metrics, nodelogger(cfg, "parser"))
+	o.discovery = resourcediscovery.New(o.Catalog, o.Records, o.Metrics, nodelogger(cfg, "resourcediscovery"))
+	o.Prefetch = prefetch.New(cfg.Prefetchimage, cfg.Prefetchhorizon, o.Catalog, o.Transport.Announcecopy,
+		nodelogger(cfg, "prefetch"))
+	o.Runtime.Gather = o.Prefetch.Gather
+	o.Replication = replication.New(o.Catalog, o.Prefetch, nodelogger(cfg, "replication"))
+	o.Storage = storage.New(cfg.Storagequota, cfg.Evictionpolicy, cfg.Resourcettl, o.Catalog, o.Transport.Announced,
+		o.Runtime.Leases, o.Transport.Evict, nodelogger(cfg, "storage"))
+	o.Storage.Dir, o.Transport.Dir = cfg.Storagedir, cfg.Storagedir
+	o.Storage.Onevict = o.Replication.Untrack
+	o.Sessions = session.New(cfg.NodeID, o.Transport, o.Records, o.Results, nodelogger(cfg, "session"))
+	o.Transport.Onhandover = o.Sessions.Release
+	o.Transport.Onfetch = o.Sessions.Fetch
+	o.Transport.Oncopy = o.OffloadResource
+	o.Transport.NodeID = cfg.NodeID
+	o.Subscriptions = subscription.New(o.Catalog, o.Records, func(request string) error {
+		return o.enqueue(o.ingress, request)
+	}, o.Runtime.Stop, nodelogger(cfg, "subscription"))
+	o.clientapi = clientapi.New(o.SubmitRequest, cfg.Clients, o.Records, o.Results, nodelogger(cfg, "clientapi"))
+	o.clientapi.Sessions = o.Sessions
+	o.clientapi.Cancel = o.CancelRequest
+	o.admin = admin.New(cfg.NodeID, cfg.Credentials, o.Catalog, o.Records, o.Runtime, nodelogger(cfg, "admin"))
+	o.admin.Peers = cfg.Peers
+	o.admin.Submit, o.admin.Cancel = o.SubmitRequest, o.RevokeRequest
+	o.admin.Offload, o.admin.Sync = o.OffloadResource, o.Transport.Sync
+	o.admin.Drain, o.admin.Undrain = o.Drain, o.Undrain
+	o.admin.Usage, o.admin.Quota = o.Storage.Usage, cfg.Storagequota
+	o.admin.Linearize, o.admin.Leader = o.Transport.Linearize, o.Transport.Leader
+
+	o.chanNewClientRequest = make(chan string, 10)
+	o.chanparseroutput = make(chan parser.Parseroutput, 10)
+	o.chandiscovery = make(chan resourcediscovery.Resourcediscoveryoutput, 10)
+	o.chanNewIotResourceArrival = make(chan resourcemanager.Newresource, 10)
+	o.chanNewIoTResourceUpdate = make(chan resourcemanager.Newresource, 10)
+
+	o.Metrics.Registerqueue("client_requests", func() int { return len(o.chanNewClientRequest) })
+	o.Metrics.Registerqueue("parser_output", func() int { return len(o.chanparseroutput) })
+	o.Metrics.Registerqueue("discovery_output", func() int { return len(o.chandiscovery) })
+	o.Metrics.Registerqueue("resource_arrivals", func() int { return len(o.chanNewIotResourceArrival) })
+	o.Metrics.Registerqueue("resource_updates", func() int { return len(o.chanNewIoTResourceUpdate) })
+	o.Metrics.Registerqueue("admission", o.Runtime.Queued)
+
+	o.ingress, o.stopingress = context.WithCancel(context.Background())
+	o.pipeline, o.stoppipeline = context.WithCancel(context.Background())
+	o.transport, o.stoptransport = context.WithCancel(context.Background())
+	return o
+}
+
+//nodelogger : returns the logger of a module of the edge node
+func nodelogger(cfg Config, subsystem string) *slog.Logger {
+	return logging.For(subsystem).With(logging.Node, cfg.NodeID)
+}
+
+/*
+Start : Starts the edge node. It returns once the edge node listens for updates from the other edge nodes, with all
+the modules running in the background. The edge node is stopped with Stop or when ctx is cancelled.
+Input: context whose cancellation stops the edge node
+Output: error if the edge node could not be started
+*/
+func (o *Orchestrator) Start(ctx context.Context) error {
+	if err := library.Checkstages(); err != nil {
+		return err
+	}
+	if err := o.Storage.Check(); err != nil {
+		return err
+	}
+	if o.Config.Credentials != nil && o.Config.NodeID != o.Config.Credentials.ID() {
+		return fmt.Errorf("node ID %s does not match the node ID %s of the certificate", o.Config.NodeID,
+			o.Config.Credentials.ID())
+	}
+	switch o.Config.Consistency {
+	case consensus.Eventual:
+	case consensus.Raft:
+		node, err := consensus.New(consensus.Config{ID: o.Config.advertised(), Address: o.Config.Raftaddress,
+			Peers: o.Config.Raftpeers, Dir: o.Config.Raftdir, Credentials: o.Config.Credentials}, o.Catalog,
+			nodelogger(o.Config, "consensus"))
+		if err != nil {
+			return err
+		}
+		o.Consensus = node
+		o.Transport.Uselog(node)
+	default:
+		return fmt.Errorf("unknown consistency mode %s, expected eventual or raft", o.Config.Consistency)
+	}
+	if o.Config.Statefile != "" {
+		if err := o.Records.Load(o.Config.Statefile); err != nil {
+			o.logger.Error("could not restore request records", "file", o.Config.Statefile, "err", err)
+		}
+	}
+
+	if err := o.Transport.Init(o.transport); err != nil {
+		o.stoptransport()
+		if o.Consensus != nil {
+			o.Consensus.Shutdown()
+		}
+		return err
+	}
+	if o.Config.Metricsaddress != "" {
+		go o.Metrics.Listenformetrics(o.transport, o.Config.Metricsaddress) //expose run time statistics and pipeline metrics
+	}
+
+	/*Starting all the gorouotines here at once. They will start processing the data as and when it arrives on the respective
+	/channels they consume from */
+	go o.discovery.Discoverresource(o.pipeline, o.chanparseroutput, o.chandiscovery)
+	go o.Runtime.Createlaunchcommand(o.pipeline, o.chandiscovery)
+	go o.Transport.Newresourceupdate(o.pipeline, o.chanNewIotResourceArrival, o.chanNewIoTResourceUpdate)
+	go o.parser.Parseinput(o.pipeline, o.chanNewClientRequest, o.chanparseroutput)
+	go o.Runtime.Reapservices(o.pipeline, o.Config.Retention, o.Config.Reapinterval)
+	go o.Results.Expire(o.pipeline, o.Config.Resultttl, time.Minute)
+	go o.Replication.Run(o.pipeline, o.Config.Replicationinterval)
+	go o.Storage.Run(o.pipeline)
+	if o.Config.Predictioninterval > 0 {
+		go o.Predictor.Share(o.pipeline, o.Config.NodeID, o.Config.Predictioninterval, o.Transport.Sharepredictions)
+	}
+	if o.Config.Clientaddress != "" {
+		go o.clientapi.Listenforclients(o.ingress, o.Config.Clientaddress)
+	}
+	if o.Config.Adminaddress != "" {
+		go o.admin.Listenforadmins(o.transport, o.Config.Adminaddress) //the edge node can be inspected while it drains
+	}
+	o.Runtime.Resume(o.pipeline)
+	o.Sessions.Resume(o.pipeline)
+	o.Subscriptions.Resume(o.pipeline)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			o.Stop()
+		case <-o.done:
+		}
+	}()
+	o.logger.Info("edge node started", "addr", o.Config.Listenaddress, "peers", o.Config.Peers)
+	return nil
+}
+
+/*
+SubmitRequest : Hands a client request to the pipeline of the edge node and records the client that submitted it and
+the priority class of the request. A request submitted again while it is active is shared with the client and not
+handed to the pipeline again. A request with a deadline goes through the admission control first. The IoT resource of
+a request with a route is prefetched along the route, and a request to prelaunch is held until its resource reached
+the first edge node of the route. A request with a lifetime is a subscription, see package subscription.
+Input: context bounding the wait for room in the pipeline, client request with the client submitting it, empty for an
+anonymous client, the priority class asked for, empty for the class of the request in the library, its deadline and
+the route of the vehicle
+Output: ErrStopped if the edge node is stopping, the error of ctx if it ends first, a clientauth.Rejection if the
+deadline of the request cannot be met
+*/
+func (o *Orchestrator) SubmitRequest(ctx context.Context, s clientapi.Submission) error {
+	if o.ingress.Err() != nil {
+		return ErrStopped
+	}
+	request := s.Request
+	submission := requestrecord.Record{Request: request, Priority: library.Priorityof(request, s.Priority),
+		Deadline: s.Due(time.Now()), Expires: s.Expiry(time.Now())}
+	if s.Client != "" {
+		submission.Clients = []string{s.Client}
+	}
+	if s.Where != nil {
+		query := s.Where.Query()
+		submission.Query = &query
+	}
+	//a request active when it is submitted again is shared at once, it cannot end between the check and the sharing
+	shared := o.Records.Share(submission)
+	if !shared {
+		decision, err := o.Admission.Admit(request, submission.Priority, submission.Deadline)
+		if err != nil {
+			return err
+		}
+		submission.Target = decision.Node
+	}
+	resource := library.ApptoResource[library.RequesttoApp[request]]
+	if len(s.Route) > 0 {
+		o.Prefetch.Prefetch(o.pipeline, resource, s.Route)
+	}
+	prelaunch := !shared && s.Prelaunch && len(s.Route) > 0 && submission.Target == ""
+	if prelaunch {
+		submission.Target = s.Route[0]
+	}
+	if !shared {
+		shared = o.Records.Submit(submission)
+	}
+	if !submission.Expires.IsZero() {
+		o.Subscriptions.Start(request)
+	}
+	if shared {
+		o.logger.Info("client request shared with an active request", logging.Request, request, "client", s.Client)
+		return nil
+	}
+	if prelaunch {
+		go o.prelaunch(request, resource, s.Route[0])
+		return nil
+	}
+	if err := o.enqueue(ctx, request); err != nil {
+		o.Records.Remove(request)
+		return err
+	}
+	return nil
+}
+
+/*
+CancelRequest : Withdraws a client request wherever it is in the pipeline of the edge node. When other clients share the
+request only the client withdrawing it is detached. Otherwise the request is cancelled: it is dropped from the queues,
+the IoT resources claimed for it are released and the services of its workload are removed from the swarm.
+Input: client request, client withdrawing it, empty for an anonymous client
+Output: whether the request was cancelled rather than only detached from the client, the error of
+requestrecord.Store.Cancel if the request cannot be withdrawn
+*/
+func (o *Orchestrator) CancelRequest(request string, client string) (bool, error) {
+	cancelled, err := o.Records.Cancel(request, client)
+	if err != nil {
+		return false, err
+	}
+	if !cancelled {
+		o.logger.Info("client detached from shared request", logging.Request, request, "client", client)
+		return false, nil
+	}
+	o.stop(request, client)
+	return true, nil
+}
+
+/*
+RevokeRequest : Withdraws a client request on behalf of an operator, like CancelRequest, or cancels it for all the
+clients sharing it when no client is given.
+Input: client request, client withdrawing it, empty to cancel the request for all its clients
+Output: whether the request was cancelled rather than only detached from the client, the error of
+requestrecord.Store.Cancel or requestrecord.Store.Abort if the request cannot be withdrawn
+*/
+func (o *Orchestrator) RevokeRequest(request string, client string) (bool, error) {
+	if client != "" {
+		return o.CancelRequest(request, client)
+	}
+	if err := o.Records.Abort(request); err != nil {
+		return false, err
+	}
+	o.stop(request, client)
+	return true, nil
+}
+
+//stop : stops the workload of a cancelled request
+func (o *Orchestrator) stop(request string, client string) {
+	//a request still in the parser or the resource discovery is dropped once it reaches the admission queue
+	stopped := o.Runtime.Stop(request)
+	o.logger.Info("client request cancelled", logging.Request, request, "client", client, "stopped", stopped)
+}
+
+//enqueue : hands a recorded client request to the parser, waiting for room in the pipeline
+func (o *Orchestrator) enqueue(ctx context.Context, request string) error {
+	select {
+	case o.chanNewClientRequest <- request:
+		return nil
+	case <-o.ingress.Done():
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+prelaunch : Hands a client request to the pipeline once its IoT resource has been prefetched to the next edge node on
+the route of the vehicle, so that its workload runs there. If the resource could not be prefetched, the request is
+served wherever the resource is.
+Input: client request, its IoT resource, next edge node on the route
+Output: Nil
+*/
+func (o *Orchestrator) prelaunch(request string, resource string, node string) {
+	if !o.Prefetch.Ready(o.ingress, resource, node) {
+		o.Records.Update(request, func(r *requestrecord.Record) {
+			r.Target = ""
+		})
+		o.logger.Info("resource not prefetched, launching request where the resource is", logging.Request, request,
+			logging.Resource, resource, "node", node)
+	}
+	if err := o.enqueue(o.ingress, request); err != nil {
+		o.Records.Remove(request)
+		o.logger.Warn("prelaunched request dropped", logging.Request, request, "err", err)
+	}
+}
+
+/*
+OffloadResource : Hands an IoT resource offloaded on an edge node to the resource manager, which records it in the
+catalog and spreads it to the other edge nodes. An IoT resource offloaded rather than copied is replicated to as many
+edge nodes as its replication factor, see package replication. When the edge node has its credentials it only
+accepts the IoT resources it holds itself, the other edge nodes reject the announcements of resources held by another.
+Input: context bounding the wait for room in the pipeline, IoT resource with the edge node holding it and optionally its
+content hash, contributor and replication factor
+Output: ErrStopped if the edge node is stopping, ErrNotholder if the IoT resource is held by another edge node, the
+error of ctx if it ends first
+*/
+func (o *Orchestrator) OffloadResource(ctx context.Context, resource resourcemanager.Newresource) error {
+	if o.ingress.Err() != nil {
+		return ErrStopped
+	}
+	if o.Config.Credentials != nil && !nodeidentity.Matches(resource.NodeID, o.Config.NodeID) {
+		return ErrNotholder
+	}
+	if resource.Origin == "" {
+		o.Replication.Track(o.pipeline, resource)
+	}
+	select {
+	case o.chanNewIotResourceArrival <- resource:
+		return nil
+	case <-o.ingress.Done():
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Stop : Stops the edge node gracefully. No new client requests or IoT resources are accepted, the requests already in
+the pipeline are given the drain timeout to be launched and, if configured, the running workloads are waited for.
+Workloads still running are left in the swarm and their tracking resumes on the next Start. The listening servers are
+then stopped and the request records persisted. Stop blocks until the edge node has stopped and can be called more
+than once.
+*/
+func (o *Orchestrator) Stop() {
+	o.stopping.Do(func() {
+		o.logger.Info("shutting down, no new client requests are accepted")
+		o.stopingress()
+
+		deadline := time.Now().Add(o.Config.Draintimeout)
+		drained := waituntil(deadline, func() bool {
+			return len(o.chanNewClientRequest) == 0 && len(o.chanparseroutput) == 0 && len(o.chandiscovery) == 0 &&
+				len(o.chanNewIotResourceArrival) == 0 && o.parser.Inflight() == 0 && o.discovery.Inflight() == 0 &&
+				o.Runtime.Inflight() == 0
+		})
+		if !drained {
+			o.logger.Warn("pipeline not drained before the deadline, dropping the requests left")
+		}
+		if o.Config.Waitworkloads {
+			if !waituntil(deadline, func() bool { return o.Runtime.Running() == 0 }) {
+				o.logger.Warn("workloads still running at the deadline", "running", o.Runtime.Running())
+			}
+		}
+		if running := o.Runtime.Running(); running > 0 {
+			o.logger.Info("leaving workloads running in the swarm, their tracking resumes on the next start",
+				"running", running)
+		}
+		o.stoppipeline()
+
+		o.stoptransport()
+		<-o.Transport.Done // to ensure we wait for server to shut down
+		if o.Consensus != nil {
+			o.Consensus.Shutdown()
+		}
+
+		if o.Config.Statefile != "" {
+			if err := o.Records.Save(o.Config.Statefile); err != nil {
+				o.logger.Error("could not persist request records", "file", o.Config.Statefile, "err", err)
+			}
+		}
+		o.logger.Info("shutdown complete")
+		close(o.done)
+	})
+	<-o.done
+}
+
+//Done : returns a channel that is closed once the edge node has stopped
+func (o *Orchestrator) Done() <-chan struct{} {
+	return o.done
+}
+
+/*
+waituntil : Polls a condition until it holds twice in a row or the deadline passes. Requests move between the channels
+and the modules of the pipeline, checking twice avoids missing a request in the hand over.
+Input: deadline, condition to wait for
+Output: whether the condition held before the deadline
+*/
+func waituntil(deadline time.Time, condition func() bool) bool {
+	held := false
+	for time.Now().Before(deadline) {
+		if condition() {
+			if held {
+				return true
+			}
+			held = true
+		} else {
+			held = false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
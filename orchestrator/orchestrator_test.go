@@ -0,0 +1,234 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/clientapi"
+	"github.com/niketagrawal/EDIRO/consensus"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//testconfig : returns the configuration of an edge node listening on a free port, without peers and servers
+func testconfig(t *testing.T, nodeID string) Config {
+	cfg := Defaultconfig()
+	cfg.NodeID = nodeID
+	cfg.Listenaddress = "127.0.0.1:0"
+	cfg.Peers = nil
+	cfg.Clientaddress = ""
+	cfg.Metricsaddress = ""
+	cfg.Draintimeout = 200 * time.Millisecond
+	cfg.Statefile = filepath.Join(t.TempDir(), "state.json")
+	return cfg
+}
+
+//credentials : issues the credentials of an edge node by a cluster CA of its own
+func credentials(t *testing.T, nodeID string) *nodeidentity.Credentials {
+	capem, cakeypem, err := nodeidentity.Newca("test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certpem, keypem, err := nodeidentity.Issue(capem, cakeypem, nodeID, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string][]byte{"ca.crt": capem, "node.crt": certpem, "node.key": keypem}
+	for name, data := range files {
+		if err := nodeidentity.Writefile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	creds, err := nodeidentity.Load(filepath.Join(dir, "node.crt"), filepath.Join(dir, "node.key"),
+		filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+func TestSubmitRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		stopped bool //whether the edge node is stopped before the submission
+		full    bool //whether the pipeline has no room left for the request
+		active  bool //whether the request is already active
+		wanterr error
+		//wantqueued : whether the request is handed to the pipeline, wantrecorded : whether the request is recorded
+		wantqueued, wantrecorded bool
+	}{
+		{name: "running", wantqueued: true, wantrecorded: true},
+		{name: "stopped", stopped: true, wanterr: ErrStopped},
+		{name: "pipeline full", full: true, wanterr: context.DeadlineExceeded},
+		{name: "request shared", active: true, wantrecorded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := New(testconfig(t, "edge_node_1"))
+			if tt.full {
+				for i := 0; i < cap(o.chanNewClientRequest); i++ {
+					o.chanNewClientRequest <- "queued"
+				}
+			}
+			if tt.stopped {
+				o.stopingress()
+			}
+			if tt.active {
+				o.Records.Submit(requestrecord.Record{Request: "client_request_1", Clients: []string{"a"}})
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := o.SubmitRequest(ctx, clientapi.Submission{Request: "client_request_1", Client: "b"})
+			if !errors.Is(err, tt.wanterr) {
+				t.Errorf("SubmitRequest = %v, want %v", err, tt.wanterr)
+			}
+			if queued := !tt.full && len(o.chanNewClientRequest) == 1; queued != tt.wantqueued {
+				t.Errorf("request handed to the pipeline %v, want %v", queued, tt.wantqueued)
+			}
+			if r, recorded := o.Records.Get("client_request_1"); recorded != tt.wantrecorded ||
+				(recorded && (!r.Has("b") || r.Priority != library.Critical)) {
+				t.Errorf("recorded %+v, want recorded %v for the client b with the priority of the library", r,
+					tt.wantrecorded)
+			}
+			if err := o.OffloadResource(ctx, resourcemanager.Newresource{Resource: "IoT_resource_1",
+				NodeID: "edge_node_1"}); tt.stopped && err != ErrStopped {
+				t.Errorf("OffloadResource = %v, want %v", err, ErrStopped)
+			}
+		})
+	}
+}
+
+func TestOffloadResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     bool //whether the edge node is given its credentials
+		holder  string
+		wanterr error
+	}{
+		{name: "own resource", holder: "edge_node_1"},
+		{name: "resource of another edge node in plain text", holder: "edge_node_2"},
+		{name: "own resource under mutual TLS", tls: true, holder: "edge_node_1"},
+		{name: "placement constraint on the edge node under mutual TLS", tls: true,
+			holder: "node.labels.device==edge_node_1"},
+		{name: "resource of another edge node under mutual TLS", tls: true, holder: "edge_node_2",
+			wanterr: ErrNotholder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testconfig(t, "edge_node_1")
+			if tt.tls {
+				cfg.Credentials = credentials(t, "edge_node_1")
+			}
+			o := New(cfg)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := o.OffloadResource(ctx, resourcemanager.Newresource{Resource: "IoT_resource_1", NodeID: tt.holder})
+			if !errors.Is(err, tt.wanterr) {
+				t.Errorf("OffloadResource = %v, want %v", err, tt.wanterr)
+			}
+		})
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	//two edge nodes embedded in the same process
+	nodes := []*Orchestrator{New(testconfig(t, "edge_node_1")), New(testconfig(t, "edge_node_2"))}
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, o := range nodes {
+		if err := o.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if nodes[0].Metrics.Registry == nodes[1].Metrics.Registry || nodes[0].Records == nodes[1].Records {
+		t.Error("edge nodes share their metrics or request records")
+	}
+	nodes[1].Stop()
+	select {
+	case <-nodes[1].Done():
+	default:
+		t.Error("edge node not done after Stop")
+	}
+	err := nodes[1].SubmitRequest(context.Background(), clientapi.Submission{Request: "client_request_1"})
+	if err != ErrStopped {
+		t.Errorf("SubmitRequest after Stop = %v, want %v", err, ErrStopped)
+	}
+	if _, err := os.Stat(nodes[1].Config.Statefile); err != nil {
+		t.Errorf("request records not persisted: %v", err)
+	}
+	cancel() //stops the edge nodes that are still running
+	select {
+	case <-nodes[0].Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("edge node not stopped once its context was cancelled")
+	}
+	nodes[0].Stop() //a second Stop returns at once
+}
+
+func TestStartCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeID  string //node ID of the certificate
+		wanterr bool
+	}{
+		{name: "node ID of the certificate", nodeID: "edge_node_1"},
+		{name: "node ID of another edge node", nodeID: "edge_node_2", wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testconfig(t, "edge_node_1")
+			cfg.Credentials = credentials(t, tt.nodeID)
+			o := New(cfg)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			err := o.Start(ctx)
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("Start = %v, want error %v", err, tt.wanterr)
+			}
+			if err == nil {
+				o.Stop()
+			}
+		})
+	}
+}
+
+func TestAdvertised(t *testing.T) {
+	tests := []struct {
+		name      string
+		advertise string
+		want      string
+	}{
+		{name: "listening address", want: "127.0.0.1:0"},
+		{name: "advertised address", advertise: "10.0.0.1:50051", want: "10.0.0.1:50051"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testconfig(t, "edge_node_1")
+			cfg.Advertiseaddress = tt.advertise
+			cfg.Consistency, cfg.Raftaddress = consensus.Raft, "127.0.0.1:0"
+			o := New(cfg)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := o.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer o.Stop()
+			//the single edge node of the Raft cluster leads it under the address the other edge nodes reach it at
+			deadline := time.Now().Add(10 * time.Second)
+			for ; !o.Consensus.Leading(); time.Sleep(10 * time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("edge node not leading")
+				}
+			}
+			if leader := o.Transport.Leader(); leader != tt.want {
+				t.Errorf("Leader = %q, want %q", leader, tt.want)
+			}
+		})
+	}
+}
@@ -11,28 +11,91 @@ Author : Niket Agrawal
 package parser
 
 import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
 	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 /*Parseroutput : The output of the parser is modelled as a structure that contains the client request, the
-corresponding application package and the associated IoT resource.
+corresponding application package and the associated IoT resource. The arrival time of the request is carried along to
+measure the latency of the pipeline and the context carries the span tracing the request. An application fusing several
+IoT resources comes with its inputs instead of a single resource, and a request served by a pipeline of applications
+comes with its stages.
 */
 type Parseroutput struct {
 	Request, Application, Resource string
+	Inputs                         []library.Input
+	Stages                         []library.Stage
+	Arrived                        time.Time
+	Ctx                            context.Context
+}
+
+//Parser : The parser module of an edge node
+type Parser struct {
+	//inflight : number of client requests taken from the input channel that are not yet handed to resource discovery
+	inflight atomic.Int64
+	metrics  *metrics.Metrics
+	logger   *slog.Logger
+}
+
+//New : creates the parser module of an edge node
+func New(m *metrics.Metrics, logger *slog.Logger) *Parser {
+	return &Parser{metrics: m, logger: logger}
 }
 
 //Parseinput : This function parses the client reqests, performs a map look up and renders the application and the
-//associated IoT resource corresponding to this client request
-func Parseinput(chIn chan string, chanparseroutput chan Parseroutput) {
+//associated IoT resource corresponding to this client request. It returns when ctx is cancelled.
+func (p *Parser) Parseinput(ctx context.Context, chIn chan string, chanparseroutput chan Parseroutput) {
 	for {
-		request := <-chIn
+		var request string
+		select {
+		case <-ctx.Done():
+			return
+		case request = <-chIn:
+		}
+		p.inflight.Add(1)
+		arrived := time.Now()
+		//the span of the request is ended by the task initiator once its workload has finished
+		spanctx, _ := tracing.Tracer.Start(context.Background(), "request",
+			trace.WithAttributes(attribute.String("ediro.request", request)))
+		_, span := tracing.Tracer.Start(spanctx, "parse")
 		requiredapp := library.RequesttoApp[request]
 		requiredresource := library.ApptoResource[requiredapp]
 		var output Parseroutput
 		output.Application = requiredapp
 		output.Resource = requiredresource
+		output.Inputs = library.ApptoInputs[requiredapp]
+		output.Stages = library.RequesttoStages[request]
 		output.Request = request
-		chanparseroutput <- output
+		output.Arrived = arrived
+		output.Ctx = spanctx
+		span.SetAttributes(attribute.String("ediro.application", requiredapp),
+			attribute.String("ediro.resource", requiredresource))
+		span.End()
+		if requiredapp == "" && len(output.Stages) == 0 {
+			p.logger.Warn("no application known for client request", logging.Request, request)
+		}
+		p.logger.Debug("client request parsed", logging.Request, request, "application", requiredapp,
+			logging.Resource, requiredresource)
+		p.metrics.Observestage(metrics.Parse, arrived)
+		select {
+		case <-ctx.Done():
+		case chanparseroutput <- output:
+		}
+		p.inflight.Add(-1)
 	}
 
 }
+
+//Inflight : returns the number of client requests being parsed, used to drain the pipeline on shutdown
+func (p *Parser) Inflight() int64 {
+	return p.inflight.Load()
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+)
+
+func TestParseinput(t *testing.T) {
+	tests := []struct {
+		request         string
+		wantapplication string
+		wantresource    string
+	}{
+		{request: "client_request_1", wantapplication: "application_image_1", wantresource: "IoT_resource_1"},
+		{request: "client_request_3", wantapplication: "application_image_3", wantresource: "IoT_resource_3"},
+		{request: "unknown_request"},
+	}
+	p := New(metrics.New(slog.Default()), slog.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	in, out := make(chan string), make(chan Parseroutput)
+	done := make(chan struct{})
+	go func() {
+		p.Parseinput(ctx, in, out)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	for _, tt := range tests {
+		in <- tt.request
+		got := <-out
+		if got.Request != tt.request || got.Application != tt.wantapplication || got.Resource != tt.wantresource {
+			t.Errorf("parsed %s into %q and %q, want %q and %q", got.Request, got.Application, got.Resource,
+				tt.wantapplication, tt.wantresource)
+		}
+		if got.Ctx == nil || got.Arrived.IsZero() {
+			t.Errorf("parsed %s without the span or the arrival of the request", tt.request)
+		}
+	}
+}
+
+func TestParseinputShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		request bool //whether a request is handed over that is never read from the output channel
+	}{
+		{name: "idle"},
+		{name: "output not read", request: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(metrics.New(slog.Default()), slog.Default())
+			ctx, cancel := context.WithCancel(context.Background())
+			in := make(chan string)
+			done := make(chan struct{})
+			go func() {
+				p.Parseinput(ctx, in, make(chan Parseroutput))
+				close(done)
+			}()
+			if tt.request {
+				in <- "client_request_1"
+			}
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Parseinput did not return once cancelled")
+			}
+			if n := p.Inflight(); n != 0 {
+				t.Errorf("%d requests in flight after the shutdown", n)
+			}
+		})
+	}
+}
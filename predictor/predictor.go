@@ -0,0 +1,275 @@
+/*
+This package implements the prediction of the execution time of the workloads launched by EDIRO. The duration of every
+completed workload is recorded by application, edge node of the swarm it ran on and class of the size of its input, and
+online statistics are kept for each of them: a moving average (EWMA) and the percentiles over the latest runs. The
+statistics are also kept for all the edge nodes and for all the input sizes, which is what a prediction falls back on
+when nothing was observed for the exact combination.
+The edge nodes share their statistics periodically through the inter edge communication (see package resourcemanager).
+A prediction combines the statistics observed by this edge node with the ones heard from the other edge nodes,
+weighted by their number of samples.
+
+*/
+
+package predictor
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+)
+
+//alpha : weight of the latest runtime in the moving average of the runtime
+const alpha = 0.3
+
+//window : number of latest runtimes the percentiles are computed over
+const window = 128
+
+//Anynode and Anysize : stand for all the edge nodes and all the input sizes in the statistics
+const (
+	Anynode = ""
+	Anysize = -1
+)
+
+//Prediction : The expected execution time of a workload
+type Prediction struct {
+	//Samples : number of runs the prediction is based on
+	Samples int64
+	//Mean : moving average of the runtime, P50, P90, P99 : percentiles of the runtime
+	Mean, P50, P90, P99 time.Duration
+}
+
+//key : the application, edge node and input size class statistics are kept for
+type key struct {
+	Application, Node string
+	Sizeclass         int
+}
+
+//stats : the online statistics of the runtime for one key, in seconds
+type stats struct {
+	samples int64
+	ewma    float64
+	latest  []float64 //ring buffer of the latest runtimes
+	next    int
+}
+
+//Predictor : The execution time statistics of an edge node
+type Predictor struct {
+	local map[key]*stats
+	//remote : the statistics heard from each other edge node, replaced by each of its updates
+	remote map[string]map[key]Prediction
+	mux    sync.Mutex
+	logger *slog.Logger
+}
+
+//New : creates a predictor without any history
+func New(logger *slog.Logger) *Predictor {
+	return &Predictor{local: map[key]*stats{}, remote: map[string]map[key]Prediction{}, logger: logger}
+}
+
+/*
+Sizeclass : Returns the class of an input size. Class k holds the sizes from 2^(k-1) to 2^k - 1 bytes so that inputs
+of a similar order of magnitude share their statistics.
+Input: size of the input in bytes, 0 if unknown
+Output: the size class, Anysize for an unknown size
+*/
+func Sizeclass(size int64) int {
+	if size <= 0 {
+		return Anysize
+	}
+	return bits.Len64(uint64(size))
+}
+
+//Inputsize : returns the size of the data of an IoT resource on this edge node, 0 if it cannot be read
+func Inputsize(resource string) int64 {
+	info, err := os.Stat(resource)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+//keys : the keys updated by a run, from the most to the least specific
+func keys(application string, node string, sizeclass int) []key {
+	ks := []key{{application, node, sizeclass}, {application, node, Anysize},
+		{application, Anynode, sizeclass}, {application, Anynode, Anysize}}
+	if sizeclass == Anysize {
+		ks = []key{ks[1], ks[3]}
+	}
+	if node == Anynode {
+		ks = ks[len(ks)/2:]
+	}
+	return ks
+}
+
+/*
+Observe : Records the runtime of a completed workload. Workloads that failed or were not run to completion are ignored.
+Input: record of the request of the workload
+Output: Nil
+*/
+func (p *Predictor) Observe(r requestrecord.Record) {
+	if r.State != requestrecord.Completed || r.Application == "" || r.Launched.IsZero() || r.Finished.Before(r.Launched) {
+		return
+	}
+	runtime := r.Finished.Sub(r.Launched).Seconds()
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for _, k := range keys(r.Application, r.Node, Sizeclass(r.Inputsize)) {
+		s, ok := p.local[k]
+		if !ok {
+			s = &stats{ewma: runtime}
+			p.local[k] = s
+		}
+		s.add(runtime)
+	}
+	p.logger.Debug("recorded workload runtime", "application", r.Application, "node", r.Node,
+		"inputsize", r.Inputsize, "runtime", r.Finished.Sub(r.Launched))
+}
+
+//add : adds a runtime to the statistics
+func (s *stats) add(runtime float64) {
+	if s.samples > 0 {
+		s.ewma = alpha*runtime + (1-alpha)*s.ewma
+	}
+	s.samples++
+	if len(s.latest) < window {
+		s.latest = append(s.latest, runtime)
+		return
+	}
+	s.latest[s.next] = runtime
+	s.next = (s.next + 1) % window
+}
+
+//prediction : returns the prediction made from the statistics
+func (s *stats) prediction() Prediction {
+	sorted := append([]float64(nil), s.latest...)
+	sort.Float64s(sorted)
+	percentile := func(q float64) time.Duration {
+		i := int(math.Ceil(q*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return seconds(sorted[i])
+	}
+	return Prediction{Samples: s.samples, Mean: seconds(s.ewma), P50: percentile(.5), P90: percentile(.9),
+		P99: percentile(.99)}
+}
+
+/*
+Predict : Predicts the execution time of an application on an edge node of the swarm. Without history for the input
+size on that edge node, the prediction falls back on all the input sizes and then on all the edge nodes.
+Input: application, edge node it would run on, Anynode for any, size of its input in bytes, 0 if unknown
+Output: the prediction, whether the application has any history
+*/
+func (p *Predictor) Predict(application string, node string, inputsize int64) (Prediction, bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for _, k := range keys(application, node, Sizeclass(inputsize)) {
+		var predictions []Prediction
+		if s, ok := p.local[k]; ok {
+			predictions = append(predictions, s.prediction())
+		}
+		for _, heard := range p.remote {
+			if prediction, ok := heard[k]; ok {
+				predictions = append(predictions, prediction)
+			}
+		}
+		if len(predictions) > 0 {
+			return combine(predictions), true
+		}
+	}
+	return Prediction{}, false
+}
+
+//combine : combines the predictions of several edge nodes, weighted by their number of samples
+func combine(predictions []Prediction) Prediction {
+	var total int64
+	var mean, p50, p90, p99 float64
+	for _, prediction := range predictions {
+		w := float64(prediction.Samples)
+		total += prediction.Samples
+		mean += w * prediction.Mean.Seconds()
+		p50 += w * prediction.P50.Seconds()
+		p90 += w * prediction.P90.Seconds()
+		p99 += w * prediction.P99.Seconds()
+	}
+	if total == 0 {
+		return Prediction{}
+	}
+	n := float64(total)
+	return Prediction{Samples: total, Mean: seconds(mean / n), P50: seconds(p50 / n), P90: seconds(p90 / n),
+		P99: seconds(p99 / n)}
+}
+
+//seconds : converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+/*
+Export : Returns the statistics observed by this edge node, to be shared with the other edge nodes.
+Input: ID of this edge node
+Output: the statistics in the format of the inter edge communication
+*/
+func (p *Predictor) Export(nodeID string) *pb.Predictions {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	out := &pb.Predictions{ID: nodeID}
+	for k, s := range p.local {
+		prediction := s.prediction()
+		out.Predictions = append(out.Predictions, &pb.Prediction{Application: k.Application, Node: k.Node,
+			Sizeclass: int32(k.Sizeclass), Samples: prediction.Samples, Ewma: prediction.Mean.Seconds(),
+			P50: prediction.P50.Seconds(), P90: prediction.P90.Seconds(), P99: prediction.P99.Seconds()})
+	}
+	return out
+}
+
+/*
+Merge : Records the statistics heard from another edge node, replacing the ones it shared before.
+Input: statistics shared by the other edge node
+Output: Nil
+*/
+func (p *Predictor) Merge(in *pb.Predictions) {
+	heard := map[key]Prediction{}
+	for _, prediction := range in.Predictions {
+		if prediction.Samples <= 0 {
+			continue
+		}
+		heard[key{prediction.Application, prediction.Node, int(prediction.Sizeclass)}] = Prediction{
+			Samples: prediction.Samples, Mean: seconds(prediction.Ewma), P50: seconds(prediction.P50),
+			P90: seconds(prediction.P90), P99: seconds(prediction.P99)}
+	}
+	p.mux.Lock()
+	p.remote[in.ID] = heard
+	p.mux.Unlock()
+	p.logger.Debug("runtime statistics heard from peer", "from", in.ID, "entries", len(heard))
+}
+
+/*
+Share : Periodically hands the statistics of this edge node to the inter edge communication.
+Input: context whose cancellation stops the sharing, ID of this edge node, interval between two updates, function
+sending an update to the other edge nodes
+Output: Nil
+*/
+func (p *Predictor) Share(ctx context.Context, nodeID string, interval time.Duration,
+	send func(ctx context.Context, in *pb.Predictions)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if update := p.Export(nodeID); len(update.Predictions) > 0 {
+			send(ctx, update)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package predictor
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+)
+
+//run : the record of a completed run of an application on an edge node
+func run(application string, node string, inputsize int64, runtime time.Duration) requestrecord.Record {
+	launched := time.Now()
+	return requestrecord.Record{Application: application, Node: node, Inputsize: inputsize,
+		State: requestrecord.Completed, Launched: launched, Finished: launched.Add(runtime)}
+}
+
+func newpredictor() *Predictor {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		runtimes []float64
+		want     Prediction
+	}{
+		{
+			name:     "single run",
+			runtimes: []float64{10},
+			want: Prediction{Samples: 1, Mean: 10 * time.Second, P50: 10 * time.Second, P90: 10 * time.Second,
+				P99: 10 * time.Second},
+		},
+		{
+			name:     "moving average",
+			runtimes: []float64{10, 20},
+			want: Prediction{Samples: 2, Mean: 13 * time.Second, P50: 10 * time.Second, P90: 20 * time.Second,
+				P99: 20 * time.Second},
+		},
+		{
+			name:     "percentiles",
+			runtimes: []float64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			want:     Prediction{Samples: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &stats{ewma: tt.runtimes[0]}
+			for _, runtime := range tt.runtimes {
+				s.add(runtime)
+			}
+			got := s.prediction()
+			//a zero mean is not checked
+			if (tt.want.Mean != 0 && got.Mean.Round(time.Millisecond) != tt.want.Mean) ||
+				got.Samples != tt.want.Samples || got.P50 != tt.want.P50 || got.P90 != tt.want.P90 ||
+				got.P99 != tt.want.P99 {
+				t.Errorf("prediction %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsWindow(t *testing.T) {
+	s := &stats{ewma: 1000}
+	s.add(1000)
+	for i := 1; i <= window; i++ {
+		s.add(float64(i))
+	}
+	got := s.prediction()
+	if got.Samples != window+1 {
+		t.Errorf("samples %d, want %d", got.Samples, window+1)
+	}
+	//the oldest run left the window
+	if got.P99 != seconds(127) {
+		t.Errorf("P99 %s, want %s", got.P99, seconds(127))
+	}
+}
+
+func TestPredict(t *testing.T) {
+	observed := []requestrecord.Record{
+		run("app", "n1", 100, 10*time.Second),
+		run("app", "n1", 10000, 30*time.Second),
+		run("app", "n2", 100, 50*time.Second),
+		run("failed", "n1", 100, time.Second),
+	}
+	observed[3].State = requestrecord.Failed
+	tests := []struct {
+		name        string
+		application string
+		node        string
+		inputsize   int64
+		want        time.Duration //median runtime
+		wantok      bool
+	}{
+		{name: "exact", application: "app", node: "n1", inputsize: 100, want: 10 * time.Second, wantok: true},
+		{name: "same size class", application: "app", node: "n1", inputsize: 120, want: 10 * time.Second,
+			wantok: true},
+		{name: "all sizes on the edge node", application: "app", node: "n1", inputsize: 1 << 30,
+			want: 10 * time.Second, wantok: true},
+		{name: "unknown size", application: "app", node: "n2", want: 50 * time.Second, wantok: true},
+		{name: "all edge nodes", application: "app", node: "n3", inputsize: 100, want: 10 * time.Second,
+			wantok: true},
+		{name: "failed runs are ignored", application: "failed", node: "n1"},
+		{name: "no history", application: "other", node: "n1"},
+	}
+	p := newpredictor()
+	for _, r := range observed {
+		p.Observe(r)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.Predict(tt.application, tt.node, tt.inputsize)
+			if ok != tt.wantok || got.P50 != tt.want {
+				t.Errorf("Predict = %+v, %v, want P50 %s, %v", got, ok, tt.want, tt.wantok)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	heard := func(id string, samples int64, p50 float64) *pb.Predictions {
+		return &pb.Predictions{ID: id, Predictions: []*pb.Prediction{{Application: "app", Node: "n1",
+			Sizeclass: Anysize, Samples: samples, Ewma: p50, P50: p50, P90: p50, P99: p50}}}
+	}
+	tests := []struct {
+		name   string
+		local  []time.Duration
+		merged []*pb.Predictions
+		want   Prediction
+		wantok bool
+	}{
+		{
+			name:   "heard only",
+			merged: []*pb.Predictions{heard("peer", 2, 20)},
+			want:   Prediction{Samples: 2, P50: 20 * time.Second}, wantok: true,
+		},
+		{
+			name:   "weighted by samples",
+			local:  []time.Duration{10 * time.Second},
+			merged: []*pb.Predictions{heard("peer", 3, 30)},
+			want:   Prediction{Samples: 4, P50: 25 * time.Second}, wantok: true,
+		},
+		{
+			name:   "update replaces the previous one",
+			merged: []*pb.Predictions{heard("peer", 2, 20), heard("peer", 1, 40)},
+			want:   Prediction{Samples: 1, P50: 40 * time.Second}, wantok: true,
+		},
+		{
+			name:   "several edge nodes",
+			merged: []*pb.Predictions{heard("a", 1, 10), heard("b", 1, 30)},
+			want:   Prediction{Samples: 2, P50: 20 * time.Second}, wantok: true,
+		},
+		{
+			name:   "empty statistics are ignored",
+			merged: []*pb.Predictions{heard("peer", 0, 20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newpredictor()
+			for _, runtime := range tt.local {
+				p.Observe(run("app", "n1", 0, runtime))
+			}
+			for _, in := range tt.merged {
+				p.Merge(in)
+			}
+			got, ok := p.Predict("app", "n1", 0)
+			if ok != tt.wantok || got.Samples != tt.want.Samples || got.P50 != tt.want.P50 {
+				t.Errorf("Predict = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantok)
+			}
+		})
+	}
+}
+
+func TestExportMerge(t *testing.T) {
+	source := newpredictor()
+	source.Observe(run("app", "n1", 100, 10*time.Second))
+	p := newpredictor()
+	p.Merge(source.Export("peer"))
+	want, _ := source.Predict("app", "n1", 100)
+	if got, ok := p.Predict("app", "n1", 100); !ok || got != want {
+		t.Errorf("Predict after merge = %+v, %v, want %+v", got, ok, want)
+	}
+}
@@ -0,0 +1,307 @@
+/*
+This package implements the predictive prefetching of IoT resources along the route of a vehicle. A vehicle may
+announce with its request the edge nodes it will reach next. The prefetch planner copies the IoT resource the request
+needs from an edge node holding it to the next edge nodes of the route that do not hold it yet, so that it is at hand
+when the vehicle gets there. A copy is made by a one-shot transfer service run on the destination edge node: its image
+is given by the operator and it is handed the resource, its content hash and the edge node to copy it from in the
+environment variables EDIRO_RESOURCE, EDIRO_RESOURCE_HASH and EDIRO_SOURCE_NODE. Once the transfer service completed,
+the edge node holding the copy is asked to record it in its catalog and announce it to the other edge nodes like an
+offloaded resource, so that the announcement is signed by the edge node holding the resource.
+The same transfer service gathers the inputs of an application fusing several IoT resources on the edge node its
+workload is placed on, those copies are consumed by the workload and are not announced. It also replicates the IoT
+resources of an edge node being drained to the other edge nodes, those copies are announced.
+Without a transfer image the planner only logs the transfers it would make.
+
+*/
+
+package prefetch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//pollinterval : interval at which the state of a transfer service is checked
+const pollinterval = time.Second
+
+//transfertimeout : time after which a transfer service that did not complete is given up
+const transfertimeout = 5 * time.Minute
+
+//recordtimeout : time given to the edge node holding a copy to record it and announce it to this edge node
+const recordtimeout = 5 * time.Second
+
+//Transfer : A copy of an IoT resource from an edge node holding it to another edge node, e.g. on the route of a vehicle
+type Transfer struct {
+	Resource, From, To string
+	Provenance         resourcemanager.Provenance
+	//Descriptor : what the IoT resource is about, announced with the copy, the zero descriptor if unknown
+	Descriptor resourcemanager.Descriptor
+}
+
+//pending : A transfer in progress, done is closed when it ends and ok tells whether the resource was copied
+type pending struct {
+	done chan struct{}
+	ok   bool
+}
+
+//Planner : The prefetch planner of an edge node
+type Planner struct {
+	//Image : image of the transfer service, empty to only plan the transfers
+	Image string
+	//Horizon : number of the next edge nodes of a route the resources are prefetched to
+	Horizon int
+
+	catalog *resourcemanager.Catalog
+	//announce : has the edge node holding a copied resource record it and announce it to the other edge nodes
+	announce func(ctx context.Context, resource resourcemanager.Newresource) error
+	//inflight : the transfers in progress keyed by resource and destination
+	inflight map[string]*pending
+	mux      sync.Mutex
+	logger   *slog.Logger
+}
+
+//New : creates the prefetch planner of an edge node
+func New(image string, horizon int, catalog *resourcemanager.Catalog,
+	announce func(ctx context.Context, resource resourcemanager.Newresource) error, logger *slog.Logger) *Planner {
+	return &Planner{Image: image, Horizon: horizon, catalog: catalog, announce: announce,
+		inflight: map[string]*pending{}, logger: logger}
+}
+
+/*
+Plan : Plans the transfers of an IoT resource to the next edge nodes of a route that do not hold it yet.
+Input: IoT resource, edge nodes the vehicle will reach, in order
+Output: the transfers to make, none if no edge node holds the resource
+*/
+func (p *Planner) Plan(resource string, route []string) []Transfer {
+	holders := p.catalog.Holders(resource)
+	if len(holders) == 0 {
+		return nil
+	}
+	held, planned := map[string]bool{}, map[string]bool{}
+	for _, node := range holders {
+		held[node] = true
+	}
+	var transfers []Transfer
+	for i, node := range route {
+		if i >= p.Horizon {
+			break
+		}
+		if held[node] || planned[node] {
+			continue
+		}
+		//the resource is copied from the holder closest to the destination on the route, if any
+		from := holders[0]
+		for j := i - 1; j >= 0; j-- {
+			if held[route[j]] {
+				from = route[j]
+				break
+			}
+		}
+		provenance, _ := p.catalog.Lookup(resource, from)
+		transfers = append(transfers, Transfer{Resource: resource, From: from, To: node, Provenance: provenance})
+		planned[node] = true
+	}
+	return transfers
+}
+
+/*
+Prefetch : Starts the transfers planned for an IoT resource along a route, skipping those already in progress. Each copy
+is announced by the edge node of the route it was made on, see Replicate, and a transfer only counts as done once the
+copy is recorded in the catalog of this edge node.
+Input: context whose cancellation stops the transfers, IoT resource, edge nodes the vehicle will reach, in order
+Output: Nil
+*/
+func (p *Planner) Prefetch(ctx context.Context, resource string, route []string) {
+	for _, t := range p.Plan(resource, route) {
+		if p.Image == "" {
+			p.logger.Info("would prefetch resource, no transfer image given", logging.Resource, t.Resource,
+				"from", t.From, "to", t.To)
+			continue
+		}
+		p.mux.Lock()
+		if _, ok := p.inflight[t.Resource+"@"+t.To]; ok {
+			p.mux.Unlock()
+			continue
+		}
+		transfer := &pending{done: make(chan struct{})}
+		p.inflight[t.Resource+"@"+t.To] = transfer
+		p.mux.Unlock()
+
+		go func(t Transfer) {
+			defer func() {
+				p.mux.Lock()
+				delete(p.inflight, t.Resource+"@"+t.To)
+				p.mux.Unlock()
+				close(transfer.done)
+			}()
+			if err := p.Replicate(ctx, t); err != nil {
+				p.logger.Warn("could not prefetch resource", logging.Resource, t.Resource, "from", t.From, "to", t.To,
+					"err", err)
+				return
+			}
+			transfer.ok = true
+			p.logger.Info("resource prefetched", logging.Resource, t.Resource, "from", t.From, "to", t.To)
+		}(t)
+	}
+}
+
+/*
+Replicate : Copies an IoT resource to an edge node and has that edge node announce the copy like an offloaded
+resource, along with the edge node it was copied from, then waits for the copy to be recorded in the catalog.
+Input: context whose cancellation stops the transfer, transfer to make
+Output: error if the resource could not be copied, or the copy could not be announced or was not recorded in time
+*/
+func (p *Planner) Replicate(ctx context.Context, t Transfer) error {
+	if p.Image == "" {
+		return errors.New("no transfer image given")
+	}
+	if err := p.transfer(ctx, t); err != nil {
+		return err
+	}
+	err := p.announce(ctx, resourcemanager.Newresource{Resource: t.Resource, NodeID: t.To,
+		Hash: t.Provenance.Hash, Contributor: t.Provenance.Contributor, Type: t.Descriptor.Type,
+		Footprint: t.Descriptor.Footprint, Offloaded: t.Descriptor.Offloaded, Size: t.Descriptor.Size, Origin: t.From})
+	if err != nil {
+		return fmt.Errorf("copy could not be announced: %v", err)
+	}
+	//the copy reaches the catalog of this edge node with the announcement of the edge node holding it
+	for deadline := time.Now().Add(recordtimeout); !p.recorded(t); {
+		if time.Now().After(deadline) {
+			return errors.New("copy was not recorded in the catalog in time")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+//recorded : returns whether the catalog records the version of an IoT resource copied by a transfer on its destination
+func (p *Planner) recorded(t Transfer) bool {
+	provenance, ok := p.catalog.Lookup(t.Resource, t.To)
+	return ok && (t.Provenance.Hash == "" || provenance.Hash == t.Provenance.Hash)
+}
+
+/*
+Ready : Waits for the transfer of an IoT resource to an edge node to end, if one is in progress.
+Input: context bounding the wait, IoT resource, edge node
+Output: whether the edge node holds the resource
+*/
+func (p *Planner) Ready(ctx context.Context, resource string, node string) bool {
+	p.mux.Lock()
+	transfer, ok := p.inflight[resource+"@"+node]
+	p.mux.Unlock()
+	if ok {
+		select {
+		case <-transfer.done:
+			return transfer.ok
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return p.holds(resource, node)
+}
+
+//holds : returns whether an edge node holds an available IoT resource according to the catalog
+func (p *Planner) holds(resource string, node string) bool {
+	for _, holder := range p.catalog.Holders(resource) {
+		if holder == node {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Gather : Copies IoT resources to the edge node a workload is placed on and waits for the copies. A resource being
+prefetched to that edge node is waited for instead of being copied again.
+Input: context whose cancellation stops the transfers, transfers to make
+Output: error naming the first resource that could not be copied
+*/
+func (p *Planner) Gather(ctx context.Context, transfers []Transfer) error {
+	errs := make(chan error, len(transfers))
+	for _, t := range transfers {
+		if p.Image == "" {
+			p.logger.Info("would gather resource, no transfer image given", logging.Resource, t.Resource,
+				"from", t.From, "to", t.To)
+			errs <- nil
+			continue
+		}
+		go func(t Transfer) {
+			if p.Ready(ctx, t.Resource, t.To) {
+				errs <- nil
+				return
+			}
+			if err := p.transfer(ctx, t); err != nil {
+				errs <- fmt.Errorf("resource %s could not be copied from %s to %s: %v", t.Resource, t.From, t.To, err)
+				return
+			}
+			p.logger.Info("resource gathered", logging.Resource, t.Resource, "from", t.From, "to", t.To)
+			errs <- nil
+		}(t)
+	}
+	var first error
+	for range transfers {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+/*
+transfer : Runs the transfer service copying an IoT resource to an edge node and waits for it to complete. The service
+is removed once it is over. Its name carries a nonce, so that concurrent transfers of the same IoT resource to the same
+edge node, e.g. a prefetch and a replication, each run and remove their own service.
+Input: context whose cancellation stops the wait, transfer to make
+Output: error if the service could not be created, failed or did not complete in time
+*/
+func (p *Planner) transfer(ctx context.Context, t Transfer) error {
+	sum := sha256.Sum256([]byte(t.Resource + "@" + t.To))
+	nonce := make([]byte, 4)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("transfer service could not be named: %v", err)
+	}
+	servicename := "ediro-prefetch-" + hex.EncodeToString(sum[:6]) + "-" + hex.EncodeToString(nonce)
+	out, err := exec.Command("docker", "service", "create", "--name", servicename, "--restart-condition", "none",
+		"--detach", "--env", "EDIRO_RESOURCE="+t.Resource, "--env", "EDIRO_RESOURCE_HASH="+t.Provenance.Hash,
+		"--env", "EDIRO_SOURCE_NODE="+t.From, "--constraint", t.To, p.Image).CombinedOutput()
+	if err != nil {
+		return errors.New("transfer service could not be created: " + strings.TrimSpace(string(out)))
+	}
+	defer exec.Command("docker", "service", "rm", servicename).Run()
+
+	deadline := time.After(transfertimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return errors.New("transfer service did not complete in time")
+		case <-time.After(pollinterval):
+		}
+		out, err := exec.Command("docker", "service", "ps", servicename).Output()
+		if err != nil {
+			continue
+		}
+		if output := string(out); strings.Contains(output, "Failed") || strings.Contains(output, "Rejected") {
+			return errors.New("transfer service failed")
+		} else if strings.Contains(output, "Complete") {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package prefetch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//fakedockerscript : a docker command logging its calls and listing the tasks of a service from FAKEDOCKER_TASKS
+const fakedockerscript = `#!/bin/sh
+echo "$*" >> "$FAKEDOCKER_LOG"
+case "$1 $2" in
+"service ps") printf '%s\n' "$FAKEDOCKER_TASKS" ;;
+esac
+`
+
+//fakedocker : puts a fake docker command first in the PATH and returns a function returning the calls made to it
+func fakedocker(t *testing.T, tasks string) func() []string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(fakedockerscript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	log := filepath.Join(dir, "calls")
+	t.Setenv("FAKEDOCKER_LOG", log)
+	t.Setenv("FAKEDOCKER_TASKS", tasks)
+	return func() []string {
+		data, _ := os.ReadFile(log)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+//newcatalog : returns a catalog in which each edge node holds the given resources, with the hash h-<node>
+func newcatalog(held map[string][]string) *resourcemanager.Catalog {
+	c := resourcemanager.NewCatalog(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for node, resources := range held {
+		for _, resource := range resources {
+			c.Add(resource, node, resourcemanager.Provenance{Hash: "h-" + node})
+		}
+	}
+	return c
+}
+
+//copyof : returns the transfer of the resource r from an edge node of the catalog to another
+func copyof(from string, to string) Transfer {
+	return Transfer{Resource: "r", From: from, To: to, Provenance: resourcemanager.Provenance{Hash: "h-" + from}}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		held    map[string][]string
+		route   []string
+		horizon int
+		want    []Transfer
+	}{
+		{name: "resource held nowhere", held: map[string][]string{"n1": {"a"}}, route: []string{"n2"}, horizon: 2},
+		{
+			name: "next edge nodes", held: map[string][]string{"n1": {"r"}}, route: []string{"n2", "n3"}, horizon: 2,
+			want: []Transfer{copyof("n1", "n2"), copyof("n1", "n3")},
+		},
+		{
+			name: "beyond the horizon", held: map[string][]string{"n1": {"r"}}, route: []string{"n2", "n3"},
+			horizon: 1,
+			want:    []Transfer{copyof("n1", "n2")},
+		},
+		{
+			name: "holder on the route", held: map[string][]string{"n1": {"r"}, "n2": {"r"}},
+			route: []string{"n2", "n3"}, horizon: 2,
+			want: []Transfer{copyof("n2", "n3")},
+		},
+		{
+			name: "edge node listed twice", held: map[string][]string{"n1": {"r"}}, route: []string{"n2", "n2"},
+			horizon: 2,
+			want:    []Transfer{copyof("n1", "n2")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New("", tt.horizon, newcatalog(tt.held), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if got := p.Plan("r", tt.route); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	tests := []struct {
+		name        string
+		image       string
+		tasks       string //state of the transfer service
+		announceerr error
+		wantready   bool
+		//wantcreated : whether the transfer service is created
+		wantcreated bool
+	}{
+		{name: "no transfer image"},
+		{name: "resource copied", image: "transfer", tasks: "Complete", wantready: true, wantcreated: true},
+		{name: "transfer failed", image: "transfer", tasks: "Failed", wantcreated: true},
+		{name: "copy not announced", image: "transfer", tasks: "Complete", announceerr: errors.New("unreachable"),
+			wantcreated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, tt.tasks)
+			c := newcatalog(map[string][]string{"n1": {"r"}})
+			var announced []resourcemanager.Newresource
+			p := New(tt.image, 1, c, func(ctx context.Context, resource resourcemanager.Newresource) error {
+				if tt.announceerr != nil {
+					return tt.announceerr
+				}
+				announced = append(announced, resource)
+				c.Add(resource.Resource, resource.NodeID, resourcemanager.Provenance{Hash: resource.Hash})
+				return nil
+			}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			p.Prefetch(context.Background(), "r", []string{"n2"})
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if ready := p.Ready(ctx, "r", "n2"); ready != tt.wantready {
+				t.Errorf("Ready = %v, want %v", ready, tt.wantready)
+			}
+			if tt.wantready && (len(announced) != 1 || announced[0].Hash != "h-n1") {
+				t.Errorf("announced %+v, want the copy on n2 with the hash of the original", announced)
+			}
+			created := strings.HasPrefix(calls()[0], "service create")
+			if created != tt.wantcreated {
+				t.Errorf("transfer service created %v, want %v: %v", created, tt.wantcreated, calls())
+			}
+			if created && (!strings.Contains(calls()[0], "--constraint n2") ||
+				!strings.Contains(calls()[0], "EDIRO_SOURCE_NODE=n1") ||
+				!strings.HasPrefix(calls()[len(calls())-1], "service rm")) {
+				t.Errorf("transfer service not run from n1 on n2 or not removed: %v", calls())
+			}
+		})
+	}
+}
+
+func TestGather(t *testing.T) {
+	tests := []struct {
+		name        string
+		image       string
+		tasks       string //state of the transfer services
+		wanterr     bool
+		wantcreated int //number of transfer services created
+	}{
+		{name: "no transfer image"},
+		{name: "resources copied", image: "transfer", tasks: "Complete", wantcreated: 2},
+		{name: "transfer failed", image: "transfer", tasks: "Failed", wanterr: true, wantcreated: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, tt.tasks)
+			p := New(tt.image, 1, newcatalog(map[string][]string{"n1": {"a", "b"}}), nil,
+				slog.New(slog.NewTextHandler(io.Discard, nil)))
+			err := p.Gather(context.Background(), []Transfer{{Resource: "a", From: "n1", To: "n2"},
+				{Resource: "b", From: "n1", To: "n2"}})
+			if (err != nil) != tt.wanterr {
+				t.Errorf("Gather = %v, want error %v", err, tt.wanterr)
+			}
+			created := 0
+			for _, call := range calls() {
+				if strings.HasPrefix(call, "service create") {
+					created++
+				}
+			}
+			if created != tt.wantcreated {
+				t.Errorf("%d transfer services created, want %d: %v", created, tt.wantcreated, calls())
+			}
+		})
+	}
+}
+
+func TestReplicate(t *testing.T) {
+	tests := []struct {
+		name          string
+		image         string
+		tasks         string //state of the transfer service
+		announceerr   error
+		wanterr       bool
+		wantannounced bool
+	}{
+		{name: "no transfer image", wanterr: true},
+		{name: "resource copied", image: "transfer", tasks: "Complete", wantannounced: true},
+		{name: "transfer failed", image: "transfer", tasks: "Failed", wanterr: true},
+		{name: "copy not announced", image: "transfer", tasks: "Complete", announceerr: errors.New("unreachable"),
+			wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t, tt.tasks)
+			c := newcatalog(map[string][]string{"n1": {"r"}})
+			var announced []resourcemanager.Newresource
+			p := New(tt.image, 1, c, func(ctx context.Context, resource resourcemanager.Newresource) error {
+				if tt.announceerr != nil {
+					return tt.announceerr
+				}
+				announced = append(announced, resource)
+				c.Add(resource.Resource, resource.NodeID, resourcemanager.Provenance{Hash: resource.Hash})
+				return nil
+			}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			transfer := copyof("n1", "n2")
+			transfer.Descriptor = resourcemanager.Descriptor{Type: "hd_map", Size: 10}
+			if err := p.Replicate(context.Background(), transfer); (err != nil) != tt.wanterr {
+				t.Errorf("Replicate = %v, want error %v", err, tt.wanterr)
+			}
+			if (len(announced) == 1) != tt.wantannounced {
+				t.Fatalf("announced %+v, want announced %v", announced, tt.wantannounced)
+			}
+			if tt.wantannounced && (announced[0].NodeID != "n2" || announced[0].Hash != "h-n1" ||
+				announced[0].Type != "hd_map" || announced[0].Size != 10) {
+				t.Errorf("announced %+v, want the copy on n2 with the hash and the descriptor of the original",
+					announced[0])
+			}
+		})
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	calls := fakedocker(t, "Complete")
+	p := New("transfer", 1, newcatalog(nil), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	//a prefetch and a replication of the same resource to the same edge node at once
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- p.transfer(context.Background(), copyof("n1", "n2")) }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("transfer = %v", err)
+		}
+	}
+	created, removed := map[string]bool{}, map[string]bool{}
+	for _, call := range calls() {
+		fields := strings.Fields(call)
+		switch {
+		case strings.HasPrefix(call, "service create --name "):
+			created[fields[3]] = true
+		case strings.HasPrefix(call, "service rm "):
+			removed[fields[2]] = true
+		}
+	}
+	if len(created) != 2 || !reflect.DeepEqual(created, removed) {
+		t.Errorf("transfer services created %v and removed %v, want two of their own", created, removed)
+	}
+}
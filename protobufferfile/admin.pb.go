@@ -0,0 +1,1646 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: admin.proto
+
+package frontend
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type MembersRequest struct {
+	// read the catalog once it applied every mutation committed before the call, in the consistent mode
+	Linearizable         bool     `protobuf:"varint,1,opt,name=linearizable,proto3" json:"linearizable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MembersRequest) Reset()         { *m = MembersRequest{} }
+func (m *MembersRequest) String() string { return proto.CompactTextString(m) }
+func (*MembersRequest) ProtoMessage()    {}
+func (*MembersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{0}
+}
+
+func (m *MembersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MembersRequest.Unmarshal(m, b)
+}
+func (m *MembersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MembersRequest.Marshal(b, m, deterministic)
+}
+func (m *MembersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MembersRequest.Merge(m, src)
+}
+func (m *MembersRequest) XXX_Size() int {
+	return xxx_messageInfo_MembersRequest.Size(m)
+}
+func (m *MembersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MembersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MembersRequest proto.InternalMessageInfo
+
+func (m *MembersRequest) GetLinearizable() bool {
+	if m != nil {
+		return m.Linearizable
+	}
+	return false
+}
+
+type MemberList struct {
+	// edge node answering
+	ID string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// addresses of the other edge nodes of the cluster
+	Peers []string `protobuf:"bytes,2,rep,name=peers,proto3" json:"peers,omitempty"`
+	// edge nodes of the swarm holding IoT resources or running workloads
+	Members []*Member `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"`
+	// edge node leading the replicated log of the catalog, empty in the best-effort mode
+	Leader               string   `protobuf:"bytes,4,opt,name=leader,proto3" json:"leader,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MemberList) Reset()         { *m = MemberList{} }
+func (m *MemberList) String() string { return proto.CompactTextString(m) }
+func (*MemberList) ProtoMessage()    {}
+func (*MemberList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{1}
+}
+
+func (m *MemberList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MemberList.Unmarshal(m, b)
+}
+func (m *MemberList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MemberList.Marshal(b, m, deterministic)
+}
+func (m *MemberList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MemberList.Merge(m, src)
+}
+func (m *MemberList) XXX_Size() int {
+	return xxx_messageInfo_MemberList.Size(m)
+}
+func (m *MemberList) XXX_DiscardUnknown() {
+	xxx_messageInfo_MemberList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MemberList proto.InternalMessageInfo
+
+func (m *MemberList) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *MemberList) GetPeers() []string {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+func (m *MemberList) GetMembers() []*Member {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+func (m *MemberList) GetLeader() string {
+	if m != nil {
+		return m.Leader
+	}
+	return ""
+}
+
+type Member struct {
+	Node string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// IoT resources available on the edge node and IoT resources used by workloads
+	Resources int32 `protobuf:"varint,2,opt,name=resources,proto3" json:"resources,omitempty"`
+	Used      int32 `protobuf:"varint,3,opt,name=used,proto3" json:"used,omitempty"`
+	// workloads running on the edge node and client requests waiting for it
+	Running int32 `protobuf:"varint,4,opt,name=running,proto3" json:"running,omitempty"`
+	Queued  int32 `protobuf:"varint,5,opt,name=queued,proto3" json:"queued,omitempty"`
+	// number of workloads the edge node runs at the same time, 0 for no limit
+	Capacity int32 `protobuf:"varint,6,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// active, draining or departed
+	State string `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`
+	// bytes of IoT resources stored on the edge node through this edge node and quota, 0 for no quota
+	Stored               int64    `protobuf:"varint,8,opt,name=stored,proto3" json:"stored,omitempty"`
+	Quota                int64    `protobuf:"varint,9,opt,name=quota,proto3" json:"quota,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Member) Reset()         { *m = Member{} }
+func (m *Member) String() string { return proto.CompactTextString(m) }
+func (*Member) ProtoMessage()    {}
+func (*Member) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{2}
+}
+
+func (m *Member) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Member.Unmarshal(m, b)
+}
+func (m *Member) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Member.Marshal(b, m, deterministic)
+}
+func (m *Member) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Member.Merge(m, src)
+}
+func (m *Member) XXX_Size() int {
+	return xxx_messageInfo_Member.Size(m)
+}
+func (m *Member) XXX_DiscardUnknown() {
+	xxx_messageInfo_Member.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Member proto.InternalMessageInfo
+
+func (m *Member) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *Member) GetResources() int32 {
+	if m != nil {
+		return m.Resources
+	}
+	return 0
+}
+
+func (m *Member) GetUsed() int32 {
+	if m != nil {
+		return m.Used
+	}
+	return 0
+}
+
+func (m *Member) GetRunning() int32 {
+	if m != nil {
+		return m.Running
+	}
+	return 0
+}
+
+func (m *Member) GetQueued() int32 {
+	if m != nil {
+		return m.Queued
+	}
+	return 0
+}
+
+func (m *Member) GetCapacity() int32 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+func (m *Member) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Member) GetStored() int64 {
+	if m != nil {
+		return m.Stored
+	}
+	return 0
+}
+
+func (m *Member) GetQuota() int64 {
+	if m != nil {
+		return m.Quota
+	}
+	return 0
+}
+
+type CatalogFilter struct {
+	// all empty or false for the whole catalog, resource matches a prefix of the name
+	Node      string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Resource  string `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	Type      string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Available bool   `protobuf:"varint,4,opt,name=available,proto3" json:"available,omitempty"`
+	// read the catalog once it applied every mutation committed before the call, in the consistent mode
+	Linearizable         bool     `protobuf:"varint,5,opt,name=linearizable,proto3" json:"linearizable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CatalogFilter) Reset()         { *m = CatalogFilter{} }
+func (m *CatalogFilter) String() string { return proto.CompactTextString(m) }
+func (*CatalogFilter) ProtoMessage()    {}
+func (*CatalogFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{3}
+}
+
+func (m *CatalogFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CatalogFilter.Unmarshal(m, b)
+}
+func (m *CatalogFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CatalogFilter.Marshal(b, m, deterministic)
+}
+func (m *CatalogFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CatalogFilter.Merge(m, src)
+}
+func (m *CatalogFilter) XXX_Size() int {
+	return xxx_messageInfo_CatalogFilter.Size(m)
+}
+func (m *CatalogFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_CatalogFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CatalogFilter proto.InternalMessageInfo
+
+func (m *CatalogFilter) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *CatalogFilter) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *CatalogFilter) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *CatalogFilter) GetAvailable() bool {
+	if m != nil {
+		return m.Available
+	}
+	return false
+}
+
+func (m *CatalogFilter) GetLinearizable() bool {
+	if m != nil {
+		return m.Linearizable
+	}
+	return false
+}
+
+type CatalogEntries struct {
+	Entries              []*CatalogEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *CatalogEntries) Reset()         { *m = CatalogEntries{} }
+func (m *CatalogEntries) String() string { return proto.CompactTextString(m) }
+func (*CatalogEntries) ProtoMessage()    {}
+func (*CatalogEntries) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{4}
+}
+
+func (m *CatalogEntries) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CatalogEntries.Unmarshal(m, b)
+}
+func (m *CatalogEntries) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CatalogEntries.Marshal(b, m, deterministic)
+}
+func (m *CatalogEntries) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CatalogEntries.Merge(m, src)
+}
+func (m *CatalogEntries) XXX_Size() int {
+	return xxx_messageInfo_CatalogEntries.Size(m)
+}
+func (m *CatalogEntries) XXX_DiscardUnknown() {
+	xxx_messageInfo_CatalogEntries.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CatalogEntries proto.InternalMessageInfo
+
+func (m *CatalogEntries) GetEntries() []*CatalogEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type CatalogEntry struct {
+	Resource string `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	Node     string `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	// whether the IoT resource is not used by a workload yet
+	Available   bool   `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	Hash        string `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	Contributor string `protobuf:"bytes,5,opt,name=contributor,proto3" json:"contributor,omitempty"`
+	Verified    bool   `protobuf:"varint,6,opt,name=verified,proto3" json:"verified,omitempty"`
+	Type        string `protobuf:"bytes,7,opt,name=type,proto3" json:"type,omitempty"`
+	// time the IoT resource was offloaded, in unix nanoseconds, and size of its data in bytes, 0 if unknown
+	Offloaded int64 `protobuf:"varint,8,opt,name=offloaded,proto3" json:"offloaded,omitempty"`
+	Size      int64 `protobuf:"varint,9,opt,name=size,proto3" json:"size,omitempty"`
+	// edge node the IoT resource was copied from, empty if it was offloaded there
+	Origin               string   `protobuf:"bytes,10,opt,name=origin,proto3" json:"origin,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CatalogEntry) Reset()         { *m = CatalogEntry{} }
+func (m *CatalogEntry) String() string { return proto.CompactTextString(m) }
+func (*CatalogEntry) ProtoMessage()    {}
+func (*CatalogEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{5}
+}
+
+func (m *CatalogEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CatalogEntry.Unmarshal(m, b)
+}
+func (m *CatalogEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CatalogEntry.Marshal(b, m, deterministic)
+}
+func (m *CatalogEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CatalogEntry.Merge(m, src)
+}
+func (m *CatalogEntry) XXX_Size() int {
+	return xxx_messageInfo_CatalogEntry.Size(m)
+}
+func (m *CatalogEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_CatalogEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CatalogEntry proto.InternalMessageInfo
+
+func (m *CatalogEntry) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *CatalogEntry) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *CatalogEntry) GetAvailable() bool {
+	if m != nil {
+		return m.Available
+	}
+	return false
+}
+
+func (m *CatalogEntry) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *CatalogEntry) GetContributor() string {
+	if m != nil {
+		return m.Contributor
+	}
+	return ""
+}
+
+func (m *CatalogEntry) GetVerified() bool {
+	if m != nil {
+		return m.Verified
+	}
+	return false
+}
+
+func (m *CatalogEntry) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *CatalogEntry) GetOffloaded() int64 {
+	if m != nil {
+		return m.Offloaded
+	}
+	return 0
+}
+
+func (m *CatalogEntry) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *CatalogEntry) GetOrigin() string {
+	if m != nil {
+		return m.Origin
+	}
+	return ""
+}
+
+type RequestFilter struct {
+	// empty for all states and all clients
+	State                string   `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	Client               string   `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestFilter) Reset()         { *m = RequestFilter{} }
+func (m *RequestFilter) String() string { return proto.CompactTextString(m) }
+func (*RequestFilter) ProtoMessage()    {}
+func (*RequestFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{6}
+}
+
+func (m *RequestFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestFilter.Unmarshal(m, b)
+}
+func (m *RequestFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestFilter.Marshal(b, m, deterministic)
+}
+func (m *RequestFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestFilter.Merge(m, src)
+}
+func (m *RequestFilter) XXX_Size() int {
+	return xxx_messageInfo_RequestFilter.Size(m)
+}
+func (m *RequestFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestFilter proto.InternalMessageInfo
+
+func (m *RequestFilter) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *RequestFilter) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+type RequestList struct {
+	Requests             []*RequestInfo `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *RequestList) Reset()         { *m = RequestList{} }
+func (m *RequestList) String() string { return proto.CompactTextString(m) }
+func (*RequestList) ProtoMessage()    {}
+func (*RequestList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{7}
+}
+
+func (m *RequestList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestList.Unmarshal(m, b)
+}
+func (m *RequestList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestList.Marshal(b, m, deterministic)
+}
+func (m *RequestList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestList.Merge(m, src)
+}
+func (m *RequestList) XXX_Size() int {
+	return xxx_messageInfo_RequestList.Size(m)
+}
+func (m *RequestList) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestList proto.InternalMessageInfo
+
+func (m *RequestList) GetRequests() []*RequestInfo {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+type RequestInfo struct {
+	Request     string   `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	State       string   `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Reason      string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Application string   `protobuf:"bytes,4,opt,name=application,proto3" json:"application,omitempty"`
+	Node        string   `protobuf:"bytes,5,opt,name=node,proto3" json:"node,omitempty"`
+	Service     string   `protobuf:"bytes,6,opt,name=service,proto3" json:"service,omitempty"`
+	Priority    string   `protobuf:"bytes,7,opt,name=priority,proto3" json:"priority,omitempty"`
+	Clients     []string `protobuf:"bytes,8,rep,name=clients,proto3" json:"clients,omitempty"`
+	// times in unix nanoseconds, 0 if not reached
+	Submitted            int64        `protobuf:"varint,9,opt,name=submitted,proto3" json:"submitted,omitempty"`
+	Launched             int64        `protobuf:"varint,10,opt,name=launched,proto3" json:"launched,omitempty"`
+	Finished             int64        `protobuf:"varint,11,opt,name=finished,proto3" json:"finished,omitempty"`
+	Preemptions          int32        `protobuf:"varint,12,opt,name=preemptions,proto3" json:"preemptions,omitempty"`
+	Runs                 int32        `protobuf:"varint,13,opt,name=runs,proto3" json:"runs,omitempty"`
+	Stages               []*StageInfo `protobuf:"bytes,14,rep,name=stages,proto3" json:"stages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RequestInfo) Reset()         { *m = RequestInfo{} }
+func (m *RequestInfo) String() string { return proto.CompactTextString(m) }
+func (*RequestInfo) ProtoMessage()    {}
+func (*RequestInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{8}
+}
+
+func (m *RequestInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestInfo.Unmarshal(m, b)
+}
+func (m *RequestInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestInfo.Marshal(b, m, deterministic)
+}
+func (m *RequestInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestInfo.Merge(m, src)
+}
+func (m *RequestInfo) XXX_Size() int {
+	return xxx_messageInfo_RequestInfo.Size(m)
+}
+func (m *RequestInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestInfo proto.InternalMessageInfo
+
+func (m *RequestInfo) GetRequest() string {
+	if m != nil {
+		return m.Request
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetApplication() string {
+	if m != nil {
+		return m.Application
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetPriority() string {
+	if m != nil {
+		return m.Priority
+	}
+	return ""
+}
+
+func (m *RequestInfo) GetClients() []string {
+	if m != nil {
+		return m.Clients
+	}
+	return nil
+}
+
+func (m *RequestInfo) GetSubmitted() int64 {
+	if m != nil {
+		return m.Submitted
+	}
+	return 0
+}
+
+func (m *RequestInfo) GetLaunched() int64 {
+	if m != nil {
+		return m.Launched
+	}
+	return 0
+}
+
+func (m *RequestInfo) GetFinished() int64 {
+	if m != nil {
+		return m.Finished
+	}
+	return 0
+}
+
+func (m *RequestInfo) GetPreemptions() int32 {
+	if m != nil {
+		return m.Preemptions
+	}
+	return 0
+}
+
+func (m *RequestInfo) GetRuns() int32 {
+	if m != nil {
+		return m.Runs
+	}
+	return 0
+}
+
+func (m *RequestInfo) GetStages() []*StageInfo {
+	if m != nil {
+		return m.Stages
+	}
+	return nil
+}
+
+type StageInfo struct {
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Application string `protobuf:"bytes,2,opt,name=application,proto3" json:"application,omitempty"`
+	Node        string `protobuf:"bytes,3,opt,name=node,proto3" json:"node,omitempty"`
+	State       string `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	Reason      string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// times in unix nanoseconds, 0 if not reached
+	Launched             int64    `protobuf:"varint,6,opt,name=launched,proto3" json:"launched,omitempty"`
+	Finished             int64    `protobuf:"varint,7,opt,name=finished,proto3" json:"finished,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StageInfo) Reset()         { *m = StageInfo{} }
+func (m *StageInfo) String() string { return proto.CompactTextString(m) }
+func (*StageInfo) ProtoMessage()    {}
+func (*StageInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{9}
+}
+
+func (m *StageInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StageInfo.Unmarshal(m, b)
+}
+func (m *StageInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StageInfo.Marshal(b, m, deterministic)
+}
+func (m *StageInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StageInfo.Merge(m, src)
+}
+func (m *StageInfo) XXX_Size() int {
+	return xxx_messageInfo_StageInfo.Size(m)
+}
+func (m *StageInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_StageInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StageInfo proto.InternalMessageInfo
+
+func (m *StageInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *StageInfo) GetApplication() string {
+	if m != nil {
+		return m.Application
+	}
+	return ""
+}
+
+func (m *StageInfo) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *StageInfo) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *StageInfo) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *StageInfo) GetLaunched() int64 {
+	if m != nil {
+		return m.Launched
+	}
+	return 0
+}
+
+func (m *StageInfo) GetFinished() int64 {
+	if m != nil {
+		return m.Finished
+	}
+	return 0
+}
+
+type OffloadRequest struct {
+	Resource string `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	// edge node holding the IoT resource
+	Node        string `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	Hash        string `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Contributor string `protobuf:"bytes,4,opt,name=contributor,proto3" json:"contributor,omitempty"`
+	Type        string `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	Size        int64  `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	// number of edge nodes that should hold the IoT resource, 0 for the replication factor of its type
+	Replicas             int32    `protobuf:"varint,7,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OffloadRequest) Reset()         { *m = OffloadRequest{} }
+func (m *OffloadRequest) String() string { return proto.CompactTextString(m) }
+func (*OffloadRequest) ProtoMessage()    {}
+func (*OffloadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{10}
+}
+
+func (m *OffloadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OffloadRequest.Unmarshal(m, b)
+}
+func (m *OffloadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OffloadRequest.Marshal(b, m, deterministic)
+}
+func (m *OffloadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OffloadRequest.Merge(m, src)
+}
+func (m *OffloadRequest) XXX_Size() int {
+	return xxx_messageInfo_OffloadRequest.Size(m)
+}
+func (m *OffloadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_OffloadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OffloadRequest proto.InternalMessageInfo
+
+func (m *OffloadRequest) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *OffloadRequest) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *OffloadRequest) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *OffloadRequest) GetContributor() string {
+	if m != nil {
+		return m.Contributor
+	}
+	return ""
+}
+
+func (m *OffloadRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *OffloadRequest) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *OffloadRequest) GetReplicas() int32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+type SubmitRequest struct {
+	Request  string `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Priority string `protobuf:"bytes,2,opt,name=priority,proto3" json:"priority,omitempty"`
+	// client the request is submitted for, empty for an anonymous client
+	Client string `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	// deadline and lifetime relative to the submission, e.g. 30s, empty for none
+	Within               string   `protobuf:"bytes,4,opt,name=within,proto3" json:"within,omitempty"`
+	Lifetime             string   `protobuf:"bytes,5,opt,name=lifetime,proto3" json:"lifetime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubmitRequest) Reset()         { *m = SubmitRequest{} }
+func (m *SubmitRequest) String() string { return proto.CompactTextString(m) }
+func (*SubmitRequest) ProtoMessage()    {}
+func (*SubmitRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{11}
+}
+
+func (m *SubmitRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubmitRequest.Unmarshal(m, b)
+}
+func (m *SubmitRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubmitRequest.Marshal(b, m, deterministic)
+}
+func (m *SubmitRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubmitRequest.Merge(m, src)
+}
+func (m *SubmitRequest) XXX_Size() int {
+	return xxx_messageInfo_SubmitRequest.Size(m)
+}
+func (m *SubmitRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubmitRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubmitRequest proto.InternalMessageInfo
+
+func (m *SubmitRequest) GetRequest() string {
+	if m != nil {
+		return m.Request
+	}
+	return ""
+}
+
+func (m *SubmitRequest) GetPriority() string {
+	if m != nil {
+		return m.Priority
+	}
+	return ""
+}
+
+func (m *SubmitRequest) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *SubmitRequest) GetWithin() string {
+	if m != nil {
+		return m.Within
+	}
+	return ""
+}
+
+func (m *SubmitRequest) GetLifetime() string {
+	if m != nil {
+		return m.Lifetime
+	}
+	return ""
+}
+
+type CancelRequest struct {
+	Request string `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	// client withdrawn from the request, empty to cancel the request for all its clients
+	Client               string   `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CancelRequest) Reset()         { *m = CancelRequest{} }
+func (m *CancelRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelRequest) ProtoMessage()    {}
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{12}
+}
+
+func (m *CancelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelRequest.Unmarshal(m, b)
+}
+func (m *CancelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelRequest.Marshal(b, m, deterministic)
+}
+func (m *CancelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelRequest.Merge(m, src)
+}
+func (m *CancelRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelRequest.Size(m)
+}
+func (m *CancelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelRequest proto.InternalMessageInfo
+
+func (m *CancelRequest) GetRequest() string {
+	if m != nil {
+		return m.Request
+	}
+	return ""
+}
+
+func (m *CancelRequest) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+type AdminReply struct {
+	// e.g. the state of a cancelled request
+	Detail               string   `protobuf:"bytes,1,opt,name=detail,proto3" json:"detail,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AdminReply) Reset()         { *m = AdminReply{} }
+func (m *AdminReply) String() string { return proto.CompactTextString(m) }
+func (*AdminReply) ProtoMessage()    {}
+func (*AdminReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{13}
+}
+
+func (m *AdminReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdminReply.Unmarshal(m, b)
+}
+func (m *AdminReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdminReply.Marshal(b, m, deterministic)
+}
+func (m *AdminReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdminReply.Merge(m, src)
+}
+func (m *AdminReply) XXX_Size() int {
+	return xxx_messageInfo_AdminReply.Size(m)
+}
+func (m *AdminReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdminReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdminReply proto.InternalMessageInfo
+
+func (m *AdminReply) GetDetail() string {
+	if m != nil {
+		return m.Detail
+	}
+	return ""
+}
+
+type SyncRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncRequest) Reset()         { *m = SyncRequest{} }
+func (m *SyncRequest) String() string { return proto.CompactTextString(m) }
+func (*SyncRequest) ProtoMessage()    {}
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{14}
+}
+
+func (m *SyncRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncRequest.Unmarshal(m, b)
+}
+func (m *SyncRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncRequest.Marshal(b, m, deterministic)
+}
+func (m *SyncRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncRequest.Merge(m, src)
+}
+func (m *SyncRequest) XXX_Size() int {
+	return xxx_messageInfo_SyncRequest.Size(m)
+}
+func (m *SyncRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncRequest proto.InternalMessageInfo
+
+type SyncReply struct {
+	// IoT resources added to the catalog and edge nodes that could not be reached
+	Added                int32    `protobuf:"varint,1,opt,name=added,proto3" json:"added,omitempty"`
+	Unreachable          []string `protobuf:"bytes,2,rep,name=unreachable,proto3" json:"unreachable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncReply) Reset()         { *m = SyncReply{} }
+func (m *SyncReply) String() string { return proto.CompactTextString(m) }
+func (*SyncReply) ProtoMessage()    {}
+func (*SyncReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{15}
+}
+
+func (m *SyncReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncReply.Unmarshal(m, b)
+}
+func (m *SyncReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncReply.Marshal(b, m, deterministic)
+}
+func (m *SyncReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncReply.Merge(m, src)
+}
+func (m *SyncReply) XXX_Size() int {
+	return xxx_messageInfo_SyncReply.Size(m)
+}
+func (m *SyncReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncReply proto.InternalMessageInfo
+
+func (m *SyncReply) GetAdded() int32 {
+	if m != nil {
+		return m.Added
+	}
+	return 0
+}
+
+func (m *SyncReply) GetUnreachable() []string {
+	if m != nil {
+		return m.Unreachable
+	}
+	return nil
+}
+
+type DrainRequest struct {
+	Node string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// copy the IoT resources held only by the edge node to the other edge nodes first
+	Replicate bool `protobuf:"varint,2,opt,name=replicate,proto3" json:"replicate,omitempty"`
+	// move the workloads of the edge node to other edge nodes instead of letting them finish
+	Migrate bool `protobuf:"varint,3,opt,name=migrate,proto3" json:"migrate,omitempty"`
+	// time given to the workloads to finish or move before the edge node departs, e.g. 5m, empty for the default
+	Timeout              string   `protobuf:"bytes,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DrainRequest) Reset()         { *m = DrainRequest{} }
+func (m *DrainRequest) String() string { return proto.CompactTextString(m) }
+func (*DrainRequest) ProtoMessage()    {}
+func (*DrainRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{16}
+}
+
+func (m *DrainRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DrainRequest.Unmarshal(m, b)
+}
+func (m *DrainRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DrainRequest.Marshal(b, m, deterministic)
+}
+func (m *DrainRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DrainRequest.Merge(m, src)
+}
+func (m *DrainRequest) XXX_Size() int {
+	return xxx_messageInfo_DrainRequest.Size(m)
+}
+func (m *DrainRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DrainRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DrainRequest proto.InternalMessageInfo
+
+func (m *DrainRequest) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *DrainRequest) GetReplicate() bool {
+	if m != nil {
+		return m.Replicate
+	}
+	return false
+}
+
+func (m *DrainRequest) GetMigrate() bool {
+	if m != nil {
+		return m.Migrate
+	}
+	return false
+}
+
+func (m *DrainRequest) GetTimeout() string {
+	if m != nil {
+		return m.Timeout
+	}
+	return ""
+}
+
+type UndrainRequest struct {
+	Node                 string   `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UndrainRequest) Reset()         { *m = UndrainRequest{} }
+func (m *UndrainRequest) String() string { return proto.CompactTextString(m) }
+func (*UndrainRequest) ProtoMessage()    {}
+func (*UndrainRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{17}
+}
+
+func (m *UndrainRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UndrainRequest.Unmarshal(m, b)
+}
+func (m *UndrainRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UndrainRequest.Marshal(b, m, deterministic)
+}
+func (m *UndrainRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UndrainRequest.Merge(m, src)
+}
+func (m *UndrainRequest) XXX_Size() int {
+	return xxx_messageInfo_UndrainRequest.Size(m)
+}
+func (m *UndrainRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UndrainRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UndrainRequest proto.InternalMessageInfo
+
+func (m *UndrainRequest) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*MembersRequest)(nil), "MembersRequest")
+	proto.RegisterType((*MemberList)(nil), "MemberList")
+	proto.RegisterType((*Member)(nil), "Member")
+	proto.RegisterType((*CatalogFilter)(nil), "CatalogFilter")
+	proto.RegisterType((*CatalogEntries)(nil), "CatalogEntries")
+	proto.RegisterType((*CatalogEntry)(nil), "CatalogEntry")
+	proto.RegisterType((*RequestFilter)(nil), "RequestFilter")
+	proto.RegisterType((*RequestList)(nil), "RequestList")
+	proto.RegisterType((*RequestInfo)(nil), "RequestInfo")
+	proto.RegisterType((*StageInfo)(nil), "StageInfo")
+	proto.RegisterType((*OffloadRequest)(nil), "OffloadRequest")
+	proto.RegisterType((*SubmitRequest)(nil), "SubmitRequest")
+	proto.RegisterType((*CancelRequest)(nil), "CancelRequest")
+	proto.RegisterType((*AdminReply)(nil), "AdminReply")
+	proto.RegisterType((*SyncRequest)(nil), "SyncRequest")
+	proto.RegisterType((*SyncReply)(nil), "SyncReply")
+	proto.RegisterType((*DrainRequest)(nil), "DrainRequest")
+	proto.RegisterType((*UndrainRequest)(nil), "UndrainRequest")
+}
+
+func init() { proto.RegisterFile("admin.proto", fileDescriptor_73a7fc70dcc2027c) }
+
+var fileDescriptor_73a7fc70dcc2027c = []byte{
+	// 1042 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xcd, 0x6e, 0x24, 0x35,
+	0x10, 0x4e, 0x4f, 0x4f, 0x4f, 0xcf, 0xd4, 0xfc, 0x44, 0xb2, 0x56, 0xa8, 0x35, 0xe2, 0x10, 0xac,
+	0x45, 0x3b, 0x41, 0xa8, 0x0f, 0x0b, 0x12, 0xe2, 0xc0, 0x61, 0xd9, 0x80, 0x14, 0x09, 0x84, 0xd4,
+	0x11, 0x17, 0x6e, 0x4e, 0xb7, 0x27, 0x63, 0xa9, 0xc7, 0x3d, 0xb1, 0xdd, 0x41, 0xd9, 0x57, 0x80,
+	0x0b, 0x07, 0x5e, 0x06, 0x5e, 0x86, 0x37, 0x01, 0xb9, 0xec, 0xfe, 0x9b, 0x64, 0x58, 0xed, 0xcd,
+	0x5f, 0x95, 0x7f, 0xaa, 0xbe, 0xaf, 0xca, 0x36, 0xcc, 0x59, 0xb1, 0x17, 0x32, 0x3d, 0xa8, 0xca,
+	0x54, 0xf4, 0x4b, 0x58, 0xfd, 0xc8, 0xf7, 0xb7, 0x5c, 0xe9, 0x8c, 0xdf, 0xd7, 0x5c, 0x1b, 0x42,
+	0x61, 0x51, 0x0a, 0xc9, 0x99, 0x12, 0xef, 0xd8, 0x6d, 0xc9, 0x93, 0xe0, 0x22, 0xd8, 0x4c, 0xb3,
+	0x81, 0x8d, 0xee, 0x01, 0xdc, 0xaa, 0x1f, 0x84, 0x36, 0x64, 0x05, 0xa3, 0xeb, 0x2b, 0x9c, 0x37,
+	0xcb, 0x46, 0xd7, 0x57, 0xe4, 0x05, 0x44, 0x07, 0xce, 0x95, 0x4e, 0x46, 0x17, 0xe1, 0x66, 0x96,
+	0x39, 0x40, 0x3e, 0x81, 0x78, 0xef, 0x4e, 0x4a, 0xc2, 0x8b, 0x70, 0x33, 0x7f, 0x1d, 0xa7, 0x6e,
+	0x8f, 0xac, 0xb1, 0x93, 0x8f, 0x60, 0x52, 0x72, 0x56, 0x70, 0x95, 0x8c, 0x71, 0x33, 0x8f, 0xe8,
+	0x3f, 0x01, 0x4c, 0xdc, 0x5c, 0x42, 0x60, 0x2c, 0xab, 0x82, 0xfb, 0xd3, 0x70, 0x4c, 0x3e, 0x86,
+	0x99, 0xe2, 0xba, 0xaa, 0x55, 0xce, 0xed, 0x99, 0xc1, 0x26, 0xca, 0x3a, 0x83, 0x5d, 0x51, 0x6b,
+	0x5e, 0x24, 0x21, 0x3a, 0x70, 0x4c, 0x12, 0x88, 0x55, 0x2d, 0xa5, 0x90, 0x77, 0x78, 0x52, 0x94,
+	0x35, 0xd0, 0x86, 0x70, 0x5f, 0xf3, 0x9a, 0x17, 0x49, 0x84, 0x0e, 0x8f, 0xc8, 0x1a, 0xa6, 0x39,
+	0x3b, 0xb0, 0x5c, 0x98, 0xc7, 0x64, 0x82, 0x9e, 0x16, 0xdb, 0x7c, 0xb5, 0x61, 0x86, 0x27, 0x31,
+	0x06, 0xe5, 0x80, 0xdd, 0x49, 0x9b, 0x4a, 0xf1, 0x22, 0x99, 0x5e, 0x04, 0x9b, 0x30, 0xf3, 0xc8,
+	0xce, 0xbe, 0xaf, 0x2b, 0xc3, 0x92, 0x19, 0x9a, 0x1d, 0xa0, 0x7f, 0x06, 0xb0, 0x7c, 0xcb, 0x0c,
+	0x2b, 0xab, 0xbb, 0xef, 0x45, 0x69, 0x4e, 0x64, 0xba, 0x86, 0x69, 0x93, 0x18, 0x26, 0x3a, 0xcb,
+	0x5a, 0x6c, 0xe7, 0x9b, 0xc7, 0x03, 0xc7, 0x3c, 0x67, 0x19, 0x8e, 0x2d, 0x33, 0xec, 0x81, 0x89,
+	0x12, 0x85, 0x1c, 0xa3, 0x90, 0x9d, 0xe1, 0x89, 0xd2, 0xd1, 0x33, 0x4a, 0x7f, 0x0d, 0x2b, 0x1f,
+	0xd6, 0x77, 0xd2, 0x28, 0xc1, 0x35, 0x79, 0x05, 0x31, 0x77, 0xc3, 0x24, 0x40, 0x1d, 0x97, 0x69,
+	0x6f, 0xc6, 0x63, 0xd6, 0x78, 0xe9, 0xef, 0x23, 0x58, 0xf4, 0x3d, 0x83, 0xe8, 0x83, 0xa7, 0xd1,
+	0x63, 0xb6, 0xa3, 0xa1, 0xae, 0x5d, 0xf4, 0xe1, 0x71, 0xf4, 0x04, 0xc6, 0x3b, 0xa6, 0x77, 0xbe,
+	0x54, 0x70, 0x4c, 0x2e, 0x60, 0x9e, 0x57, 0xf6, 0xf8, 0xdb, 0xda, 0x54, 0x0a, 0x13, 0x9a, 0x65,
+	0x7d, 0x93, 0x8d, 0xe1, 0x81, 0x2b, 0xb1, 0x15, 0xbc, 0x40, 0x1d, 0xa7, 0x59, 0x8b, 0x5b, 0x06,
+	0xe3, 0x21, 0x83, 0xd5, 0x76, 0x5b, 0x56, 0xac, 0x68, 0x85, 0xec, 0x0c, 0x76, 0x85, 0x16, 0xef,
+	0xb8, 0x97, 0x12, 0xc7, 0x56, 0xf7, 0x4a, 0x89, 0x3b, 0x21, 0x13, 0x70, 0x45, 0xec, 0x10, 0xfd,
+	0x06, 0x96, 0xbe, 0xc5, 0xbc, 0xc0, 0x6d, 0xd9, 0x04, 0x47, 0x65, 0x93, 0x97, 0x82, 0x4b, 0xe3,
+	0xa9, 0xf0, 0x88, 0x7e, 0x05, 0x73, 0xbf, 0x1c, 0x7b, 0x6e, 0x63, 0xb9, 0x44, 0xd8, 0xc8, 0xb0,
+	0x48, 0xbd, 0xff, 0x5a, 0x6e, 0xab, 0xac, 0xf5, 0xd2, 0xdf, 0x42, 0x98, 0xf7, 0x3c, 0x58, 0xfb,
+	0x0e, 0xfa, 0x83, 0x1b, 0xd8, 0x05, 0x34, 0x3a, 0x0a, 0x48, 0x71, 0xa6, 0x2b, 0xe9, 0x2b, 0xcb,
+	0x23, 0xcb, 0x35, 0x3b, 0x1c, 0x4a, 0x91, 0x33, 0x23, 0x2a, 0xe9, 0x65, 0xe8, 0x9b, 0x5a, 0x4d,
+	0xa3, 0x9e, 0xa6, 0x09, 0xc4, 0x9a, 0xab, 0x07, 0x91, 0x73, 0xa4, 0x7f, 0x96, 0x35, 0xd0, 0x2a,
+	0x73, 0x50, 0xa2, 0x52, 0xb6, 0xc3, 0x9c, 0x02, 0x2d, 0xb6, 0xab, 0x1c, 0x0d, 0x3a, 0x99, 0xe2,
+	0x9d, 0xd2, 0x40, 0xab, 0x8f, 0xae, 0x6f, 0xf7, 0xc2, 0x18, 0x5e, 0x78, 0x19, 0x3a, 0x83, 0xdd,
+	0xb3, 0x64, 0xb5, 0xcc, 0x77, 0xbc, 0x40, 0x35, 0xc2, 0xac, 0xc5, 0xd6, 0xb7, 0x15, 0x52, 0x68,
+	0xeb, 0x9b, 0x3b, 0x5f, 0x83, 0x6d, 0x6e, 0x07, 0xc5, 0xf9, 0xfe, 0x60, 0xf3, 0xd0, 0xc9, 0x02,
+	0x1b, 0xbe, 0x6f, 0xb2, 0xb9, 0xa9, 0x5a, 0xea, 0x64, 0x89, 0x2e, 0x1c, 0x13, 0x6a, 0x3b, 0x9e,
+	0xdd, 0x71, 0x9d, 0xac, 0x50, 0x11, 0x48, 0x6f, 0x2c, 0x44, 0x3d, 0xbc, 0x87, 0xfe, 0x1d, 0xc0,
+	0xac, 0xb5, 0x22, 0x43, 0x6c, 0xdf, 0xf5, 0x38, 0xdb, 0xf3, 0x63, 0x5e, 0x47, 0xa7, 0x79, 0x0d,
+	0x7b, 0xbc, 0xb6, 0xda, 0x8d, 0x9f, 0xd7, 0x2e, 0x1a, 0x68, 0xd7, 0xe7, 0x65, 0xf2, 0x3f, 0xbc,
+	0xc4, 0x43, 0x5e, 0xe8, 0x5f, 0x01, 0xac, 0x7e, 0x72, 0xd5, 0xdf, 0x3c, 0x17, 0x1f, 0xda, 0xd4,
+	0x4d, 0xdb, 0x86, 0xa7, 0xdb, 0x76, 0xfc, 0xb4, 0x6d, 0x9b, 0xd6, 0x8c, 0x7a, 0xad, 0xd9, 0x34,
+	0xdf, 0xa4, 0xd7, 0x7c, 0x18, 0x0d, 0x32, 0xa5, 0x31, 0xf8, 0x28, 0x6b, 0x31, 0xfd, 0x23, 0x80,
+	0xe5, 0x0d, 0x96, 0x46, 0x13, 0xfb, 0xe9, 0x56, 0xe8, 0x17, 0xe3, 0xe8, 0xa8, 0x18, 0xbb, 0x0e,
+	0x0d, 0xfb, 0x1d, 0x6a, 0xed, 0xbf, 0x0a, 0xb3, 0x13, 0x4d, 0x2f, 0x78, 0x84, 0x64, 0x8b, 0x2d,
+	0x37, 0x62, 0xdf, 0xc4, 0xdf, 0x62, 0xfa, 0xc6, 0xde, 0xfa, 0x32, 0xe7, 0xe5, 0xfb, 0x43, 0x3a,
+	0x75, 0x31, 0xbc, 0x04, 0x78, 0x63, 0x1f, 0xf4, 0x8c, 0x1f, 0x4a, 0x0c, 0xae, 0xe0, 0x86, 0x89,
+	0xd2, 0x2f, 0xf7, 0x88, 0x2e, 0x61, 0x7e, 0xf3, 0x28, 0x73, 0x7f, 0x0c, 0x7d, 0x0b, 0x33, 0x07,
+	0xed, 0x9a, 0x17, 0x10, 0xb1, 0xc2, 0xde, 0x6f, 0x01, 0x32, 0xe6, 0x80, 0x15, 0xa5, 0x96, 0x8a,
+	0xb3, 0x7c, 0x87, 0xf7, 0xaf, 0x7b, 0xcb, 0xfb, 0x26, 0x6a, 0x60, 0x71, 0xa5, 0x98, 0x90, 0x7e,
+	0xd3, 0xd3, 0x6f, 0xb3, 0x2b, 0x5d, 0x57, 0x07, 0xd3, 0xac, 0x33, 0xd8, 0x6c, 0xf7, 0xe2, 0x4e,
+	0x59, 0x9f, 0xbb, 0xdf, 0x1b, 0x68, 0x3d, 0x96, 0xa0, 0xaa, 0x36, 0x9e, 0xcd, 0x06, 0xd2, 0x97,
+	0xb0, 0xfa, 0x59, 0x16, 0xef, 0x39, 0xf7, 0xf5, 0xbf, 0x23, 0x88, 0x90, 0x16, 0x72, 0x09, 0xb1,
+	0xff, 0xe1, 0x90, 0xf3, 0x74, 0xf8, 0xd7, 0x59, 0xcf, 0xd3, 0xee, 0x1b, 0x43, 0xcf, 0xc8, 0xe7,
+	0x10, 0xfb, 0x07, 0x8b, 0xac, 0xd2, 0xc1, 0x6b, 0xbc, 0x3e, 0x4f, 0x87, 0xcf, 0x20, 0x3d, 0x23,
+	0x9f, 0xc1, 0xd4, 0xef, 0xa3, 0xc9, 0x2a, 0x1d, 0xdc, 0xed, 0xeb, 0xf6, 0x32, 0xf6, 0x3b, 0x5f,
+	0x42, 0xec, 0xfb, 0x86, 0x9c, 0xa7, 0xc3, 0x0e, 0x5a, 0xcf, 0xd3, 0x4e, 0x3f, 0x7a, 0x46, 0x5e,
+	0xc1, 0xc4, 0x55, 0x29, 0x59, 0xa5, 0x83, 0x72, 0x7d, 0x66, 0xa2, 0xab, 0x1d, 0x0c, 0xb6, 0x57,
+	0x44, 0xc7, 0x13, 0x29, 0x8c, 0xad, 0xd8, 0x64, 0x91, 0xf6, 0x4a, 0x60, 0x0d, 0x69, 0x5b, 0x01,
+	0xf4, 0x8c, 0x7c, 0x0a, 0x11, 0x6a, 0x49, 0x96, 0x69, 0x5f, 0xd3, 0xe3, 0xad, 0x2e, 0x21, 0xf6,
+	0xe4, 0x93, 0xf3, 0x74, 0x28, 0xc3, 0xd1, 0xd4, 0x6f, 0xe1, 0x97, 0xe9, 0x56, 0x55, 0xd2, 0x70,
+	0x59, 0xdc, 0x4e, 0xf0, 0xb3, 0xf9, 0xc5, 0x7f, 0x03, 0x00, 0xb0, 0x57, 0x1f, 0x9d, 0x7b, 0x0a,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	Members(ctx context.Context, in *MembersRequest, opts ...grpc.CallOption) (*MemberList, error)
+	Catalog(ctx context.Context, in *CatalogFilter, opts ...grpc.CallOption) (*CatalogEntries, error)
+	Requests(ctx context.Context, in *RequestFilter, opts ...grpc.CallOption) (*RequestList, error)
+	Offload(ctx context.Context, in *OffloadRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncReply, error)
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	Undrain(ctx context.Context, in *UndrainRequest, opts ...grpc.CallOption) (*AdminReply, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) Members(ctx context.Context, in *MembersRequest, opts ...grpc.CallOption) (*MemberList, error) {
+	out := new(MemberList)
+	err := c.cc.Invoke(ctx, "/Admin/Members", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Catalog(ctx context.Context, in *CatalogFilter, opts ...grpc.CallOption) (*CatalogEntries, error) {
+	out := new(CatalogEntries)
+	err := c.cc.Invoke(ctx, "/Admin/Catalog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Requests(ctx context.Context, in *RequestFilter, opts ...grpc.CallOption) (*RequestList, error) {
+	out := new(RequestList)
+	err := c.cc.Invoke(ctx, "/Admin/Requests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Offload(ctx context.Context, in *OffloadRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, "/Admin/Offload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, "/Admin/Submit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, "/Admin/Cancel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncReply, error) {
+	out := new(SyncReply)
+	err := c.cc.Invoke(ctx, "/Admin/Sync", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, "/Admin/Drain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Undrain(ctx context.Context, in *UndrainRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, "/Admin/Undrain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	Members(context.Context, *MembersRequest) (*MemberList, error)
+	Catalog(context.Context, *CatalogFilter) (*CatalogEntries, error)
+	Requests(context.Context, *RequestFilter) (*RequestList, error)
+	Offload(context.Context, *OffloadRequest) (*AdminReply, error)
+	Submit(context.Context, *SubmitRequest) (*AdminReply, error)
+	Cancel(context.Context, *CancelRequest) (*AdminReply, error)
+	Sync(context.Context, *SyncRequest) (*SyncReply, error)
+	Drain(context.Context, *DrainRequest) (*AdminReply, error)
+	Undrain(context.Context, *UndrainRequest) (*AdminReply, error)
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_Members_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Members(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Members",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Members(ctx, req.(*MembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Catalog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CatalogFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Catalog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Catalog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Catalog(ctx, req.(*CatalogFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Requests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Requests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Requests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Requests(ctx, req.(*RequestFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Offload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OffloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Offload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Offload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Offload(ctx, req.(*OffloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Submit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Submit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Submit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Submit(ctx, req.(*SubmitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Cancel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Sync",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Sync(ctx, req.(*SyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Drain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Undrain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Undrain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Admin/Undrain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Undrain(ctx, req.(*UndrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Members",
+			Handler:    _Admin_Members_Handler,
+		},
+		{
+			MethodName: "Catalog",
+			Handler:    _Admin_Catalog_Handler,
+		},
+		{
+			MethodName: "Requests",
+			Handler:    _Admin_Requests_Handler,
+		},
+		{
+			MethodName: "Offload",
+			Handler:    _Admin_Offload_Handler,
+		},
+		{
+			MethodName: "Submit",
+			Handler:    _Admin_Submit_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _Admin_Cancel_Handler,
+		},
+		{
+			MethodName: "Sync",
+			Handler:    _Admin_Sync_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _Admin_Drain_Handler,
+		},
+		{
+			MethodName: "Undrain",
+			Handler:    _Admin_Undrain_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
@@ -23,8 +23,27 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type TableUpdate struct {
-	Resource             string   `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
-	ID                   string   `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	Resource string `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	ID       string `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	// content hash of the IoT resource, e.g. sha256:<hex>
+	Hash string `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	// vehicle or device that contributed the data of the IoT resource
+	Contributor string `protobuf:"bytes,4,opt,name=contributor,proto3" json:"contributor,omitempty"`
+	// signature of the originating edge node over the other fields and its certificate (DER)
+	Signature   []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	Certificate []byte `protobuf:"bytes,6,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	// type of the IoT resource, e.g. hd_map, and the area it covers in degrees, all zero if unknown
+	Type   string  `protobuf:"bytes,7,opt,name=type,proto3" json:"type,omitempty"`
+	Minlat float64 `protobuf:"fixed64,8,opt,name=minlat,proto3" json:"minlat,omitempty"`
+	Minlon float64 `protobuf:"fixed64,9,opt,name=minlon,proto3" json:"minlon,omitempty"`
+	Maxlat float64 `protobuf:"fixed64,10,opt,name=maxlat,proto3" json:"maxlat,omitempty"`
+	Maxlon float64 `protobuf:"fixed64,11,opt,name=maxlon,proto3" json:"maxlon,omitempty"`
+	// time the IoT resource was offloaded, in unix nanoseconds
+	Offloaded int64 `protobuf:"varint,12,opt,name=offloaded,proto3" json:"offloaded,omitempty"`
+	// size of the data of the IoT resource in bytes, 0 if unknown
+	Size int64 `protobuf:"varint,13,opt,name=size,proto3" json:"size,omitempty"`
+	// edge node the IoT resource was copied from, empty if it was offloaded on the edge node holding it
+	Origin               string   `protobuf:"bytes,14,opt,name=origin,proto3" json:"origin,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -37,95 +56,1066 @@ func (*TableUpdate) Descriptor() ([]byte, []int) {
 	return fileDescriptor_eca3873955a29cfe, []int{0}
 }
 
-func (m *TableUpdate) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_TableUpdate.Unmarshal(m, b)
+func (m *TableUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TableUpdate.Unmarshal(m, b)
+}
+func (m *TableUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TableUpdate.Marshal(b, m, deterministic)
+}
+func (m *TableUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableUpdate.Merge(m, src)
+}
+func (m *TableUpdate) XXX_Size() int {
+	return xxx_messageInfo_TableUpdate.Size(m)
+}
+func (m *TableUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TableUpdate proto.InternalMessageInfo
+
+func (m *TableUpdate) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *TableUpdate) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *TableUpdate) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *TableUpdate) GetContributor() string {
+	if m != nil {
+		return m.Contributor
+	}
+	return ""
+}
+
+func (m *TableUpdate) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *TableUpdate) GetCertificate() []byte {
+	if m != nil {
+		return m.Certificate
+	}
+	return nil
+}
+
+func (m *TableUpdate) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *TableUpdate) GetMinlat() float64 {
+	if m != nil {
+		return m.Minlat
+	}
+	return 0
+}
+
+func (m *TableUpdate) GetMinlon() float64 {
+	if m != nil {
+		return m.Minlon
+	}
+	return 0
+}
+
+func (m *TableUpdate) GetMaxlat() float64 {
+	if m != nil {
+		return m.Maxlat
+	}
+	return 0
+}
+
+func (m *TableUpdate) GetMaxlon() float64 {
+	if m != nil {
+		return m.Maxlon
+	}
+	return 0
+}
+
+func (m *TableUpdate) GetOffloaded() int64 {
+	if m != nil {
+		return m.Offloaded
+	}
+	return 0
+}
+
+func (m *TableUpdate) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *TableUpdate) GetOrigin() string {
+	if m != nil {
+		return m.Origin
+	}
+	return ""
+}
+
+type TableUpdateACK struct {
+	Ack                  string   `protobuf:"bytes,3,opt,name=ack,proto3" json:"ack,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TableUpdateACK) Reset()         { *m = TableUpdateACK{} }
+func (m *TableUpdateACK) String() string { return proto.CompactTextString(m) }
+func (*TableUpdateACK) ProtoMessage()    {}
+func (*TableUpdateACK) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{1}
+}
+
+func (m *TableUpdateACK) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TableUpdateACK.Unmarshal(m, b)
+}
+func (m *TableUpdateACK) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TableUpdateACK.Marshal(b, m, deterministic)
+}
+func (m *TableUpdateACK) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableUpdateACK.Merge(m, src)
+}
+func (m *TableUpdateACK) XXX_Size() int {
+	return xxx_messageInfo_TableUpdateACK.Size(m)
+}
+func (m *TableUpdateACK) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableUpdateACK.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TableUpdateACK proto.InternalMessageInfo
+
+func (m *TableUpdateACK) GetAck() string {
+	if m != nil {
+		return m.Ack
+	}
+	return ""
+}
+
+type Predictions struct {
+	// edge node the statistics were observed by
+	ID                   string        `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Predictions          []*Prediction `protobuf:"bytes,2,rep,name=predictions,proto3" json:"predictions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Predictions) Reset()         { *m = Predictions{} }
+func (m *Predictions) String() string { return proto.CompactTextString(m) }
+func (*Predictions) ProtoMessage()    {}
+func (*Predictions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{2}
+}
+
+func (m *Predictions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Predictions.Unmarshal(m, b)
+}
+func (m *Predictions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Predictions.Marshal(b, m, deterministic)
+}
+func (m *Predictions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Predictions.Merge(m, src)
+}
+func (m *Predictions) XXX_Size() int {
+	return xxx_messageInfo_Predictions.Size(m)
+}
+func (m *Predictions) XXX_DiscardUnknown() {
+	xxx_messageInfo_Predictions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Predictions proto.InternalMessageInfo
+
+func (m *Predictions) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Predictions) GetPredictions() []*Prediction {
+	if m != nil {
+		return m.Predictions
+	}
+	return nil
+}
+
+type Prediction struct {
+	Application string `protobuf:"bytes,1,opt,name=application,proto3" json:"application,omitempty"`
+	// edge node of the swarm the workloads ran on, empty for all edge nodes
+	Node string `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	// class of the size of the input of the workloads, -1 for all sizes
+	Sizeclass int32 `protobuf:"varint,3,opt,name=sizeclass,proto3" json:"sizeclass,omitempty"`
+	Samples   int64 `protobuf:"varint,4,opt,name=samples,proto3" json:"samples,omitempty"`
+	// moving average and percentiles of the runtime, in seconds
+	Ewma                 float64  `protobuf:"fixed64,5,opt,name=ewma,proto3" json:"ewma,omitempty"`
+	P50                  float64  `protobuf:"fixed64,6,opt,name=p50,proto3" json:"p50,omitempty"`
+	P90                  float64  `protobuf:"fixed64,7,opt,name=p90,proto3" json:"p90,omitempty"`
+	P99                  float64  `protobuf:"fixed64,8,opt,name=p99,proto3" json:"p99,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Prediction) Reset()         { *m = Prediction{} }
+func (m *Prediction) String() string { return proto.CompactTextString(m) }
+func (*Prediction) ProtoMessage()    {}
+func (*Prediction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{3}
+}
+
+func (m *Prediction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Prediction.Unmarshal(m, b)
+}
+func (m *Prediction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Prediction.Marshal(b, m, deterministic)
+}
+func (m *Prediction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Prediction.Merge(m, src)
+}
+func (m *Prediction) XXX_Size() int {
+	return xxx_messageInfo_Prediction.Size(m)
+}
+func (m *Prediction) XXX_DiscardUnknown() {
+	xxx_messageInfo_Prediction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Prediction proto.InternalMessageInfo
+
+func (m *Prediction) GetApplication() string {
+	if m != nil {
+		return m.Application
+	}
+	return ""
+}
+
+func (m *Prediction) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *Prediction) GetSizeclass() int32 {
+	if m != nil {
+		return m.Sizeclass
+	}
+	return 0
+}
+
+func (m *Prediction) GetSamples() int64 {
+	if m != nil {
+		return m.Samples
+	}
+	return 0
+}
+
+func (m *Prediction) GetEwma() float64 {
+	if m != nil {
+		return m.Ewma
+	}
+	return 0
+}
+
+func (m *Prediction) GetP50() float64 {
+	if m != nil {
+		return m.P50
+	}
+	return 0
+}
+
+func (m *Prediction) GetP90() float64 {
+	if m != nil {
+		return m.P90
+	}
+	return 0
+}
+
+func (m *Prediction) GetP99() float64 {
+	if m != nil {
+		return m.P99
+	}
+	return 0
+}
+
+type HandoverRequest struct {
+	Client string `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	// edge node the client attached to
+	ID                   string   `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandoverRequest) Reset()         { *m = HandoverRequest{} }
+func (m *HandoverRequest) String() string { return proto.CompactTextString(m) }
+func (*HandoverRequest) ProtoMessage()    {}
+func (*HandoverRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{4}
+}
+
+func (m *HandoverRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandoverRequest.Unmarshal(m, b)
+}
+func (m *HandoverRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandoverRequest.Marshal(b, m, deterministic)
+}
+func (m *HandoverRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandoverRequest.Merge(m, src)
+}
+func (m *HandoverRequest) XXX_Size() int {
+	return xxx_messageInfo_HandoverRequest.Size(m)
+}
+func (m *HandoverRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandoverRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandoverRequest proto.InternalMessageInfo
+
+func (m *HandoverRequest) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *HandoverRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+type HandoverReply struct {
+	// edge node the session was handed over from
+	ID string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// whether the edge node knew the client
+	Found                bool            `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Requests             []*RequestState `protobuf:"bytes,3,rep,name=requests,proto3" json:"requests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *HandoverReply) Reset()         { *m = HandoverReply{} }
+func (m *HandoverReply) String() string { return proto.CompactTextString(m) }
+func (*HandoverReply) ProtoMessage()    {}
+func (*HandoverReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{5}
+}
+
+func (m *HandoverReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandoverReply.Unmarshal(m, b)
+}
+func (m *HandoverReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandoverReply.Marshal(b, m, deterministic)
+}
+func (m *HandoverReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandoverReply.Merge(m, src)
+}
+func (m *HandoverReply) XXX_Size() int {
+	return xxx_messageInfo_HandoverReply.Size(m)
+}
+func (m *HandoverReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandoverReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandoverReply proto.InternalMessageInfo
+
+func (m *HandoverReply) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *HandoverReply) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *HandoverReply) GetRequests() []*RequestState {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+type RequestState struct {
+	Request     string `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	State       string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Reason      string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Application string `protobuf:"bytes,4,opt,name=application,proto3" json:"application,omitempty"`
+	Priority    string `protobuf:"bytes,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	// submission time of the request, in unix nanoseconds
+	Submitted            int64    `protobuf:"varint,6,opt,name=submitted,proto3" json:"submitted,omitempty"`
+	Hasresult            bool     `protobuf:"varint,7,opt,name=hasresult,proto3" json:"hasresult,omitempty"`
+	Result               []byte   `protobuf:"bytes,8,opt,name=result,proto3" json:"result,omitempty"`
+	Contenttype          string   `protobuf:"bytes,9,opt,name=contenttype,proto3" json:"contenttype,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestState) Reset()         { *m = RequestState{} }
+func (m *RequestState) String() string { return proto.CompactTextString(m) }
+func (*RequestState) ProtoMessage()    {}
+func (*RequestState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{6}
+}
+
+func (m *RequestState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestState.Unmarshal(m, b)
+}
+func (m *RequestState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestState.Marshal(b, m, deterministic)
+}
+func (m *RequestState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestState.Merge(m, src)
+}
+func (m *RequestState) XXX_Size() int {
+	return xxx_messageInfo_RequestState.Size(m)
+}
+func (m *RequestState) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestState proto.InternalMessageInfo
+
+func (m *RequestState) GetRequest() string {
+	if m != nil {
+		return m.Request
+	}
+	return ""
+}
+
+func (m *RequestState) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *RequestState) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *RequestState) GetApplication() string {
+	if m != nil {
+		return m.Application
+	}
+	return ""
+}
+
+func (m *RequestState) GetPriority() string {
+	if m != nil {
+		return m.Priority
+	}
+	return ""
+}
+
+func (m *RequestState) GetSubmitted() int64 {
+	if m != nil {
+		return m.Submitted
+	}
+	return 0
+}
+
+func (m *RequestState) GetHasresult() bool {
+	if m != nil {
+		return m.Hasresult
+	}
+	return false
+}
+
+func (m *RequestState) GetResult() []byte {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *RequestState) GetContenttype() string {
+	if m != nil {
+		return m.Contenttype
+	}
+	return ""
+}
+
+type FetchRequest struct {
+	Request string `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	// edge node fetching the result
+	ID string `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	// time the call waits for the result of an active request, in milliseconds
+	Waitms               int64    `protobuf:"varint,3,opt,name=waitms,proto3" json:"waitms,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FetchRequest) Reset()         { *m = FetchRequest{} }
+func (m *FetchRequest) String() string { return proto.CompactTextString(m) }
+func (*FetchRequest) ProtoMessage()    {}
+func (*FetchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{7}
+}
+
+func (m *FetchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FetchRequest.Unmarshal(m, b)
+}
+func (m *FetchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FetchRequest.Marshal(b, m, deterministic)
+}
+func (m *FetchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchRequest.Merge(m, src)
+}
+func (m *FetchRequest) XXX_Size() int {
+	return xxx_messageInfo_FetchRequest.Size(m)
+}
+func (m *FetchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FetchRequest proto.InternalMessageInfo
+
+func (m *FetchRequest) GetRequest() string {
+	if m != nil {
+		return m.Request
+	}
+	return ""
+}
+
+func (m *FetchRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *FetchRequest) GetWaitms() int64 {
+	if m != nil {
+		return m.Waitms
+	}
+	return 0
+}
+
+type AnnouncementsRequest struct {
+	// edge node asking for the updates
+	ID                   string   `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AnnouncementsRequest) Reset()         { *m = AnnouncementsRequest{} }
+func (m *AnnouncementsRequest) String() string { return proto.CompactTextString(m) }
+func (*AnnouncementsRequest) ProtoMessage()    {}
+func (*AnnouncementsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{8}
+}
+
+func (m *AnnouncementsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AnnouncementsRequest.Unmarshal(m, b)
+}
+func (m *AnnouncementsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AnnouncementsRequest.Marshal(b, m, deterministic)
+}
+func (m *AnnouncementsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AnnouncementsRequest.Merge(m, src)
+}
+func (m *AnnouncementsRequest) XXX_Size() int {
+	return xxx_messageInfo_AnnouncementsRequest.Size(m)
+}
+func (m *AnnouncementsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AnnouncementsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AnnouncementsRequest proto.InternalMessageInfo
+
+func (m *AnnouncementsRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+type TableUpdates struct {
+	// the updates of the IoT resources offloaded on the edge node, as they were broadcast
+	Updates              []*TableUpdate `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *TableUpdates) Reset()         { *m = TableUpdates{} }
+func (m *TableUpdates) String() string { return proto.CompactTextString(m) }
+func (*TableUpdates) ProtoMessage()    {}
+func (*TableUpdates) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{9}
+}
+
+func (m *TableUpdates) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TableUpdates.Unmarshal(m, b)
+}
+func (m *TableUpdates) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TableUpdates.Marshal(b, m, deterministic)
+}
+func (m *TableUpdates) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableUpdates.Merge(m, src)
+}
+func (m *TableUpdates) XXX_Size() int {
+	return xxx_messageInfo_TableUpdates.Size(m)
+}
+func (m *TableUpdates) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableUpdates.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TableUpdates proto.InternalMessageInfo
+
+func (m *TableUpdates) GetUpdates() []*TableUpdate {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+type NodeState struct {
+	Node string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// active, draining or departed
+	State                string   `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeState) Reset()         { *m = NodeState{} }
+func (m *NodeState) String() string { return proto.CompactTextString(m) }
+func (*NodeState) ProtoMessage()    {}
+func (*NodeState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{10}
+}
+
+func (m *NodeState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeState.Unmarshal(m, b)
+}
+func (m *NodeState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeState.Marshal(b, m, deterministic)
+}
+func (m *NodeState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeState.Merge(m, src)
+}
+func (m *NodeState) XXX_Size() int {
+	return xxx_messageInfo_NodeState.Size(m)
+}
+func (m *NodeState) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeState proto.InternalMessageInfo
+
+func (m *NodeState) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *NodeState) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+type Eviction struct {
+	Resource string `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	// edge node the IoT resource was evicted from
+	ID string `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	// content hash of the version evicted
+	Hash                 string   `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Eviction) Reset()         { *m = Eviction{} }
+func (m *Eviction) String() string { return proto.CompactTextString(m) }
+func (*Eviction) ProtoMessage()    {}
+func (*Eviction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{11}
+}
+
+func (m *Eviction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Eviction.Unmarshal(m, b)
+}
+func (m *Eviction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Eviction.Marshal(b, m, deterministic)
+}
+func (m *Eviction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Eviction.Merge(m, src)
+}
+func (m *Eviction) XXX_Size() int {
+	return xxx_messageInfo_Eviction.Size(m)
+}
+func (m *Eviction) XXX_DiscardUnknown() {
+	xxx_messageInfo_Eviction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Eviction proto.InternalMessageInfo
+
+func (m *Eviction) GetResource() string {
+	if m != nil {
+		return m.Resource
+	}
+	return ""
+}
+
+func (m *Eviction) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Eviction) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+type Command struct {
+	// edge node handing the command to the leader
+	ID string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// mutation of the catalog, encoded in JSON
+	Command              []byte   `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Command) Reset()         { *m = Command{} }
+func (m *Command) String() string { return proto.CompactTextString(m) }
+func (*Command) ProtoMessage()    {}
+func (*Command) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{12}
+}
+
+func (m *Command) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Command.Unmarshal(m, b)
+}
+func (m *Command) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Command.Marshal(b, m, deterministic)
+}
+func (m *Command) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Command.Merge(m, src)
+}
+func (m *Command) XXX_Size() int {
+	return xxx_messageInfo_Command.Size(m)
+}
+func (m *Command) XXX_DiscardUnknown() {
+	xxx_messageInfo_Command.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Command proto.InternalMessageInfo
+
+func (m *Command) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Command) GetCommand() []byte {
+	if m != nil {
+		return m.Command
+	}
+	return nil
+}
+
+type CommandResult struct {
+	// result of the mutation, encoded in JSON
+	Result []byte `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// index of the mutation in the replicated log
+	Index                uint64   `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CommandResult) Reset()         { *m = CommandResult{} }
+func (m *CommandResult) String() string { return proto.CompactTextString(m) }
+func (*CommandResult) ProtoMessage()    {}
+func (*CommandResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{13}
+}
+
+func (m *CommandResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommandResult.Unmarshal(m, b)
+}
+func (m *CommandResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommandResult.Marshal(b, m, deterministic)
+}
+func (m *CommandResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommandResult.Merge(m, src)
+}
+func (m *CommandResult) XXX_Size() int {
+	return xxx_messageInfo_CommandResult.Size(m)
+}
+func (m *CommandResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommandResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommandResult proto.InternalMessageInfo
+
+func (m *CommandResult) GetResult() []byte {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *CommandResult) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+type ReadIndexRequest struct {
+	// edge node asking for the index
+	ID                   string   `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReadIndexRequest) Reset()         { *m = ReadIndexRequest{} }
+func (m *ReadIndexRequest) String() string { return proto.CompactTextString(m) }
+func (*ReadIndexRequest) ProtoMessage()    {}
+func (*ReadIndexRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{14}
 }
-func (m *TableUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_TableUpdate.Marshal(b, m, deterministic)
+
+func (m *ReadIndexRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadIndexRequest.Unmarshal(m, b)
 }
-func (m *TableUpdate) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_TableUpdate.Merge(m, src)
+func (m *ReadIndexRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadIndexRequest.Marshal(b, m, deterministic)
 }
-func (m *TableUpdate) XXX_Size() int {
-	return xxx_messageInfo_TableUpdate.Size(m)
+func (m *ReadIndexRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadIndexRequest.Merge(m, src)
 }
-func (m *TableUpdate) XXX_DiscardUnknown() {
-	xxx_messageInfo_TableUpdate.DiscardUnknown(m)
+func (m *ReadIndexRequest) XXX_Size() int {
+	return xxx_messageInfo_ReadIndexRequest.Size(m)
 }
-
-var xxx_messageInfo_TableUpdate proto.InternalMessageInfo
-
-func (m *TableUpdate) GetResource() string {
-	if m != nil {
-		return m.Resource
-	}
-	return ""
+func (m *ReadIndexRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadIndexRequest.DiscardUnknown(m)
 }
 
-func (m *TableUpdate) GetID() string {
+var xxx_messageInfo_ReadIndexRequest proto.InternalMessageInfo
+
+func (m *ReadIndexRequest) GetID() string {
 	if m != nil {
 		return m.ID
 	}
 	return ""
 }
 
-type TableUpdateACK struct {
-	Ack                  string   `protobuf:"bytes,3,opt,name=ack,proto3" json:"ack,omitempty"`
+type ReadIndexReply struct {
+	// index of the replicated log the catalog must have applied for a linearizable read
+	Index                uint64   `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *TableUpdateACK) Reset()         { *m = TableUpdateACK{} }
-func (m *TableUpdateACK) String() string { return proto.CompactTextString(m) }
-func (*TableUpdateACK) ProtoMessage()    {}
-func (*TableUpdateACK) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eca3873955a29cfe, []int{1}
+func (m *ReadIndexReply) Reset()         { *m = ReadIndexReply{} }
+func (m *ReadIndexReply) String() string { return proto.CompactTextString(m) }
+func (*ReadIndexReply) ProtoMessage()    {}
+func (*ReadIndexReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{15}
 }
 
-func (m *TableUpdateACK) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_TableUpdateACK.Unmarshal(m, b)
+func (m *ReadIndexReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadIndexReply.Unmarshal(m, b)
 }
-func (m *TableUpdateACK) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_TableUpdateACK.Marshal(b, m, deterministic)
+func (m *ReadIndexReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadIndexReply.Marshal(b, m, deterministic)
 }
-func (m *TableUpdateACK) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_TableUpdateACK.Merge(m, src)
+func (m *ReadIndexReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadIndexReply.Merge(m, src)
 }
-func (m *TableUpdateACK) XXX_Size() int {
-	return xxx_messageInfo_TableUpdateACK.Size(m)
+func (m *ReadIndexReply) XXX_Size() int {
+	return xxx_messageInfo_ReadIndexReply.Size(m)
 }
-func (m *TableUpdateACK) XXX_DiscardUnknown() {
-	xxx_messageInfo_TableUpdateACK.DiscardUnknown(m)
+func (m *ReadIndexReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadIndexReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_TableUpdateACK proto.InternalMessageInfo
+var xxx_messageInfo_ReadIndexReply proto.InternalMessageInfo
 
-func (m *TableUpdateACK) GetAck() string {
+func (m *ReadIndexReply) GetIndex() uint64 {
 	if m != nil {
-		return m.Ack
+		return m.Index
+	}
+	return 0
+}
+
+type CopyRequest struct {
+	// edge node that made the copy
+	ID string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// the copy, to be announced by the edge node holding it
+	Copy                 *TableUpdate `protobuf:"bytes,2,opt,name=copy,proto3" json:"copy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CopyRequest) Reset()         { *m = CopyRequest{} }
+func (m *CopyRequest) String() string { return proto.CompactTextString(m) }
+func (*CopyRequest) ProtoMessage()    {}
+func (*CopyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eca3873955a29cfe, []int{16}
+}
+
+func (m *CopyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CopyRequest.Unmarshal(m, b)
+}
+func (m *CopyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CopyRequest.Marshal(b, m, deterministic)
+}
+func (m *CopyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CopyRequest.Merge(m, src)
+}
+func (m *CopyRequest) XXX_Size() int {
+	return xxx_messageInfo_CopyRequest.Size(m)
+}
+func (m *CopyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CopyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CopyRequest proto.InternalMessageInfo
+
+func (m *CopyRequest) GetID() string {
+	if m != nil {
+		return m.ID
 	}
 	return ""
 }
 
+func (m *CopyRequest) GetCopy() *TableUpdate {
+	if m != nil {
+		return m.Copy
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*TableUpdate)(nil), "TableUpdate")
 	proto.RegisterType((*TableUpdateACK)(nil), "TableUpdateACK")
+	proto.RegisterType((*Predictions)(nil), "Predictions")
+	proto.RegisterType((*Prediction)(nil), "Prediction")
+	proto.RegisterType((*HandoverRequest)(nil), "HandoverRequest")
+	proto.RegisterType((*HandoverReply)(nil), "HandoverReply")
+	proto.RegisterType((*RequestState)(nil), "RequestState")
+	proto.RegisterType((*FetchRequest)(nil), "FetchRequest")
+	proto.RegisterType((*AnnouncementsRequest)(nil), "AnnouncementsRequest")
+	proto.RegisterType((*TableUpdates)(nil), "TableUpdates")
+	proto.RegisterType((*NodeState)(nil), "NodeState")
+	proto.RegisterType((*Eviction)(nil), "Eviction")
+	proto.RegisterType((*Command)(nil), "Command")
+	proto.RegisterType((*CommandResult)(nil), "CommandResult")
+	proto.RegisterType((*ReadIndexRequest)(nil), "ReadIndexRequest")
+	proto.RegisterType((*ReadIndexReply)(nil), "ReadIndexReply")
+	proto.RegisterType((*CopyRequest)(nil), "CopyRequest")
 }
 
 func init() { proto.RegisterFile("frontend.proto", fileDescriptor_eca3873955a29cfe) }
 
 var fileDescriptor_eca3873955a29cfe = []byte{
-	// 143 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4b, 0x2b, 0xca, 0xcf,
-	0x2b, 0x49, 0xcd, 0x4b, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x57, 0xb2, 0xe4, 0xe2, 0x0e, 0x49,
-	0x4c, 0xca, 0x49, 0x0d, 0x2d, 0x48, 0x49, 0x2c, 0x49, 0x15, 0x92, 0xe2, 0xe2, 0x28, 0x4a, 0x2d,
-	0xce, 0x2f, 0x2d, 0x4a, 0x4e, 0x95, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x82, 0xf3, 0x85, 0xf8,
-	0xb8, 0x98, 0x3c, 0x5d, 0x24, 0x98, 0xc0, 0xa2, 0x4c, 0x9e, 0x2e, 0x4a, 0x4a, 0x5c, 0x7c, 0x48,
-	0x5a, 0x1d, 0x9d, 0xbd, 0x85, 0x04, 0xb8, 0x98, 0x13, 0x93, 0xb3, 0x25, 0x98, 0xc1, 0x4a, 0x40,
-	0x4c, 0x23, 0x27, 0x2e, 0x0e, 0x37, 0xa8, 0x85, 0x42, 0x66, 0x5c, 0xc2, 0x41, 0x50, 0xb3, 0x90,
-	0xad, 0xe4, 0xd1, 0x43, 0xe2, 0x49, 0xf1, 0xeb, 0xa1, 0x9a, 0xa9, 0xc4, 0x90, 0xc4, 0x06, 0x76,
-	0xa9, 0x31, 0x20, 0x00, 0x00, 0xff, 0xff, 0x44, 0x12, 0xdf, 0x4a, 0xbb, 0x00, 0x00, 0x00,
+	// 906 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0x4d, 0x6f, 0x23, 0x45,
+	0x10, 0xf5, 0xd8, 0x4e, 0x3c, 0x2e, 0x7f, 0xac, 0x69, 0xc2, 0xaa, 0x65, 0x71, 0xb0, 0xfa, 0x10,
+	0x19, 0x01, 0xa3, 0xb0, 0xd1, 0x2e, 0xca, 0x01, 0xa1, 0x55, 0x96, 0x15, 0x01, 0x84, 0x56, 0x0d,
+	0xdc, 0xe9, 0xcc, 0xb4, 0x93, 0x11, 0xe3, 0xee, 0x61, 0xba, 0xbd, 0xbb, 0xde, 0x33, 0x27, 0x7e,
+	0x17, 0x7f, 0x87, 0xff, 0x80, 0xba, 0xa6, 0xe7, 0xcb, 0x71, 0x4e, 0xdc, 0xaa, 0x5e, 0x4d, 0xf5,
+	0xc7, 0x7b, 0xaf, 0xcb, 0x86, 0xf9, 0xa6, 0xd0, 0xca, 0x4a, 0x95, 0x44, 0x79, 0xa1, 0xad, 0x66,
+	0xff, 0xf6, 0x61, 0xf2, 0xab, 0xb8, 0xcd, 0xe4, 0x6f, 0x79, 0x22, 0xac, 0x24, 0x4b, 0x08, 0x0b,
+	0x69, 0xf4, 0xae, 0x88, 0x25, 0x0d, 0x56, 0xc1, 0x7a, 0xcc, 0xeb, 0x9c, 0xcc, 0xa1, 0x7f, 0xf3,
+	0x8a, 0xf6, 0x11, 0xed, 0xdf, 0xbc, 0x22, 0x04, 0x86, 0xf7, 0xc2, 0xdc, 0xd3, 0x01, 0x22, 0x18,
+	0x93, 0x15, 0x4c, 0x62, 0xad, 0x6c, 0x91, 0xde, 0xee, 0xac, 0x2e, 0xe8, 0x10, 0x4b, 0x6d, 0x88,
+	0x7c, 0x0a, 0x63, 0x93, 0xde, 0x29, 0x61, 0x77, 0x85, 0xa4, 0x27, 0xab, 0x60, 0x3d, 0xe5, 0x0d,
+	0x80, 0xfd, 0xb2, 0xb0, 0xe9, 0x26, 0x8d, 0x85, 0x95, 0xf4, 0x14, 0xeb, 0x6d, 0xc8, 0xed, 0x6a,
+	0xf7, 0xb9, 0xa4, 0xa3, 0x72, 0x57, 0x17, 0x93, 0xa7, 0x70, 0xba, 0x4d, 0x55, 0x26, 0x2c, 0x0d,
+	0x57, 0xc1, 0x3a, 0xe0, 0x3e, 0xab, 0x70, 0xad, 0xe8, 0xb8, 0xc1, 0xb5, 0x42, 0x5c, 0xbc, 0x77,
+	0xdf, 0x83, 0xc7, 0x31, 0xab, 0x70, 0xad, 0xe8, 0xa4, 0xc1, 0xb5, 0x72, 0x67, 0xd6, 0x9b, 0x4d,
+	0xa6, 0x45, 0x22, 0x13, 0x3a, 0x5d, 0x05, 0xeb, 0x01, 0x6f, 0x00, 0x77, 0x22, 0x93, 0x7e, 0x90,
+	0x74, 0x86, 0x05, 0x8c, 0xdd, 0x4a, 0xba, 0x48, 0xef, 0x52, 0x45, 0xe7, 0x78, 0x4e, 0x9f, 0x31,
+	0x06, 0xf3, 0x16, 0xdd, 0x2f, 0xaf, 0x7f, 0x24, 0x0b, 0x18, 0x88, 0xf8, 0x0f, 0x4f, 0xa2, 0x0b,
+	0xd9, 0x4f, 0x30, 0x79, 0x53, 0xc8, 0x24, 0x8d, 0x6d, 0xaa, 0x95, 0xf1, 0xb4, 0x07, 0x35, 0xed,
+	0x5f, 0xc2, 0x24, 0x6f, 0xca, 0xb4, 0xbf, 0x1a, 0xac, 0x27, 0xcf, 0x26, 0x51, 0xd3, 0xc2, 0xdb,
+	0x75, 0xf6, 0x4f, 0x00, 0xd0, 0xd4, 0x1c, 0xc1, 0x22, 0xcf, 0x33, 0xc7, 0x65, 0xaa, 0x95, 0x5f,
+	0xb6, 0x0d, 0xb9, 0xeb, 0x28, 0x9d, 0x48, 0x2f, 0x34, 0xc6, 0xa5, 0x68, 0x1f, 0x64, 0x9c, 0x09,
+	0x63, 0xf0, 0xa8, 0x27, 0xbc, 0x01, 0x08, 0x85, 0x91, 0x11, 0xdb, 0x3c, 0x93, 0x06, 0x05, 0x1f,
+	0xf0, 0x2a, 0x75, 0x6b, 0xc9, 0x77, 0x5b, 0x81, 0x3a, 0x07, 0x1c, 0x63, 0x77, 0xe1, 0xfc, 0xf9,
+	0x05, 0x4a, 0x1b, 0x70, 0x17, 0x22, 0x72, 0x75, 0x41, 0x47, 0x1e, 0xb9, 0xf2, 0xc8, 0x95, 0x57,
+	0xd3, 0x85, 0xec, 0x0a, 0x9e, 0x7c, 0x2f, 0x54, 0xa2, 0xdf, 0xca, 0x82, 0xcb, 0x3f, 0x77, 0xd2,
+	0xa0, 0x5a, 0x71, 0x96, 0x4a, 0x65, 0xfd, 0x2d, 0x7c, 0x76, 0xe8, 0x53, 0xf6, 0x3b, 0xcc, 0x9a,
+	0xd6, 0x3c, 0xdb, 0x3f, 0x60, 0xf4, 0x0c, 0x4e, 0x36, 0x7a, 0xa7, 0x12, 0xec, 0x09, 0x79, 0x99,
+	0x90, 0xcf, 0xdc, 0x53, 0xc0, 0x9d, 0xdc, 0x95, 0x1d, 0xc9, 0xb3, 0xc8, 0x6f, 0xfd, 0x8b, 0x15,
+	0x56, 0xf2, 0xba, 0xcc, 0xfe, 0xee, 0xc3, 0xb4, 0x5d, 0x72, 0x8c, 0xf8, 0xa2, 0xdf, 0xa6, 0x4a,
+	0xdd, 0x5e, 0xc6, 0x7d, 0xe2, 0xcf, 0x57, 0x26, 0xee, 0x2a, 0x85, 0x14, 0x46, 0x2b, 0xef, 0x03,
+	0x9f, 0x1d, 0xaa, 0x35, 0x7c, 0xa8, 0xd6, 0x12, 0xc2, 0xbc, 0x48, 0x75, 0x91, 0xda, 0x3d, 0xb2,
+	0x3c, 0xe6, 0x75, 0x8e, 0xaa, 0xed, 0x6e, 0xb7, 0xa9, 0xb5, 0x32, 0x41, 0xbe, 0x07, 0xbc, 0x01,
+	0x5c, 0xf5, 0x5e, 0x98, 0x42, 0x9a, 0x5d, 0x66, 0x91, 0xfb, 0x90, 0x37, 0x40, 0x79, 0x22, 0x2c,
+	0x85, 0xf8, 0x06, 0x7d, 0x56, 0x3d, 0x70, 0xa9, 0x2c, 0xbe, 0xc2, 0x71, 0xf3, 0xc0, 0x3d, 0xc4,
+	0xde, 0xc0, 0xf4, 0xb5, 0xb4, 0xf1, 0x7d, 0x25, 0xd3, 0xe3, 0x5c, 0x1c, 0x0e, 0x94, 0xa7, 0x70,
+	0xfa, 0x4e, 0xa4, 0x76, 0x5b, 0x5a, 0x6c, 0xc0, 0x7d, 0xc6, 0xce, 0xe1, 0xec, 0xa5, 0x52, 0x7a,
+	0xa7, 0x62, 0xb9, 0x95, 0xca, 0x1a, 0xde, 0xe9, 0xaf, 0x75, 0x64, 0x2f, 0x60, 0xda, 0x7a, 0x5c,
+	0x86, 0x9c, 0xc3, 0x68, 0x57, 0x86, 0x34, 0x40, 0x01, 0xa7, 0x51, 0xab, 0xce, 0xab, 0x22, 0x7b,
+	0x0e, 0xe3, 0x9f, 0x75, 0x22, 0x4b, 0xe9, 0x2a, 0xfb, 0x07, 0x2d, 0xfb, 0x1f, 0x15, 0x8d, 0xfd,
+	0x00, 0xe1, 0x77, 0x6f, 0xfd, 0xb3, 0xfa, 0x9f, 0x73, 0x93, 0x5d, 0xc2, 0xe8, 0x5a, 0x6f, 0xb7,
+	0x42, 0x25, 0x0f, 0xdc, 0x49, 0x61, 0x14, 0x97, 0x25, 0x5c, 0x63, 0xca, 0xab, 0x94, 0x7d, 0x03,
+	0x33, 0xdf, 0xc4, 0x0f, 0x45, 0x0b, 0x3a, 0xa2, 0x9d, 0xc1, 0x49, 0xaa, 0x12, 0xf9, 0x1e, 0x17,
+	0x18, 0xf2, 0x32, 0x61, 0x0c, 0x16, 0x5c, 0x8a, 0xe4, 0xc6, 0x25, 0x8f, 0x51, 0x7a, 0x0e, 0xf3,
+	0xd6, 0x37, 0xee, 0xf1, 0xd4, 0x6b, 0x05, 0xed, 0xb5, 0xbe, 0x85, 0xc9, 0xb5, 0xce, 0xf7, 0x8f,
+	0x2c, 0x43, 0x56, 0x30, 0x8c, 0x75, 0xbe, 0xc7, 0xfd, 0x0f, 0x65, 0xc0, 0xca, 0xb3, 0xbf, 0x86,
+	0x10, 0xbe, 0xf6, 0xbf, 0x4d, 0xe4, 0x05, 0x7c, 0xcc, 0x3d, 0x7b, 0xad, 0x2f, 0x49, 0xa7, 0x6f,
+	0xf9, 0x24, 0xea, 0x4e, 0x52, 0xd6, 0x23, 0x97, 0xb0, 0x68, 0x46, 0x5d, 0xdd, 0xd4, 0x40, 0xe6,
+	0x58, 0x53, 0x04, 0x61, 0x35, 0x1e, 0xc8, 0x22, 0x3a, 0x18, 0x32, 0xcb, 0x79, 0xd4, 0x99, 0x1d,
+	0xac, 0xe7, 0xe6, 0xaf, 0xf7, 0x37, 0x72, 0x3b, 0x8b, 0xda, 0x6e, 0x5f, 0x76, 0x67, 0x04, 0xeb,
+	0x91, 0xaf, 0x61, 0xd6, 0x31, 0x2f, 0xf9, 0x24, 0x3a, 0x66, 0xe6, 0xe5, 0xac, 0x7d, 0x32, 0xc3,
+	0x7a, 0xe4, 0x73, 0x00, 0xe7, 0x4a, 0x7f, 0x0d, 0x88, 0x6a, 0x8b, 0x1e, 0xbb, 0xc4, 0x05, 0x2c,
+	0x2a, 0xc6, 0x6a, 0x4f, 0x8e, 0xa3, 0x2a, 0x3c, 0xd6, 0xf1, 0x05, 0xcc, 0xaf, 0x85, 0x15, 0x99,
+	0xbe, 0xab, 0x8c, 0x17, 0x46, 0x3e, 0x5a, 0xce, 0xa3, 0x8e, 0xaf, 0x58, 0x8f, 0x7c, 0x05, 0xe3,
+	0xda, 0x07, 0xe4, 0xa3, 0xe8, 0xd0, 0x37, 0xcb, 0x27, 0x51, 0xd7, 0x26, 0xa5, 0x18, 0xce, 0x12,
+	0xed, 0xcb, 0x92, 0x69, 0xd4, 0x72, 0xc9, 0x91, 0x53, 0xdd, 0x9e, 0xe2, 0xdf, 0x92, 0xcb, 0xff,
+	0x06, 0x00, 0x26, 0x80, 0xc3, 0x97, 0xa8, 0x08, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -141,6 +1131,15 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type FrontendClient interface {
 	ResourceTableUpdate(ctx context.Context, in *TableUpdate, opts ...grpc.CallOption) (*TableUpdateACK, error)
+	PredictionUpdate(ctx context.Context, in *Predictions, opts ...grpc.CallOption) (*TableUpdateACK, error)
+	Handover(ctx context.Context, in *HandoverRequest, opts ...grpc.CallOption) (*HandoverReply, error)
+	FetchResult(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*RequestState, error)
+	Announcements(ctx context.Context, in *AnnouncementsRequest, opts ...grpc.CallOption) (*TableUpdates, error)
+	NodeUpdate(ctx context.Context, in *NodeState, opts ...grpc.CallOption) (*TableUpdateACK, error)
+	ResourceEviction(ctx context.Context, in *Eviction, opts ...grpc.CallOption) (*TableUpdateACK, error)
+	CatalogCommand(ctx context.Context, in *Command, opts ...grpc.CallOption) (*CommandResult, error)
+	ReadIndex(ctx context.Context, in *ReadIndexRequest, opts ...grpc.CallOption) (*ReadIndexReply, error)
+	CopyAnnouncement(ctx context.Context, in *CopyRequest, opts ...grpc.CallOption) (*TableUpdateACK, error)
 }
 
 type frontendClient struct {
@@ -160,9 +1159,99 @@ func (c *frontendClient) ResourceTableUpdate(ctx context.Context, in *TableUpdat
 	return out, nil
 }
 
+func (c *frontendClient) PredictionUpdate(ctx context.Context, in *Predictions, opts ...grpc.CallOption) (*TableUpdateACK, error) {
+	out := new(TableUpdateACK)
+	err := c.cc.Invoke(ctx, "/Frontend/PredictionUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) Handover(ctx context.Context, in *HandoverRequest, opts ...grpc.CallOption) (*HandoverReply, error) {
+	out := new(HandoverReply)
+	err := c.cc.Invoke(ctx, "/Frontend/Handover", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) FetchResult(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*RequestState, error) {
+	out := new(RequestState)
+	err := c.cc.Invoke(ctx, "/Frontend/FetchResult", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) Announcements(ctx context.Context, in *AnnouncementsRequest, opts ...grpc.CallOption) (*TableUpdates, error) {
+	out := new(TableUpdates)
+	err := c.cc.Invoke(ctx, "/Frontend/Announcements", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) NodeUpdate(ctx context.Context, in *NodeState, opts ...grpc.CallOption) (*TableUpdateACK, error) {
+	out := new(TableUpdateACK)
+	err := c.cc.Invoke(ctx, "/Frontend/NodeUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) ResourceEviction(ctx context.Context, in *Eviction, opts ...grpc.CallOption) (*TableUpdateACK, error) {
+	out := new(TableUpdateACK)
+	err := c.cc.Invoke(ctx, "/Frontend/ResourceEviction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) CatalogCommand(ctx context.Context, in *Command, opts ...grpc.CallOption) (*CommandResult, error) {
+	out := new(CommandResult)
+	err := c.cc.Invoke(ctx, "/Frontend/CatalogCommand", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) ReadIndex(ctx context.Context, in *ReadIndexRequest, opts ...grpc.CallOption) (*ReadIndexReply, error) {
+	out := new(ReadIndexReply)
+	err := c.cc.Invoke(ctx, "/Frontend/ReadIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) CopyAnnouncement(ctx context.Context, in *CopyRequest, opts ...grpc.CallOption) (*TableUpdateACK, error) {
+	out := new(TableUpdateACK)
+	err := c.cc.Invoke(ctx, "/Frontend/CopyAnnouncement", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FrontendServer is the server API for Frontend service.
 type FrontendServer interface {
 	ResourceTableUpdate(context.Context, *TableUpdate) (*TableUpdateACK, error)
+	PredictionUpdate(context.Context, *Predictions) (*TableUpdateACK, error)
+	Handover(context.Context, *HandoverRequest) (*HandoverReply, error)
+	FetchResult(context.Context, *FetchRequest) (*RequestState, error)
+	Announcements(context.Context, *AnnouncementsRequest) (*TableUpdates, error)
+	NodeUpdate(context.Context, *NodeState) (*TableUpdateACK, error)
+	ResourceEviction(context.Context, *Eviction) (*TableUpdateACK, error)
+	CatalogCommand(context.Context, *Command) (*CommandResult, error)
+	ReadIndex(context.Context, *ReadIndexRequest) (*ReadIndexReply, error)
+	CopyAnnouncement(context.Context, *CopyRequest) (*TableUpdateACK, error)
 }
 
 func RegisterFrontendServer(s *grpc.Server, srv FrontendServer) {
@@ -187,6 +1276,168 @@ func _Frontend_ResourceTableUpdate_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Frontend_PredictionUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Predictions)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).PredictionUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/PredictionUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).PredictionUpdate(ctx, req.(*Predictions))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_Handover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).Handover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/Handover",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).Handover(ctx, req.(*HandoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_FetchResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).FetchResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/FetchResult",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).FetchResult(ctx, req.(*FetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_Announcements_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnnouncementsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).Announcements(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/Announcements",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).Announcements(ctx, req.(*AnnouncementsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_NodeUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeState)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).NodeUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/NodeUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).NodeUpdate(ctx, req.(*NodeState))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_ResourceEviction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Eviction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).ResourceEviction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/ResourceEviction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).ResourceEviction(ctx, req.(*Eviction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_CatalogCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Command)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).CatalogCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/CatalogCommand",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).CatalogCommand(ctx, req.(*Command))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_ReadIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).ReadIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/ReadIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).ReadIndex(ctx, req.(*ReadIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_CopyAnnouncement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).CopyAnnouncement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Frontend/CopyAnnouncement",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).CopyAnnouncement(ctx, req.(*CopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Frontend_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Frontend",
 	HandlerType: (*FrontendServer)(nil),
@@ -195,6 +1446,42 @@ var _Frontend_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ResourceTableUpdate",
 			Handler:    _Frontend_ResourceTableUpdate_Handler,
 		},
+		{
+			MethodName: "PredictionUpdate",
+			Handler:    _Frontend_PredictionUpdate_Handler,
+		},
+		{
+			MethodName: "Handover",
+			Handler:    _Frontend_Handover_Handler,
+		},
+		{
+			MethodName: "FetchResult",
+			Handler:    _Frontend_FetchResult_Handler,
+		},
+		{
+			MethodName: "Announcements",
+			Handler:    _Frontend_Announcements_Handler,
+		},
+		{
+			MethodName: "NodeUpdate",
+			Handler:    _Frontend_NodeUpdate_Handler,
+		},
+		{
+			MethodName: "ResourceEviction",
+			Handler:    _Frontend_ResourceEviction_Handler,
+		},
+		{
+			MethodName: "CatalogCommand",
+			Handler:    _Frontend_CatalogCommand_Handler,
+		},
+		{
+			MethodName: "ReadIndex",
+			Handler:    _Frontend_ReadIndex_Handler,
+		},
+		{
+			MethodName: "CopyAnnouncement",
+			Handler:    _Frontend_CopyAnnouncement_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "frontend.proto",
@@ -0,0 +1,288 @@
+/*
+This package implements the replication of the IoT resources contributed to EDIRO, so that the requests needing an IoT
+resource do not stall when the edge node holding it fails. Every IoT resource has a replication factor, i.e. the number
+of edge nodes of the swarm that should hold it, asked by its contributor or given by its type (see
+library.Typereplication). The edge node an IoT resource is offloaded through owns its replication: once the resource is
+recorded, the owner pushes copies of it to the nearest active edge nodes with the transfer service of the prefetch
+planner (see package prefetch). It then checks periodically that enough active edge nodes hold the current version of
+the resource and copies it again when a replica was lost, e.g. when its edge node was drained. The copies are announced
+like offloaded resources along with the edge node they were copied from, so that every edge node tracks all the
+replicas in its catalog and the resource discovery claims any active replica.
+The edge nodes have no position of their own, the nearness of two edge nodes is estimated from the footprints of the
+IoT resources they hold.
+
+*/
+
+package replication
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//owned : An IoT resource whose replication is owned by this edge node
+type owned struct {
+	//node : edge node the IoT resource was offloaded on
+	node string
+	//hash : content hash of the current version of the IoT resource, empty until it is recorded
+	hash   string
+	factor int
+}
+
+//Manager : The replication of the IoT resources offloaded through an edge node
+type Manager struct {
+	catalog *resourcemanager.Catalog
+	planner *prefetch.Planner
+	//owned : the IoT resources replicated, keyed by resource
+	owned map[string]*owned
+	//inflight : the copies in progress, keyed by resource then destination
+	inflight map[string]map[string]bool
+	wake     chan struct{}
+	mux      sync.Mutex
+	logger   *slog.Logger
+}
+
+//New : creates the replication manager of an edge node copying the IoT resources with the transfers of the planner
+func New(catalog *resourcemanager.Catalog, planner *prefetch.Planner, logger *slog.Logger) *Manager {
+	return &Manager{catalog: catalog, planner: planner, owned: map[string]*owned{},
+		inflight: map[string]map[string]bool{}, wake: make(chan struct{}, 1), logger: logger}
+}
+
+/*
+Track : Takes over the replication of an IoT resource offloaded through this edge node. Its copies are pushed once the
+resource manager recorded it. A new version of an IoT resource replaces the previous one, and a resource whose
+replication factor is 1 is no longer replicated.
+Input: context whose cancellation stops the wait for the recording, the IoT resource as offloaded
+Output: Nil
+*/
+func (m *Manager) Track(ctx context.Context, resource resourcemanager.Newresource) {
+	factor := library.Replicationof(resource.Type, resource.Replicas)
+	m.mux.Lock()
+	if factor <= 1 {
+		delete(m.owned, resource.Resource)
+		m.mux.Unlock()
+		return
+	}
+	m.owned[resource.Resource] = &owned{node: resource.NodeID, hash: resource.Hash, factor: factor}
+	m.mux.Unlock()
+
+	recorded := m.catalog.Watch([]string{resource.Resource}, nil)
+	go func() {
+		select {
+		case <-recorded:
+			m.signal()
+		case <-ctx.Done():
+			m.catalog.Unwatch(recorded)
+		}
+	}()
+}
+
+//Untrack : stops the replication of an IoT resource evicted from the edge node it was offloaded on, its replicas are
+//evicted in turn by the storage of their edge node
+func (m *Manager) Untrack(resource string, node string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if o, ok := m.owned[resource]; ok && o.node == node {
+		delete(m.owned, resource)
+	}
+}
+
+//signal : wakes the replication up, an IoT resource was recorded
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+/*
+Run : Checks the replicas of the IoT resources owned by this edge node whenever one of them is recorded and at every
+interval, and copies those held by too few active edge nodes.
+Input: context whose cancellation stops the replication and the copies in progress, interval between two checks, 0
+to only check when an IoT resource is recorded
+Output: Nil
+*/
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	for {
+		var tick <-chan time.Time
+		if interval > 0 {
+			tick = time.After(interval)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		case <-tick:
+		}
+		m.mux.Lock()
+		resources := make(map[string]owned, len(m.owned))
+		for resource, o := range m.owned {
+			resources[resource] = *o
+		}
+		m.mux.Unlock()
+		for resource, o := range resources {
+			m.repair(ctx, resource, o)
+		}
+	}
+}
+
+/*
+repair : Copies an IoT resource to the nearest active edge nodes not holding it until as many edge nodes hold its
+current version, or will once the copies in progress complete, as its replication factor.
+Input: context whose cancellation stops the copies, IoT resource, its replication
+Output: Nil
+*/
+func (m *Manager) repair(ctx context.Context, resource string, o owned) {
+	locations := m.catalog.Locations(resource)
+	for _, l := range locations {
+		if l.Node == o.node && l.Provenance.Hash != o.hash {
+			//the hash of the resource was computed when it was recorded
+			o.hash = l.Provenance.Hash
+			m.mux.Lock()
+			if current, ok := m.owned[resource]; ok && current.node == o.node {
+				current.hash = o.hash
+			}
+			m.mux.Unlock()
+		}
+	}
+	var holders []resourcemanager.Entry
+	for _, l := range locations {
+		if l.Provenance.Hash == o.hash {
+			holders = append(holders, l)
+		}
+	}
+	if len(holders) == 0 {
+		return //not recorded yet, or every replica was lost
+	}
+	source := holders[0]
+	for _, h := range holders {
+		if h.Node == o.node {
+			source = h
+		}
+	}
+
+	m.mux.Lock()
+	missing := o.factor - len(holders) - len(m.inflight[resource])
+	excluded := map[string]bool{}
+	for _, h := range holders {
+		excluded[h.Node] = true
+	}
+	for node := range m.inflight[resource] {
+		excluded[node] = true
+	}
+	m.mux.Unlock()
+	if missing <= 0 {
+		return
+	}
+	targets := m.nearest(source.Descriptor.Footprint, excluded)
+	if len(targets) == 0 {
+		m.logger.Warn("no active edge node to replicate resource to", logging.Resource, resource,
+			"holders", len(holders), "factor", o.factor)
+		return
+	}
+	if len(targets) > missing {
+		targets = targets[:missing]
+	}
+	for _, to := range targets {
+		t := prefetch.Transfer{Resource: resource, From: source.Node, To: to, Provenance: source.Provenance,
+			Descriptor: source.Descriptor}
+		if m.planner.Image == "" {
+			m.logger.Info("would replicate resource, no transfer image given", logging.Resource, resource,
+				"from", t.From, "to", t.To)
+			continue
+		}
+		m.mux.Lock()
+		if m.inflight[resource] == nil {
+			m.inflight[resource] = map[string]bool{}
+		}
+		m.inflight[resource][to] = true
+		m.mux.Unlock()
+		go m.copy(ctx, t)
+	}
+}
+
+//copy : copies an IoT resource to an edge node and announces the replica
+func (m *Manager) copy(ctx context.Context, t prefetch.Transfer) {
+	defer func() {
+		m.mux.Lock()
+		delete(m.inflight[t.Resource], t.To)
+		if len(m.inflight[t.Resource]) == 0 {
+			delete(m.inflight, t.Resource)
+		}
+		m.mux.Unlock()
+	}()
+	if err := m.planner.Replicate(ctx, t); err != nil {
+		m.logger.Warn("could not replicate resource", logging.Resource, t.Resource, "from", t.From, "to", t.To,
+			"err", err)
+		return
+	}
+	m.logger.Info("resource replicated", logging.Resource, t.Resource, "from", t.From, "to", t.To)
+}
+
+/*
+nearest : Orders the active edge nodes of the swarm by their distance to an area. The position of an edge node is the
+mean of the centres of the footprints of the IoT resources it holds. Edge nodes without position, or every edge node
+when the area is unknown, come after and hold the fewest IoT resources first.
+Input: area, the zero box if unknown, edge nodes to leave out
+Output: the edge nodes, nearest first
+*/
+func (m *Manager) nearest(area geo.Box, excluded map[string]bool) []string {
+	type candidate struct {
+		node               string
+		lat, lon           float64
+		located, resources int
+	}
+	states := m.catalog.States()
+	candidates := map[string]*candidate{}
+	for _, e := range m.catalog.Entries() {
+		if excluded[e.Node] || states[e.Node] != "" {
+			continue
+		}
+		c := candidates[e.Node]
+		if c == nil {
+			c = &candidate{node: e.Node}
+			candidates[e.Node] = c
+		}
+		c.resources++
+		if !e.Descriptor.Footprint.Empty() {
+			lat, lon := e.Descriptor.Footprint.Centre()
+			c.lat, c.lon, c.located = c.lat+lat, c.lon+lon, c.located+1
+		}
+	}
+
+	lat, lon := area.Centre()
+	distance := func(c *candidate) float64 {
+		return geo.Distance(lat, lon, c.lat/float64(c.located), c.lon/float64(c.located))
+	}
+	ordered := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		ordered = append(ordered, c)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if !area.Empty() && (a.located > 0) != (b.located > 0) {
+			return a.located > 0
+		}
+		if !area.Empty() && a.located > 0 && b.located > 0 && distance(a) != distance(b) {
+			return distance(a) < distance(b)
+		}
+		if a.resources != b.resources {
+			return a.resources < b.resources
+		}
+		return a.node < b.node
+	})
+	nodes := make([]string, len(ordered))
+	for i, c := range ordered {
+		nodes[i] = c.node
+	}
+	return nodes
+}
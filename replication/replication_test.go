@@ -0,0 +1,209 @@
+package replication
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//fakedockerscript : a docker command whose services all complete
+const fakedockerscript = `#!/bin/sh
+case "$1 $2" in
+"service ps") echo Complete ;;
+esac
+`
+
+//fakedocker : puts a fake docker command first in the PATH
+func fakedocker(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(fakedockerscript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+var (
+	munich = geo.Box{Minlat: 48.1, Minlon: 11.5, Maxlat: 48.2, Maxlon: 11.6}
+	berlin = geo.Box{Minlat: 52.4, Minlon: 13.3, Maxlat: 52.6, Maxlon: 13.5}
+)
+
+//newmanager : returns the replication manager of a catalog in which the copies announced by the planner are recorded,
+//and a function returning the edge nodes the resources were copied to
+func newmanager(image string) (*Manager, func() []string) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	catalog := resourcemanager.NewCatalog(logger)
+	var mux sync.Mutex
+	var copied []string
+	planner := prefetch.New(image, 1, catalog, func(ctx context.Context, r resourcemanager.Newresource) error {
+		mux.Lock()
+		copied = append(copied, r.NodeID)
+		mux.Unlock()
+		catalog.Add(r.Resource, r.NodeID, resourcemanager.Provenance{Hash: r.Hash, Origin: r.Origin})
+		return nil
+	}, logger)
+	return New(catalog, planner, logger), func() []string {
+		mux.Lock()
+		defer mux.Unlock()
+		nodes := append([]string(nil), copied...)
+		sort.Strings(nodes)
+		return nodes
+	}
+}
+
+//hold : records a resource of a footprint on an edge node, with the hash h
+func hold(m *Manager, resource string, node string, footprint geo.Box) {
+	m.catalog.Add(resource, node, resourcemanager.Provenance{Hash: "h"})
+	m.catalog.Describe(resource, node, resourcemanager.Descriptor{Footprint: footprint})
+}
+
+func TestNearest(t *testing.T) {
+	tests := []struct {
+		name     string
+		area     geo.Box
+		excluded map[string]bool
+		want     []string
+	}{
+		{name: "nearest first", area: munich, want: []string{"munich", "berlin", "idle", "busy"}},
+		{name: "other area", area: berlin, want: []string{"berlin", "munich", "idle", "busy"}},
+		{name: "edge node left out", area: munich, excluded: map[string]bool{"munich": true},
+			want: []string{"berlin", "idle", "busy"}},
+		{name: "unknown area", want: []string{"berlin", "idle", "munich", "busy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newmanager("")
+			hold(m, "map_1", "munich", munich)
+			hold(m, "map_2", "munich", munich)
+			hold(m, "map_3", "berlin", berlin)
+			hold(m, "frame_1", "idle", geo.Box{})
+			hold(m, "frame_2", "busy", geo.Box{})
+			hold(m, "frame_3", "busy", geo.Box{})
+			hold(m, "frame_4", "busy", geo.Box{})
+			hold(m, "map_4", "draining", munich)
+			m.catalog.Setstate("draining", resourcemanager.Draining)
+			if got := m.nearest(tt.area, tt.excluded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nearest = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name   string
+		image  string
+		factor int
+		//holders : edge nodes holding the resource with the hash h, stale : edge nodes holding an older version
+		holders, stale []string
+		wantcopied     []string
+	}{
+		{name: "replica missing", image: "transfer", factor: 2, holders: []string{"munich"},
+			wantcopied: []string{"near"}},
+		{name: "replicas missing", image: "transfer", factor: 3, holders: []string{"munich"},
+			wantcopied: []string{"far", "near"}},
+		{name: "enough replicas", image: "transfer", factor: 2, holders: []string{"munich", "far"}},
+		{name: "stale replica", image: "transfer", factor: 2, holders: []string{"munich"}, stale: []string{"near"},
+			wantcopied: []string{"near"}},
+		{name: "not recorded yet", image: "transfer", factor: 2},
+		{name: "no transfer image", factor: 2, holders: []string{"munich"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t)
+			m, copied := newmanager(tt.image)
+			hold(m, "map_near", "near", munich)
+			hold(m, "map_far", "far", berlin)
+			for _, node := range tt.holders {
+				hold(m, "r", node, munich)
+			}
+			for _, node := range tt.stale {
+				m.catalog.Add("r", node, resourcemanager.Provenance{Hash: "old"})
+			}
+			m.repair(context.Background(), "r", owned{node: "munich", hash: "h", factor: tt.factor})
+			deadline := time.Now().Add(5 * time.Second)
+			for len(copied()) < len(tt.wantcopied) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(50 * time.Millisecond)
+			if got := copied(); !reflect.DeepEqual(got, tt.wantcopied) {
+				t.Errorf("copied to %v, want %v", got, tt.wantcopied)
+			}
+		})
+	}
+}
+
+func TestTrack(t *testing.T) {
+	tests := []struct {
+		name       string
+		resource   resourcemanager.Newresource
+		wantfactor int //replication factor of the resource afterwards, 0 if it is not replicated
+	}{
+		{name: "type replicated", resource: resourcemanager.Newresource{Resource: "r", NodeID: "n1", Type: "hd_map"},
+			wantfactor: 2},
+		{name: "type not replicated", resource: resourcemanager.Newresource{Resource: "r", NodeID: "n1",
+			Type: "camera_frame"}},
+		{name: "asked by the contributor", resource: resourcemanager.Newresource{Resource: "r", NodeID: "n1",
+			Replicas: 3}, wantfactor: 3},
+		{name: "new version not replicated", resource: resourcemanager.Newresource{Resource: "r", NodeID: "n1",
+			Type: "hd_map", Replicas: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newmanager("")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			m.owned["r"] = &owned{node: "n2", factor: 2}
+			m.Track(ctx, tt.resource)
+			factor, node := 0, ""
+			if o, ok := m.owned["r"]; ok {
+				factor, node = o.factor, o.node
+			}
+			if factor != tt.wantfactor || (factor != 0 && node != tt.resource.NodeID) {
+				t.Errorf("replicated %d times from %q, want %d times from %s", factor, node, tt.wantfactor,
+					tt.resource.NodeID)
+			}
+			if tt.wantfactor == 0 {
+				return
+			}
+			//the copies are pushed once the resource is recorded
+			hold(m, "r", "n1", munich)
+			select {
+			case <-m.wake:
+			case <-time.After(5 * time.Second):
+				t.Error("replication not woken up once the resource was recorded")
+			}
+		})
+	}
+}
+
+func TestUntrack(t *testing.T) {
+	tests := []struct {
+		name      string
+		node      string //edge node the resource is evicted from
+		wantowned bool
+	}{
+		{name: "evicted from the edge node it was offloaded on", node: "n1"},
+		{name: "replica evicted", node: "n2", wantowned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newmanager("")
+			m.owned["r"] = &owned{node: "n1", factor: 2}
+			m.Untrack("r", tt.node)
+			if _, owned := m.owned["r"]; owned != tt.wantowned {
+				t.Errorf("replication kept %v, want %v", owned, tt.wantowned)
+			}
+		})
+	}
+}
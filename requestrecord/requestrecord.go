@@ -0,0 +1,354 @@
+/*
+This package implements the request record of EDIRO. Every client request submitted to an edge node gets a record here
+which tracks the clients that submitted it, the service created for it, its state and, once the workload has finished,
+its exit status and logs. The record outlives the swarm service so that the outcome of a request is still known after
+the service has been garbage collected.
+
+*/
+
+package requestrecord
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/library"
+)
+
+//States a request record goes through during its lifetime
+const (
+	Submitted = "submitted"
+	Launched  = "launched"
+	Completed = "completed"
+	Failed    = "failed"
+	Reaped    = "reaped"
+	//Subscribed : a subscription waits for a new version of its IoT resource to run its workload again
+	Subscribed = "subscribed"
+	//Cancelled : the request was withdrawn by its clients or an operator before it finished
+	Cancelled = "cancelled"
+)
+
+//Errors returned when a request cannot be cancelled
+var (
+	ErrUnknown   = errors.New("unknown request")
+	ErrInactive  = errors.New("request is no longer active")
+	ErrNotclient = errors.New("request was not submitted by the client")
+)
+
+//Skipped : state of a stage of a pipeline that did not run because a stage it comes after failed
+const Skipped = "skipped"
+
+//Stage : State of a stage of the pipeline of applications serving a client request
+type Stage struct {
+	Name        string `json:"name"`
+	Application string `json:"application"`
+	Service     string `json:"service,omitempty"`
+	Node        string `json:"node"`
+	//State : submitted until the stage is launched, then launched, completed, failed or skipped
+	State string `json:"state"`
+	//Reason : why the stage failed or was skipped
+	Reason     string    `json:"reason,omitempty"`
+	ExitStatus int       `json:"exitstatus"`
+	Launched   time.Time `json:"launched"`
+	Finished   time.Time `json:"finished"`
+}
+
+//Record : Information about a client request and the workload launched to serve it
+type Record struct {
+	Request, Application, Service, Node string
+	//Clients : the clients that submitted the request, a request submitted again while it is active is shared.
+	//Anonymous : number of submissions of the request by anonymous clients sharing it
+	Clients   []string
+	Anonymous int
+	//Priority : priority class of the request, Preemptions : number of times its workload was preempted
+	Priority    string
+	Preemptions int
+	//Deadline : time by which the workload must have finished, zero for none. Target : edge node the request was
+	//steered to by the admission control, empty if none
+	Deadline time.Time
+	Target   string
+	//Query : location query selecting the IoT resources of the workload, nil for the resource of the request
+	Query *geo.Query
+	//Reason : why the request failed when it was not run to completion, e.g. its deadline was missed
+	Reason string
+	//Origin : address of the edge node serving the request when it was handed over to this edge node with the session
+	//of its client, empty if this edge node serves it
+	Origin string
+	//Inputsize : size in bytes of the data of the IoT resource, 0 if unknown
+	Inputsize int64
+	//Inputs : the IoT resources gathered for the inputs of the workload, empty if it consumes a single IoT resource
+	Inputs []string
+	//Stages : the stages of the pipeline serving the request, empty if a single workload serves it
+	Stages []Stage
+	//Expires : end of the lifetime of a subscription, zero for a request served once. Runs : number of times the
+	//workload of a subscription was run again
+	Expires time.Time
+	Runs    int
+	//Resource, ResourceHash, Contributor : the IoT resource consumed by the workload and its provenance
+	Resource, ResourceHash, Contributor string
+	//Callbacktoken : token the workload delivers its result through the callback with, empty until it is launched
+	Callbacktoken                         string
+	State                                 string
+	ExitStatus                            int
+	Logs                                  string
+	Submitted, Launched, Finished, Reaped time.Time
+}
+
+//Active : returns whether the request is waiting for or running its workload, or is a subscription waiting to run again
+func (r Record) Active() bool {
+	return r.State == Submitted || r.State == Launched || r.State == Subscribed
+}
+
+//Live : returns whether the request is a subscription whose lifetime has not ended
+func (r Record) Live() bool {
+	return !r.Expires.IsZero() && time.Now().Before(r.Expires)
+}
+
+//Has : returns whether a client submitted the request
+func (r Record) Has(client string) bool {
+	for _, c := range r.Clients {
+		if c == client {
+			return true
+		}
+	}
+	return false
+}
+
+//Store : The request records of an edge node
+type Store struct {
+	//Records : Declaring the map to store the request records keyed by the client request
+	Records map[string]*Record
+	mux     sync.Mutex
+}
+
+//New : creates an empty store of request records
+func New() *Store {
+	return &Store{Records: map[string]*Record{}}
+}
+
+//Add : stores a new record, replacing any earlier record of the same request
+func (s *Store) Add(r Record) {
+	s.mux.Lock()
+	s.Records[r.Request] = &r
+	s.mux.Unlock()
+}
+
+/*
+Submit : Records the submission of a client request. A request submitted again while it is active is shared, the clients
+are added to its record, its priority is raised to the priority of the new submission, its lifetime is extended to that
+of the new submission and no new workload is needed.
+Input: record of the submission holding the request, the client submitting it if any and the priority of the request
+Output: whether the request is shared with an active request
+*/
+func (s *Store) Submit(submission Record) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.share(submission) {
+		return true
+	}
+	r := submission.copy()
+	r.State, r.Submitted, r.Anonymous = Submitted, time.Now(), 0
+	if len(r.Clients) == 0 {
+		r.Anonymous = 1
+	}
+	s.Records[submission.Request] = &r
+	return false
+}
+
+//Share : shares a submission with the active request it submits again as Submit does, returns false without recording
+//anything if the request is not active
+func (s *Store) Share(submission Record) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.share(submission)
+}
+
+//share : adds a submission to the record of its request if the request is active, returns whether it did. mux must be
+//held.
+func (s *Store) share(submission Record) bool {
+	r, ok := s.Records[submission.Request]
+	if !ok || !r.Active() {
+		return false
+	}
+	for _, client := range submission.Clients {
+		if !r.Has(client) {
+			r.Clients = append(r.Clients, client)
+		}
+	}
+	if len(submission.Clients) == 0 {
+		r.Anonymous++
+	}
+	if library.Priorityrank[submission.Priority] > library.Priorityrank[r.Priority] {
+		r.Priority = submission.Priority
+	}
+	if submission.Expires.After(r.Expires) {
+		r.Expires = submission.Expires
+	}
+	return true
+}
+
+/*
+Cancel : Withdraws a client from an active request. The request is cancelled once no other client shares it, the
+clients sharing it keep it running otherwise. An anonymous client withdraws one of the anonymous submissions.
+Input: client request, client withdrawing it, empty for an anonymous client
+Output: whether the request is cancelled, ErrUnknown, ErrInactive or ErrNotclient if it cannot be withdrawn
+*/
+func (s *Store) Cancel(request string, client string) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	r, err := s.active(request)
+	if err != nil {
+		return false, err
+	}
+	if client == "" {
+		if r.Anonymous == 0 {
+			return false, ErrNotclient
+		}
+		r.Anonymous--
+	} else {
+		if !r.Has(client) {
+			return false, ErrNotclient
+		}
+		clients := []string{}
+		for _, c := range r.Clients {
+			if c != client {
+				clients = append(clients, c)
+			}
+		}
+		r.Clients = clients
+	}
+	if len(r.Clients) > 0 || r.Anonymous > 0 {
+		return false, nil
+	}
+	r.State, r.Finished = Cancelled, time.Now()
+	return true, nil
+}
+
+//Abort : cancels an active request for all the clients sharing it, returns ErrUnknown or ErrInactive if it cannot be
+//cancelled
+func (s *Store) Abort(request string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	r, err := s.active(request)
+	if err != nil {
+		return err
+	}
+	r.State, r.Finished = Cancelled, time.Now()
+	return nil
+}
+
+//active : returns the record of an active request, ErrUnknown or ErrInactive otherwise. mux must be held.
+func (s *Store) active(request string) (*Record, error) {
+	r, ok := s.Records[request]
+	if !ok {
+		return nil, ErrUnknown
+	}
+	if !r.Active() {
+		return nil, ErrInactive
+	}
+	return r, nil
+}
+
+//Remove : removes the record of a request
+func (s *Store) Remove(request string) {
+	s.mux.Lock()
+	delete(s.Records, request)
+	s.mux.Unlock()
+}
+
+//Active : returns the number of active requests submitted by a client
+func (s *Store) Active(client string) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	n := 0
+	for _, r := range s.Records {
+		if r.Active() && r.Has(client) {
+			n++
+		}
+	}
+	return n
+}
+
+//Get : returns a copy of the record of a request and whether it exists
+func (s *Store) Get(request string) (Record, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	r, ok := s.Records[request]
+	if !ok {
+		return Record{}, false
+	}
+	return r.copy(), true
+}
+
+//copy : returns a copy of the record that does not share its lists of clients, inputs and stages
+func (r *Record) copy() Record {
+	c := *r
+	c.Clients = append([]string(nil), r.Clients...)
+	c.Inputs = append([]string(nil), r.Inputs...)
+	c.Stages = append([]Stage(nil), r.Stages...)
+	return c
+}
+
+//Update : applies fn to the record of a request under the lock. It is a no-op for unknown requests.
+func (s *Store) Update(request string, fn func(r *Record)) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if r, ok := s.Records[request]; ok {
+		fn(r)
+	}
+}
+
+//List : returns a copy of all records
+func (s *Store) List() []Record {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	list := make([]Record, 0, len(s.Records))
+	for _, r := range s.Records {
+		list = append(list, r.copy())
+	}
+	return list
+}
+
+/*
+Save : Writes all records to a file so that they survive a restart of the edge node. The file is replaced atomically.
+Input: path of the file
+Output: error if the file could not be written
+*/
+func (s *Store) Save(path string) error {
+	data, err := json.MarshalIndent(s.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+/*
+Load : Restores the records written by Save. A missing file is not an error.
+Input: path of the file
+Output: error if the file could not be read or parsed
+*/
+func (s *Store) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var list []Record
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	for _, r := range list {
+		s.Add(r)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package requestrecord
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/library"
+)
+
+func TestSaveLoad(t *testing.T) {
+	launched := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		records []Record
+	}{
+		{name: "no records"},
+		{
+			name: "records",
+			records: []Record{
+				{Request: "r1", Application: "app", Service: "r1", Node: "n1", State: Launched, Launched: launched},
+				{Request: "r2", State: Reaped, ExitStatus: 1, Logs: "logs", Launched: launched,
+					Finished: launched.Add(time.Minute), Reaped: launched.Add(time.Hour)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			saved := New()
+			for _, r := range tt.records {
+				saved.Add(r)
+			}
+			if err := saved.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			s := New()
+			if err := s.Load(path); err != nil {
+				t.Fatal(err)
+			}
+			if len(s.Records) != len(tt.records) {
+				t.Fatalf("restored %d records, want %d", len(s.Records), len(tt.records))
+			}
+			for _, want := range tt.records {
+				if got, ok := s.Get(want.Request); !ok || !reflect.DeepEqual(got, want) {
+					t.Errorf("restored %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string //content of the file, no file if empty
+		wanterr bool
+	}{
+		{name: "missing file"},
+		{name: "malformed file", content: "{", wanterr: true},
+		{name: "empty list", content: "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := New()
+			if err := s.Load(path); (err != nil) != tt.wanterr {
+				t.Errorf("Load = %v, want error %v", err, tt.wanterr)
+			}
+			if len(s.Records) != 0 {
+				t.Errorf("%d records loaded", len(s.Records))
+			}
+		})
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name         string
+		state        string //state of an earlier record of the request of normal priority, none if empty
+		client       string
+		priority     string
+		wantshared   bool
+		wantclients  []string
+		wantpriority string
+		//wantanonymous : anonymous submissions of the request
+		wantanonymous int
+	}{
+		{name: "new request", client: "a", priority: library.Low, wantclients: []string{"a"},
+			wantpriority: library.Low},
+		{name: "anonymous client", wantclients: nil, wantanonymous: 1},
+		{name: "shared by an anonymous client", state: Launched, wantshared: true, wantclients: []string{"a"},
+			wantpriority: library.Normal, wantanonymous: 1},
+		{name: "request waiting", state: Submitted, client: "b", priority: library.Normal, wantshared: true,
+			wantclients: []string{"a", "b"}, wantpriority: library.Normal},
+		{name: "request running", state: Launched, client: "b", priority: library.Normal, wantshared: true,
+			wantclients: []string{"a", "b"}, wantpriority: library.Normal},
+		{name: "submitted again by the same client", state: Launched, client: "a", priority: library.Normal,
+			wantshared: true, wantclients: []string{"a"}, wantpriority: library.Normal},
+		{name: "priority raised", state: Submitted, client: "b", priority: library.Critical, wantshared: true,
+			wantclients: []string{"a", "b"}, wantpriority: library.Critical},
+		{name: "priority not lowered", state: Submitted, client: "b", priority: library.Low, wantshared: true,
+			wantclients: []string{"a", "b"}, wantpriority: library.Normal},
+		{name: "request finished", state: Completed, client: "b", priority: library.Low, wantclients: []string{"b"},
+			wantpriority: library.Low},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			if tt.state != "" {
+				s.Add(Record{Request: "req", Clients: []string{"a"}, Priority: library.Normal, State: tt.state})
+			}
+			submission := Record{Request: "req", Priority: tt.priority}
+			if tt.client != "" {
+				submission.Clients = []string{tt.client}
+			}
+			if shared := s.Submit(submission); shared != tt.wantshared {
+				t.Errorf("shared %v, want %v", shared, tt.wantshared)
+			}
+			r, _ := s.Get("req")
+			if !reflect.DeepEqual(r.Clients, tt.wantclients) || r.Priority != tt.wantpriority || !r.Active() {
+				t.Errorf("record %+v, want an active record of the clients %v with the priority %q", r,
+					tt.wantclients, tt.wantpriority)
+			}
+			if r.Anonymous != tt.wantanonymous {
+				t.Errorf("%d anonymous submissions, want %d", r.Anonymous, tt.wantanonymous)
+			}
+		})
+	}
+}
+
+func TestShare(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       string //state of an earlier record of the request, none if empty
+		wantshared  bool
+		wantclients []string //clients of the record afterwards, none if there is no record
+	}{
+		{name: "request active", state: Launched, wantshared: true, wantclients: []string{"a", "b"}},
+		{name: "request finished", state: Completed, wantclients: []string{"a"}},
+		{name: "unknown request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			if tt.state != "" {
+				s.Add(Record{Request: "req", Clients: []string{"a"}, State: tt.state})
+			}
+			if shared := s.Share(Record{Request: "req", Clients: []string{"b"}}); shared != tt.wantshared {
+				t.Errorf("Share = %v, want %v", shared, tt.wantshared)
+			}
+			r, _ := s.Get("req")
+			if !reflect.DeepEqual(r.Clients, tt.wantclients) || r.State != tt.state {
+				t.Errorf("record %+v, want the clients %v in the state %q", r, tt.wantclients, tt.state)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	s := New()
+	s.Add(Record{Request: "r1", Clients: []string{"a"}, State: Launched})
+	s.Add(Record{Request: "r2", Clients: []string{"a", "b"}, State: Submitted})
+	s.Add(Record{Request: "r3", Clients: []string{"a"}, State: Completed})
+	s.Submit(Record{Request: "r4", Clients: []string{"b"}})
+	s.Remove("r4")
+	tests := []struct {
+		client string
+		want   int
+	}{
+		{client: "a", want: 2},
+		{client: "b", want: 1},
+		{client: "c", want: 0},
+	}
+	for _, tt := range tests {
+		if got := s.Active(tt.client); got != tt.want {
+			t.Errorf("Active(%s) = %d, want %d", tt.client, got, tt.want)
+		}
+	}
+}
+
+func TestSubmitLifetime(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		expires     time.Time //end of the lifetime of the active subscription
+		submitted   time.Time //end of the lifetime asked for by the new submission
+		wantexpires time.Time
+		wantlive    bool
+	}{
+		{name: "lifetime extended", expires: now.Add(time.Minute), submitted: now.Add(time.Hour),
+			wantexpires: now.Add(time.Hour), wantlive: true},
+		{name: "lifetime kept", expires: now.Add(time.Hour), submitted: now.Add(time.Minute),
+			wantexpires: now.Add(time.Hour), wantlive: true},
+		{name: "submitted once", expires: now.Add(time.Minute), wantexpires: now.Add(time.Minute), wantlive: true},
+		{name: "expired", expires: now.Add(-time.Minute), wantexpires: now.Add(-time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.Add(Record{Request: "sub", State: Subscribed, Expires: tt.expires})
+			if shared := s.Submit(Record{Request: "sub", Expires: tt.submitted}); !shared {
+				t.Error("subscription waiting to run again not shared")
+			}
+			r, _ := s.Get("sub")
+			if !r.Expires.Equal(tt.wantexpires) || r.Live() != tt.wantlive || !r.Active() {
+				t.Errorf("subscription expiring at %v, live %v, want %v, %v", r.Expires, r.Live(), tt.wantexpires,
+					tt.wantlive)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name          string
+		state         string //state of the request, unknown request if empty
+		clients       []string
+		anonymous     int //anonymous submissions of the request
+		client        string
+		wanterr       error
+		wantcancelled bool
+		wantstate     string
+		wantclients   []string
+	}{
+		{name: "unknown request", client: "a", wanterr: ErrUnknown},
+		{name: "request finished", state: Completed, clients: []string{"a"}, client: "a", wanterr: ErrInactive,
+			wantstate: Completed, wantclients: []string{"a"}},
+		{name: "request of another client", state: Launched, clients: []string{"a"}, client: "b",
+			wanterr: ErrNotclient, wantstate: Launched, wantclients: []string{"a"}},
+		{name: "last client", state: Launched, clients: []string{"a"}, client: "a", wantcancelled: true,
+			wantstate: Cancelled},
+		{name: "shared request", state: Submitted, clients: []string{"a", "b"}, client: "a", wantstate: Submitted,
+			wantclients: []string{"b"}},
+		{name: "anonymous client of a request of clients", state: Subscribed, clients: []string{"a", "b"},
+			wanterr: ErrNotclient, wantstate: Subscribed, wantclients: []string{"a", "b"}},
+		{name: "anonymous client of a shared request", state: Launched, clients: []string{"a"}, anonymous: 1,
+			wantstate: Launched, wantclients: []string{"a"}},
+		{name: "one of several anonymous submissions", state: Launched, anonymous: 2, wantstate: Launched},
+		{name: "last submission", state: Launched, anonymous: 1, wantcancelled: true, wantstate: Cancelled},
+		{name: "client of a request shared with an anonymous client", state: Launched, clients: []string{"a"},
+			anonymous: 1, client: "a", wantstate: Launched},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			if tt.state != "" {
+				s.Add(Record{Request: "req", Clients: tt.clients, Anonymous: tt.anonymous, State: tt.state})
+			}
+			cancelled, err := s.Cancel("req", tt.client)
+			if err != tt.wanterr || cancelled != tt.wantcancelled {
+				t.Errorf("Cancel = %v, %v, want %v, %v", cancelled, err, tt.wantcancelled, tt.wanterr)
+			}
+			r, _ := s.Get("req")
+			if r.State != tt.wantstate || !reflect.DeepEqual(r.Clients, tt.wantclients) {
+				t.Errorf("record %+v, want %s for the clients %v", r, tt.wantstate, tt.wantclients)
+			}
+			if cancelled && r.Finished.IsZero() {
+				t.Error("cancelled request without its end")
+			}
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string //state of the request, unknown request if empty
+		wanterr   error
+		wantstate string
+	}{
+		{name: "unknown request", wanterr: ErrUnknown},
+		{name: "request finished", state: Completed, wanterr: ErrInactive, wantstate: Completed},
+		{name: "shared request", state: Subscribed, wantstate: Cancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			if tt.state != "" {
+				s.Add(Record{Request: "req", Clients: []string{"a", "b"}, Anonymous: 1, State: tt.state})
+			}
+			if err := s.Abort("req"); err != tt.wanterr {
+				t.Errorf("Abort = %v, want %v", err, tt.wanterr)
+			}
+			if r, _ := s.Get("req"); r.State != tt.wantstate {
+				t.Errorf("state %s, want %s", r.State, tt.wantstate)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+This file implements the discovery of the inputs of the applications fusing several IoT resources, e.g. a road
+condition query combining map tiles, camera frames and LIDAR scans of several vehicles. Each input is resolved to the
+freshest IoT resources satisfying it, by name or by type within the area of the location query of the request. The
+workload is placed on the edge node that minimises the data to move, i.e. the total size of the selected IoT resources
+it does not hold yet, and the task initiator gathers the missing ones there before the launch.
+*/
+
+package resourcediscovery
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//Inputsunavailable : reason of the failure of a request whose inputs are not all available on the edge cluster
+const Inputsunavailable = "inputs_unavailable"
+
+//Placedinput : An IoT resource of an input of the workload, the edge node it is taken from and its provenance
+type Placedinput struct {
+	Name, Resource, Node string
+	Provenance           resourcemanager.Provenance
+	//Size : size of the data of the IoT resource in bytes, 0 if unknown
+	Size int64
+}
+
+//selected : an IoT resource selected for an input and the edge nodes it is available on, the freshest first
+type selected struct {
+	input    string
+	replicas []resourcemanager.Match
+}
+
+//on : returns whether the selected IoT resource is available on an edge node
+func (s selected) on(node string) bool {
+	for _, m := range s.replicas {
+		if m.Node == node {
+			return true
+		}
+	}
+	return false
+}
+
+//weight : returns the data moved to gather the selected IoT resource, a resource of unknown size weighs a byte
+func (s selected) weight() int64 {
+	if size := s.replicas[0].Descriptor.Size; size > 0 {
+		return size
+	}
+	return 1
+}
+
+/*
+locate : Selects the freshest available IoT resources of each input of an application. An IoT resource is selected for
+one input only.
+Input: inputs of the application, record of the request whose location query bounds the area of the typed inputs
+Output: the selected IoT resources, error naming the first input lacking IoT resources
+*/
+func (d *Discovery) locate(inputs []library.Input, record requestrecord.Record) ([]selected, error) {
+	var chosen []selected
+	taken := map[string]bool{}
+	for _, in := range inputs {
+		var matches []resourcemanager.Match
+		if in.Type != "" {
+			q := geo.Query{Type: in.Type, Maxage: in.Maxage}
+			if record.Query != nil {
+				q.Area = record.Query.Area
+			}
+			matches = d.catalog.Find(q)
+		} else {
+			matches = d.catalog.Replicas(in.Resource)
+		}
+
+		//the replicas of a resource are grouped, in the order of freshness of the resources
+		index := map[string]int{}
+		var candidates []selected
+		for _, m := range matches {
+			if taken[m.Resource] {
+				continue
+			}
+			if in.Type == "" && in.Maxage > 0 && !m.Descriptor.Offloaded.IsZero() &&
+				time.Since(m.Descriptor.Offloaded) > in.Maxage {
+				continue
+			}
+			if i, ok := index[m.Resource]; ok {
+				candidates[i].replicas = append(candidates[i].replicas, m)
+				continue
+			}
+			index[m.Resource] = len(candidates)
+			candidates = append(candidates, selected{input: in.Name, replicas: []resourcemanager.Match{m}})
+		}
+		if len(candidates) < in.Min {
+			return nil, fmt.Errorf("input %s has %d of the %d resources it requires", in.Name, len(candidates), in.Min)
+		}
+		if len(candidates) > in.Wanted() {
+			candidates = candidates[:in.Wanted()]
+		}
+		for _, c := range candidates {
+			taken[c.replicas[0].Resource] = true
+		}
+		chosen = append(chosen, candidates...)
+	}
+	return chosen, nil
+}
+
+/*
+place : Chooses the edge node to launch the workload on, i.e. the edge node holding the selected IoT resources that
+minimises the data to move to it. On a tie the edge node the request was steered to by the admission control wins.
+Input: the selected IoT resources, edge node the request was steered to, empty if none
+Output: edge node, data to move in bytes
+*/
+func place(chosen []selected, preferred string) (string, int64) {
+	seen := map[string]bool{}
+	var nodes []string
+	for _, s := range chosen {
+		for _, m := range s.replicas {
+			if !seen[m.Node] {
+				seen[m.Node] = true
+				nodes = append(nodes, m.Node)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	if preferred != "" {
+		nodes = append([]string{preferred}, nodes...)
+	}
+
+	best, bestcost := "", int64(-1)
+	for _, node := range nodes {
+		var cost int64
+		for _, s := range chosen {
+			if !s.on(node) {
+				cost += s.weight()
+			}
+		}
+		if bestcost < 0 || cost < bestcost {
+			best, bestcost = node, cost
+		}
+	}
+	return best, bestcost
+}
+
+/*
+claiminputs : Claims the selected IoT resources, on the edge node of the workload when it holds them and on their
+freshest replica otherwise.
+Input: the selected IoT resources, edge node of the workload
+Output: the IoT resources claimed, those that vanished in between are left out
+*/
+func (d *Discovery) claiminputs(chosen []selected, node string) []Placedinput {
+	var placed []Placedinput
+	for _, s := range chosen {
+		resource, source := s.replicas[0].Resource, s.replicas[0].Node
+		if s.on(node) {
+			source = node
+		}
+		holder, provenance, ok := d.catalog.Claim(resource, source)
+		if !ok {
+			d.logger.Warn("selected resource is no longer available", "input", s.input, "resource", resource)
+			continue
+		}
+		placed = append(placed, Placedinput{Name: s.input, Resource: resource, Node: holder, Provenance: provenance,
+			Size: s.replicas[0].Descriptor.Size})
+	}
+	return placed
+}
@@ -0,0 +1,116 @@
+package resourcediscovery
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+var munich = geo.Box{Minlat: 48.1, Minlon: 11.5, Maxlat: 48.2, Maxlon: 11.6}
+
+func TestLocate(t *testing.T) {
+	now := time.Now()
+	//resources : IoT resources of the catalog, offloaded the given age ago over Munich on the given edge node
+	resources := []struct {
+		resource, node, kind string
+		age                  time.Duration
+	}{
+		{"map_1", "n1", "hd_map", time.Hour},
+		{"map_1", "n2", "hd_map", time.Hour},
+		{"frame_1", "n1", "camera_frame", 3 * time.Second},
+		{"frame_2", "n2", "camera_frame", 2 * time.Second},
+		{"frame_3", "n2", "camera_frame", time.Second},
+		{"frame_old", "n1", "camera_frame", time.Minute},
+	}
+	tests := []struct {
+		name    string
+		inputs  []library.Input
+		wanterr bool
+		//want : selected IoT resources as input:resource, the freshest first
+		want []string
+	}{
+		{name: "named resource", inputs: []library.Input{{Name: "map", Resource: "map_1", Min: 1}},
+			want: []string{"map:map_1"}},
+		{name: "freshest of a type", inputs: []library.Input{{Name: "camera", Type: "camera_frame", Min: 1, Max: 2}},
+			want: []string{"camera:frame_3", "camera:frame_2"}},
+		{name: "too old", inputs: []library.Input{{Name: "camera", Type: "camera_frame", Min: 4,
+			Maxage: 30 * time.Second}}, wanterr: true},
+		{name: "not enough resources", inputs: []library.Input{{Name: "lidar", Type: "lidar", Min: 1}}, wanterr: true},
+		{name: "resource selected once", inputs: []library.Input{{Name: "front", Type: "camera_frame", Min: 1},
+			{Name: "rear", Type: "camera_frame", Min: 1}}, want: []string{"front:frame_3", "rear:frame_2"}},
+		{name: "several inputs", inputs: []library.Input{{Name: "map", Type: "hd_map", Min: 1},
+			{Name: "camera", Type: "camera_frame", Min: 3, Maxage: 30 * time.Second}},
+			want: []string{"map:map_1", "camera:frame_3", "camera:frame_2", "camera:frame_1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			c := resourcemanager.NewCatalog(logger)
+			for _, r := range resources {
+				c.Add(r.resource, r.node, resourcemanager.Provenance{})
+				c.Describe(r.resource, r.node, resourcemanager.Descriptor{Type: r.kind, Footprint: munich,
+					Offloaded: now.Add(-r.age)})
+			}
+			d := New(c, requestrecord.New(), metrics.New(logger), logger)
+			chosen, err := d.locate(tt.inputs, requestrecord.Record{Query: &geo.Query{Area: munich}})
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("locate = %v, want error %v", err, tt.wanterr)
+			}
+			var got []string
+			for _, s := range chosen {
+				got = append(got, s.input+":"+s.replicas[0].Resource)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+//replica : returns an IoT resource of a size selected for an input on the given edge nodes
+func replica(resource string, size int64, nodes ...string) selected {
+	s := selected{input: "in"}
+	for _, node := range nodes {
+		s.replicas = append(s.replicas, resourcemanager.Match{Resource: resource, Node: node,
+			Descriptor: resourcemanager.Descriptor{Size: size}})
+	}
+	return s
+}
+
+func TestPlace(t *testing.T) {
+	tests := []struct {
+		name      string
+		chosen    []selected
+		preferred string
+		wantnode  string
+		wantmoved int64
+	}{
+		{name: "all inputs on one edge node", chosen: []selected{replica("a", 10, "n1", "n2"), replica("b", 10, "n2")},
+			wantnode: "n2"},
+		{name: "least data moved", chosen: []selected{replica("a", 100, "n1"), replica("b", 10, "n2")},
+			wantnode: "n1", wantmoved: 10},
+		{name: "tie", chosen: []selected{replica("a", 10, "n2"), replica("b", 10, "n1")}, wantnode: "n1",
+			wantmoved: 10},
+		{name: "tie won by the preferred edge node", chosen: []selected{replica("a", 10, "n1"), replica("b", 10, "n2")},
+			preferred: "n2", wantnode: "n2", wantmoved: 10},
+		{name: "preferred edge node holding nothing", chosen: []selected{replica("a", 10, "n1")}, preferred: "n2",
+			wantnode: "n1"},
+		{name: "resources of unknown size", chosen: []selected{replica("a", 0, "n1"), replica("b", 0, "n2"),
+			replica("c", 0, "n2")}, wantnode: "n2", wantmoved: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if node, moved := place(tt.chosen, tt.preferred); node != tt.wantnode || moved != tt.wantmoved {
+				t.Errorf("place = %s, %d, want %s, %d", node, moved, tt.wantnode, tt.wantmoved)
+			}
+		})
+	}
+}
@@ -5,6 +5,11 @@ It performs the following tasks:
 1. It discovers the location of the IoT resource on the edge cluster needed to execute an application
 to satisfy a client request. This determines the offloading location for the workload.
 2. It detects if the client request can be served by an ongiong workload on the edge cluster.
+3. It resolves the location query of a client request, if any, to the IoT resources of a type overlapping an area and
+fresh enough, the freshest of which is consumed by the workload.
+4. It locates all the inputs of an application fusing several IoT resources and places the workload where the least
+data has to be moved, see inputs.go.
+5. It places each stage of the pipeline of applications serving a client request, see stages.go.
 
 Author : Niket Agrawal
 
@@ -13,61 +18,164 @@ Author : Niket Agrawal
 package resourcediscovery
 
 import (
-	"fmt"
-	"sync"
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/metrics"
 	"github.com/niketagrawal/EDIRO/parser"
+	"github.com/niketagrawal/EDIRO/requestrecord"
 	"github.com/niketagrawal/EDIRO/resourcemanager"
+	"github.com/niketagrawal/EDIRO/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var targetnode int
-
+//Resourcediscoveryoutput : The output of resource discovery, i.e. the application to launch for a client request and the
+//edge node holding the IoT resource it needs along with the provenance of that resource
 type Resourcediscoveryoutput struct {
 	Request, Applicationtolaunch, Locationtolaunch string
+	Resource                                       string
+	Provenance                                     resourcemanager.Provenance
+	Arrived                                        time.Time
+	Ctx                                            context.Context
+	//Matches : all the IoT resources resolved by the location query of the request, empty without query
+	Matches []string
+	//Inputs : the IoT resources of the inputs of an application fusing several of them, see inputs.go
+	Inputs []Placedinput
+	//Stages : the stages of the pipeline serving the request in the order they can run, see stages.go
+	Stages []Placedstage
+}
+
+//Discovery : The resource discovery module of an edge node
+type Discovery struct {
+	//inflight : number of client requests for which resource discovery is ongoing
+	inflight atomic.Int64
+	catalog  *resourcemanager.Catalog
+	records  *requestrecord.Store
+	metrics  *metrics.Metrics
+	logger   *slog.Logger
+}
+
+//New : creates the resource discovery module of an edge node looking up the given catalog
+func New(catalog *resourcemanager.Catalog, records *requestrecord.Store, m *metrics.Metrics,
+	logger *slog.Logger) *Discovery {
+	return &Discovery{catalog: catalog, records: records, metrics: m, logger: logger}
 }
 
 /*
 DiscoverresourcesubGoroutine : function to which the task of performing resource discovery is delegated,
 runs as a go routine
 */
-func DiscoverresourcesubGoroutine(s parser.Parseroutput, chandiscov chan Resourcediscoveryoutput,
-	m *sync.Mutex) {
+func (d *Discovery) DiscoverresourcesubGoroutine(s parser.Parseroutput, chandiscov chan Resourcediscoveryoutput) {
+	defer d.inflight.Add(-1)
+	began := time.Now()
+	_, span := tracing.Tracer.Start(s.Ctx, "discovery")
+	defer span.End()
+
+	//the IoT resource is marked as used in the catalog to avoid it being detected by the resource monitoring algorithm.
+	//It is claimed on the edge node the request was steered to by the admission control, if any.
+	record, _ := d.records.Get(s.Request)
+	var out Resourcediscoveryoutput
 	var targetnode string
-out:
-	for key := range resourcemanager.Resourcetable {
-		for i := range resourcemanager.Resourcetable[key] {
-			if s.Resource == resourcemanager.Resourcetable[key][i] {
-				targetnode = key
-				m.Lock()
-				resourcemanager.Resourcetable[key][i] = "used" //adding a label to mark the IoT resource as used
-				//and avoid it being detected by the resource monitoring algorithm. Acquired Lock to ensure an
-				//atomic operation.
-				m.Unlock()
-				fmt.Println("targetnode is :", targetnode)
-				break out
+	var provenance resourcemanager.Provenance
+	resource := s.Resource
+	if len(s.Stages) > 0 {
+		stages, err := d.placestages(s.Stages, record)
+		if err != nil {
+			d.fail(s, err)
+			return
+		}
+		out.Stages = stages
+		//the pipeline takes the capacity of the edge node of its first stage
+		targetnode, resource, provenance = stages[0].Node, stages[0].Resource, stages[0].Provenance
+	} else if len(s.Inputs) > 0 {
+		chosen, err := d.locate(s.Inputs, record)
+		if err != nil {
+			d.fail(s, err)
+			return
+		}
+		var moved int64
+		targetnode, moved = place(chosen, record.Target)
+		out.Inputs = d.claiminputs(chosen, targetnode)
+		if len(out.Inputs) > 0 {
+			resource, provenance = out.Inputs[0].Resource, out.Inputs[0].Provenance
+		}
+		d.logger.Info("inputs located", logging.Request, s.Request, "inputs", len(out.Inputs), "node", targetnode,
+			"moved", moved)
+	} else {
+		if record.Query != nil {
+			if matches := d.catalog.Find(*record.Query); len(matches) > 0 {
+				resource = matches[0].Resource
+				for _, m := range matches {
+					out.Matches = append(out.Matches, m.Resource)
+					if m.Node == record.Target {
+						resource = m.Resource
+					}
+				}
+			} else {
+				d.logger.Warn("no resource matches the location query", logging.Request, s.Request,
+					"type", record.Query.Type, "area", record.Query.Area, "maxage", record.Query.Maxage)
 			}
 		}
+		targetnode, provenance, _ = d.catalog.Claim(resource, record.Target)
 	}
 
-	var out Resourcediscoveryoutput
 	out.Applicationtolaunch = s.Application
 	out.Locationtolaunch = targetnode
 	out.Request = s.Request
-	fmt.Println("Application and target node to launch are:", out)
+	out.Resource = resource
+	out.Provenance = provenance
+	out.Arrived = s.Arrived
+	out.Ctx = s.Ctx
+	d.logger.Info("application and target node to launch", logging.Request, out.Request, logging.Resource, resource,
+		"application", out.Applicationtolaunch, "node", out.Locationtolaunch, "contributor", provenance.Contributor,
+		"matches", len(out.Matches), "stages", len(out.Stages))
+	span.SetAttributes(attribute.String("ediro.node", targetnode))
+	d.metrics.Observestage(metrics.Discovery, began)
 
 	chandiscov <- out
 
 }
 
+//fail : ends a client request whose inputs are not all available on the edge cluster
+func (d *Discovery) fail(s parser.Parseroutput, err error) {
+	d.records.Update(s.Request, func(r *requestrecord.Record) {
+		if r.Active() {
+			r.State, r.Reason, r.Finished = requestrecord.Failed, Inputsunavailable, time.Now()
+		}
+	})
+	span := trace.SpanFromContext(s.Ctx)
+	span.SetStatus(codes.Error, Inputsunavailable)
+	span.End()
+	d.logger.Warn("inputs of the application are not available", logging.Request, s.Request,
+		"application", s.Application, "err", err)
+}
+
 //Discoverresource : It determines the presence and location of the IoT resource needed by an application.
 //Input: Receives a signal from detect duplicate function whether a fresh application needs to be launched or not
 //Output: provides the location to luanch a particular application. Request and application to launch are supplied as complimentary
-func Discoverresource(chanpo chan parser.Parseroutput, chandiscov chan Resourcediscoveryoutput, m *sync.Mutex) {
+//It returns when ctx is cancelled.
+func (d *Discovery) Discoverresource(ctx context.Context, chanpo chan parser.Parseroutput, chandiscov chan Resourcediscoveryoutput) {
 	for {
-		s := <-chanpo //acts on output from detect duplicate function
+		var s parser.Parseroutput
+		select {
+		case <-ctx.Done():
+			return
+		case s = <-chanpo: //acts on output from detect duplicate function
+		}
 
-		go DiscoverresourcesubGoroutine(s, chandiscov, m)
+		d.inflight.Add(1)
+		go d.DiscoverresourcesubGoroutine(s, chandiscov)
 
 	}
 
 }
+
+//Inflight : returns the number of client requests in resource discovery, used to drain the pipeline on shutdown
+func (d *Discovery) Inflight() int64 {
+	return d.inflight.Load()
+}
@@ -0,0 +1,73 @@
+/*
+This file implements the placement of the stages of the pipeline of applications serving a client request, e.g. a
+point cloud downsampling followed by an inference. Every stage is placed on its own: a stage fusing several IoT resources
+is placed like such an application (see inputs.go), a stage consuming one IoT resource on an edge node holding it, and a
+stage consuming the output of other stages only on the edge node of the first of them, so that its input does not move.
+*/
+
+package resourcediscovery
+
+import (
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//Placedstage : A stage of the pipeline serving a client request and the edge node it is launched on
+type Placedstage struct {
+	Name, Application, Node string
+	//After : stages whose output the stage consumes
+	After []string
+	//Resource, Provenance : the IoT resource consumed by the stage, empty if none
+	Resource   string
+	Provenance resourcemanager.Provenance
+	//Inputs : the IoT resources of the inputs of a stage fusing several of them
+	Inputs []Placedinput
+}
+
+/*
+placestages : Places the stages of the pipeline serving a client request and claims their IoT resources.
+Input: stages of the pipeline, record of the request
+Output: the stages in the order they can run, error if the pipeline is not valid or the inputs of a stage are not
+available
+*/
+func (d *Discovery) placestages(stages []library.Stage, record requestrecord.Record) ([]Placedstage, error) {
+	ordered, err := library.Order(stages)
+	if err != nil {
+		return nil, err
+	}
+	//the inputs of all stages are located before any is claimed
+	chosen := map[string][]selected{}
+	for _, stage := range ordered {
+		if inputs := library.ApptoInputs[stage.Application]; len(inputs) > 0 {
+			if chosen[stage.Name], err = d.locate(inputs, record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	located := map[string]string{}
+	var placed []Placedstage
+	for _, stage := range ordered {
+		p := Placedstage{Name: stage.Name, Application: stage.Application, After: stage.After}
+		if inputs, ok := chosen[stage.Name]; ok {
+			p.Node, _ = place(inputs, record.Target)
+			p.Inputs = d.claiminputs(inputs, p.Node)
+		} else if resource := library.ApptoResource[stage.Application]; resource != "" {
+			p.Resource = resource
+			p.Node, p.Provenance, _ = d.catalog.Claim(resource, record.Target)
+		}
+		if p.Node == "" && len(stage.After) > 0 {
+			p.Node = located[stage.After[0]]
+		}
+		if p.Node == "" {
+			p.Node = record.Target
+		}
+		located[stage.Name] = p.Node
+		d.logger.Debug("stage placed", logging.Request, record.Request, "stage", p.Name,
+			"application", p.Application, "node", p.Node)
+		placed = append(placed, p)
+	}
+	return placed, nil
+}
@@ -0,0 +1,62 @@
+package resourcediscovery
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+func TestPlacestages(t *testing.T) {
+	downsample := library.Stage{Name: "downsample", Application: "application_image_5"}
+	inference := library.Stage{Name: "inference", Application: "application_image_6", After: []string{"downsample"}}
+	fusion := library.Stage{Name: "fusion", Application: "application_image_4"}
+	tests := []struct {
+		name    string
+		stages  []library.Stage
+		held    map[string][]string //resources held by each edge node
+		wanterr bool
+		//wantnodes : edge node of each stage in order, wantclaimed : whether the resource of the first stage is used
+		wantnodes   []string
+		wantclaimed bool
+	}{
+		{name: "stage after the stage it consumes", stages: []library.Stage{inference, downsample},
+			held: map[string][]string{"n2": {"IoT_resource_5"}}, wantnodes: []string{"n2", "n2"}, wantclaimed: true},
+		{name: "resource not available", stages: []library.Stage{downsample, inference},
+			wantnodes: []string{"n1", "n1"}},
+		{name: "cycle", stages: []library.Stage{{Name: "a", After: []string{"b"}}, {Name: "b", After: []string{"a"}}},
+			wanterr: true},
+		{name: "inputs of a stage not available", stages: []library.Stage{fusion}, wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			c := resourcemanager.NewCatalog(logger)
+			for node, resources := range tt.held {
+				for _, resource := range resources {
+					c.Add(resource, node, resourcemanager.Provenance{})
+				}
+			}
+			d := New(c, requestrecord.New(), metrics.New(logger), logger)
+			placed, err := d.placestages(tt.stages, requestrecord.Record{Request: "req", Target: "n1"})
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("placestages = %v, want error %v", err, tt.wanterr)
+			}
+			var nodes []string
+			for _, p := range placed {
+				nodes = append(nodes, p.Node)
+			}
+			if !reflect.DeepEqual(nodes, tt.wantnodes) {
+				t.Errorf("stages placed on %v, want %v", nodes, tt.wantnodes)
+			}
+			if claimed := len(tt.held) > 0 && !c.Has("IoT_resource_5"); claimed != tt.wantclaimed {
+				t.Errorf("resource claimed %v, want %v", claimed, tt.wantclaimed)
+			}
+		})
+	}
+}
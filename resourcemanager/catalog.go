@@ -0,0 +1,452 @@
+/*
+This file implements the IoT resource catalog, i.e. the local system state of an edge node. It records which IoT
+resources are available on which edge node of the cluster, as learnt from the resources offloaded on this edge node
+and from the updates of the other edge nodes, along with the provenance of each resource, i.e. its content hash and the
+vehicle or device that contributed its data. In the consistent mode, the mutations of the catalog go through a
+replicated log and are applied to the catalog of every edge node in the same order (see consensus.go).
+*/
+
+package resourcemanager
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+)
+
+//monitorinterval : interval at which the resource monitor looks up the resource in use in the catalog
+const monitorinterval = 100 * time.Millisecond
+
+//Used : label replacing an IoT resource in the catalog once a workload has been launched on it
+const Used = "used"
+
+//Provenance : Where the data of an IoT resource held by an edge node comes from
+type Provenance struct {
+	//Hash : content hash of the IoT resource, e.g. sha256:<hex>, empty if unknown
+	Hash string
+	//Contributor : vehicle or device that contributed the data of the IoT resource, empty if unknown
+	Contributor string
+	//Verified : whether the announcement of the resource was signed by the edge node holding it
+	Verified bool
+	//Origin : edge node the IoT resource was copied from, empty if it was offloaded on the edge node holding it
+	Origin string
+}
+
+//Catalog : The IoT resource catalog of an edge node
+type Catalog struct {
+	//Resourcetable : Declaring the map to store information about IoT Resource availabiity on each edge node
+	//in the cluster
+	Resourcetable map[string][]string
+	//Provenance : provenance of each IoT resource on each edge node holding it, keyed by resource then edge node
+	Provenance map[string]map[string]Provenance
+	//index : type, footprint and offload time of the IoT resources indexed by area, see spatial.go
+	index *spatialindex
+	//watchers : channels closed at the next announcement of one of the IoT resources they watch
+	watchers map[chan struct{}]watch
+	//states : the edge nodes that are not active, see drain.go
+	states map[string]string
+	//lastused : time each IoT resource was last claimed on each edge node
+	lastused map[entry]time.Time
+	//claims : number of claims of each IoT resource on each edge node not released yet, i.e. of the Used labels it
+	//left in the resource table
+	claims map[entry]int
+	//evicted : content hash of the claimed IoT resources removed while claimed, forgotten once their last claim is
+	//released
+	evicted map[entry]string
+	//submit : hands a mutation to the replicated log in the consistent mode, nil in the best-effort mode
+	submit func(cmd Command) (Result, error)
+	mux    sync.Mutex
+	logger *slog.Logger
+}
+
+//NewCatalog : creates an empty IoT resource catalog
+func NewCatalog(logger *slog.Logger) *Catalog {
+	return &Catalog{Resourcetable: map[string][]string{}, Provenance: map[string]map[string]Provenance{},
+		index: newspatialindex(), watchers: map[chan struct{}]watch{}, states: map[string]string{},
+		lastused: map[entry]time.Time{}, claims: map[entry]int{},
+		evicted: map[entry]string{}, logger: logger}
+}
+
+/*
+Record : Records the availability of an IoT resource on an edge node along with its descriptor, see Add and Describe.
+In the consistent mode the resource is recorded through the replicated log.
+Input: IoT resource, edge node holding it, provenance and descriptor of the resource
+Output: number of resources held by that edge node, number of edge nodes holding resources, both 0 if the resource
+could not be recorded through the replicated log
+*/
+func (c *Catalog) Record(resource string, nodeID string, p Provenance, d Descriptor) (int, int) {
+	return c.record(Command{Op: Recordop, Resource: resource, Node: nodeID, Provenance: p, Descriptor: d})
+}
+
+//record : records an IoT resource as given by a record command, see Record
+func (c *Catalog) record(cmd Command) (int, int) {
+	if c.submit != nil {
+		r, err := c.submit(cmd)
+		if err != nil {
+			c.logger.Warn("could not record resource through the replicated log", logging.Resource, cmd.Resource,
+				"holder", cmd.Node, "err", err)
+		}
+		return r.Holderresources, r.Holders
+	}
+	holderresources, holders := c.Add(cmd.Resource, cmd.Node, cmd.Provenance)
+	c.Describe(cmd.Resource, cmd.Node, cmd.Descriptor)
+	return holderresources, holders
+}
+
+/*
+Add : Records the availability of an IoT resource on this replica of the catalog only, see Record.
+Input: IoT resource, edge node holding it, provenance of the resource
+Output: number of resources held by that edge node, number of edge nodes holding resources
+*/
+func (c *Catalog) Add(resource string, nodeID string, p Provenance) (int, int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.states[nodeID] == Departed {
+		delete(c.states, nodeID) //the edge node is back from maintenance
+	}
+	res := append(c.Resourcetable[nodeID], resource)
+	c.Resourcetable[nodeID] = res
+	if c.Provenance[resource] == nil {
+		c.Provenance[resource] = map[string]Provenance{}
+	}
+	c.Provenance[resource][nodeID] = p
+	return len(res), len(c.Resourcetable)
+}
+
+/*
+Claim : Finds an edge node holding an IoT resource and marks the resource as used to avoid it being detected by the
+resource monitoring algorithm. Finding and marking is an atomic operation. Resources on a draining edge node are not
+claimed. In the consistent mode the resource is claimed through the replicated log, i.e. reserved on every edge node.
+Input: IoT resource, edge node to claim the resource on if it holds it, empty for any
+Output: edge node holding the resource, provenance of the resource on it, whether the resource was found
+*/
+func (c *Catalog) Claim(resource string, preferred string) (string, Provenance, bool) {
+	if c.submit != nil {
+		r, err := c.submit(Command{Op: Claimop, Resource: resource, Node: preferred, Time: time.Now(),
+			Seed: rand.Int()})
+		if err != nil {
+			c.logger.Warn("could not claim resource through the replicated log", logging.Resource, resource,
+				"err", err)
+		}
+		return r.Node, r.Provenance, r.Ok
+	}
+	return c.claim(resource, preferred, time.Now(), -1)
+}
+
+//claim : claims an IoT resource, the edge nodes are tried in the order given by the seed, in a random order if negative
+func (c *Catalog) claim(resource string, preferred string, at time.Time, seed int) (string, Provenance, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.states[preferred] != Draining {
+		for i := range c.Resourcetable[preferred] {
+			if resource == c.Resourcetable[preferred][i] {
+				c.Resourcetable[preferred][i] = Used
+				c.lastused[entry{resource, preferred}] = at
+				c.claims[entry{resource, preferred}]++
+				return preferred, c.Provenance[resource][preferred], true
+			}
+		}
+	}
+	nodes := make([]string, 0, len(c.Resourcetable))
+	for key := range c.Resourcetable {
+		nodes = append(nodes, key)
+	}
+	if seed >= 0 && len(nodes) > 0 {
+		//every replica of the catalog claims the resource on the same edge node
+		sort.Strings(nodes)
+		first := seed % len(nodes)
+		nodes = append(nodes[first:], nodes[:first]...)
+	}
+	for _, key := range nodes {
+		if c.states[key] == Draining {
+			continue
+		}
+		for i := range c.Resourcetable[key] {
+			if resource == c.Resourcetable[key][i] {
+				c.Resourcetable[key][i] = Used
+				c.lastused[entry{resource, key}] = at
+				c.claims[entry{resource, key}]++
+				return key, c.Provenance[resource][key], true
+			}
+		}
+	}
+	return "", Provenance{}, false
+}
+
+/*
+Release : Makes an IoT resource claimed on an edge node available again, e.g. when the request it was claimed for is
+cancelled before its workload is launched. A resource that has no claim left on that edge node is left as is.
+Input: IoT resource, edge node it was claimed on
+Output: whether the resource was released
+*/
+func (c *Catalog) Release(resource string, nodeID string) bool {
+	if c.submit != nil {
+		r, err := c.submit(Command{Op: Releaseop, Resource: resource, Node: nodeID})
+		if err != nil {
+			c.logger.Warn("could not release resource through the replicated log", logging.Resource, resource,
+				"holder", nodeID, "err", err)
+		}
+		return r.Ok
+	}
+	return c.release(resource, nodeID)
+}
+
+//release : releases an IoT resource claimed on an edge node
+func (c *Catalog) release(resource string, nodeID string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	e := entry{resource, nodeID}
+	if c.claims[e] == 0 {
+		return false
+	}
+	for i := range c.Resourcetable[nodeID] {
+		if c.Resourcetable[nodeID][i] == Used {
+			//the Used labels of an edge node are alike, the claims tell which resource one stands for
+			c.Resourcetable[nodeID][i] = resource
+			c.unclaim(e)
+			if hash, ok := c.evicted[e]; ok && c.claims[e] == 0 {
+				delete(c.evicted, e)
+				if c.Provenance[resource][nodeID].Hash == hash {
+					c.forget(e)
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+//unclaim : forgets a claim of an IoT resource on an edge node, mux must be held
+func (c *Catalog) unclaim(e entry) {
+	if c.claims[e]--; c.claims[e] <= 0 {
+		delete(c.claims, e)
+	}
+}
+
+/*
+Remove : Forgets an IoT resource held by an edge node, e.g. evicted from its storage. A version of the resource other
+than the one removed, i.e. with another content hash, is kept. A resource claimed on that edge node is forgotten once
+its last claim is released, so that the workloads it was claimed for keep a consistent view of it.
+Input: IoT resource, edge node holding it, content hash of the version removed
+Output: whether the resource was removed, false if it is claimed and its removal deferred
+*/
+func (c *Catalog) Remove(resource string, nodeID string, hash string) bool {
+	if c.submit != nil {
+		r, err := c.submit(Command{Op: Removeop, Resource: resource, Node: nodeID, Hash: hash})
+		if err != nil {
+			c.logger.Warn("could not remove resource through the replicated log", logging.Resource, resource,
+				"holder", nodeID, "err", err)
+		}
+		return r.Ok
+	}
+	return c.remove(resource, nodeID, hash)
+}
+
+//remove : forgets a version of an IoT resource held by an edge node
+func (c *Catalog) remove(resource string, nodeID string, hash string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if p, ok := c.Provenance[resource][nodeID]; !ok || p.Hash != hash {
+		return false
+	}
+	e := entry{resource, nodeID}
+	if c.claims[e] > 0 {
+		c.evicted[e] = hash
+		c.logger.Info("removal of claimed resource deferred until it is released", logging.Resource, resource,
+			"holder", nodeID, "claims", c.claims[e])
+		return false
+	}
+	c.forget(e)
+	return true
+}
+
+//forget : forgets an IoT resource held by an edge node that has no claim left on it, mux must be held
+func (c *Catalog) forget(e entry) {
+	delete(c.Provenance[e.resource], e.node)
+	if len(c.Provenance[e.resource]) == 0 {
+		delete(c.Provenance, e.resource)
+	}
+	c.index.remove(e)
+	delete(c.lastused, e)
+	var resources []string
+	for _, r := range c.Resourcetable[e.node] {
+		if r != e.resource {
+			resources = append(resources, r)
+		}
+	}
+	c.Resourcetable[e.node] = resources
+}
+
+//Has : returns whether an IoT resource is available on any edge node that is not draining
+func (c *Catalog) Has(resource string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for key := range c.Resourcetable {
+		if c.available(resource, key) {
+			return true
+		}
+	}
+	return false
+}
+
+//Holders : returns the edge nodes that are not draining on which an IoT resource is available
+func (c *Catalog) Holders(resource string) []string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var holders []string
+	for key := range c.Resourcetable {
+		if c.available(resource, key) {
+			holders = append(holders, key)
+		}
+	}
+	return holders
+}
+
+//Lookup : returns the provenance of an IoT resource on an edge node and whether it was ever recorded on that edge node
+func (c *Catalog) Lookup(resource string, nodeID string) (Provenance, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	p, ok := c.Provenance[resource][nodeID]
+	return p, ok
+}
+
+//Snapshot : returns a copy of the resource table
+func (c *Catalog) Snapshot() map[string][]string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	table := make(map[string][]string, len(c.Resourcetable))
+	for key, resources := range c.Resourcetable {
+		table[key] = append([]string(nil), resources...)
+	}
+	return table
+}
+
+//Entry : An IoT resource recorded on an edge node, whether or not it is used by a workload
+type Entry struct {
+	Match
+	//Available : whether the IoT resource is not used by a workload yet and its edge node is not draining
+	Available bool
+	//Lastused : time the IoT resource was last claimed on the edge node, the zero time if never
+	Lastused time.Time
+	//Claimed : whether a claim of the IoT resource on the edge node was not released yet
+	Claimed bool
+}
+
+//Entries : returns every IoT resource recorded on every edge node, sorted by resource then edge node
+func (c *Catalog) Entries() []Entry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var entries []Entry
+	for resource, holders := range c.Provenance {
+		for node, p := range holders {
+			entries = append(entries, Entry{Match: Match{Resource: resource, Node: node,
+				Descriptor: c.index.descriptors[entry{resource, node}], Provenance: p},
+				Available: c.available(resource, node), Lastused: c.lastused[entry{resource, node}],
+				Claimed: c.claims[entry{resource, node}] > 0})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Resource != entries[j].Resource {
+			return entries[i].Resource < entries[j].Resource
+		}
+		return entries[i].Node < entries[j].Node
+	})
+	return entries
+}
+
+//Locations : returns the edge nodes that are not draining recorded as holding an IoT resource, whether or not it is used
+//by a workload, sorted by edge node
+func (c *Catalog) Locations(resource string) []Entry {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var locations []Entry
+	for node, p := range c.Provenance[resource] {
+		if c.states[node] == Draining {
+			continue
+		}
+		locations = append(locations, Entry{Match: Match{Resource: resource, Node: node,
+			Descriptor: c.index.descriptors[entry{resource, node}], Provenance: p},
+			Available: c.available(resource, node), Lastused: c.lastused[entry{resource, node}],
+			Claimed: c.claims[entry{resource, node}] > 0})
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Node < locations[j].Node })
+	return locations
+}
+
+//watch : the names and the types of the IoT resources watched through a channel
+type watch struct {
+	resources, types []string
+}
+
+/*
+Watch : Returns a channel closed the next time an IoT resource of one of the given names or types is recorded in the
+catalog, e.g. a new version of a resource offloaded on any edge node.
+Input: names of the IoT resources, types of the IoT resources
+Output: the channel, to be handed to Unwatch if it is no longer waited on
+*/
+func (c *Catalog) Watch(resources []string, types []string) chan struct{} {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	ch := make(chan struct{})
+	c.watchers[ch] = watch{resources: resources, types: types}
+	return ch
+}
+
+//Unwatch : removes a channel returned by Watch that is no longer waited on
+func (c *Catalog) Unwatch(ch chan struct{}) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.watchers, ch)
+}
+
+//notify : closes the channels watching an IoT resource that was recorded, mux must be held
+func (c *Catalog) notify(resource string, d Descriptor) {
+	for ch, w := range c.watchers {
+		if contains(w.resources, resource) || (d.Type != "" && contains(w.types, d.Type)) {
+			close(ch)
+			delete(c.watchers, ch)
+		}
+	}
+}
+
+//contains : returns whether a list holds a string
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ResourceMonitor : It monitors the availability of a new version of an IoT resource that is currently in use
+and comunicates its arrival to task initiator to take necessary actions.
+Input: context whose cancellation stops the monitoring, the resource to monitor as provided by task initiator module,
+channel of type bool which is closed when application completes its execution signalling stopping of resource monitoring
+Output: Nil (currently, only a statement is printed on the console to signal the new version of resource found)
+*/
+func (c *Catalog) ResourceMonitor(ctx context.Context, resourceToMonitor chan string, isComplete chan bool) {
+	resourcetofind := <-resourceToMonitor
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-isComplete:
+			c.logger.Debug("application has terminated, no more resource monitoring required", logging.Resource, resourcetofind)
+			return
+
+		case <-time.After(monitorinterval):
+			if c.Has(resourcetofind) {
+				c.logger.Info("new version found of resource in use", logging.Resource, resourcetofind)
+				return
+			}
+		}
+	}
+}
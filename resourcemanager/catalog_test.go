@@ -0,0 +1,459 @@
+package resourcemanager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func newcatalog() *Catalog {
+	return NewCatalog(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestClaim(t *testing.T) {
+	tests := []struct {
+		name      string
+		held      map[string][]string //resources held by each edge node
+		resource  string
+		preferred string
+		wantnode  string
+		want      bool
+		//wanttable : resource table after the claim, wantholders : edge nodes the resource is still available on
+		wanttable   map[string][]string
+		wantholders []string
+	}{
+		{
+			name: "resource held", held: map[string][]string{"n1": {"a", "r"}}, resource: "r", wantnode: "n1",
+			want: true, wanttable: map[string][]string{"n1": {"a", Used}},
+		},
+		{
+			name: "unknown resource", held: map[string][]string{"n1": {"a"}}, resource: "r",
+			wanttable: map[string][]string{"n1": {"a"}},
+		},
+		{
+			name: "preferred edge node", held: map[string][]string{"n1": {"r"}, "n2": {"r"}}, resource: "r",
+			preferred: "n2", wantnode: "n2", want: true, wanttable: map[string][]string{"n1": {"r"}, "n2": {Used}},
+			wantholders: []string{"n1"},
+		},
+		{
+			name: "preferred edge node without the resource", held: map[string][]string{"n1": {"r"}, "n2": {"a"}},
+			resource: "r", preferred: "n2", wantnode: "n1", want: true,
+			wanttable: map[string][]string{"n1": {Used}, "n2": {"a"}},
+		},
+		{
+			name: "claimed once per copy", held: map[string][]string{"n1": {Used}}, resource: "r",
+			wanttable: map[string][]string{"n1": {Used}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			for node, resources := range tt.held {
+				for _, resource := range resources {
+					c.Add(resource, node, Provenance{Hash: "h-" + node})
+				}
+			}
+			if has := c.Has(tt.resource); has != tt.want {
+				t.Errorf("Has = %v, want %v", has, tt.want)
+			}
+			node, p, ok := c.Claim(tt.resource, tt.preferred)
+			if node != tt.wantnode || ok != tt.want {
+				t.Errorf("Claim = %s, %v, want %s, %v", node, ok, tt.wantnode, tt.want)
+			}
+			if ok && p.Hash != "h-"+node {
+				t.Errorf("claimed with the provenance %+v of another edge node", p)
+			}
+			if holders := c.Holders(tt.resource); !reflect.DeepEqual(holders, tt.wantholders) {
+				t.Errorf("resource available on %v, want %v", holders, tt.wantholders)
+			}
+			if table := c.Snapshot(); !reflect.DeepEqual(table, tt.wanttable) {
+				t.Errorf("resource table %v, want %v", table, tt.wanttable)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	tests := []struct {
+		name      string
+		held      []string //resource table of the edge node n1
+		resource  string
+		want      bool
+		wanttable []string
+	}{
+		{name: "claimed resource", held: []string{"a", "r"}, resource: "r", want: true, wanttable: []string{"a", "r"}},
+		{name: "one of two copies claimed", held: []string{"r", "r"}, resource: "r", want: true,
+			wanttable: []string{"r", "r"}},
+		{name: "resource not held", held: []string{"a"}, resource: "r", wanttable: []string{Used}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			for _, resource := range tt.held {
+				c.Add(resource, "n1", Provenance{})
+			}
+			c.Claim(tt.held[len(tt.held)-1], "")
+			if released := c.Release(tt.resource, "n1"); released != tt.want {
+				t.Errorf("Release = %v, want %v", released, tt.want)
+			}
+			if table := c.Snapshot()["n1"]; !reflect.DeepEqual(table, tt.wanttable) {
+				t.Errorf("resource table %v, want %v", table, tt.wanttable)
+			}
+		})
+	}
+}
+
+//held : An IoT resource recorded on an edge node with its content hash
+type held struct {
+	resource, node, hash string
+}
+
+//op : A claim, release or removal of an IoT resource on an edge node and its expected outcome
+type op struct {
+	do, resource, node, hash string
+	want                     bool
+	//wantnode : edge node a claim is expected on
+	wantnode string
+}
+
+func TestClaimReleaseRemove(t *testing.T) {
+	tests := []struct {
+		name     string
+		held     []held
+		draining []string
+		ops      []op
+		//wantholders : edge nodes r is available on at the end, wantrecorded : whether r is still recorded on n1
+		wantholders  []string
+		wantrecorded bool
+		wanthash     string
+	}{
+		{
+			name:        "claim on the preferred edge node",
+			held:        []held{{"r", "n1", "h1"}, {"r", "n2", "h1"}},
+			ops:         []op{{do: "claim", resource: "r", node: "n2", want: true, wantnode: "n2"}},
+			wantholders: []string{"n1"}, wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "claim on any edge node",
+			held: []held{{"r", "n1", "h1"}},
+			ops: []op{{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "claim", resource: "r"}},
+			wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "claim of an unknown resource",
+			ops:  []op{{do: "claim", resource: "r", node: "n1"}},
+		},
+		{
+			name:         "draining edge node is skipped",
+			held:         []held{{"r", "n1", "h1"}, {"r", "n2", "h1"}},
+			draining:     []string{"n1"},
+			ops:          []op{{do: "claim", resource: "r", node: "n1", want: true, wantnode: "n2"}},
+			wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "release makes the resource available again",
+			held: []held{{"r", "n1", "h1"}},
+			ops: []op{{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "release", resource: "r", node: "n1", want: true}},
+			wantholders: []string{"n1"}, wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name:        "release without claim",
+			held:        []held{{"r", "n1", "h1"}},
+			ops:         []op{{do: "release", resource: "r", node: "n1"}},
+			wantholders: []string{"n1"}, wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "release once per claim",
+			held: []held{{"r", "n1", "h1"}},
+			ops: []op{{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "release", resource: "r", node: "n1", want: true},
+				{do: "release", resource: "r", node: "n1"}},
+			wantholders: []string{"n1"}, wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "release of a resource claimed on another edge node",
+			held: []held{{"r", "n1", "h1"}, {"r", "n2", "h1"}},
+			ops: []op{{do: "claim", resource: "r", node: "n1", want: true, wantnode: "n1"},
+				{do: "release", resource: "r", node: "n2"}},
+			wantholders: []string{"n2"}, wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "release does not free the claim of another resource",
+			held: []held{{"r", "n1", "h1"}, {"other", "n1", "h2"}},
+			ops: []op{{do: "claim", resource: "r", node: "n1", want: true, wantnode: "n1"},
+				{do: "release", resource: "other", node: "n1"},
+				{do: "claim", resource: "other", node: "n1", want: true, wantnode: "n1"}},
+			wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name:        "remove",
+			held:        []held{{"r", "n1", "h1"}, {"r", "n2", "h1"}},
+			ops:         []op{{do: "remove", resource: "r", node: "n1", hash: "h1", want: true}},
+			wantholders: []string{"n2"},
+		},
+		{
+			name:        "remove of another version",
+			held:        []held{{"r", "n1", "h1"}},
+			ops:         []op{{do: "remove", resource: "r", node: "n1", hash: "h0"}},
+			wantholders: []string{"n1"}, wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "remove of a claimed resource is deferred",
+			held: []held{{"r", "n1", "h1"}},
+			ops: []op{{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "remove", resource: "r", node: "n1", hash: "h1"}},
+			wantrecorded: true, wanthash: "h1",
+		},
+		{
+			name: "deferred remove once the last claim is released",
+			held: []held{{"r", "n1", "h1"}, {"r", "n1", "h1"}},
+			ops: []op{{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "remove", resource: "r", node: "n1", hash: "h1"},
+				{do: "release", resource: "r", node: "n1", want: true},
+				{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "release", resource: "r", node: "n1", want: true},
+				{do: "release", resource: "r", node: "n1", want: true}},
+		},
+		{
+			name: "deferred remove of a version replaced meanwhile",
+			held: []held{{"r", "n1", "h1"}},
+			ops: []op{{do: "claim", resource: "r", want: true, wantnode: "n1"},
+				{do: "remove", resource: "r", node: "n1", hash: "h1"},
+				{do: "add", resource: "r", node: "n1", hash: "h2"},
+				{do: "release", resource: "r", node: "n1", want: true}},
+			wantholders: []string{"n1"}, wantrecorded: true, wanthash: "h2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			for _, h := range tt.held {
+				c.Add(h.resource, h.node, Provenance{Hash: h.hash})
+			}
+			for _, node := range tt.draining {
+				c.states[node] = Draining
+			}
+			for i, o := range tt.ops {
+				var got bool
+				var node string
+				switch o.do {
+				case "claim":
+					node, _, got = c.Claim(o.resource, o.node)
+				case "release":
+					got = c.Release(o.resource, o.node)
+				case "remove":
+					got = c.Remove(o.resource, o.node, o.hash)
+				case "add":
+					c.Add(o.resource, o.node, Provenance{Hash: o.hash})
+					continue
+				}
+				if got != o.want || node != o.wantnode {
+					t.Errorf("%s %d of %s on %q = %v on %q, want %v on %q", o.do, i, o.resource, o.node, got,
+						node, o.want, o.wantnode)
+				}
+			}
+			holders := c.Holders("r")
+			sort.Strings(holders)
+			if holders = append([]string(nil), holders...); !reflect.DeepEqual(holders, tt.wantholders) {
+				t.Errorf("holders %v, want %v", holders, tt.wantholders)
+			}
+			p, recorded := c.Lookup("r", "n1")
+			if recorded != tt.wantrecorded || p.Hash != tt.wanthash {
+				t.Errorf("recorded on n1 %v with hash %q, want %v with hash %q", recorded, p.Hash,
+					tt.wantrecorded, tt.wanthash)
+			}
+		})
+	}
+}
+
+func TestEntries(t *testing.T) {
+	c := newcatalog()
+	c.Add("b", "n1", Provenance{Hash: "hb"})
+	c.Add("a", "n2", Provenance{Hash: "ha"})
+	c.Add("a", "n1", Provenance{Hash: "ha"})
+	c.Describe("a", "n2", Descriptor{Type: "hd_map", Size: 10})
+	c.Claim("a", "n2")
+	want := []Entry{
+		{Match: Match{Resource: "a", Node: "n1", Provenance: Provenance{Hash: "ha"}}, Available: true},
+		{Match: Match{Resource: "a", Node: "n2", Descriptor: Descriptor{Type: "hd_map", Size: 10},
+			Provenance: Provenance{Hash: "ha"}}, Claimed: true},
+		{Match: Match{Resource: "b", Node: "n1", Provenance: Provenance{Hash: "hb"}}, Available: true},
+	}
+	entries := c.Entries()
+	if len(entries) == len(want) && entries[1].Lastused.IsZero() {
+		t.Error("claimed resource without the time it was last used")
+	}
+	if !reflect.DeepEqual(notused(entries), want) {
+		t.Errorf("entries %+v, want %+v", entries, want)
+	}
+}
+
+//notused : returns entries without the time they were last used
+func notused(entries []Entry) []Entry {
+	for i := range entries {
+		entries[i].Lastused = time.Time{}
+	}
+	return entries
+}
+
+func TestLocations(t *testing.T) {
+	c := newcatalog()
+	c.Add("r", "n3", Provenance{Hash: "h", Origin: "n1"})
+	c.Add("r", "n1", Provenance{Hash: "h"})
+	c.Add("r", "n2", Provenance{Hash: "h", Origin: "n1"})
+	c.Add("a", "n1", Provenance{})
+	c.Claim("r", "n1")
+	c.Setstate("n2", Draining)
+	want := []Entry{
+		{Match: Match{Resource: "r", Node: "n1", Provenance: Provenance{Hash: "h"}}, Claimed: true},
+		{Match: Match{Resource: "r", Node: "n3", Provenance: Provenance{Hash: "h", Origin: "n1"}}, Available: true},
+	}
+	if locations := c.Locations("r"); !reflect.DeepEqual(notused(locations), want) {
+		t.Errorf("locations %+v, want %+v", locations, want)
+	}
+	if locations := c.Locations("unknown"); len(locations) != 0 {
+		t.Errorf("locations %+v of an unknown resource", locations)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name        string
+		claimed     bool //whether the resource is used by a workload
+		hash        string
+		want        bool
+		wanttable   []string
+		wantholders []string
+	}{
+		{name: "available resource", hash: "h", want: true, wanttable: []string{"a"}},
+		{name: "claimed resource", claimed: true, hash: "h", wanttable: []string{Used, "a"}},
+		{name: "newer version", hash: "old", wanttable: []string{"r", "a"}, wantholders: []string{"n1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			c.Add("r", "n1", Provenance{Hash: "h"})
+			c.Add("a", "n1", Provenance{})
+			c.Describe("r", "n1", Descriptor{Type: "hd_map", Footprint: munich})
+			if tt.claimed {
+				c.Claim("r", "n1")
+			}
+			if removed := c.Remove("r", "n1", tt.hash); removed != tt.want {
+				t.Errorf("Remove = %v, want %v", removed, tt.want)
+			}
+			if table := c.Snapshot()["n1"]; !reflect.DeepEqual(table, tt.wanttable) {
+				t.Errorf("resource table %v, want %v", table, tt.wanttable)
+			}
+			if holders := c.Holders("r"); !reflect.DeepEqual(holders, tt.wantholders) {
+				t.Errorf("resource available on %v, want %v", holders, tt.wantholders)
+			}
+			if _, indexed := c.index.descriptors[entry{"r", "n1"}]; indexed == tt.want {
+				t.Errorf("resource left in the spatial index %v, want %v", indexed, !tt.want)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	c := newcatalog()
+	tests := []struct {
+		resource, node      string
+		wantheld, wantnodes int
+	}{
+		{resource: "a", node: "n1", wantheld: 1, wantnodes: 1},
+		{resource: "b", node: "n1", wantheld: 2, wantnodes: 1},
+		{resource: "a", node: "n2", wantheld: 1, wantnodes: 2},
+	}
+	for _, tt := range tests {
+		if held, nodes := c.Add(tt.resource, tt.node, Provenance{}); held != tt.wantheld || nodes != tt.wantnodes {
+			t.Errorf("Add(%s, %s) = %d, %d, want %d, %d", tt.resource, tt.node, held, nodes, tt.wantheld,
+				tt.wantnodes)
+		}
+	}
+	c.Snapshot()["n1"][0] = Used
+	if !c.Has("a") {
+		t.Error("snapshot shares the resource table")
+	}
+}
+
+func TestResourceMonitor(t *testing.T) {
+	tests := []struct {
+		name     string
+		arrives  bool //whether a new version of the resource arrives
+		complete bool //whether the application completes
+	}{
+		{name: "new version found", arrives: true},
+		{name: "application completed", complete: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			resource, isComplete := make(chan string, 1), make(chan bool)
+			resource <- "r"
+			done := make(chan struct{})
+			go func() {
+				c.ResourceMonitor(context.Background(), resource, isComplete)
+				close(done)
+			}()
+			if tt.arrives {
+				c.Add("r", "n2", Provenance{})
+			}
+			if tt.complete {
+				close(isComplete)
+			}
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("resource monitoring did not stop")
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []string //names watched
+		types     []string //types watched
+		unwatch   bool     //whether the watch is removed before the announcement
+		announced string
+		kind      string //type of the resource announced
+		wantclose bool
+	}{
+		{name: "resource announced", resources: []string{"r"}, announced: "r", wantclose: true},
+		{name: "type announced", types: []string{"hd_map"}, announced: "map_2", kind: "hd_map", wantclose: true},
+		{name: "other resource", resources: []string{"r"}, types: []string{"hd_map"}, announced: "s", kind: "lidar"},
+		{name: "resource without type", types: []string{"hd_map"}, announced: "s"},
+		{name: "watch removed", resources: []string{"r"}, unwatch: true, announced: "r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			ch := c.Watch(tt.resources, tt.types)
+			if tt.unwatch {
+				c.Unwatch(ch)
+			}
+			c.Add(tt.announced, "n1", Provenance{})
+			c.Describe(tt.announced, "n1", Descriptor{Type: tt.kind})
+			closed := false
+			select {
+			case <-ch:
+				closed = true
+			default:
+			}
+			if closed != tt.wantclose {
+				t.Errorf("watch closed %v, want %v", closed, tt.wantclose)
+			}
+			if left := len(c.watchers); left != 0 && (tt.wantclose || tt.unwatch) {
+				t.Errorf("%d watches left", left)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+/*
+This file implements the consistent mode of the catalog. By default the catalog is eventually consistent: the IoT
+resources offloaded on an edge node are broadcast on a best-effort basis and a resource claimed for a workload is only
+marked as used in the catalog of the edge node that claimed it. In the consistent mode, the mutations of the catalog,
+i.e. the IoT resources recorded, claimed, released and removed and the states of the edge nodes, are commands appended
+to a log replicated across the edge cluster (see package consensus). Every edge node applies the commands to its
+catalog in the order of the log, so that a resource claimed on one edge node is reserved on all of them. An edge node
+that does not lead the log hands its commands to the leader and waits until its own catalog applied them. Reads are
+served by the local catalog, and are linearizable when Linearize is called first.
+*/
+
+package resourcemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//committimeout : time given to a command to be committed to the replicated log, elections included
+const committimeout = 5 * time.Second
+
+//retryinterval : interval between two attempts to reach the leader of the replicated log
+const retryinterval = 50 * time.Millisecond
+
+//Operations of the commands of the replicated log
+const (
+	Recordop  = "record"
+	Claimop   = "claim"
+	Releaseop = "release"
+	Removeop  = "remove"
+	Stateop   = "state"
+)
+
+//ErrNotleader : returned by a Log when the edge node does not lead the replicated log
+var ErrNotleader = errors.New("edge node does not lead the replicated log")
+
+//errnoleader : no leader of the replicated log is known or reachable, e.g. during an election
+var errnoleader = errors.New("no leader of the replicated log")
+
+//retryable : returns whether a command or a read can be handed again to the leader, i.e. it was not appended
+func retryable(err error) bool {
+	return errors.Is(err, ErrNotleader) || errors.Is(err, errnoleader)
+}
+
+//leadererror : returns the error of a call to the leader, the leader was not reached or no longer leads the log
+func leadererror(err error) error {
+	switch status.Code(err) {
+	case codes.FailedPrecondition:
+		return ErrNotleader //the leader changed in between
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %v", errnoleader, err) //the leader went down, a new one is being elected
+	}
+	return err
+}
+
+//Command : A mutation of the catalog appended to the replicated log
+type Command struct {
+	Op string
+	//Resource, Node : IoT resource and edge node the command is about, Node is the preferred edge node of a claim
+	Resource, Node string
+	//Provenance, Descriptor : the IoT resource recorded
+	Provenance Provenance
+	Descriptor Descriptor
+	//Hash : content hash of the version removed
+	Hash string
+	//State : state of the edge node
+	State string
+	//Time, Seed : time of a claim and order in which the edge nodes are tried, the same on every edge node
+	Time time.Time
+	Seed int
+	//Update : the update announcing the IoT resource recorded, signed by the edge node holding it when it has its
+	//credentials. Under mutual TLS the leader only appends the records of other edge nodes that come with it.
+	Update *pb.TableUpdate `json:",omitempty"`
+}
+
+//announces : returns whether a record is about the IoT resource announced by an update
+func (cmd Command) announces(n Newresource) bool {
+	return n.Resource == cmd.Resource && n.NodeID == cmd.Node && n.Hash == cmd.Provenance.Hash &&
+		n.Contributor == cmd.Provenance.Contributor && n.Origin == cmd.Provenance.Origin &&
+		n.Type == cmd.Descriptor.Type && n.Footprint == cmd.Descriptor.Footprint && n.Size == cmd.Descriptor.Size
+}
+
+//Result : The result of a command applied to the catalog
+type Result struct {
+	//Node, Provenance : edge node the IoT resource was claimed on and its provenance there
+	Node       string
+	Provenance Provenance
+	//Ok : whether the IoT resource was claimed, released or removed
+	Ok bool
+	//Holderresources, Holders : resources held by the edge node and edge nodes holding resources once recorded
+	Holderresources, Holders int
+}
+
+//Log : The log the mutations of the catalog are replicated with in the consistent mode, see package consensus
+type Log interface {
+	//Leading : returns whether this edge node leads the log
+	Leading() bool
+	//Leader : returns the listening address of the edge node leading the log, empty if unknown
+	Leader() string
+	//Apply : appends a command to the log, on the leader only, and returns its result and index once applied
+	Apply(command []byte, timeout time.Duration) (Result, uint64, error)
+	//Barrier : returns, on the leader only, the index of the log once every command before it was applied
+	Barrier(timeout time.Duration) (uint64, error)
+	//Wait : waits until the catalog of this edge node applied the log up to an index
+	Wait(ctx context.Context, index uint64) error
+}
+
+/*
+Execute : Applies a command of the replicated log to the catalog. Every edge node gets the same result for the same
+commands applied in the same order.
+Input: the command
+Output: its result
+*/
+func (c *Catalog) Execute(cmd Command) Result {
+	switch cmd.Op {
+	case Recordop:
+		holderresources, holders := c.Add(cmd.Resource, cmd.Node, cmd.Provenance)
+		c.Describe(cmd.Resource, cmd.Node, cmd.Descriptor)
+		return Result{Holderresources: holderresources, Holders: holders}
+	case Claimop:
+		node, provenance, ok := c.claim(cmd.Resource, cmd.Node, cmd.Time, cmd.Seed)
+		return Result{Node: node, Provenance: provenance, Ok: ok}
+	case Releaseop:
+		return Result{Ok: c.release(cmd.Resource, cmd.Node)}
+	case Removeop:
+		return Result{Ok: c.remove(cmd.Resource, cmd.Node, cmd.Hash)}
+	case Stateop:
+		c.setstate(cmd.Node, cmd.State)
+		return Result{Ok: true}
+	}
+	c.logger.Warn("unknown command of the replicated log", "op", cmd.Op)
+	return Result{}
+}
+
+//catalogstate : the catalog as saved in a snapshot of the replicated log
+type catalogstate struct {
+	Table       map[string][]string
+	Provenance  map[string]map[string]Provenance
+	Descriptors []described
+	States      map[string]string
+	Claims      []claimed
+}
+
+//claimed : the claims of an IoT resource on an edge node not released yet and the content hash of the version to forget
+//once they are, if it was removed while claimed
+type claimed struct {
+	Resource, Node string
+	Claims         int
+	Evicted        string
+}
+
+//described : the descriptor of an IoT resource held by an edge node and the time it was last claimed there
+type described struct {
+	Resource, Node string
+	Descriptor     Descriptor
+	Lastused       time.Time
+}
+
+//Save : returns the state of the catalog, encoded for a snapshot of the replicated log
+func (c *Catalog) Save() ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	state := catalogstate{Table: c.Resourcetable, Provenance: c.Provenance, States: c.states}
+	for e, d := range c.index.descriptors {
+		state.Descriptors = append(state.Descriptors, described{Resource: e.resource, Node: e.node, Descriptor: d,
+			Lastused: c.lastused[e]})
+	}
+	sort.Slice(state.Descriptors, func(i, j int) bool {
+		a, b := state.Descriptors[i], state.Descriptors[j]
+		return a.Resource < b.Resource || (a.Resource == b.Resource && a.Node < b.Node)
+	})
+	for e, n := range c.claims {
+		state.Claims = append(state.Claims, claimed{Resource: e.resource, Node: e.node, Claims: n,
+			Evicted: c.evicted[e]})
+	}
+	sort.Slice(state.Claims, func(i, j int) bool {
+		a, b := state.Claims[i], state.Claims[j]
+		return a.Resource < b.Resource || (a.Resource == b.Resource && a.Node < b.Node)
+	})
+	return json.Marshal(state)
+}
+
+/*
+Restore : Replaces the state of the catalog with a snapshot of the replicated log. The channels watching resources are
+kept.
+Input: the state as returned by Save
+Output: error if the state could not be decoded
+*/
+func (c *Catalog) Restore(data []byte) error {
+	var state catalogstate
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.Resourcetable, c.Provenance, c.states = state.Table, state.Provenance, state.States
+	if c.Resourcetable == nil {
+		c.Resourcetable = map[string][]string{}
+	}
+	if c.Provenance == nil {
+		c.Provenance = map[string]map[string]Provenance{}
+	}
+	if c.states == nil {
+		c.states = map[string]string{}
+	}
+	c.index, c.lastused = newspatialindex(), map[entry]time.Time{}
+	c.claims, c.evicted = map[entry]int{}, map[entry]string{}
+	for _, d := range state.Descriptors {
+		c.index.add(entry{d.Resource, d.Node}, d.Descriptor)
+		if !d.Lastused.IsZero() {
+			c.lastused[entry{d.Resource, d.Node}] = d.Lastused
+		}
+	}
+	for _, cl := range state.Claims {
+		c.claims[entry{cl.Resource, cl.Node}] = cl.Claims
+		if cl.Evicted != "" {
+			c.evicted[entry{cl.Resource, cl.Node}] = cl.Evicted
+		}
+	}
+	return nil
+}
+
+//Uselog : switches the catalog to the consistent mode, its mutations then go through the replicated log
+func (t *Transport) Uselog(l Log) {
+	t.log = l
+	t.catalog.submit = t.submit
+}
+
+//Leader : returns the listening address of the edge node leading the replicated log, empty in the best-effort mode
+func (t *Transport) Leader() string {
+	if t.log == nil {
+		return ""
+	}
+	return t.log.Leader()
+}
+
+/*
+submit : Appends a command to the replicated log, through the leader if this edge node does not lead the log, and
+waits until the catalog of this edge node applied it. A command is handed again to the leader only if it was not
+appended, e.g. during an election.
+Input: the command
+Output: its result, error if it could not be committed before the commit timeout
+*/
+func (t *Transport) submit(cmd Command) (Result, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), committimeout)
+	defer cancel()
+	command, err := json.Marshal(cmd)
+	if err != nil {
+		return Result{}, err
+	}
+	for {
+		var result Result
+		var index uint64
+		if t.log.Leading() {
+			result, index, err = t.log.Apply(command, committimeout)
+		} else {
+			result, index, err = t.forward(ctx, command)
+		}
+		if err == nil {
+			if err = t.log.Wait(ctx, index); err != nil {
+				return Result{}, err
+			}
+			t.metrics.Commitlatency.WithLabelValues(cmd.Op).Observe(time.Since(start).Seconds())
+			return result, nil
+		}
+		if !retryable(err) {
+			return Result{}, err
+		}
+		select {
+		case <-ctx.Done():
+			return Result{}, fmt.Errorf("%s not committed: %v", cmd.Op, err)
+		case <-time.After(retryinterval):
+		}
+	}
+}
+
+//forward : hands a command to the leader of the replicated log
+func (t *Transport) forward(ctx context.Context, command []byte) (Result, uint64, error) {
+	leader := t.log.Leader()
+	if leader == "" {
+		return Result{}, 0, errnoleader
+	}
+	conn, err := grpc.Dial(leader, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		return Result{}, 0, err
+	}
+	defer conn.Close()
+	out, err := pb.NewFrontendClient(conn).CatalogCommand(ctx, &pb.Command{ID: t.NodeID, Command: command})
+	if err != nil {
+		return Result{}, 0, leadererror(err)
+	}
+	var result Result
+	if err := json.Unmarshal(out.Result, &result); err != nil {
+		return Result{}, 0, err
+	}
+	return result, out.Index, nil
+}
+
+/*
+Linearize : Waits until the catalog of this edge node applied every command committed to the replicated log before
+the call, so that the reads that follow are linearizable. The leader confirms it still leads the log with a barrier.
+Nothing is done in the best-effort mode.
+Input: context bounding the wait
+Output: error if the leader could not be reached or the catalog did not catch up in time
+*/
+func (t *Transport) Linearize(ctx context.Context) error {
+	if t.log == nil {
+		return nil
+	}
+	for {
+		var index uint64
+		var err error
+		if t.log.Leading() {
+			index, err = t.log.Barrier(committimeout)
+		} else {
+			index, err = t.readindex(ctx)
+		}
+		if err == nil {
+			return t.log.Wait(ctx, index)
+		}
+		if !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("catalog not linearized: %v", err)
+		case <-time.After(retryinterval):
+		}
+	}
+}
+
+//readindex : asks the leader of the replicated log for the index to wait for before a linearizable read
+func (t *Transport) readindex(ctx context.Context) (uint64, error) {
+	leader := t.log.Leader()
+	if leader == "" {
+		return 0, errnoleader
+	}
+	conn, err := grpc.Dial(leader, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	out, err := pb.NewFrontendClient(conn).ReadIndex(ctx, &pb.ReadIndexRequest{ID: t.NodeID})
+	if err != nil {
+		return 0, leadererror(err)
+	}
+	return out.Index, nil
+}
+
+func (s *server) CatalogCommand(ctx context.Context, in *pb.Command) (*pb.CommandResult, error) {
+	if err := s.authorize(ctx, in.ID, "CatalogCommand"); err != nil {
+		return nil, err
+	}
+	if s.t.log == nil || !s.t.log.Leading() {
+		return nil, status.Error(codes.FailedPrecondition, ErrNotleader.Error())
+	}
+	var cmd Command
+	if err := json.Unmarshal(in.Command, &cmd); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.checkrecord(ctx, cmd); err != nil {
+		return nil, err
+	}
+	result, index, err := s.t.log.Apply(in.Command, committimeout)
+	if errors.Is(err, ErrNotleader) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		//the command may still be committed, e.g. when the leadership was lost meanwhile
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.CommandResult{Result: out, Index: index}, nil
+}
+
+/*
+checkrecord : Checks, when the edge nodes use mutual TLS, that a record handed to the leader by another edge node is
+about an IoT resource held by that edge node and comes with the update it signed announcing the resource. The other
+commands are appended as they are.
+Input: context of the call carrying the certificate of the caller, the command
+Output: error of the call if the record is rejected
+*/
+func (s *server) checkrecord(ctx context.Context, cmd Command) error {
+	if s.t.credentials == nil || cmd.Op != Recordop {
+		return nil
+	}
+	caller, err := nodeidentity.Caller(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !nodeidentity.Matches(cmd.Node, caller) {
+		s.t.logger.Warn("rejected record of a resource held by another node", logging.Resource, cmd.Resource,
+			"holder", cmd.Node, "caller", caller)
+		return status.Errorf(codes.PermissionDenied, "node %s cannot record a resource held by %s", caller, cmd.Node)
+	}
+	if cmd.Update == nil {
+		s.t.logger.Warn("rejected record without its resource update", logging.Resource, cmd.Resource,
+			"holder", cmd.Node)
+		return status.Error(codes.PermissionDenied, "record without the signed update of the resource")
+	}
+	if err := s.t.verify(cmd.Update); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if !cmd.announces(fromupdate(cmd.Update)) {
+		s.t.logger.Warn("rejected record differing from its resource update", logging.Resource, cmd.Resource,
+			"holder", cmd.Node)
+		return status.Error(codes.PermissionDenied, "record differs from the signed update of the resource")
+	}
+	return nil
+}
+
+func (s *server) ReadIndex(ctx context.Context, in *pb.ReadIndexRequest) (*pb.ReadIndexReply, error) {
+	if err := s.authorize(ctx, in.ID, "ReadIndex"); err != nil {
+		return nil, err
+	}
+	if s.t.log == nil || !s.t.log.Leading() {
+		return nil, status.Error(codes.FailedPrecondition, ErrNotleader.Error())
+	}
+	index, err := s.t.log.Barrier(committimeout)
+	if errors.Is(err, ErrNotleader) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	return &pb.ReadIndexReply{Index: index}, nil
+}
@@ -0,0 +1,278 @@
+package resourcemanager
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//memorylog : a replicated log of a single edge node applying the commands to its catalog right away
+type memorylog struct {
+	catalog *Catalog
+	leading bool
+	index   uint64
+	mux     sync.Mutex
+}
+
+func (l *memorylog) Leading() bool  { return l.leading }
+func (l *memorylog) Leader() string { return "" }
+
+func (l *memorylog) Apply(command []byte, timeout time.Duration) (Result, uint64, error) {
+	if !l.leading {
+		return Result{}, 0, ErrNotleader
+	}
+	var cmd Command
+	if err := json.Unmarshal(command, &cmd); err != nil {
+		return Result{}, 0, err
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.index++
+	return l.catalog.Execute(cmd), l.index, nil
+}
+
+func (l *memorylog) Barrier(timeout time.Duration) (uint64, error) {
+	if !l.leading {
+		return 0, ErrNotleader
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.index, nil
+}
+
+func (l *memorylog) Wait(ctx context.Context, index uint64) error { return nil }
+
+func TestExecute(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	held := []Command{
+		{Op: Recordop, Resource: "r", Node: "n1", Provenance: Provenance{Hash: "h1"}},
+		{Op: Recordop, Resource: "r", Node: "n2", Provenance: Provenance{Hash: "h2"}},
+		{Op: Recordop, Resource: "r", Node: "n3", Provenance: Provenance{Hash: "h3"}},
+	}
+	tests := []struct {
+		name     string
+		commands []Command
+		want     Result //result of the last command
+	}{
+		{name: "record", commands: held[:1], want: Result{Holderresources: 1, Holders: 1}},
+		{name: "claim on the preferred edge node", commands: append(held[:3:3],
+			Command{Op: Claimop, Resource: "r", Node: "n2", Time: at, Seed: 0}),
+			want: Result{Node: "n2", Provenance: Provenance{Hash: "h2"}, Ok: true}},
+		{name: "claim in the order of the seed", commands: append(held[:3:3],
+			Command{Op: Claimop, Resource: "r", Time: at, Seed: 4}),
+			want: Result{Node: "n2", Provenance: Provenance{Hash: "h2"}, Ok: true}},
+		{name: "claim skips a draining edge node", commands: append(held[:3:3],
+			Command{Op: Stateop, Node: "n2", State: Draining}, Command{Op: Claimop, Resource: "r", Time: at, Seed: 4}),
+			want: Result{Node: "n3", Provenance: Provenance{Hash: "h3"}, Ok: true}},
+		{name: "claim of an unknown resource", commands: append(held[:3:3],
+			Command{Op: Claimop, Resource: "a", Time: at, Seed: 1})},
+		{name: "release", commands: append(held[:1:1], Command{Op: Claimop, Resource: "r", Time: at},
+			Command{Op: Releaseop, Resource: "r", Node: "n1"}), want: Result{Ok: true}},
+		{name: "release of a resource not claimed", commands: append(held[:1:1],
+			Command{Op: Releaseop, Resource: "r", Node: "n1"})},
+		{name: "remove", commands: append(held[:1:1], Command{Op: Removeop, Resource: "r", Node: "n1", Hash: "h1"}),
+			want: Result{Ok: true}},
+		{name: "remove of another version", commands: append(held[:1:1],
+			Command{Op: Removeop, Resource: "r", Node: "n1", Hash: "h0"})},
+		{name: "state", commands: []Command{{Op: Stateop, Node: "n1", State: Draining}}, want: Result{Ok: true}},
+		{name: "unknown command", commands: []Command{{Op: "unknown"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//two replicas applying the same commands end up in the same state
+			replicas := []*Catalog{newcatalog(), newcatalog()}
+			var states [][]byte
+			for _, c := range replicas {
+				var result Result
+				for _, cmd := range tt.commands {
+					result = c.Execute(cmd)
+				}
+				if !reflect.DeepEqual(result, tt.want) {
+					t.Errorf("Execute = %+v, want %+v", result, tt.want)
+				}
+				state, err := c.Save()
+				if err != nil {
+					t.Fatal(err)
+				}
+				states = append(states, state)
+			}
+			if string(states[0]) != string(states[1]) {
+				t.Errorf("replicas diverged: %s and %s", states[0], states[1])
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newcatalog()
+	for _, cmd := range []Command{
+		{Op: Recordop, Resource: "r", Node: "n1", Provenance: Provenance{Hash: "h1"},
+			Descriptor: Descriptor{Type: "hd_map", Size: 10}},
+		{Op: Recordop, Resource: "a", Node: "n2", Provenance: Provenance{Hash: "h2"}},
+		{Op: Claimop, Resource: "r", Node: "n1", Time: at},
+		{Op: Removeop, Resource: "r", Node: "n1", Hash: "h1"}, //deferred until released
+		{Op: Stateop, Node: "n2", State: Draining},
+	} {
+		c.Execute(cmd)
+	}
+	state, err := c.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newcatalog()
+	restored.Add("b", "n3", Provenance{}) //replaced by the snapshot
+	if err := restored.Restore(state); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := restored.Entries(), c.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries = %+v, want %+v", got, want)
+	}
+	if got, err := restored.Save(); err != nil || string(got) != string(state) {
+		t.Errorf("Save = %s, %v, want %s", got, err, state)
+	}
+	//the removal deferred while the resource was claimed survives the snapshot
+	restored.Execute(Command{Op: Releaseop, Resource: "r", Node: "n1"})
+	if _, recorded := restored.Lookup("r", "n1"); recorded {
+		t.Error("resource removed while claimed still recorded once released")
+	}
+	if err := restored.Restore([]byte("{")); err == nil {
+		t.Error("Restore of a corrupted snapshot succeeded")
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	tests := []struct {
+		name    string
+		leading bool
+		want    bool //whether the resource is recorded and claimed through the log
+	}{
+		{name: "leader", leading: true, want: true},
+		{name: "no leader", leading: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			c := NewCatalog(logger)
+			tr := NewTransport("127.0.0.1:0", nil, nil, c, metrics.New(logger), logger)
+			l := &memorylog{catalog: newcatalog(), leading: tt.leading}
+			tr.Uselog(l)
+
+			c.Record("r", "n1", Provenance{Hash: "h1"}, Descriptor{})
+			node, _, ok := c.Claim("r", "")
+			if ok != tt.want || (ok && node != "n1") {
+				t.Errorf("Claim = %q, %v, want %v", node, ok, tt.want)
+			}
+			//the mutations went to the log, not to the local replica directly
+			if len(c.Entries()) != 0 {
+				t.Errorf("local replica mutated: %+v", c.Entries())
+			}
+			if held := len(l.catalog.Entries()) == 1; held != tt.want {
+				t.Errorf("log replica holds %+v", l.catalog.Entries())
+			}
+		})
+	}
+}
+
+func TestCatalogCommand(t *testing.T) {
+	command, err := json.Marshal(Command{Op: Recordop, Resource: "r", Node: "n1", Provenance: Provenance{Hash: "h1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		log      *memorylog
+		wantcode codes.Code
+	}{
+		{name: "leader", log: &memorylog{catalog: newcatalog(), leading: true}, wantcode: codes.OK},
+		{name: "follower", log: &memorylog{catalog: newcatalog()}, wantcode: codes.FailedPrecondition},
+		{name: "best-effort mode", wantcode: codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := &server{t: NewTransport("127.0.0.1:0", nil, nil, NewCatalog(logger), metrics.New(logger), logger)}
+			if tt.log != nil {
+				s.t.Uselog(tt.log)
+			}
+			out, err := s.CatalogCommand(context.Background(), &pb.Command{ID: "n1", Command: command})
+			if code := status.Code(err); code != tt.wantcode {
+				t.Fatalf("CatalogCommand = %v, want %s", err, tt.wantcode)
+			}
+			if err != nil {
+				return
+			}
+			var result Result
+			if err := json.Unmarshal(out.Result, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Holders != 1 || out.Index != 1 {
+				t.Errorf("CatalogCommand = %+v at %d", result, out.Index)
+			}
+		})
+	}
+}
+
+func TestCatalogCommandRecord(t *testing.T) {
+	nodes := cluster(t, "edge_node_1", "edge_node_2", "edge_node_3")
+	record := func(update *pb.TableUpdate) Command {
+		return Command{Op: Recordop, Resource: update.Resource, Node: update.ID,
+			Provenance: Provenance{Hash: update.Hash, Contributor: update.Contributor}, Update: update}
+	}
+	altered := record(signed(t, nodes["edge_node_2"], "edge_node_2"))
+	altered.Provenance.Hash = "sha256:01"
+	tests := []struct {
+		name     string
+		caller   string
+		command  Command
+		wantcode codes.Code
+	}{
+		{name: "signed by the holder", caller: "edge_node_2", command: record(signed(t, nodes["edge_node_2"],
+			"edge_node_2")), wantcode: codes.OK},
+		{name: "forged for another holder", caller: "edge_node_2", command: record(signed(t, nodes["edge_node_2"],
+			"edge_node_3")), wantcode: codes.PermissionDenied},
+		{name: "update signed by another node", caller: "edge_node_3", command: record(signed(t, nodes["edge_node_2"],
+			"edge_node_3")), wantcode: codes.PermissionDenied},
+		{name: "unsigned update", caller: "edge_node_2", command: record(signed(t, nil, "edge_node_2")),
+			wantcode: codes.PermissionDenied},
+		{name: "no update", caller: "edge_node_2", command: Command{Op: Recordop, Resource: "r", Node: "edge_node_2"},
+			wantcode: codes.PermissionDenied},
+		{name: "record differing from its update", caller: "edge_node_2", command: altered,
+			wantcode: codes.PermissionDenied},
+		{name: "other command", caller: "edge_node_2", command: Command{Op: Stateop, Node: "edge_node_2",
+			State: Draining}, wantcode: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			l := &memorylog{catalog: newcatalog(), leading: true}
+			s := &server{t: NewTransport("127.0.0.1:0", nil, nodes["edge_node_1"], NewCatalog(logger),
+				metrics.New(logger), logger)}
+			s.t.Uselog(l)
+			command, err := json.Marshal(tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.CatalogCommand(callerctx(tt.caller), &pb.Command{ID: tt.caller, Command: command})
+			if code := status.Code(err); code != tt.wantcode {
+				t.Fatalf("CatalogCommand = %v, want %s", err, tt.wantcode)
+			}
+			//a rejected record never reaches the log
+			if _, recorded := l.catalog.Lookup("r", tt.command.Node); recorded != (err == nil &&
+				tt.command.Op == Recordop) {
+				t.Errorf("resource recorded %v", recorded)
+			}
+		})
+	}
+}
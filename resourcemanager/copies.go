@@ -0,0 +1,134 @@
+/*
+This file implements the announcement of the copies of IoT resources made by an edge node, e.g. when prefetching or
+replicating them (see packages prefetch and replication). The edge node that made a copy does not announce it itself:
+when the edge nodes use mutual TLS, the other edge nodes only accept updates signed by the edge node holding the
+resource. It asks the edge node holding the copy instead, which records the copy and announces it like an IoT resource
+offloaded on it. It only does so for an edge node its catalog records as holding the resource or as the origin of a
+copy of it, and once it read the data of the copy and checked its content hash. Without credentials an edge node still
+announces the copies held by edge nodes that do not run EDIRO.
+*/
+
+package resourcemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//source : returns whether the catalog records an edge node as holding an IoT resource or as the origin of a copy of it
+func (t *Transport) source(resource string, node string) bool {
+	for _, e := range t.catalog.Entries() {
+		if e.Resource == resource && (nodeidentity.Matches(e.Node, node) || e.Provenance.Origin == node) {
+			return true
+		}
+	}
+	return false
+}
+
+//self : returns whether an edge node, as the holder of an IoT resource is given, is this edge node
+func (t *Transport) self(node string) bool {
+	return t.NodeID != "" && nodeidentity.Matches(node, t.NodeID)
+}
+
+func (s *server) CopyAnnouncement(ctx context.Context, in *pb.CopyRequest) (*pb.TableUpdateACK, error) {
+	if err := s.authorize(ctx, in.ID, "CopyAnnouncement"); err != nil {
+		return nil, err
+	}
+	if in.Copy == nil || !s.t.self(in.Copy.ID) {
+		return nil, status.Error(codes.NotFound, "the copy is not held by this edge node")
+	}
+	if s.t.Oncopy == nil {
+		return nil, status.Error(codes.Unimplemented, "copies are not accepted by this edge node")
+	}
+	copied := fromupdate(in.Copy)
+	if in.Copy.Offloaded == 0 {
+		copied.Offloaded = time.Time{}
+	}
+	if !s.t.source(copied.Resource, in.ID) {
+		s.t.logger.Warn("rejected copy from a node neither holding the resource nor its origin", logging.Resource,
+			copied.Resource, "from", in.ID)
+		return nil, status.Errorf(codes.PermissionDenied, "node %s neither holds %s nor is the origin of a copy of it",
+			in.ID, copied.Resource)
+	}
+	hash := contenthash(Datapath(s.t.Dir, copied.Resource))
+	if hash == "" {
+		s.t.logger.Warn("rejected copy whose data cannot be read", logging.Resource, copied.Resource, "from", in.ID)
+		return nil, status.Error(codes.FailedPrecondition, "data of the copy cannot be read on this edge node")
+	}
+	if copied.Hash != "" && hash != copied.Hash {
+		s.t.logger.Warn("rejected copy whose data has another content hash", logging.Resource, copied.Resource,
+			"hash", copied.Hash, "data", hash, "from", in.ID)
+		return nil, status.Errorf(codes.FailedPrecondition, "data of the copy has the content hash %s", hash)
+	}
+	if err := s.t.Oncopy(ctx, copied); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	s.t.logger.Info("copy announced for peer", logging.Resource, copied.Resource, "origin", copied.Origin,
+		"from", in.ID)
+	return &pb.TableUpdateACK{Ack: "copyACK" + copied.Resource}, nil
+}
+
+/*
+Announcecopy : Has the edge node an IoT resource was copied to record and announce the copy. The other edge nodes are
+asked in turn until the one holding the copy answers. Without credentials, a copy held by no edge node that answers is
+announced by this edge node once every other edge node answered.
+Input: context bounding the calls, the copy with the edge node holding it and the edge node it was copied from
+Output: error if no edge node announced the copy
+*/
+func (t *Transport) Announcecopy(ctx context.Context, copied Newresource) error {
+	if t.self(copied.NodeID) {
+		if t.Oncopy == nil {
+			return errors.New("copies are not accepted by this edge node")
+		}
+		return t.Oncopy(ctx, copied)
+	}
+	update := tableupdate(copied)
+	if copied.Offloaded.IsZero() {
+		update.Offloaded = 0
+	}
+	var last error
+	for _, peer := range t.Peers {
+		conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		if err != nil {
+			t.logger.Warn("did not connect", "peer", peer, "err", err)
+			last = err
+			continue
+		}
+		callctx, cancel := context.WithTimeout(ctx, time.Second)
+		_, err = pb.NewFrontendClient(conn).CopyAnnouncement(callctx, &pb.CopyRequest{ID: t.NodeID,
+			Copy: update})
+		cancel()
+		conn.Close()
+		if status.Code(err) == codes.NotFound {
+			continue //not the edge node holding the copy
+		}
+		if err != nil {
+			t.logger.Warn("could not have copy announced", "peer", peer, logging.Resource, copied.Resource,
+				"err", err)
+			last = err
+			continue
+		}
+		t.logger.Debug("copy announced by its holder", "peer", peer, logging.Resource, copied.Resource,
+			"holder", copied.NodeID)
+		return nil
+	}
+	if last != nil {
+		return fmt.Errorf("copy held by %s could not be announced: %v", copied.NodeID, last)
+	}
+	if t.credentials == nil && t.Oncopy != nil {
+		//the edge node holding the copy does not run EDIRO, nobody checks who announces its resources
+		return t.Oncopy(ctx, copied)
+	}
+	return fmt.Errorf("no edge node holding the copy on %s answered", copied.NodeID)
+}
@@ -0,0 +1,132 @@
+package resourcemanager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCopyAnnouncement(t *testing.T) {
+	nodes := cluster(t, "edge_node_1", "edge_node_2")
+	data := filepath.Join(t.TempDir(), "hd_map_1")
+	if err := os.WriteFile(data, []byte("map"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(filepath.Dir(data), "hd_map_2")
+	const hash = "sha256:60be9861750facbfad8758254a2f76c0cfe78d54459a3bc187d49b1401fcd8e8"
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		in         *pb.CopyRequest
+		dir        string //storage directory of this edge node
+		nocopies   bool   //whether this edge node does not accept copies
+		wantcode   codes.Code
+		wantcopied bool
+	}{
+		{name: "copy held by this edge node", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{ID: "edge_node_2",
+			Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_1", Hash: hash, Origin: "edge_node_2"}},
+			wantcode: codes.OK, wantcopied: true},
+		{name: "copy held by another edge node", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{
+			ID: "edge_node_2", Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_2", Hash: hash}},
+			wantcode: codes.NotFound},
+		{name: "no copy", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{ID: "edge_node_2"},
+			wantcode: codes.NotFound},
+		{name: "caller on behalf of another node", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{
+			ID: "edge_node_3", Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_1", Hash: hash}},
+			wantcode: codes.PermissionDenied},
+		{name: "data with another content hash", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{
+			ID: "edge_node_2", Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_1", Hash: "sha256:other"}},
+			wantcode: codes.FailedPrecondition},
+		{name: "data with another content hash in the storage directory", ctx: callerctx("edge_node_2"),
+			in: &pb.CopyRequest{ID: "edge_node_2", Copy: &pb.TableUpdate{Resource: "hd_map_1", ID: "edge_node_1",
+				Hash: "sha256:other"}}, dir: filepath.Dir(data), wantcode: codes.FailedPrecondition},
+		{name: "caller neither holding the resource nor its origin", ctx: callerctx("edge_node_3"),
+			in: &pb.CopyRequest{ID: "edge_node_3", Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_1",
+				Hash: hash}}, wantcode: codes.PermissionDenied},
+		{name: "caller origin of a copy of the resource", ctx: callerctx("edge_node_4"), in: &pb.CopyRequest{
+			ID: "edge_node_4", Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_1", Hash: hash,
+				Origin: "edge_node_4"}}, wantcode: codes.OK, wantcopied: true},
+		{name: "data of the copy missing", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{ID: "edge_node_2",
+			Copy: &pb.TableUpdate{Resource: missing, ID: "edge_node_1", Hash: hash}},
+			wantcode: codes.FailedPrecondition},
+		{name: "copies not accepted", ctx: callerctx("edge_node_2"), in: &pb.CopyRequest{ID: "edge_node_2",
+			Copy: &pb.TableUpdate{Resource: data, ID: "edge_node_1", Hash: hash}}, nocopies: true,
+			wantcode: codes.Unimplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := &server{t: NewTransport("127.0.0.1:0", nil, nodes["edge_node_1"], NewCatalog(logger),
+				metrics.New(logger), logger)}
+			s.t.NodeID, s.t.Dir = "edge_node_1", tt.dir
+			//edge_node_2 holds the resources, a copy of the resource was made from edge_node_4 to edge_node_5
+			for _, resource := range []string{data, "hd_map_1", missing} {
+				s.t.catalog.Add(resource, "edge_node_2", Provenance{Hash: hash})
+			}
+			s.t.catalog.Add(data, "edge_node_5", Provenance{Hash: hash, Origin: "edge_node_4"})
+			var copied []Newresource
+			if !tt.nocopies {
+				s.t.Oncopy = func(ctx context.Context, resource Newresource) error {
+					copied = append(copied, resource)
+					return nil
+				}
+			}
+			_, err := s.CopyAnnouncement(tt.ctx, tt.in)
+			if code := status.Code(err); code != tt.wantcode {
+				t.Fatalf("CopyAnnouncement = %v, want %s", err, tt.wantcode)
+			}
+			if (len(copied) == 1) != tt.wantcopied {
+				t.Fatalf("copies announced %+v", copied)
+			}
+			if tt.wantcopied && (copied[0].NodeID != "edge_node_1" || copied[0].Hash != hash ||
+				copied[0].Origin != tt.in.Copy.Origin || !copied[0].Offloaded.IsZero()) {
+				t.Errorf("announced the copy %+v", copied[0])
+			}
+		})
+	}
+}
+
+func TestAnnouncecopy(t *testing.T) {
+	nodes := cluster(t, "edge_node_1")
+	tests := []struct {
+		name       string
+		holder     string
+		tls        bool //whether the edge node is given its credentials
+		wanterr    bool
+		wantcopied bool
+	}{
+		{name: "copy held by this edge node", holder: "edge_node_1", wantcopied: true},
+		//no other edge node runs EDIRO, the copy is announced by this edge node in plain text
+		{name: "copy held by an edge node not running EDIRO", holder: "edge_node_2", wantcopied: true},
+		//under mutual TLS only the edge node holding the copy can announce it
+		{name: "no edge node holding the copy under mutual TLS", holder: "edge_node_2", tls: true, wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			creds := nodes["edge_node_1"]
+			if !tt.tls {
+				creds = nil
+			}
+			tr := NewTransport("127.0.0.1:0", nil, creds, NewCatalog(logger), metrics.New(logger), logger)
+			tr.NodeID = "edge_node_1"
+			var copied []Newresource
+			tr.Oncopy = func(ctx context.Context, resource Newresource) error {
+				copied = append(copied, resource)
+				return nil
+			}
+			err := tr.Announcecopy(context.Background(), Newresource{Resource: "r", NodeID: tt.holder})
+			if (err != nil) != tt.wanterr || (len(copied) == 1) != tt.wantcopied {
+				t.Errorf("Announcecopy = %v announcing %+v", err, copied)
+			}
+		})
+	}
+}
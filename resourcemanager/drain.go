@@ -0,0 +1,162 @@
+/*
+This file implements the maintenance states of the edge nodes of the swarm. An edge node taken down for maintenance is
+first drained: it keeps the IoT resources it holds but no new workload is placed on it, i.e. its resources are no
+longer claimed, located or counted as available. Once drained it departs from the cluster and the catalog forgets the
+resources it held, until the edge node is active again or a resource is offloaded on it. The state of an edge node is
+announced to the other edge nodes so that none of them places workloads on it.
+*/
+
+package resourcemanager
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//States of an edge node of the swarm
+const (
+	Active   = "active"
+	Draining = "draining"
+	Departed = "departed"
+)
+
+//Validstate : returns whether a string is a state of an edge node
+func Validstate(state string) bool {
+	return state == Active || state == Draining || state == Departed
+}
+
+/*
+Setstate : Records the state of an edge node. A departed edge node is removed from the catalog along with the IoT
+resources it held. In the consistent mode the state is recorded through the replicated log.
+Input: edge node, its state
+Output: Nil
+*/
+func (c *Catalog) Setstate(nodeID string, state string) {
+	if c.submit != nil {
+		if _, err := c.submit(Command{Op: Stateop, Node: nodeID, State: state}); err != nil {
+			c.logger.Warn("could not record edge node state through the replicated log", "node", nodeID,
+				"state", state, "err", err)
+		}
+		return
+	}
+	c.setstate(nodeID, state)
+}
+
+//setstate : records the state of an edge node
+func (c *Catalog) setstate(nodeID string, state string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	switch state {
+	case Active:
+		delete(c.states, nodeID)
+	case Draining:
+		c.states[nodeID] = Draining
+	case Departed:
+		c.states[nodeID] = Departed
+		delete(c.Resourcetable, nodeID)
+		for resource, holders := range c.Provenance {
+			if _, ok := holders[nodeID]; !ok {
+				continue
+			}
+			delete(holders, nodeID)
+			c.index.remove(entry{resource, nodeID})
+			delete(c.lastused, entry{resource, nodeID})
+			delete(c.claims, entry{resource, nodeID})
+			delete(c.evicted, entry{resource, nodeID})
+			if len(holders) == 0 {
+				delete(c.Provenance, resource)
+			}
+		}
+	}
+}
+
+//State : returns the state of an edge node, active unless it was drained
+func (c *Catalog) State(nodeID string) string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if state, ok := c.states[nodeID]; ok {
+		return state
+	}
+	return Active
+}
+
+//States : returns the edge nodes that are not active and their state
+func (c *Catalog) States() map[string]string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	states := make(map[string]string, len(c.states))
+	for node, state := range c.states {
+		states[node] = state
+	}
+	return states
+}
+
+func (s *server) NodeUpdate(ctx context.Context, in *pb.NodeState) (*pb.TableUpdateACK, error) {
+	if err := s.authorize(ctx, in.Node, "NodeUpdate"); err != nil {
+		return nil, err
+	}
+	if !Validstate(in.State) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown state %s", in.State)
+	}
+	s.t.setstate(in.Node, in.State)
+	s.t.logger.Info("edge node state updated from peer", "node", in.Node, "state", in.State)
+	return &pb.TableUpdateACK{Ack: "nodeupdateACK" + in.Node}, nil
+}
+
+/*
+Announcestate : Records the state of an edge node and announces it to all other edge nodes, unless it was recorded
+through the replicated log.
+Input: context whose cancellation stops the announcement, edge node, its state
+Output: edge nodes that could not be reached
+*/
+func (t *Transport) Announcestate(ctx context.Context, node string, state string) []string {
+	t.setstate(node, state)
+	if t.log != nil {
+		return nil //recorded through the replicated log
+	}
+	var unreachable []string
+	for _, peer := range t.Peers {
+		conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		if err != nil {
+			t.logger.Warn("did not connect", "peer", peer, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		callctx, cancel := context.WithTimeout(ctx, time.Second)
+		_, err = pb.NewFrontendClient(conn).NodeUpdate(callctx, &pb.NodeState{Node: node, State: state})
+		cancel()
+		conn.Close()
+		if err != nil {
+			t.logger.Warn("could not deliver edge node state", "peer", peer, "node", node, "state", state, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		t.logger.Debug("edge node state delivered", "peer", peer, "node", node, "state", state)
+	}
+	return unreachable
+}
+
+//setstate : records the state of an edge node, the updates of a departed edge node are no longer sent on a sync
+func (t *Transport) setstate(node string, state string) {
+	t.catalog.Setstate(node, state)
+	if state != Departed {
+		return
+	}
+	t.announcedmux.Lock()
+	defer t.announcedmux.Unlock()
+	for resource, holders := range t.announced {
+		delete(holders, node)
+		if len(holders) == 0 {
+			delete(t.announced, resource)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package resourcemanager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetstate(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string //state of the edge node n2, both n1 and n2 hold r
+		//offload : whether a resource is offloaded on n2 afterwards
+		offload     bool
+		wantstate   string
+		wantholders []string
+		wantclaimed string //edge node the resource is claimed on when n2 is preferred
+		wantentries int
+	}{
+		{name: "active", state: Active, wantstate: Active, wantholders: []string{"n1", "n2"}, wantclaimed: "n2",
+			wantentries: 2},
+		{name: "draining", state: Draining, wantstate: Draining, wantholders: []string{"n1"}, wantclaimed: "n1",
+			wantentries: 2},
+		{name: "departed", state: Departed, wantstate: Departed, wantholders: []string{"n1"}, wantclaimed: "n1",
+			wantentries: 1},
+		{name: "back from maintenance", state: Departed, offload: true, wantstate: Active,
+			wantholders: []string{"n1", "n2"}, wantclaimed: "n2", wantentries: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			c.Add("r", "n1", Provenance{})
+			c.Add("r", "n2", Provenance{})
+			c.Setstate("n2", tt.state)
+			if tt.offload {
+				c.Add("r", "n2", Provenance{})
+			}
+			if state := c.State("n2"); state != tt.wantstate {
+				t.Errorf("State = %s, want %s", state, tt.wantstate)
+			}
+			holders := c.Holders("r")
+			if len(holders) == 2 && holders[0] > holders[1] {
+				holders[0], holders[1] = holders[1], holders[0]
+			}
+			if !reflect.DeepEqual(holders, tt.wantholders) {
+				t.Errorf("Holders = %v, want %v", holders, tt.wantholders)
+			}
+			if entries := c.Entries(); len(entries) != tt.wantentries {
+				t.Errorf("%d entries, want %d", len(entries), tt.wantentries)
+			}
+			if node, _, _ := c.Claim("r", "n2"); node != tt.wantclaimed {
+				t.Errorf("claimed on %s, want %s", node, tt.wantclaimed)
+			}
+		})
+	}
+}
+
+func TestNodeUpdate(t *testing.T) {
+	nodes := cluster(t, "edge_node_1", "edge_node_2")
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		in        *pb.NodeState
+		wantcode  codes.Code
+		wantstate string
+	}{
+		{name: "own state", ctx: callerctx("edge_node_2"), in: &pb.NodeState{Node: "edge_node_2", State: Draining},
+			wantcode: codes.OK, wantstate: Draining},
+		{name: "state of another node", ctx: callerctx("edge_node_2"),
+			in: &pb.NodeState{Node: "edge_node_3", State: Draining}, wantcode: codes.PermissionDenied,
+			wantstate: Active},
+		{name: "unknown state", ctx: callerctx("edge_node_2"), in: &pb.NodeState{Node: "edge_node_2", State: "gone"},
+			wantcode: codes.InvalidArgument, wantstate: Active},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			c := NewCatalog(logger)
+			s := &server{t: NewTransport("127.0.0.1:0", nil, nodes["edge_node_1"], c, metrics.New(logger), logger)}
+			if _, err := s.NodeUpdate(tt.ctx, tt.in); status.Code(err) != tt.wantcode {
+				t.Errorf("NodeUpdate = %v, want %s", err, tt.wantcode)
+			}
+			if state := c.State(tt.in.Node); state != tt.wantstate {
+				t.Errorf("edge node %s, want %s", state, tt.wantstate)
+			}
+		})
+	}
+}
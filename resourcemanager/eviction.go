@@ -0,0 +1,80 @@
+/*
+This file implements the announcement of the IoT resources evicted from the storage of an edge node (see package
+storage). The edge node that announced an IoT resource forgets it and tells the other edge nodes, so that their
+catalog does not point at data that is gone. Only the version evicted is forgotten, a newer version offloaded in
+between is kept.
+*/
+
+package resourcemanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+//Announced : returns whether this edge node announced an IoT resource held by an edge node, i.e. stores it
+func (t *Transport) Announced(resource string, node string) bool {
+	t.announcedmux.Lock()
+	defer t.announcedmux.Unlock()
+	_, ok := t.announced[resource][node]
+	return ok
+}
+
+func (s *server) ResourceEviction(ctx context.Context, in *pb.Eviction) (*pb.TableUpdateACK, error) {
+	if err := s.authorize(ctx, in.ID, "ResourceEviction"); err != nil {
+		return nil, err
+	}
+	removed := s.t.catalog.Remove(in.Resource, in.ID, in.Hash)
+	s.t.logger.Info("resource evicted by peer", logging.Resource, in.Resource, "holder", in.ID, "removed", removed)
+	return &pb.TableUpdateACK{Ack: "evictionACK" + in.Resource}, nil
+}
+
+/*
+Evict : Forgets an IoT resource evicted from the storage of an edge node and announces the eviction to all other edge
+nodes, unless it was removed through the replicated log.
+Input: context whose cancellation stops the announcement, IoT resource, edge node it was evicted from
+Output: edge nodes that could not be reached
+*/
+func (t *Transport) Evict(ctx context.Context, resource string, node string) []string {
+	p, _ := t.catalog.Lookup(resource, node)
+	t.catalog.Remove(resource, node, p.Hash)
+	t.announcedmux.Lock()
+	delete(t.announced[resource], node)
+	if len(t.announced[resource]) == 0 {
+		delete(t.announced, resource)
+	}
+	t.announcedmux.Unlock()
+	if t.log != nil {
+		return nil //removed through the replicated log
+	}
+
+	var unreachable []string
+	for _, peer := range t.Peers {
+		conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		if err != nil {
+			t.logger.Warn("did not connect", "peer", peer, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		callctx, cancel := context.WithTimeout(ctx, time.Second)
+		_, err = pb.NewFrontendClient(conn).ResourceEviction(callctx, &pb.Eviction{Resource: resource, ID: node,
+			Hash: p.Hash})
+		cancel()
+		conn.Close()
+		if err != nil {
+			t.logger.Warn("could not deliver eviction", "peer", peer, logging.Resource, resource, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		t.logger.Debug("eviction delivered", "peer", peer, logging.Resource, resource)
+	}
+	return unreachable
+}
@@ -0,0 +1,64 @@
+package resourcemanager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResourceEviction(t *testing.T) {
+	nodes := cluster(t, "edge_node_1", "edge_node_2")
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		in       *pb.Eviction
+		wantcode codes.Code
+		wantheld bool
+	}{
+		{name: "own resource", ctx: callerctx("edge_node_2"),
+			in: &pb.Eviction{Resource: "r", ID: "edge_node_2", Hash: "h"}, wantcode: codes.OK},
+		{name: "newer version", ctx: callerctx("edge_node_2"),
+			in: &pb.Eviction{Resource: "r", ID: "edge_node_2", Hash: "old"}, wantcode: codes.OK, wantheld: true},
+		{name: "resource of another node", ctx: callerctx("edge_node_3"),
+			in: &pb.Eviction{Resource: "r", ID: "edge_node_2", Hash: "h"}, wantcode: codes.PermissionDenied,
+			wantheld: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			c := NewCatalog(logger)
+			c.Add("r", "edge_node_2", Provenance{Hash: "h"})
+			s := &server{t: NewTransport("127.0.0.1:0", nil, nodes["edge_node_1"], c, metrics.New(logger), logger)}
+			if _, err := s.ResourceEviction(tt.ctx, tt.in); status.Code(err) != tt.wantcode {
+				t.Errorf("ResourceEviction = %v, want %s", err, tt.wantcode)
+			}
+			if _, held := c.Lookup("r", "edge_node_2"); held != tt.wantheld {
+				t.Errorf("resource kept %v, want %v", held, tt.wantheld)
+			}
+		})
+	}
+}
+
+func TestEvict(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c := NewCatalog(logger)
+	tr := NewTransport("127.0.0.1:0", nil, nil, c, metrics.New(logger), logger)
+	c.Add("r", "n1", Provenance{Hash: "h"})
+	c.Add("r", "n2", Provenance{Hash: "h"})
+	tr.announced["r"] = map[string]*pb.TableUpdate{"n1": {Resource: "r", ID: "n1"}, "n2": {Resource: "r", ID: "n2"}}
+	if unreachable := tr.Evict(context.Background(), "r", "n1"); len(unreachable) != 0 {
+		t.Errorf("unreachable %v without peers", unreachable)
+	}
+	if _, held := c.Lookup("r", "n1"); held || tr.Announced("r", "n1") {
+		t.Error("evicted resource still recorded or announced")
+	}
+	if _, held := c.Lookup("r", "n2"); !held || !tr.Announced("r", "n2") {
+		t.Error("copy of the evicted resource on another edge node forgotten")
+	}
+}
@@ -0,0 +1,81 @@
+/*
+This file implements the provenance of the IoT resources announced to the other edge nodes. The edge node on which an
+IoT resource is offloaded hashes its content, keeps the vehicle or device that contributed it and signs the announcement
+with its node certificate (see package nodeidentity). The other edge nodes verify the signature before recording the
+resource in their catalog.
+
+*/
+
+package resourcemanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/niketagrawal/EDIRO/geo"
+)
+
+//hashprefix : prefix of the content hashes computed by EDIRO, naming the hash function
+const hashprefix = "sha256:"
+
+//announcement : the fields of a resource update covered by the signature, in a fixed order
+type announcement struct {
+	Resource, Holder, Hash, Contributor string
+	Type                                string
+	Footprint                           geo.Box
+	Offloaded                           int64
+	Size                                int64
+	Origin                              string
+}
+
+//payload : returns the bytes of a resource update that are signed by the edge node it originates from
+func payload(r Newresource) []byte {
+	data, _ := json.Marshal(announcement{Resource: r.Resource, Holder: r.NodeID, Hash: r.Hash,
+		Contributor: r.Contributor, Type: r.Type, Footprint: r.Footprint, Offloaded: r.Offloaded.UnixNano(),
+		Size: r.Size, Origin: r.Origin})
+	return data
+}
+
+//Datapath : returns the path of the data of an IoT resource kept in a storage directory, the name of the resource being
+//confined to the directory. Without a storage directory, the IoT resource is the path of its data.
+func Datapath(dir string, resource string) string {
+	if dir == "" {
+		return resource
+	}
+	return filepath.Join(dir, filepath.Clean("/"+resource))
+}
+
+/*
+contenthash : Hashes the content of an IoT resource offloaded on this edge node.
+Input: path of the data of the IoT resource on this edge node (see Datapath)
+Output: content hash of the form sha256:<hex>, empty if the data of the resource cannot be read on this edge node
+*/
+func contenthash(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hashprefix + hex.EncodeToString(h.Sum(nil))
+}
+
+//datasize : returns the size in bytes of the data of an IoT resource offloaded on this edge node given the path of its
+//data, 0 if it cannot be read
+func datasize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,40 @@
+package resourcemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContenthash(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hd_map_1")
+	if err := os.WriteFile(file, []byte("map"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	const hash = "sha256:60be9861750facbfad8758254a2f76c0cfe78d54459a3bc187d49b1401fcd8e8"
+	tests := []struct {
+		name     string
+		dir      string //storage directory
+		resource string
+		want     string
+		wantsize int64
+	}{
+		{name: "data on this edge node", resource: file, want: hash, wantsize: 3},
+		{name: "no data", resource: filepath.Join(dir, "hd_map_2")},
+		{name: "directory", resource: dir},
+		{name: "data in the storage directory", dir: dir, resource: "hd_map_1", want: hash, wantsize: 3},
+		{name: "name escaping the storage directory", dir: filepath.Join(dir, "storage"), resource: "../hd_map_1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := Datapath(tt.dir, tt.resource)
+			if got := contenthash(path); got != tt.want {
+				t.Errorf("contenthash = %q, want %q", got, tt.want)
+			}
+			if size := datasize(path); size != tt.wantsize {
+				t.Errorf("datasize = %d, want %d", size, tt.wantsize)
+			}
+		})
+	}
+}
@@ -6,6 +6,17 @@ following tasks:
 3. Handling of similar updates from other edge nodes
 4. Monitoring of IoT resource for a running workload
 5. Measure the time takn to spread the metadata about an IoT resource to other edge nodes.
+6. Sharing of the runtime statistics of the applications with other edge nodes (see package predictor)
+7. Handover of the sessions of the clients moving between edge nodes (see package session)
+8. Anti-entropy sync of the catalog: the updates of the IoT resources offloaded on an edge node are kept and sent again
+to an edge node asking for them, which adds those it missed
+9. Drain of the edge nodes taken down for maintenance, whose state is announced to the other edge nodes (see drain.go)
+10. Announcement of the IoT resources evicted from the storage of an edge node (see eviction.go)
+11. Optional consistent mode, in which the mutations of the catalog and the reservations of the IoT resources go
+through a replicated log instead of being broadcast, and reads can be made linearizable (see consensus.go)
+When the edge node is given its credentials, the edge nodes talk to each other over mutual TLS and an update is only
+accepted from the edge node it claims to come from (see package nodeidentity). Each update is also signed by the edge
+node it originates from and carries the provenance of the resource (see provenance.go).
 
 Author: Niket Agrawal
 
@@ -16,213 +27,561 @@ package resourcemanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
 	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/tracing"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-//Resourcetable : Declaring the map to store information about IoT Resource availabiity on each edge node
-//in the cluster
-var Resourcetable map[string][]string
-
-//Specifying the litsening address of this edge node on which it listens for messages from other edge nodes
-const (
-	port = "1.1.1.1:1"
-)
-
-type server struct{}
-
-//Done channel to signal main() when the go routine finishes.
-var Done = make(chan bool)
-var Done1 = make(chan bool)
-
-var mux sync.Mutex
-
 //Newresource : struct to hold the data format in which the newresourceupdate function will pack data in and send to
 //broadcasting go routine on a channel
 type Newresource struct {
 	Resource, NodeID string
+	//Hash : content hash of the resource, computed from the data of the resource on this edge node if not given
+	Hash string
+	//Contributor : vehicle or device that contributed the data of the resource
+	Contributor string
+	//Type : type of the resource, e.g. hd_map, and Footprint : area it covers, both optional (see spatial.go)
+	Type      string
+	Footprint geo.Box
+	//Offloaded : time the resource was offloaded, set by Newresourceupdate if not given
+	Offloaded time.Time
+	//Size : size of the data of the resource in bytes, read from the resource on this edge node if not given
+	Size int64
+	//Origin : edge node the resource was copied from, empty if it was offloaded on the edge node holding it
+	Origin string
+	//Replicas : number of edge nodes that should hold the resource as asked by its contributor, 0 for the replication
+	//factor of its type (see package replication), not announced
+	Replicas int `json:"-"`
+	//Signature and Certificate : signature of this edge node over the update and its certificate (DER), set by
+	//Newresourceupdate
+	Signature, Certificate []byte `json:"-"`
+}
+
+/*
+Transport : The inter edge communication of an edge node. It listens for updates from the other edge nodes and
+broadcasts the IoT resources offloaded on this edge node to them.
+*/
+type Transport struct {
+	//Address : the listening address of this edge node on which it listens for messages from other edge nodes
+	Address string
+	//NodeID : name of this edge node, given to the other edge nodes when asking them for their updates
+	NodeID string
+	//Peers : Reachabililty details of all other edge nodes in the cluster in terms of their IP address and
+	//listening ports
+	Peers []string
+
+	//Done channel is closed when the listening server has stopped.
+	Done chan bool
+	//Onpredictions : called with the runtime statistics shared by another edge node, nil to ignore them
+	Onpredictions func(in *pb.Predictions)
+	//Onhandover : called when another edge node takes over the session of a client, nil if sessions are not kept
+	Onhandover func(in *pb.HandoverRequest) *pb.HandoverReply
+	//Onfetch : called when another edge node fetches the result of a request of a client it took over
+	Onfetch func(ctx context.Context, in *pb.FetchRequest) (*pb.RequestState, error)
+	//Oncopy : called to record and announce an IoT resource another edge node copied to this edge node, nil if
+	//copies are not accepted (see copies.go)
+	Oncopy func(ctx context.Context, resource Newresource) error
+	//Dir : directory the data of the IoT resources offloaded on this edge node is read from, empty if the IoT resources
+	//are the paths of their data (see Datapath)
+	Dir string
+
+	//credentials : certificate of this edge node and cluster CA, nil to talk to the other edge nodes in plain text
+	credentials *nodeidentity.Credentials
+	//log : the replicated log of the catalog in the consistent mode, nil in the best-effort mode (see consensus.go)
+	log Log
+	//announced : the updates broadcast for the IoT resources offloaded on this edge node, keyed by resource then edge
+	//node holding it
+	announced    map[string]map[string]*pb.TableUpdate
+	announcedmux sync.Mutex
+	catalog      *Catalog
+	metrics      *metrics.Metrics
+	logger       *slog.Logger
+}
+
+/*
+NewTransport : creates the inter edge communication of an edge node updating the given catalog. Without credentials the
+edge nodes talk in plain text and updates are accepted from anyone reaching the listening address.
+*/
+func NewTransport(address string, peers []string, creds *nodeidentity.Credentials, catalog *Catalog, m *metrics.Metrics,
+	logger *slog.Logger) *Transport {
+	return &Transport{Address: address, Peers: peers, Done: make(chan bool), credentials: creds,
+		announced: map[string]map[string]*pb.TableUpdate{}, catalog: catalog, metrics: m, logger: logger}
+}
+
+//server : the Frontend service of an edge node
+type server struct {
+	t *Transport
 }
 
 func (s *server) ResourceTableUpdate(ctx context.Context, in *pb.TableUpdate) (*pb.TableUpdateACK, error) {
-	log.Printf("Received: %v %v", in.Resource, in.ID)
-	Updatetableafterhearing(in.Resource, in.ID, &mux)
+	if s.t.credentials != nil {
+		caller, err := nodeidentity.Caller(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if !nodeidentity.Matches(in.ID, caller) {
+			s.t.logger.Warn("rejected resource update on behalf of another node", logging.Resource, in.Resource,
+				"claimed", in.ID, "caller", caller)
+			return nil, status.Errorf(codes.PermissionDenied, "node %s cannot announce resources of %s", caller, in.ID)
+		}
+	}
+	s.t.logger.Debug("received resource update", logging.Resource, in.Resource, "from", in.ID)
+	if err := s.t.Updatetableafterhearing(in); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 	return &pb.TableUpdateACK{Ack: "tableupdateACK" + in.Resource}, nil
 }
 
+//authorize : checks that the edge node calling is the one it acts on behalf of, when the edge nodes use mutual TLS
+func (s *server) authorize(ctx context.Context, claimed string, call string) error {
+	if s.t.credentials == nil {
+		return nil
+	}
+	caller, err := nodeidentity.Caller(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !nodeidentity.Matches(claimed, caller) {
+		s.t.logger.Warn("rejected call on behalf of another node", "call", call, "claimed", claimed, "caller", caller)
+		return status.Errorf(codes.PermissionDenied, "node %s cannot call %s on behalf of %s", caller, call, claimed)
+	}
+	return nil
+}
+
+func (s *server) PredictionUpdate(ctx context.Context, in *pb.Predictions) (*pb.TableUpdateACK, error) {
+	if err := s.authorize(ctx, in.ID, "PredictionUpdate"); err != nil {
+		return nil, err
+	}
+	if s.t.Onpredictions != nil {
+		s.t.Onpredictions(in)
+	}
+	return &pb.TableUpdateACK{Ack: "predictionupdateACK" + in.ID}, nil
+}
+
+func (s *server) Handover(ctx context.Context, in *pb.HandoverRequest) (*pb.HandoverReply, error) {
+	if err := s.authorize(ctx, in.ID, "Handover"); err != nil {
+		return nil, err
+	}
+	if s.t.Onhandover == nil {
+		return &pb.HandoverReply{}, nil
+	}
+	return s.t.Onhandover(in), nil
+}
+
+func (s *server) FetchResult(ctx context.Context, in *pb.FetchRequest) (*pb.RequestState, error) {
+	if err := s.authorize(ctx, in.ID, "FetchResult"); err != nil {
+		return nil, err
+	}
+	if s.t.Onfetch == nil {
+		return nil, status.Error(codes.Unimplemented, "sessions are not kept by this edge node")
+	}
+	return s.t.Onfetch(ctx, in)
+}
+
+func (s *server) Announcements(ctx context.Context, in *pb.AnnouncementsRequest) (*pb.TableUpdates, error) {
+	if err := s.authorize(ctx, in.ID, "Announcements"); err != nil {
+		return nil, err
+	}
+	s.t.announcedmux.Lock()
+	defer s.t.announcedmux.Unlock()
+	out := &pb.TableUpdates{}
+	for _, holders := range s.t.announced {
+		for _, update := range holders {
+			out.Updates = append(out.Updates, update)
+		}
+	}
+	return out, nil
+}
+
 /*
-Listenforupdates : This function starts the listening server on the edge node to listen for updates from
-other edge nodes about IoT resource availability
-Source: https://github.com/grpc/grpc-go/tree/master/examples/helloworld
+Init function is called from orchestartor only once when orchestrator starts. It starts a listener for receiving
+updates from other nodes and returns once the listener is bound so that updates from other nodes are not lost.
+Input: context whose cancellation stops the listener
+Output: error if the listening address could not be bound
 */
-func Listenforupdates() {
-	fmt.Println("launching grpcserver for listening to updates")
-	lis, err := net.Listen("tcp", port)
+func (t *Transport) Init(ctx context.Context) error {
+	lis, err := net.Listen("tcp", t.Address)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
-	}
-	s := grpc.NewServer()
-	pb.RegisterFrontendServer(s, &server{})
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		return err
 	}
-	Done <- true //signalling done here but this line gets hit only when we close the server
+	//start listener in background
+	go t.Listenforupdates(ctx, lis)
+	return nil
 }
 
 /*
-Reachabililty details of all other edge nodes in the cluster in terms of their IP address and
-listening ports is specified here.
+Listenforupdates : This function runs the listening server on the edge node to listen for updates from
+other edge nodes about IoT resource availability. The server is stopped gracefully when ctx is cancelled, letting
+the updates being received complete.
+Input: context whose cancellation stops the server, listener bound to the listening address of this edge node
+Output: Nil
+Source: https://github.com/grpc/grpc-go/tree/master/examples/helloworld
 */
-var address = [1]string{"2.2.2.2:2"}
+func (t *Transport) Listenforupdates(ctx context.Context, lis net.Listener) {
+	t.logger.Info("launching grpcserver for listening to updates", "addr", lis.Addr())
+	options := []grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler())} //picks up the trace context of the caller
+	if t.credentials != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(t.credentials.Server())))
+	} else {
+		t.logger.Warn("no node certificate given, accepting updates from other edge nodes in plain text")
+	}
+	s := grpc.NewServer(options...)
+	pb.RegisterFrontendServer(s, &server{t: t})
+	go func() {
+		<-ctx.Done()
+		t.logger.Info("stopping grpcserver")
+		s.GracefulStop()
+	}()
+	if err := s.Serve(lis); err != nil {
+		t.logger.Error("failed to serve", "err", err)
+	}
+	close(t.Done) //signalling done here, this line gets hit only when the server is stopped
+}
 
 /*
 Broadcast : This function broadcasts the information about upload of a new IoT resource on this edge
-node to all other edge nodes
+node to all other edge nodes. The address of every edge node that acknowledged the update is written to the measure
+channel, which is closed once all edge nodes have been tried.
 Source: https://github.com/grpc/grpc-go/tree/master/examples/helloworld
 */
-func Broadcast(ch chan Newresource, measurechannel chan bool) {
+func (t *Transport) Broadcast(ctx context.Context, ch chan Newresource, measurechannel chan string) {
 	input := <-ch
+	defer close(measurechannel)
+	defer trace.SpanFromContext(ctx).End()
 
-	var counter int
 	//loop to send on all other edge nodes
-	for i := 0; i < 1; i++ {
+	for _, peer := range t.Peers {
 		// Establish a connection to the server.
-		conn, err := grpc.Dial(address[i], grpc.WithInsecure())
+		conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
 		if err != nil {
-			log.Fatalf("did not connect: %v", err)
+			t.logger.Warn("did not connect", "peer", peer, logging.Resource, input.Resource, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			continue
 		}
 		defer conn.Close()
 		c := pb.NewFrontendClient(conn)
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
 		defer cancel()
-		r, err := c.ResourceTableUpdate(ctx, &pb.TableUpdate{Resource: input.Resource, ID: input.NodeID})
+		r, err := c.ResourceTableUpdate(ctx, tableupdate(input))
 		if err != nil {
-			log.Fatalf("could not greet: %v", err)
-		}
-		log.Printf("Greeting: %s", r.Ack)
-		// update a global counter after every ACK recceived and when it reaches 'n-1' (n = no. of nodes in cluster)
-		// update on the channel so that measure function stops the timer
-		counter++
-		if counter == 1 {
-			measurechannel <- true
+			t.logger.Warn("could not deliver resource update", "peer", peer, logging.Resource, input.Resource, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			continue
 		}
+		t.logger.Debug("resource update acknowledged", "peer", peer, logging.Resource, input.Resource, "ack", r.Ack)
+		measurechannel <- peer
+	}
+
+}
+
+//tableupdate : returns the update announcing an IoT resource to the other edge nodes
+func tableupdate(n Newresource) *pb.TableUpdate {
+	return &pb.TableUpdate{Resource: n.Resource, ID: n.NodeID, Hash: n.Hash, Contributor: n.Contributor,
+		Signature: n.Signature, Certificate: n.Certificate, Type: n.Type, Minlat: n.Footprint.Minlat,
+		Minlon: n.Footprint.Minlon, Maxlat: n.Footprint.Maxlat, Maxlon: n.Footprint.Maxlon,
+		Offloaded: n.Offloaded.UnixNano(), Size: n.Size, Origin: n.Origin}
+}
+
+//fromupdate : returns the IoT resource announced by an update, the inverse of tableupdate
+func fromupdate(in *pb.TableUpdate) Newresource {
+	return Newresource{Resource: in.Resource, NodeID: in.ID, Hash: in.Hash, Contributor: in.Contributor,
+		Type: in.Type, Footprint: geo.Box{Minlat: in.Minlat, Minlon: in.Minlon, Maxlat: in.Maxlat, Maxlon: in.Maxlon},
+		Offloaded: time.Unix(0, in.Offloaded), Size: in.Size, Origin: in.Origin}
+}
 
+/*
+Sync : Asks all other edge nodes for the updates of the IoT resources offloaded on them and adds to the catalog those
+this edge node missed, e.g. because a broadcast failed or this edge node was down. An update already recorded with the
+same content hash is skipped, the others are checked like a broadcast update.
+Input: context bounding the sync
+Output: number of IoT resources added to the catalog, edge nodes that could not be reached
+*/
+func (t *Transport) Sync(ctx context.Context) (int, []string) {
+	if t.log != nil {
+		t.logger.Info("catalog not synced, the replicated log keeps it consistent")
+		return 0, nil
+	}
+	added := 0
+	var unreachable []string
+	for _, peer := range t.Peers {
+		conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		if err != nil {
+			t.logger.Warn("did not connect", "peer", peer, "err", err)
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		callctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		updates, err := pb.NewFrontendClient(conn).Announcements(callctx, &pb.AnnouncementsRequest{ID: t.NodeID})
+		cancel()
+		conn.Close()
+		if err != nil {
+			t.logger.Warn("could not ask for the updates", "peer", peer, "err", err)
+			unreachable = append(unreachable, peer)
+			continue
+		}
+		missed := 0
+		for _, update := range updates.Updates {
+			if p, known := t.catalog.Lookup(update.Resource, update.ID); known && p.Hash == update.Hash {
+				continue
+			}
+			if err := t.Updatetableafterhearing(update); err == nil {
+				missed++
+			}
+		}
+		added += missed
+		t.logger.Info("catalog synced", "peer", peer, "updates", len(updates.Updates), "added", missed)
 	}
+	return added, unreachable
+}
 
+/*
+Sharepredictions : Sends the runtime statistics of the applications observed by this edge node to all other edge nodes.
+Input: context whose cancellation stops the sending, statistics of this edge node
+Output: Nil
+*/
+func (t *Transport) Sharepredictions(ctx context.Context, in *pb.Predictions) {
+	for _, peer := range t.Peers {
+		conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		if err != nil {
+			t.logger.Warn("did not connect", "peer", peer, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			continue
+		}
+		callctx, cancel := context.WithTimeout(ctx, time.Second)
+		_, err = pb.NewFrontendClient(conn).PredictionUpdate(callctx, in)
+		cancel()
+		conn.Close()
+		if err != nil {
+			t.logger.Warn("could not deliver runtime statistics", "peer", peer, "err", err)
+			t.metrics.Broadcastfailures.WithLabelValues(peer).Inc()
+			continue
+		}
+		t.logger.Debug("runtime statistics delivered", "peer", peer, "entries", len(in.Predictions))
+	}
 }
 
 /*
-Init function is called from orchestartor only once when orchestrator starts. This initializes the
-IoT resource catalog or the local system state and starts a listener for receiving updates from other nodes
+Handover : Asks all other edge nodes to hand over the session of a client that attached to this edge node. The edge
+nodes are asked at the same time and the first one that knew the client answers for the session.
+Input: context bounding the handover, client and ID of this edge node
+Output: address of the edge node the session was handed over from and its reply, whether one of them knew the client
 */
-func Init() {
+func (t *Transport) Handover(ctx context.Context, in *pb.HandoverRequest) (string, *pb.HandoverReply, bool) {
+	type answer struct {
+		peer  string
+		reply *pb.HandoverReply
+	}
+	answers := make(chan answer, len(t.Peers))
+	for _, peer := range t.Peers {
+		go func(peer string) {
+			conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+			if err != nil {
+				t.logger.Warn("did not connect", "peer", peer, "err", err)
+				answers <- answer{peer: peer}
+				return
+			}
+			defer conn.Close()
+			callctx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			reply, err := pb.NewFrontendClient(conn).Handover(callctx, in)
+			if err != nil {
+				t.logger.Warn("could not ask for handover", "peer", peer, "client", in.Client, "err", err)
+			}
+			answers <- answer{peer: peer, reply: reply}
+		}(peer)
+	}
+	for range t.Peers {
+		if a := <-answers; a.reply != nil && a.reply.Found {
+			return a.peer, a.reply, true
+		}
+	}
+	return "", nil, false
+}
 
-	Resourcetable = map[string][]string{}
+/*
+Fetchresult : Fetches the state and, once available, the result of a request from the edge node serving it.
+Input: context bounding the call, address of the edge node serving the request, request to fetch
+Output: state of the request, error if the edge node could not be reached
+*/
+func (t *Transport) Fetchresult(ctx context.Context, peer string, in *pb.FetchRequest) (*pb.RequestState, error) {
+	conn, err := grpc.Dial(peer, t.dialcredentials(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return pb.NewFrontendClient(conn).FetchResult(ctx, in)
+}
 
-	//start listener in background
-	go Listenforupdates()
-	Done1 <- true
+//dialcredentials : returns the transport credentials used to dial the other edge nodes
+func (t *Transport) dialcredentials() grpc.DialOption {
+	if t.credentials == nil {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(t.credentials.Client()))
 }
 
 /*
 Newresourceupdate : Handles the IoT resources offloaded on this edge node, updates the local state and broadcasts
 this update to other edge nodes in the cluster.
-Input: arrival of message on the channel dedicated for new IoT resources offloaded
+Input: context whose cancellation stops the function, arrival of message on the channel dedicated for new IoT
+resources offloaded
 Output: Nil
 */
-func Newresourceupdate(chIn chan Newresource, m *sync.Mutex, chOut chan Newresource) {
+func (t *Transport) Newresourceupdate(ctx context.Context, chIn chan Newresource, chOut chan Newresource) {
 
 	for {
-		NewIoTResourceUpload := <-chIn
+		var NewIoTResourceUpload Newresource
+		select {
+		case <-ctx.Done():
+			return
+		case NewIoTResourceUpload = <-chIn:
+		}
 
-		measurechannel := make(chan bool) //channel to indicate about ACK reception on resource updation from
+		measurechannel := make(chan string) //channel to indicate about ACK reception on resource updation from
 		//other nodes
 
-		go MeasureTime(measurechannel, NewIoTResourceUpload.Resource)
-		m.Lock()
-		fmt.Println("Lock acquired by Newresourceupdate")
-		fmt.Println("Newresourceupdate: Received iot resource and nodeID to append are:", NewIoTResourceUpload.Resource, NewIoTResourceUpload.NodeID)
-		fmt.Println("Newresourceupdate: Map before appending on node 1 is : ", Resourcetable)
-		fmt.Println("Newresourceupdate: Received IoT resource to append is :", NewIoTResourceUpload.Resource)
-
-		res := append(Resourcetable[NewIoTResourceUpload.NodeID], NewIoTResourceUpload.Resource)
-		Resourcetable[NewIoTResourceUpload.NodeID] = res
-		m.Unlock()
-		fmt.Println("Lock released by Newresourceupdate")
-		fmt.Println("Newresourceupdate: slice after appending is :", res)
-		fmt.Println("Newresourceupdate: map after appending is : ", Resourcetable)
-
-		//broadcast this update
+		go t.MeasureTime(measurechannel, NewIoTResourceUpload.Resource)
+		//the span of the offload is ended by the broadcast once all edge nodes have been tried
+		spanctx, _ := tracing.Tracer.Start(context.Background(), "offload", trace.WithAttributes(
+			attribute.String("ediro.resource", NewIoTResourceUpload.Resource),
+			attribute.String("ediro.node", NewIoTResourceUpload.NodeID)))
+		if NewIoTResourceUpload.Hash == "" {
+			NewIoTResourceUpload.Hash = contenthash(Datapath(t.Dir, NewIoTResourceUpload.Resource))
+		}
+		if NewIoTResourceUpload.Offloaded.IsZero() {
+			NewIoTResourceUpload.Offloaded = time.Now()
+		}
+		if NewIoTResourceUpload.Size == 0 {
+			NewIoTResourceUpload.Size = datasize(Datapath(t.Dir, NewIoTResourceUpload.Resource))
+		}
+
+		//sign the update, recorded with the resource and broadcast to the other edge nodes
 		var output Newresource
 		output.Resource = NewIoTResourceUpload.Resource
 		output.NodeID = NewIoTResourceUpload.NodeID
+		output.Hash = NewIoTResourceUpload.Hash
+		output.Contributor = NewIoTResourceUpload.Contributor
+		output.Type = NewIoTResourceUpload.Type
+		output.Footprint = NewIoTResourceUpload.Footprint
+		output.Offloaded = NewIoTResourceUpload.Offloaded
+		output.Size = NewIoTResourceUpload.Size
+		output.Origin = NewIoTResourceUpload.Origin
+		if t.credentials != nil {
+			var err error
+			output.Signature, output.Certificate, err = t.credentials.Sign(payload(output))
+			if err != nil {
+				t.logger.Error("could not sign resource update", logging.Resource, output.Resource, "err", err)
+			}
+		}
+		update := tableupdate(output)
+		provenance := Provenance{Hash: output.Hash, Contributor: output.Contributor, Origin: output.Origin}
+		holderresources, holders := t.record(update, provenance, Descriptor{Type: output.Type,
+			Footprint: output.Footprint, Offloaded: output.Offloaded, Size: output.Size})
+		t.logger.Info("new IoT resource offloaded", logging.Resource, output.Resource, "holder", output.NodeID,
+			"hash", provenance.Hash, "contributor", provenance.Contributor, "type", output.Type,
+			"holderresources", holderresources, "holders", holders)
+		t.announcedmux.Lock()
+		if t.announced[output.Resource] == nil {
+			t.announced[output.Resource] = map[string]*pb.TableUpdate{}
+		}
+		t.announced[output.Resource][output.NodeID] = update
+		t.announcedmux.Unlock()
+		if t.log != nil {
+			//the replicated log already spread the resource to the other edge nodes
+			close(measurechannel)
+			trace.SpanFromContext(spanctx).End()
+			continue
+		}
 		chOut <- output
-		go Broadcast(chOut, measurechannel)
+		go t.Broadcast(spanctx, chOut, measurechannel)
 	}
 
 }
 
 /*
-MeasureTime : Measures time from the time of resource upload on a node to its update on all other edge ndoes.
-Input: a boolean channel to indicate start and stop of timer, The associated iot resource for which the
-spreading time is being measured
+MeasureTime : Measures time from the time of resource upload on a node to its update on each other edge node.
+Input: a channel carrying the address of every edge node that acknowledged the update, closed when the broadcast is
+over, The associated iot resource for which the spreading time is being measured
 Output: Nil
 */
-func MeasureTime(measurechannel chan bool, iotresource string) {
+func (t *Transport) MeasureTime(measurechannel chan string, iotresource string) {
 	start := time.Now()
-	<-measurechannel
-	elapsed := time.Since(start)
-	fmt.Println("time elapsed in spreading resource is:", iotresource, elapsed)
+	for peer := range measurechannel {
+		t.metrics.Propagationtime.WithLabelValues(peer).Observe(time.Since(start).Seconds())
+	}
 }
 
 /*
 Updatetableafterhearing : Updates the resource catalog upon hearing new resource availability updates from
-other nodes. This function is called by the server on this node upon reception of new resource updates.
-Input: Received resource name and associated edge node ID
-Output: Nil.
+other nodes. This function is called by the server on this node upon reception of new resource updates. When this edge
+node has its credentials, the update must be signed by the edge node holding the resource, otherwise it is rejected.
+Input: Received resource update
+Output: error if the update was rejected
 */
-func Updatetableafterhearing(input string, ID string, m *sync.Mutex) {
-	m.Lock()
-	fmt.Println("Updatetableafterhearing: Received resource and nodeID are: ", input, ID)
-	fmt.Println("Updatetableafterhearing: Map before appending is:", Resourcetable)
-	res := append(Resourcetable[ID], input)
-	Resourcetable[ID] = res
-	fmt.Println("Updatetableafterhearing: slice after appending is :", res)
-	fmt.Println("Updatetableafterhearing: table after receiving update is : ", Resourcetable)
-	m.Unlock()
+func (t *Transport) Updatetableafterhearing(in *pb.TableUpdate) error {
+	provenance := Provenance{Hash: in.Hash, Contributor: in.Contributor, Origin: in.Origin}
+	announced := fromupdate(in)
+	if t.credentials != nil {
+		if err := t.verify(in); err != nil {
+			return err
+		}
+		provenance.Verified = true
+	}
+	descriptor := Descriptor{Type: announced.Type, Footprint: announced.Footprint, Offloaded: announced.Offloaded,
+		Size: announced.Size}
+	if in.Offloaded == 0 {
+		descriptor.Offloaded = time.Now() //announced by an edge node not giving the offload time
+	}
+	holderresources, holders := t.record(in, provenance, descriptor)
+	t.logger.Info("resource table updated from peer", logging.Resource, in.Resource, "holder", in.ID,
+		"hash", in.Hash, "contributor", in.Contributor, "verified", provenance.Verified,
+		"holderresources", holderresources, "holders", holders)
+	return nil
 }
 
-/*
-ResourceMonitor : It monitors the availability of a new version of an IoT resource that is currently in use
-and comunicates its arrival to task initiator to take necessary actions.
-Input: the resource to monitor as provided by task initiator module, channel of type bool which is closed
-when application completes its execution signalling stopping of resource monitoring
-Output: Nil (currently, only a statement is printed on the console to signal the new version of resource found)
-*/
-func ResourceMonitor(resourceToMonitor chan string, isComplete chan bool) {
-	resourcetofind := <-resourceToMonitor
-	for {
-		select {
-		case <-isComplete:
-			fmt.Println("channel closed: application has terminated, no more resource monitoring required")
-			return
-
-		default:
-			for key := range Resourcetable {
-				for i := range Resourcetable[key] {
-					if resourcetofind == Resourcetable[key][i] {
-						fmt.Println("New version found of resource : ", resourcetofind)
-						return
-					}
-				}
-			}
-		}
+//verify : checks that a resource update is signed by the edge node holding the resource
+func (t *Transport) verify(in *pb.TableUpdate) error {
+	if len(in.Signature) == 0 {
+		t.logger.Warn("rejected unsigned resource update", logging.Resource, in.Resource, "holder", in.ID)
+		return errors.New("resource update is not signed")
+	}
+	signer, err := t.credentials.Verify(in.Certificate, payload(fromupdate(in)), in.Signature)
+	if err != nil {
+		t.logger.Warn("rejected resource update with an invalid signature", logging.Resource, in.Resource,
+			"holder", in.ID, "err", err)
+		return fmt.Errorf("invalid signature: %v", err)
 	}
+	if !nodeidentity.Matches(in.ID, signer) {
+		t.logger.Warn("rejected resource update signed by another node", logging.Resource, in.Resource,
+			"holder", in.ID, "signer", signer)
+		return fmt.Errorf("resource update of %s is signed by %s", in.ID, signer)
+	}
+	return nil
+}
+
+//record : records in the catalog the IoT resource announced by an update, handing the update along to the leader of
+//the replicated log which checks its signature
+func (t *Transport) record(update *pb.TableUpdate, p Provenance, d Descriptor) (int, int) {
+	return t.catalog.record(Command{Op: Recordop, Resource: update.Resource, Node: update.ID, Provenance: p,
+		Descriptor: d, Update: update})
 }
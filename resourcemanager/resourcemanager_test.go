@@ -0,0 +1,161 @@
+package resourcemanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/nodeidentity"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//callerctx : returns the context of a gRPC call made over mutual TLS by the node with the given certificate identity
+func callerctx(identity string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: identity}}
+	state := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+//cluster : issues the credentials of the given edge nodes by one cluster CA
+func cluster(t *testing.T, nodeIDs ...string) map[string]*nodeidentity.Credentials {
+	capem, cakeypem, err := nodeidentity.Newca("test", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cafile := filepath.Join(dir, "ca.crt")
+	if err := nodeidentity.Writefile(cafile, capem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	nodes := map[string]*nodeidentity.Credentials{}
+	for _, nodeID := range nodeIDs {
+		certpem, keypem, err := nodeidentity.Issue(capem, cakeypem, nodeID, nil, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certfile, keyfile := filepath.Join(dir, nodeID+".crt"), filepath.Join(dir, nodeID+".key")
+		if err := nodeidentity.Writefile(certfile, certpem, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodeidentity.Writefile(keyfile, keypem, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if nodes[nodeID], err = nodeidentity.Load(certfile, keyfile, cafile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return nodes
+}
+
+//signed : returns a resource update announcing r on the given holder, signed by signer if not nil
+func signed(t *testing.T, signer *nodeidentity.Credentials, holder string) *pb.TableUpdate {
+	in := &pb.TableUpdate{Resource: "r", ID: holder, Hash: "sha256:00", Contributor: "vehicle_1"}
+	if signer != nil {
+		var err error
+		in.Signature, in.Certificate, err = signer.Sign(payload(Newresource{Resource: in.Resource, NodeID: in.ID,
+			Hash: in.Hash, Contributor: in.Contributor, Offloaded: time.Unix(0, in.Offloaded)}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return in
+}
+
+func TestResourceTableUpdate(t *testing.T) {
+	nodes := cluster(t, "edge_node_1", "edge_node_2", "edge_node_3")
+	tests := []struct {
+		name         string
+		tls          bool //whether the edge node is given its credentials
+		ctx          context.Context
+		in           *pb.TableUpdate
+		wantcode     codes.Code
+		wantverified bool
+	}{
+		{name: "plain text", ctx: context.Background(), in: signed(t, nil, "edge_node_2"), wantcode: codes.OK},
+		{name: "own resource", tls: true, ctx: callerctx("edge_node_2"), in: signed(t, nodes["edge_node_2"],
+			"edge_node_2"), wantcode: codes.OK, wantverified: true},
+		{name: "placement constraint on the caller", tls: true, ctx: callerctx("edge_node_2"),
+			in: signed(t, nodes["edge_node_2"], "node.labels.device==edge_node_2"), wantcode: codes.OK,
+			wantverified: true},
+		{name: "resource of another node", tls: true, ctx: callerctx("edge_node_2"),
+			in: signed(t, nodes["edge_node_2"], "edge_node_3"), wantcode: codes.PermissionDenied},
+		{name: "caller without certificate", tls: true, ctx: context.Background(),
+			in: signed(t, nodes["edge_node_2"], "edge_node_2"), wantcode: codes.Unauthenticated},
+		{name: "unsigned update", tls: true, ctx: callerctx("edge_node_2"), in: signed(t, nil, "edge_node_2"),
+			wantcode: codes.PermissionDenied},
+		{name: "signed by another node", tls: true, ctx: callerctx("edge_node_2"),
+			in: signed(t, nodes["edge_node_3"], "edge_node_2"), wantcode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			var creds *nodeidentity.Credentials
+			if tt.tls {
+				creds = nodes["edge_node_1"]
+			}
+			c := NewCatalog(logger)
+			s := &server{t: NewTransport("127.0.0.1:0", nil, creds, c, metrics.New(logger), logger)}
+			_, err := s.ResourceTableUpdate(tt.ctx, tt.in)
+			if code := status.Code(err); code != tt.wantcode {
+				t.Fatalf("ResourceTableUpdate = %v, want %s", err, tt.wantcode)
+			}
+			_, p, recorded := c.Claim("r", "")
+			if recorded != (tt.wantcode == codes.OK) {
+				t.Errorf("resource recorded %v", recorded)
+			}
+			if recorded && (p.Verified != tt.wantverified || p.Hash != tt.in.Hash ||
+				p.Contributor != tt.in.Contributor) {
+				t.Errorf("recorded with the provenance %+v", p)
+			}
+		})
+	}
+}
+
+func TestAnnouncements(t *testing.T) {
+	nodes := cluster(t, "edge_node_1", "edge_node_2")
+	tests := []struct {
+		name        string
+		tls         bool
+		ctx         context.Context
+		in          *pb.AnnouncementsRequest
+		wantcode    codes.Code
+		wantupdates int
+	}{
+		{name: "plain text", ctx: context.Background(), in: &pb.AnnouncementsRequest{ID: "edge_node_2"},
+			wantcode: codes.OK, wantupdates: 2},
+		{name: "asked by the caller", tls: true, ctx: callerctx("edge_node_2"),
+			in: &pb.AnnouncementsRequest{ID: "edge_node_2"}, wantcode: codes.OK, wantupdates: 2},
+		{name: "asked on behalf of another node", tls: true, ctx: callerctx("edge_node_2"),
+			in: &pb.AnnouncementsRequest{ID: "edge_node_3"}, wantcode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			var creds *nodeidentity.Credentials
+			if tt.tls {
+				creds = nodes["edge_node_1"]
+			}
+			tr := NewTransport("127.0.0.1:0", nil, creds, NewCatalog(logger), metrics.New(logger), logger)
+			tr.announced["r"] = map[string]*pb.TableUpdate{"edge_node_1": tableupdate(Newresource{Resource: "r",
+				NodeID: "edge_node_1"})}
+			tr.announced["s"] = map[string]*pb.TableUpdate{"edge_node_1": tableupdate(Newresource{Resource: "s",
+				NodeID: "edge_node_1"})}
+			out, err := (&server{t: tr}).Announcements(tt.ctx, tt.in)
+			if code := status.Code(err); code != tt.wantcode || len(out.GetUpdates()) != tt.wantupdates {
+				t.Errorf("Announcements = %d updates, %v, want %d, %s", len(out.GetUpdates()), err, tt.wantupdates,
+					tt.wantcode)
+			}
+		})
+	}
+}
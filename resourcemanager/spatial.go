@@ -0,0 +1,208 @@
+/*
+This file implements the spatial index of the IoT resource catalog. An IoT resource may be announced with its type
+(e.g. hd_map), the area it covers and the time it was offloaded. The catalog indexes the area under geohash cells (see
+package geo) so that discovery can resolve a location query, i.e. all the available IoT resources of a type overlapping
+an area and fresher than a given age, instead of a single resource name.
+*/
+
+package resourcemanager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+)
+
+//maxcells : maximum number of geohash cells a footprint is indexed under
+const maxcells = 16
+
+//maxquerycells : number of geohash cells of a query above which the index is scanned instead
+const maxquerycells = 4096
+
+//Descriptor : What an IoT resource held by an edge node is about
+type Descriptor struct {
+	//Type : type of the IoT resource, e.g. hd_map, empty if unknown
+	Type string
+	//Footprint : area covered by the IoT resource, the zero box if unknown
+	Footprint geo.Box
+	//Offloaded : time the IoT resource was offloaded on the edge cluster
+	Offloaded time.Time
+	//Size : size of the data of the IoT resource in bytes, 0 if unknown
+	Size int64
+}
+
+//Match : An available IoT resource matching a location query
+type Match struct {
+	Resource, Node string
+	Descriptor     Descriptor
+	Provenance     Provenance
+}
+
+//entry : an IoT resource on an edge node
+type entry struct {
+	resource, node string
+}
+
+//spatialindex : the descriptors of the IoT resources and the geohash cells their footprint is indexed under
+type spatialindex struct {
+	descriptors map[entry]Descriptor
+	//cells : entries keyed by geohash cell, the cells of all precisions share the map as their length differs
+	cells map[string]map[entry]bool
+	//precisions : number of footprints indexed at each precision
+	precisions map[int]int
+}
+
+//newspatialindex : creates an empty spatial index
+func newspatialindex() *spatialindex {
+	return &spatialindex{descriptors: map[entry]Descriptor{}, cells: map[string]map[entry]bool{},
+		precisions: map[int]int{}}
+}
+
+//remove : removes an entry from the index
+func (s *spatialindex) remove(e entry) {
+	d, ok := s.descriptors[e]
+	if !ok {
+		return
+	}
+	delete(s.descriptors, e)
+	if d.Footprint.Empty() {
+		return
+	}
+	precision := geo.Precision(d.Footprint, maxcells)
+	for _, cell := range geo.Cells(d.Footprint, precision) {
+		delete(s.cells[cell], e)
+		if len(s.cells[cell]) == 0 {
+			delete(s.cells, cell)
+		}
+	}
+	s.precisions[precision]--
+}
+
+//add : indexes an entry under the cells of its footprint
+func (s *spatialindex) add(e entry, d Descriptor) {
+	s.remove(e)
+	s.descriptors[e] = d
+	if d.Footprint.Empty() {
+		return
+	}
+	precision := geo.Precision(d.Footprint, maxcells)
+	for _, cell := range geo.Cells(d.Footprint, precision) {
+		if s.cells[cell] == nil {
+			s.cells[cell] = map[entry]bool{}
+		}
+		s.cells[cell][e] = true
+	}
+	s.precisions[precision]++
+}
+
+//candidates : returns the entries whose footprint may overlap an area
+func (s *spatialindex) candidates(area geo.Box) map[entry]bool {
+	found := map[entry]bool{}
+	for precision, n := range s.precisions {
+		if n <= 0 {
+			continue
+		}
+		if geo.Count(area, precision) > maxquerycells {
+			//the area is too large for the cells of this precision, every entry is a candidate
+			for e := range s.descriptors {
+				found[e] = true
+			}
+			return found
+		}
+		for _, cell := range geo.Cells(area, precision) {
+			for e := range s.cells[cell] {
+				found[e] = true
+			}
+		}
+	}
+	return found
+}
+
+/*
+Describe : Records the type, footprint and offload time of an IoT resource held by an edge node and indexes it. The
+channels watching the resource are closed, see Watch.
+Input: IoT resource, edge node holding it, descriptor of the resource
+Output: Nil
+*/
+func (c *Catalog) Describe(resource string, nodeID string, d Descriptor) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.index.add(entry{resource, nodeID}, d)
+	c.notify(resource, d)
+}
+
+/*
+Find : Resolves a location query against the catalog.
+Input: the query, i.e. type, area and maximum age of the IoT resources
+Output: the available IoT resources matching the query, the freshest first
+*/
+func (c *Catalog) Find(q geo.Query) []Match {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var candidates map[entry]bool
+	if q.Area.Empty() {
+		candidates = map[entry]bool{}
+		for e := range c.index.descriptors {
+			candidates[e] = true
+		}
+	} else {
+		candidates = c.index.candidates(q.Area)
+	}
+
+	var matches []Match
+	for e := range candidates {
+		d := c.index.descriptors[e]
+		if q.Type != "" && d.Type != q.Type {
+			continue
+		}
+		if !q.Area.Empty() && (d.Footprint.Empty() || !d.Footprint.Intersects(q.Area)) {
+			continue
+		}
+		if q.Maxage > 0 && time.Since(d.Offloaded) > q.Maxage {
+			continue
+		}
+		if !c.available(e.resource, e.node) {
+			continue
+		}
+		matches = append(matches, Match{Resource: e.resource, Node: e.node, Descriptor: d,
+			Provenance: c.Provenance[e.resource][e.node]})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Descriptor.Offloaded.After(matches[j].Descriptor.Offloaded)
+	})
+	return matches
+}
+
+//Replicas : returns the edge nodes on which an IoT resource is available along with what it is about, the freshest first
+func (c *Catalog) Replicas(resource string) []Match {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var matches []Match
+	for node := range c.Resourcetable {
+		if c.available(resource, node) {
+			matches = append(matches, Match{Resource: resource, Node: node,
+				Descriptor: c.index.descriptors[entry{resource, node}], Provenance: c.Provenance[resource][node]})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Descriptor.Offloaded.After(matches[j].Descriptor.Offloaded)
+	})
+	return matches
+}
+
+//available : returns whether an edge node that is not draining holds an IoT resource that is not used yet, mux must be
+//held
+func (c *Catalog) available(resource string, nodeID string) bool {
+	return c.states[nodeID] != Draining && c.held(resource, nodeID)
+}
+
+//held : returns whether an edge node holds an IoT resource that is not used yet, whatever its state, mux must be held
+func (c *Catalog) held(resource string, nodeID string) bool {
+	for _, r := range c.Resourcetable[nodeID] {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
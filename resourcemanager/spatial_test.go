@@ -0,0 +1,116 @@
+package resourcemanager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+)
+
+var (
+	munich  = geo.Box{Minlat: 48.1, Minlon: 11.5, Maxlat: 48.2, Maxlon: 11.6}
+	bavaria = geo.Box{Minlat: 47, Minlon: 9, Maxlat: 50, Maxlon: 14}
+	berlin  = geo.Box{Minlat: 52.4, Minlon: 13.3, Maxlat: 52.6, Maxlon: 13.5}
+	world   = geo.Box{Minlat: -90, Minlon: -180, Maxlat: 90, Maxlon: 180}
+)
+
+//locatedresource : an IoT resource recorded on an edge node with what it is about
+type locatedresource struct {
+	resource, node string
+	descriptor     Descriptor
+}
+
+func TestFind(t *testing.T) {
+	now := time.Now()
+	resources := []locatedresource{
+		{"map_a", "n1", Descriptor{Type: "hd_map", Footprint: munich, Offloaded: now.Add(-time.Minute)}},
+		{"big", "n2", Descriptor{Type: "hd_map", Footprint: bavaria, Offloaded: now.Add(-10 * time.Second)}},
+		{"sensor", "n1", Descriptor{Type: "sensor", Footprint: geo.Box{Minlat: 48.14, Minlon: 11.58, Maxlat: 48.14,
+			Maxlon: 11.58}, Offloaded: now.Add(-5 * time.Minute)}},
+		{"map_b", "n2", Descriptor{Type: "hd_map", Footprint: berlin, Offloaded: now.Add(-90 * time.Second)}},
+		{"nofootprint", "n1", Descriptor{Type: "hd_map", Offloaded: now.Add(-2 * time.Minute)}},
+	}
+	tests := []struct {
+		name    string
+		query   geo.Query
+		claimed []string //resources claimed before the query
+		want    []string //matching resources, the freshest first
+	}{
+		{name: "type over an area", query: geo.Query{Type: "hd_map", Area: munich}, want: []string{"big", "map_a"}},
+		{name: "any type over an area", query: geo.Query{Area: munich}, want: []string{"big", "map_a", "sensor"}},
+		{name: "too old", query: geo.Query{Area: berlin, Maxage: 30 * time.Second}},
+		{name: "fresh enough", query: geo.Query{Area: berlin, Maxage: 2 * time.Minute}, want: []string{"map_b"}},
+		{name: "no area", query: geo.Query{Type: "hd_map"}, want: []string{"big", "map_a", "map_b", "nofootprint"}},
+		{name: "area scanned", query: geo.Query{Type: "hd_map", Area: world}, want: []string{"big", "map_a", "map_b"}},
+		{name: "area without resources", query: geo.Query{Area: geo.Box{Minlat: -34, Minlon: 18, Maxlat: -33,
+			Maxlon: 19}}},
+		{name: "claimed resource", query: geo.Query{Type: "hd_map", Area: munich}, claimed: []string{"map_a"},
+			want: []string{"big"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newcatalog()
+			for _, r := range resources {
+				c.Add(r.resource, r.node, Provenance{})
+				c.Describe(r.resource, r.node, r.descriptor)
+			}
+			for _, resource := range tt.claimed {
+				if _, _, ok := c.Claim(resource, ""); !ok {
+					t.Fatalf("could not claim %s", resource)
+				}
+			}
+			var got []string
+			for _, m := range c.Find(tt.query) {
+				got = append(got, m.Resource)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpatialindex(t *testing.T) {
+	e := entry{"map", "n1"}
+	tests := []struct {
+		name        string
+		footprints  []geo.Box //footprints the entry is described with in turn
+		remove      bool
+		query       geo.Box
+		wantfound   bool
+		wantindexed bool //whether cells are left in the index
+	}{
+		{name: "indexed", footprints: []geo.Box{munich}, query: munich, wantfound: true, wantindexed: true},
+		{name: "described again elsewhere", footprints: []geo.Box{munich, berlin}, query: munich,
+			wantindexed: true},
+		{name: "found at its new place", footprints: []geo.Box{munich, berlin}, query: berlin, wantfound: true,
+			wantindexed: true},
+		{name: "footprint dropped", footprints: []geo.Box{munich, {}}, query: munich},
+		{name: "removed", footprints: []geo.Box{munich}, remove: true, query: munich},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newspatialindex()
+			for _, footprint := range tt.footprints {
+				s.add(e, Descriptor{Type: "hd_map", Footprint: footprint})
+			}
+			if tt.remove {
+				s.remove(e)
+			}
+			if found := s.candidates(tt.query)[e]; found != tt.wantfound {
+				t.Errorf("candidate %v, want %v", found, tt.wantfound)
+			}
+			if indexed := len(s.cells) > 0; indexed != tt.wantindexed {
+				t.Errorf("%d cells indexed, want cells %v", len(s.cells), tt.wantindexed)
+			}
+			indexed := 0
+			for _, n := range s.precisions {
+				indexed += n
+			}
+			if (indexed > 0) != tt.wantindexed {
+				t.Errorf("%d footprints counted, want footprints %v", indexed, tt.wantindexed)
+			}
+		})
+	}
+}
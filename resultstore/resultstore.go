@@ -0,0 +1,168 @@
+/*
+This package implements the result store of EDIRO. Workloads hand their result back to EDIRO either by writing it to a
+file in their mounted output directory or by calling back the EDIRO node that launched them. The result is stored
+against the client request that triggered the workload and kept for a configurable time to live, during which the client
+can fetch it through the client API or be pushed the result as soon as it arrives. The workload of a subscription
+delivers a result for every run, each of which replaces the previous one and is pushed to the watching clients.
+
+*/
+
+package resultstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Sources from which a result can reach the result store
+const (
+	Fromfile     = "file"
+	Fromcallback = "callback"
+	//Fromhandover : the result was fetched from the edge node serving the request of a client that moved here
+	Fromhandover = "handover"
+)
+
+//Result : The result produced by the workload of a client request
+type Result struct {
+	Request     string
+	ContentType string
+	Source      string
+	Data        []byte
+	Stored      time.Time
+}
+
+//Store : The results of the client requests served by an edge node
+type Store struct {
+	//Results : Declaring the map to store the results keyed by the client request
+	Results map[string]Result
+	//subscribers : channels of clients waiting to be pushed the result of a request
+	subscribers map[string][]chan Result
+	//watchers : channels of clients pushed every result of a request, e.g. of a subscription
+	watchers map[string][]chan Result
+	mux      sync.Mutex
+}
+
+//New : creates an empty result store
+func New() *Store {
+	return &Store{Results: map[string]Result{}, subscribers: map[string][]chan Result{},
+		watchers: map[string][]chan Result{}}
+}
+
+//Put : stores the result of a request and pushes it to every client waiting for it or watching the request
+func (s *Store) Put(r Result) {
+	r.Stored = time.Now()
+	s.mux.Lock()
+	s.Results[r.Request] = r
+	waiting := s.subscribers[r.Request]
+	delete(s.subscribers, r.Request)
+	for _, ch := range s.watchers[r.Request] {
+		select {
+		case ch <- r:
+		default: //the client does not keep up, it misses this result
+		}
+	}
+	s.mux.Unlock()
+
+	for _, ch := range waiting {
+		ch <- r
+		close(ch)
+	}
+}
+
+//Get : returns the result of a request and whether it is available
+func (s *Store) Get(request string) (Result, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	r, ok := s.Results[request]
+	return r, ok
+}
+
+/*
+Subscribe : returns a channel on which the result of a request is delivered once. If the result is already available it
+is delivered right away.
+Input: client request
+Output: channel carrying the result
+*/
+func (s *Store) Subscribe(request string) chan Result {
+	ch := make(chan Result, 1)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if r, ok := s.Results[request]; ok {
+		ch <- r
+		close(ch)
+		return ch
+	}
+	s.subscribers[request] = append(s.subscribers[request], ch)
+	return ch
+}
+
+//Unsubscribe : removes a channel returned by Subscribe that is no longer read from
+func (s *Store) Unsubscribe(request string, ch chan Result) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	waiting := s.subscribers[request]
+	for i := range waiting {
+		if waiting[i] == ch {
+			s.subscribers[request] = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(s.subscribers[request]) == 0 {
+		delete(s.subscribers, request)
+	}
+}
+
+//watchbuffer : number of results kept for a watching client that did not read them yet
+const watchbuffer = 16
+
+/*
+Watch : returns a channel on which every result of a request stored from now on is delivered, until Unwatch is called.
+Input: client request
+Output: channel carrying the results
+*/
+func (s *Store) Watch(request string) chan Result {
+	ch := make(chan Result, watchbuffer)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.watchers[request] = append(s.watchers[request], ch)
+	return ch
+}
+
+//Unwatch : removes a channel returned by Watch that is no longer read from
+func (s *Store) Unwatch(request string, ch chan Result) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	watching := s.watchers[request]
+	for i := range watching {
+		if watching[i] == ch {
+			s.watchers[request] = append(watching[:i], watching[i+1:]...)
+			break
+		}
+	}
+	if len(s.watchers[request]) == 0 {
+		delete(s.watchers, request)
+	}
+}
+
+/*
+Expire : Periodically removes the results that are older than the time to live.
+Input: context whose cancellation stops the function, time to live of a result, interval between two passes
+Output: Nil
+*/
+func (s *Store) Expire(ctx context.Context, ttl time.Duration, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		s.mux.Lock()
+		for request, r := range s.Results {
+			if time.Since(r.Stored) > ttl {
+				delete(s.Results, request)
+			}
+		}
+		s.mux.Unlock()
+	}
+}
@@ -0,0 +1,149 @@
+package resultstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		storedfirst bool //whether the result is stored before the subscription
+		unsubscribe bool //whether the subscriber gives up before the result is stored
+		wantpushed  bool
+	}{
+		{name: "result stored after the subscription", wantpushed: true},
+		{name: "result already stored", storedfirst: true, wantpushed: true},
+		{name: "subscriber gave up", unsubscribe: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			result := Result{Request: "req", Source: Fromcallback, Data: []byte("42")}
+			if tt.storedfirst {
+				s.Put(result)
+			}
+			ch := s.Subscribe("req")
+			if tt.unsubscribe {
+				s.Unsubscribe("req", ch)
+			}
+			if !tt.storedfirst {
+				s.Put(result)
+			}
+			select {
+			case r, ok := <-ch:
+				if !tt.wantpushed || !ok || string(r.Data) != "42" {
+					t.Errorf("pushed %q, %v, want pushed %v", r.Data, ok, tt.wantpushed)
+				}
+			default:
+				if tt.wantpushed {
+					t.Error("result not pushed")
+				}
+			}
+			if len(s.subscribers) != 0 {
+				t.Errorf("%d requests left with subscribers", len(s.subscribers))
+			}
+		})
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	s := New()
+	first, second := s.Subscribe("req"), s.Subscribe("req")
+	s.Unsubscribe("req", first)
+	if len(s.subscribers["req"]) != 1 || s.subscribers["req"][0] != second {
+		t.Fatalf("subscribers %v, want only the second one", s.subscribers["req"])
+	}
+	s.Unsubscribe("req", second)
+	if _, ok := s.subscribers["req"]; ok {
+		t.Error("request kept without subscribers")
+	}
+}
+
+func TestGet(t *testing.T) {
+	s := New()
+	before := time.Now()
+	s.Put(Result{Request: "req", Data: []byte("42")})
+	if r, ok := s.Get("req"); !ok || string(r.Data) != "42" || r.Stored.Before(before) {
+		t.Errorf("Get = %+v, %v, want the stored result", r, ok)
+	}
+	if _, ok := s.Get("other"); ok {
+		t.Error("result of a request never stored")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   time.Duration //age of the result
+		wantkept bool
+	}{
+		{name: "recent result", stored: time.Minute, wantkept: true},
+		{name: "expired result", stored: 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			s.Results["req"] = Result{Request: "req", Stored: time.Now().Add(-tt.stored)}
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				s.Expire(ctx, time.Hour, time.Millisecond)
+				close(done)
+			}()
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expire did not return once cancelled")
+			}
+			if _, kept := s.Get("req"); kept != tt.wantkept {
+				t.Errorf("result kept %v, want %v", kept, tt.wantkept)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		results int  //results stored while the client watches
+		unwatch bool //whether the client stops watching before the results are stored
+		want    int  //results pushed to the client
+	}{
+		{name: "every result pushed", results: 3, want: 3},
+		{name: "client not keeping up", results: watchbuffer + 2, want: watchbuffer},
+		{name: "client stopped watching", results: 1, unwatch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			ch := s.Watch("sub")
+			if tt.unwatch {
+				s.Unwatch("sub", ch)
+			}
+			for i := 0; i < tt.results; i++ {
+				s.Put(Result{Request: "sub", Data: []byte{byte(i)}})
+			}
+			pushed := 0
+			for len(ch) > 0 {
+				if r := <-ch; r.Data[0] != byte(pushed) {
+					t.Errorf("result %d pushed in place of %d", r.Data[0], pushed)
+				}
+				pushed++
+			}
+			if pushed != tt.want {
+				t.Errorf("%d results pushed, want %d", pushed, tt.want)
+			}
+			if r, _ := s.Get("sub"); r.Data[0] != byte(tt.results-1) {
+				t.Errorf("result %d stored, want the last one", r.Data[0])
+			}
+			s.Unwatch("sub", ch)
+			if len(s.watchers) != 0 {
+				t.Errorf("%d requests left with watchers", len(s.watchers))
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+/*
+This package implements the sessions of the clients of EDIRO. Vehicles move between edge nodes, e.g. from one traffic
+light to the next, while their requests are being served. The session of a client records the edge node currently
+serving it, i.e. the last edge node the client attached to. A client attaches by submitting a request or explicitly
+when it reconnects. When a client attaches to an edge node that is not serving it, the session is handed over from the
+edge node that served it before: the states of the requests of the client and the results that are already available
+move to the new edge node. The workloads keep running where they were launched and the new edge node fetches their
+output from the previous one as soon as it is available, so that the client finds everything at the edge node it is
+attached to.
+
+*/
+
+package session
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resultstore"
+)
+
+//fetchwait : time a fetch of the result of a request waits on the edge node serving it
+const fetchwait = 10 * time.Second
+
+//retryinterval : time before fetching again from an edge node that could not be reached
+const retryinterval = 5 * time.Second
+
+//Session : The attachment of a client to the edge cluster
+type Session struct {
+	Client string `json:"client"`
+	//Node : edge node serving the client
+	Node string `json:"node"`
+	//Previous : edge node the session was handed over from, empty if none
+	Previous string    `json:"previous,omitempty"`
+	Attached time.Time `json:"attached"`
+}
+
+//Transport : The inter edge communication used to hand the sessions over, see package resourcemanager
+type Transport interface {
+	Handover(ctx context.Context, in *pb.HandoverRequest) (string, *pb.HandoverReply, bool)
+	Fetchresult(ctx context.Context, peer string, in *pb.FetchRequest) (*pb.RequestState, error)
+}
+
+//Manager : The sessions known to an edge node
+type Manager struct {
+	//NodeID : ID of this edge node
+	NodeID string
+
+	sessions map[string]*Session
+	//ctx : context whose cancellation stops the following of the handed over requests, set by Resume
+	ctx       context.Context
+	mux       sync.Mutex
+	transport Transport
+	records   *requestrecord.Store
+	results   *resultstore.Store
+	logger    *slog.Logger
+}
+
+//New : creates the session manager of an edge node handing the sessions over through the given transport
+func New(nodeID string, transport Transport, records *requestrecord.Store, results *resultstore.Store,
+	logger *slog.Logger) *Manager {
+	return &Manager{NodeID: nodeID, sessions: map[string]*Session{}, ctx: context.Background(), transport: transport,
+		records: records, results: results, logger: logger}
+}
+
+//Get : returns the session of a client and whether this edge node knows it
+func (m *Manager) Get(client string) (Session, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	s, ok := m.sessions[client]
+	if !ok {
+		return Session{}, false
+	}
+	return *s, true
+}
+
+/*
+Attach : Attaches a client to this edge node. If this edge node was not serving the client yet, the session is handed
+over from the edge node that served it before, if any, and the results of the requests of the client still running
+are fetched from there.
+Input: context bounding the handover, client
+Output: the session of the client
+*/
+func (m *Manager) Attach(ctx context.Context, client string) Session {
+	m.mux.Lock()
+	if s, ok := m.sessions[client]; ok && s.Node == m.NodeID {
+		s.Attached = time.Now()
+		defer m.mux.Unlock()
+		return *s
+	}
+	s := &Session{Client: client, Node: m.NodeID, Attached: time.Now()}
+	m.sessions[client] = s
+	m.mux.Unlock()
+
+	peer, reply, found := m.transport.Handover(ctx, &pb.HandoverRequest{Client: client, ID: m.NodeID})
+	if !found {
+		m.logger.Info("client attached", "client", client)
+		return *s
+	}
+	m.mux.Lock()
+	s.Previous = reply.ID
+	session := *s
+	m.mux.Unlock()
+
+	for _, state := range reply.Requests {
+		m.take(peer, client, state)
+	}
+	m.logger.Info("session handed over", "client", client, "from", reply.ID, "peer", peer,
+		"requests", len(reply.Requests))
+	return session
+}
+
+/*
+take : Records a request of a client whose session was handed over to this edge node, along with its result if it is
+available, and follows it on the edge node serving it otherwise.
+Input: address of the edge node serving the request, client, state of the request on that edge node
+Output: Nil
+*/
+func (m *Manager) take(peer string, client string, state *pb.RequestState) {
+	if r, ok := m.records.Get(state.Request); ok && r.Origin == "" {
+		//this edge node serves the request itself
+		m.records.Update(state.Request, func(r *requestrecord.Record) {
+			if !r.Has(client) {
+				r.Clients = append(r.Clients, client)
+			}
+		})
+		return
+	}
+	m.records.Add(requestrecord.Record{Request: state.Request, Application: state.Application,
+		Clients: []string{client}, Priority: state.Priority, Origin: peer, State: state.State, Reason: state.Reason,
+		Submitted: time.Unix(0, state.Submitted)})
+	if state.Hasresult {
+		m.results.Put(resultstore.Result{Request: state.Request, ContentType: state.Contenttype,
+			Source: resultstore.Fromhandover, Data: state.Result})
+	}
+	if state.State == requestrecord.Submitted || state.State == requestrecord.Launched {
+		m.mux.Lock()
+		ctx := m.ctx
+		m.mux.Unlock()
+		go m.follow(ctx, peer, state.Request)
+	}
+}
+
+/*
+follow : Fetches the state of a request handed over to this edge node from the edge node serving it until the request
+is over, and stores its result once it is available.
+Input: context whose cancellation stops the function, address of the edge node serving the request, request
+Output: Nil
+*/
+func (m *Manager) follow(ctx context.Context, peer string, request string) {
+	for ctx.Err() == nil {
+		callctx, cancel := context.WithTimeout(ctx, fetchwait+time.Second)
+		state, err := m.transport.Fetchresult(callctx, peer, &pb.FetchRequest{Request: request, ID: m.NodeID,
+			Waitms: fetchwait.Milliseconds()})
+		cancel()
+		if err != nil {
+			m.logger.Warn("could not fetch result of handed over request", logging.Request, request, "peer", peer,
+				"err", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryinterval):
+			}
+			continue
+		}
+		m.records.Update(request, func(r *requestrecord.Record) {
+			r.State, r.Reason = state.State, state.Reason
+		})
+		if state.Hasresult {
+			m.results.Put(resultstore.Result{Request: request, ContentType: state.Contenttype,
+				Source: resultstore.Fromhandover, Data: state.Result})
+			m.logger.Info("result of handed over request forwarded", logging.Request, request, "peer", peer)
+			return
+		}
+		if state.State != requestrecord.Submitted && state.State != requestrecord.Launched {
+			return
+		}
+	}
+}
+
+/*
+Resume : Follows again the handed over requests that were still active when this edge node was stopped. The requests
+handed over from now on are followed until ctx is cancelled.
+Input: context whose cancellation stops the following
+Output: Nil
+*/
+func (m *Manager) Resume(ctx context.Context) {
+	m.mux.Lock()
+	m.ctx = ctx
+	m.mux.Unlock()
+	for _, r := range m.records.List() {
+		if r.Origin != "" && r.Active() {
+			go m.follow(ctx, r.Origin, r.Request)
+		}
+	}
+}
+
+/*
+Release : Hands the session of a client over to the edge node it attached to. The client is served by that edge node
+from now on.
+Input: the handover request of the other edge node
+Output: the states of the requests of the client and their results if available, found is false if this edge node
+does not know the client
+*/
+func (m *Manager) Release(in *pb.HandoverRequest) *pb.HandoverReply {
+	reply := &pb.HandoverReply{ID: m.NodeID}
+	if in.ID == m.NodeID {
+		return reply
+	}
+	for _, r := range m.records.List() {
+		if r.Has(in.Client) {
+			reply.Requests = append(reply.Requests, m.state(r))
+		}
+	}
+	m.mux.Lock()
+	s, known := m.sessions[in.Client]
+	if known {
+		s.Previous, s.Node = s.Node, in.ID
+	}
+	m.mux.Unlock()
+	reply.Found = known || len(reply.Requests) > 0
+	if reply.Found {
+		m.logger.Info("session handed over to another edge node", "client", in.Client, "to", in.ID,
+			"requests", len(reply.Requests))
+	}
+	return reply
+}
+
+/*
+Fetch : Returns the state of a request to the edge node its client moved to. For an active request the call waits a
+while for the result.
+Input: context bounding the wait, the fetch request of the other edge node
+Output: state of the request and its result if available
+*/
+func (m *Manager) Fetch(ctx context.Context, in *pb.FetchRequest) (*pb.RequestState, error) {
+	if r, ok := m.records.Get(in.Request); ok && r.Active() && in.Waitms > 0 {
+		ch := m.results.Subscribe(in.Request)
+		select {
+		case <-ch:
+		case <-time.After(time.Duration(in.Waitms) * time.Millisecond):
+			m.results.Unsubscribe(in.Request, ch)
+		case <-ctx.Done():
+			m.results.Unsubscribe(in.Request, ch)
+		}
+	}
+	r, ok := m.records.Get(in.Request)
+	if !ok {
+		return &pb.RequestState{Request: in.Request, State: requestrecord.Failed, Reason: "unknown_request"}, nil
+	}
+	return m.state(r), nil
+}
+
+//state : returns the state of a request along with its result if available
+func (m *Manager) state(r requestrecord.Record) *pb.RequestState {
+	state := &pb.RequestState{Request: r.Request, State: r.State, Reason: r.Reason, Application: r.Application,
+		Priority: r.Priority, Submitted: r.Submitted.UnixNano()}
+	if result, ok := m.results.Get(r.Request); ok {
+		state.Hasresult, state.Result, state.Contenttype = true, result.Data, result.ContentType
+	}
+	return state
+}
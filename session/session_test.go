@@ -0,0 +1,241 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/niketagrawal/EDIRO/protobufferfile"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resultstore"
+)
+
+//faketransport : inter edge communication with a single other edge node, at address peer, known by its replies
+type faketransport struct {
+	peer string
+	//reply : handover reply of the other edge node, nil if it does not know the client
+	reply *pb.HandoverReply
+	//states : states of the requests served by the other edge node, returned when their result is fetched
+	states map[string]*pb.RequestState
+}
+
+func (f *faketransport) Handover(ctx context.Context, in *pb.HandoverRequest) (string, *pb.HandoverReply, bool) {
+	if f.reply == nil {
+		return "", nil, false
+	}
+	return f.peer, f.reply, true
+}
+
+func (f *faketransport) Fetchresult(ctx context.Context, peer string, in *pb.FetchRequest) (*pb.RequestState, error) {
+	if state, ok := f.states[in.Request]; ok && peer == f.peer {
+		return state, nil
+	}
+	return nil, errors.New("unreachable")
+}
+
+//eventually : waits for a condition to hold, failing the test after a while
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("%s did not happen", what)
+}
+
+func TestAttach(t *testing.T) {
+	tests := []struct {
+		name     string
+		attached bool //whether the client is already attached to this edge node
+		served   bool //whether this edge node serves the request r1 of another client
+		reply    *pb.HandoverReply
+		states   map[string]*pb.RequestState
+		//wantprevious : edge node the session was handed over from, wantstates : state of each request afterwards
+		wantprevious string
+		wantstates   map[string]string
+		//wantresults : results stored on this edge node, wantorigins : edge node serving each request
+		wantresults map[string]string
+		wantorigins map[string]string
+	}{
+		{name: "new client"},
+		{
+			name: "client already attached", attached: true,
+			reply: &pb.HandoverReply{ID: "n2", Found: true},
+		},
+		{
+			name: "result available", wantprevious: "n2",
+			reply: &pb.HandoverReply{ID: "n2", Found: true, Requests: []*pb.RequestState{{Request: "r1",
+				State: requestrecord.Completed, Hasresult: true, Result: []byte("done"), Contenttype: "text/plain"}}},
+			wantstates:  map[string]string{"r1": requestrecord.Completed},
+			wantresults: map[string]string{"r1": "done"},
+			wantorigins: map[string]string{"r1": "n2:5001"},
+		},
+		{
+			name: "request running", wantprevious: "n2",
+			reply: &pb.HandoverReply{ID: "n2", Found: true, Requests: []*pb.RequestState{{Request: "r1",
+				State: requestrecord.Launched}}},
+			states: map[string]*pb.RequestState{"r1": {Request: "r1", State: requestrecord.Completed, Hasresult: true,
+				Result: []byte("later")}},
+			wantstates:  map[string]string{"r1": requestrecord.Completed},
+			wantresults: map[string]string{"r1": "later"},
+			wantorigins: map[string]string{"r1": "n2:5001"},
+		},
+		{
+			name: "request failed", wantprevious: "n2",
+			reply: &pb.HandoverReply{ID: "n2", Found: true, Requests: []*pb.RequestState{{Request: "r1",
+				State: requestrecord.Failed, Reason: "exit_status"}}},
+			wantstates:  map[string]string{"r1": requestrecord.Failed},
+			wantorigins: map[string]string{"r1": "n2:5001"},
+		},
+		{
+			name: "request served by this edge node", served: true, wantprevious: "n2",
+			reply: &pb.HandoverReply{ID: "n2", Found: true, Requests: []*pb.RequestState{{Request: "r1",
+				State: requestrecord.Launched}}},
+			wantstates:  map[string]string{"r1": requestrecord.Launched},
+			wantorigins: map[string]string{"r1": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, results := requestrecord.New(), resultstore.New()
+			transport := &faketransport{peer: "n2:5001", states: tt.states}
+			m := New("n1", transport, records, results, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			m.Resume(ctx)
+			if tt.attached {
+				m.Attach(ctx, "c")
+			}
+			transport.reply = tt.reply
+			if tt.served {
+				records.Add(requestrecord.Record{Request: "r1", Clients: []string{"other"},
+					State: requestrecord.Launched})
+			}
+			s := m.Attach(ctx, "c")
+			if s.Client != "c" || s.Node != "n1" || s.Previous != tt.wantprevious {
+				t.Errorf("session %+v, want client c attached to n1, handed over from %q", s, tt.wantprevious)
+			}
+			for request, want := range tt.wantresults {
+				eventually(t, "result of "+request, func() bool {
+					result, ok := results.Get(request)
+					return ok && string(result.Data) == want && result.Source == resultstore.Fromhandover
+				})
+			}
+			for request, want := range tt.wantstates {
+				eventually(t, "state of "+request, func() bool {
+					r, _ := records.Get(request)
+					return r.State == want
+				})
+				r, _ := records.Get(request)
+				if !r.Has("c") || r.Origin != tt.wantorigins[request] {
+					t.Errorf("record %+v, want a record of the client c served by %q", r, tt.wantorigins[request])
+				}
+			}
+			if tt.wantstates == nil && len(records.List()) != 0 {
+				t.Errorf("records %+v, want none", records.List())
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	tests := []struct {
+		name      string
+		attached  bool //whether the client is attached to this edge node
+		request   bool //whether this edge node has a request of the client with its result
+		from      string
+		wantfound bool
+		//wantrequests : requests handed over, wantnode : edge node serving the client afterwards
+		wantrequests []string
+		wantnode     string
+	}{
+		{name: "unknown client", from: "n2"},
+		{name: "attached client", attached: true, from: "n2", wantfound: true, wantnode: "n2"},
+		{name: "client with requests", request: true, from: "n2", wantfound: true, wantrequests: []string{"r1"}},
+		{name: "attached client with requests", attached: true, request: true, from: "n2", wantfound: true,
+			wantrequests: []string{"r1"}, wantnode: "n2"},
+		{name: "asked by this edge node", attached: true, request: true, from: "n1", wantnode: "n1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, results := requestrecord.New(), resultstore.New()
+			m := New("n1", &faketransport{}, records, results, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.attached {
+				m.Attach(context.Background(), "c")
+			}
+			if tt.request {
+				records.Add(requestrecord.Record{Request: "r1", Clients: []string{"c"}, State: requestrecord.Completed})
+				records.Add(requestrecord.Record{Request: "r2", Clients: []string{"other"},
+					State: requestrecord.Launched})
+				results.Put(resultstore.Result{Request: "r1", Data: []byte("done")})
+			}
+			reply := m.Release(&pb.HandoverRequest{Client: "c", ID: tt.from})
+			var requests []string
+			for _, state := range reply.Requests {
+				requests = append(requests, state.Request)
+				if !state.Hasresult || string(state.Result) != "done" {
+					t.Errorf("request %s handed over without its result", state.Request)
+				}
+			}
+			if reply.ID != "n1" || reply.Found != tt.wantfound || !reflect.DeepEqual(requests, tt.wantrequests) {
+				t.Errorf("reply %+v, want found %v with the requests %v", reply, tt.wantfound, tt.wantrequests)
+			}
+			if s, _ := m.Get("c"); s.Node != tt.wantnode {
+				t.Errorf("client served by %q, want %q", s.Node, tt.wantnode)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string //state of the request on this edge node, unknown request if empty
+		result    bool   //whether the result is available before the fetch
+		push      bool   //whether the result is pushed while the fetch waits
+		wantstate string
+		//wantreason : reason of the state returned, wantresult : whether the result is returned
+		wantreason string
+		wantresult bool
+	}{
+		{name: "unknown request", wantstate: requestrecord.Failed, wantreason: "unknown_request"},
+		{name: "result available", state: requestrecord.Completed, result: true, wantstate: requestrecord.Completed,
+			wantresult: true},
+		{name: "request running", state: requestrecord.Launched, wantstate: requestrecord.Launched},
+		{name: "result pushed while waiting", state: requestrecord.Launched, push: true,
+			wantstate: requestrecord.Launched, wantresult: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, results := requestrecord.New(), resultstore.New()
+			m := New("n1", &faketransport{}, records, results, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.state != "" {
+				records.Add(requestrecord.Record{Request: "r1", State: tt.state})
+			}
+			if tt.result {
+				results.Put(resultstore.Result{Request: "r1", Data: []byte("done")})
+			}
+			waitms := int64(50)
+			if tt.push {
+				waitms = 5000
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					results.Put(resultstore.Result{Request: "r1", Data: []byte("done")})
+				}()
+			}
+			state, err := m.Fetch(context.Background(), &pb.FetchRequest{Request: "r1", ID: "n2", Waitms: waitms})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.State != tt.wantstate || state.Reason != tt.wantreason || state.Hasresult != tt.wantresult {
+				t.Errorf("state %+v, want %s for %q with result %v", state, tt.wantstate, tt.wantreason,
+					tt.wantresult)
+			}
+		})
+	}
+}
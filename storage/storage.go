@@ -0,0 +1,216 @@
+/*
+This package implements the storage management of the IoT resources kept by the edge nodes of the swarm. Vehicles
+offload large HD maps and sensor dumps continuously, the store bounds what each edge node keeps. It manages the IoT
+resources stored through this edge node, i.e. offloaded on it or copied to it and announced by it, and evicts them
+according to a policy:
+1. lru - when an edge node exceeds its quota, the IoT resources claimed the longest ago, or never claimed, go first
+2. oldest - when an edge node exceeds its quota, the IoT resources offloaded the longest ago go first
+3. ttl - the IoT resources offloaded longer ago than the TTL are evicted, and the oldest first when the quota is exceeded
+An IoT resource leased to a queued request or a running workload, or claimed in the catalog, is never evicted, even if
+its edge node stays above its quota. The eviction is announced to the other edge nodes. The data of an evicted IoT
+resource offloaded on this edge node is removed from the storage directory, where it is read from (see package
+resourcemanager), once no edge node holds it; the copies pushed to other edge nodes are left to their transfer service.
+
+*/
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//Eviction policies
+const (
+	LRU    = "lru"
+	Oldest = "oldest"
+	TTL    = "ttl"
+)
+
+//sweepinterval : interval at which the IoT resources stored through this edge node are checked
+const sweepinterval = 10 * time.Second
+
+//Store : The storage of the IoT resources kept by the edge nodes of the swarm through this edge node
+type Store struct {
+	//Quota : bytes of IoT resources each edge node of the swarm keeps, 0 for no limit
+	Quota int64
+	//Policy : eviction policy, lru, oldest or ttl
+	Policy string
+	//TTL : age beyond which an IoT resource is evicted with the ttl policy
+	TTL time.Duration
+	//Dir : directory the data of the IoT resources offloaded on this edge node is kept in, empty to leave the data of an
+	//evicted resource in place
+	Dir string
+	//Onevict : called with every IoT resource evicted, nil if nothing else is to be done
+	Onevict func(resource string, node string)
+
+	catalog *resourcemanager.Catalog
+	//stored : returns whether an IoT resource held by an edge node is stored through this edge node
+	stored func(resource string, node string) bool
+	//leases : returns the IoT resources leased to the queued requests and the running workloads, keyed by edge node
+	leases func() map[string]map[string]bool
+	//evict : forgets an evicted IoT resource and announces the eviction
+	evict  func(ctx context.Context, resource string, node string) []string
+	logger *slog.Logger
+}
+
+//New : creates the store of an edge node with the given quota, policy and TTL
+func New(quota int64, policy string, ttl time.Duration, catalog *resourcemanager.Catalog,
+	stored func(resource string, node string) bool, leases func() map[string]map[string]bool,
+	evict func(ctx context.Context, resource string, node string) []string, logger *slog.Logger) *Store {
+	return &Store{Quota: quota, Policy: policy, TTL: ttl, catalog: catalog, stored: stored, leases: leases,
+		evict: evict, logger: logger}
+}
+
+//Check : returns an error if the policy, quota or TTL of the store are not valid
+func (s *Store) Check() error {
+	if s.Policy != LRU && s.Policy != Oldest && s.Policy != TTL {
+		return fmt.Errorf("unknown eviction policy %s, expected lru, oldest or ttl", s.Policy)
+	}
+	if s.Quota < 0 {
+		return errors.New("storage quota cannot be negative")
+	}
+	if s.Policy == TTL && s.TTL <= 0 {
+		return errors.New("eviction policy ttl requires a positive TTL")
+	}
+	return nil
+}
+
+//enabled : returns whether the store evicts anything, i.e. it has a quota or a TTL
+func (s *Store) enabled() bool {
+	return s.Quota > 0 || (s.Policy == TTL && s.TTL > 0)
+}
+
+/*
+Run : Evicts the IoT resources according to the policy at a fixed interval. It returns at once if the store has neither
+quota nor TTL.
+Input: context whose cancellation stops the store
+Output: Nil
+*/
+func (s *Store) Run(ctx context.Context) {
+	if !s.enabled() {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sweepinterval):
+		}
+		s.Sweep(ctx)
+	}
+}
+
+//Usage : returns the bytes of IoT resources stored through this edge node on each edge node of the swarm
+func (s *Store) Usage() map[string]int64 {
+	usage := map[string]int64{}
+	for _, e := range s.catalog.Entries() {
+		if s.stored(e.Resource, e.Node) {
+			usage[e.Node] += e.Descriptor.Size
+		}
+	}
+	return usage
+}
+
+/*
+Sweep : Evicts the IoT resources past their TTL and, on the edge nodes above their quota, the IoT resources picked by
+the policy until the edge node is within its quota. Leased and claimed IoT resources are skipped.
+Input: context bounding the announcements of the evictions
+Output: number of IoT resources evicted
+*/
+func (s *Store) Sweep(ctx context.Context) int {
+	leases := s.leases()
+	nodes := map[string][]resourcemanager.Entry{}
+	for _, e := range s.catalog.Entries() {
+		if s.stored(e.Resource, e.Node) {
+			nodes[e.Node] = append(nodes[e.Node], e)
+		}
+	}
+
+	evicted := 0
+	for node, entries := range nodes {
+		sort.Slice(entries, func(i, j int) bool { return s.before(entries[i], entries[j]) })
+		var usage int64
+		for _, e := range entries {
+			usage += e.Descriptor.Size
+		}
+		leased := 0
+		for _, e := range entries {
+			expired := s.Policy == TTL && time.Since(e.Descriptor.Offloaded) > s.TTL
+			if !expired && (s.Quota == 0 || usage <= s.Quota) {
+				continue
+			}
+			if leases[node][e.Resource] || e.Claimed {
+				leased++
+				continue
+			}
+			reason := "quota"
+			if expired {
+				reason = "ttl"
+			}
+			s.remove(ctx, e, reason)
+			usage -= e.Descriptor.Size
+			evicted++
+		}
+		if s.Quota > 0 && usage > s.Quota {
+			s.logger.Warn("edge node above its storage quota, its resources left are leased", "node", node,
+				"stored", usage, "quota", s.Quota, "leased", leased)
+		}
+	}
+	return evicted
+}
+
+//before : returns whether an IoT resource is to be evicted before another by the policy
+func (s *Store) before(a resourcemanager.Entry, b resourcemanager.Entry) bool {
+	if s.Policy == LRU {
+		lastused := func(e resourcemanager.Entry) time.Time {
+			if e.Lastused.IsZero() {
+				return e.Descriptor.Offloaded
+			}
+			return e.Lastused
+		}
+		if !lastused(a).Equal(lastused(b)) {
+			return lastused(a).Before(lastused(b))
+		}
+	}
+	if !a.Descriptor.Offloaded.Equal(b.Descriptor.Offloaded) {
+		return a.Descriptor.Offloaded.Before(b.Descriptor.Offloaded)
+	}
+	return a.Resource < b.Resource
+}
+
+//offloaded : returns whether the data of an IoT resource is still read from the storage directory, i.e. the resource
+//is still stored through this edge node without being a copy
+func (s *Store) offloaded(resource string) bool {
+	for _, e := range s.catalog.Entries() {
+		if e.Resource == resource && e.Provenance.Origin == "" && s.stored(resource, e.Node) {
+			return true
+		}
+	}
+	return false
+}
+
+//remove : evicts an IoT resource, removes its data and announces the eviction
+func (s *Store) remove(ctx context.Context, e resourcemanager.Entry, reason string) {
+	unreachable := s.evict(ctx, e.Resource, e.Node)
+	if s.Dir != "" && e.Provenance.Origin == "" && !s.offloaded(e.Resource) {
+		path := resourcemanager.Datapath(s.Dir, e.Resource)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("could not remove data of evicted resource", logging.Resource, e.Resource, "path", path,
+				"err", err)
+		}
+	}
+	if s.Onevict != nil {
+		s.Onevict(e.Resource, e.Node)
+	}
+	s.logger.Info("resource evicted", logging.Resource, e.Resource, "node", e.Node, "reason", reason,
+		"size", e.Descriptor.Size, "unreachable", unreachable)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   int64
+		policy  string
+		ttl     time.Duration
+		wanterr bool
+	}{
+		{name: "lru", quota: 100, policy: LRU},
+		{name: "no quota", policy: Oldest},
+		{name: "ttl", policy: TTL, ttl: time.Hour},
+		{name: "unknown policy", policy: "fifo", wanterr: true},
+		{name: "negative quota", quota: -1, policy: LRU, wanterr: true},
+		{name: "ttl without TTL", policy: TTL, wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.quota, tt.policy, tt.ttl, nil, nil, nil, nil, nil)
+			if err := s.Check(); (err != nil) != tt.wanterr {
+				t.Errorf("Check = %v, want error %v", err, tt.wanterr)
+			}
+		})
+	}
+}
+
+//stored : An IoT resource stored through the edge node, of 10 bytes on n1 unless on another edge node
+type stored struct {
+	resource string
+	node     string
+	age      time.Duration //time since it was offloaded
+	used     time.Duration //time since it was last claimed, never if 0
+	origin   string        //edge node it was copied from, empty if it was offloaded
+}
+
+//newstore : returns a store of the given resources and a function returning the resources evicted, as resource@node
+func newstore(policy string, quota int64, ttl time.Duration, resources []stored,
+	leased map[string]map[string]bool) (*Store, func() []string) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	catalog := resourcemanager.NewCatalog(logger)
+	now := time.Now()
+	for _, r := range resources {
+		node := r.node
+		if node == "" {
+			node = "n1"
+		}
+		catalog.Add(r.resource, node, resourcemanager.Provenance{Hash: "h", Origin: r.origin})
+		catalog.Describe(r.resource, node, resourcemanager.Descriptor{Size: 10, Offloaded: now.Add(-r.age)})
+	}
+	//the resources are claimed from the least recently used on
+	sort.SliceStable(resources, func(i, j int) bool { return resources[i].used > resources[j].used })
+	for _, r := range resources {
+		if r.used > 0 {
+			node := r.node
+			if node == "" {
+				node = "n1"
+			}
+			catalog.Claim(r.resource, node)
+			catalog.Release(r.resource, node)
+			time.Sleep(time.Millisecond)
+		}
+	}
+	var evicted []string
+	s := New(quota, policy, ttl, catalog, func(resource string, node string) bool { return true },
+		func() map[string]map[string]bool { return leased },
+		func(ctx context.Context, resource string, node string) []string {
+			evicted = append(evicted, resource+"@"+node)
+			catalog.Remove(resource, node, "h")
+			return nil
+		}, logger)
+	return s, func() []string { return evicted }
+}
+
+func TestSweep(t *testing.T) {
+	resources := []stored{
+		{resource: "old", age: 3 * time.Hour},
+		{resource: "used", age: 2 * time.Hour, used: time.Second},
+		{resource: "new", age: time.Minute},
+	}
+	tests := []struct {
+		name   string
+		policy string
+		quota  int64
+		ttl    time.Duration
+		leased map[string]map[string]bool
+		//claimed : resources claimed on n1 in the catalog
+		claimed []string
+		want    []string
+	}{
+		{name: "within the quota", policy: LRU, quota: 30},
+		{name: "lru", policy: LRU, quota: 20, want: []string{"old@n1"}},
+		{name: "lru, never used first", policy: LRU, quota: 10, want: []string{"old@n1", "new@n1"}},
+		{name: "oldest", policy: Oldest, quota: 10, want: []string{"old@n1", "used@n1"}},
+		{name: "ttl", policy: TTL, ttl: 90 * time.Minute, want: []string{"old@n1", "used@n1"}},
+		{name: "longer ttl", policy: TTL, ttl: 150 * time.Minute, want: []string{"old@n1"}},
+		{name: "leased resource kept", policy: Oldest, quota: 10,
+			leased: map[string]map[string]bool{"n1": {"old": true}}, want: []string{"used@n1", "new@n1"}},
+		{name: "claimed resource kept", policy: Oldest, quota: 10, claimed: []string{"old"},
+			want: []string{"used@n1", "new@n1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, evicted := newstore(tt.policy, tt.quota, tt.ttl, append([]stored(nil), resources...), tt.leased)
+			for _, resource := range tt.claimed {
+				s.catalog.Claim(resource, "n1")
+			}
+			if n := s.Sweep(context.Background()); n != len(tt.want) || !reflect.DeepEqual(evicted(), tt.want) {
+				t.Errorf("Sweep = %d evicting %v, want %v", n, evicted(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	s, _ := newstore(LRU, 0, 0, []stored{{resource: "a"}, {resource: "b"}, {resource: "a", node: "n2"}}, nil)
+	if usage := s.Usage(); !reflect.DeepEqual(usage, map[string]int64{"n1": 20, "n2": 10}) {
+		t.Errorf("Usage = %v", usage)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []stored
+		//wantdata : whether the data of the resource a is left in the storage directory
+		wantdata bool
+	}{
+		{name: "offloaded resource", resources: []stored{{resource: "a", age: time.Hour}}},
+		{name: "copy", resources: []stored{{resource: "a", age: time.Hour, origin: "n2"}}, wantdata: true},
+		{name: "offloaded on another edge node as well", resources: []stored{{resource: "a", age: time.Hour},
+			{resource: "a", node: "n2"}}, wantdata: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newstore(Oldest, 0, 0, tt.resources, nil)
+			s.Dir = t.TempDir()
+			path := filepath.Join(s.Dir, "a")
+			if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			e := s.catalog.Entries()[0]
+			s.remove(context.Background(), e, "quota")
+			if _, err := os.Stat(path); (err == nil) != tt.wantdata {
+				t.Errorf("data left %v, want %v", err == nil, tt.wantdata)
+			}
+		})
+	}
+}
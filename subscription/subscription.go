@@ -0,0 +1,192 @@
+/*
+This package implements the subscriptions of EDIRO, i.e. standing requests with a lifetime such as "notify me of hazards
+on segment X for the next 10 minutes". The workload of a subscription is told when the subscription expires. A streaming
+workload keeps running until then and delivers a result through the callback whenever it has one. A workload that
+completes leaves the subscription waiting: it runs again whenever a new version of its IoT resource, or a new IoT
+resource of the type it consumes, is recorded in the catalog. Every result replaces the previous one and is pushed to
+the clients watching the subscription (see package clientapi). When the subscription expires its workload is stopped.
+
+*/
+
+package subscription
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//recheckinterval : interval at which a subscription notified of a new IoT resource while its workload runs is checked
+const recheckinterval = time.Second
+
+//Manager : The subscriptions served by an edge node
+type Manager struct {
+	catalog *resourcemanager.Catalog
+	records *requestrecord.Store
+	//rerun : hands a subscription to the pipeline of the edge node again
+	rerun func(request string) error
+	//stop : stops the workload of a request if it is queued or running
+	stop func(request string) bool
+	//following : the subscriptions being followed
+	following map[string]bool
+	//ctx : context whose cancellation stops the following of the subscriptions, set by Resume
+	ctx    context.Context
+	mux    sync.Mutex
+	logger *slog.Logger
+}
+
+//New : creates the subscription manager of an edge node
+func New(catalog *resourcemanager.Catalog, records *requestrecord.Store, rerun func(request string) error,
+	stop func(request string) bool, logger *slog.Logger) *Manager {
+	return &Manager{catalog: catalog, records: records, rerun: rerun, stop: stop, following: map[string]bool{},
+		ctx: context.Background(), logger: logger}
+}
+
+/*
+Start : Follows a subscription until it expires or ends otherwise. A subscription already followed is left as is, its
+lifetime is read from its record.
+Input: client request of the subscription
+Output: Nil
+*/
+func (m *Manager) Start(request string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if m.following[request] {
+		return
+	}
+	m.following[request] = true
+	go m.follow(m.ctx, request)
+}
+
+/*
+Resume : Follows again the subscriptions that were live when this edge node was stopped. The subscriptions started from
+now on are followed until ctx is cancelled.
+Input: context whose cancellation stops the following
+Output: Nil
+*/
+func (m *Manager) Resume(ctx context.Context) {
+	m.mux.Lock()
+	m.ctx = ctx
+	m.mux.Unlock()
+	for _, r := range m.records.List() {
+		if !r.Expires.IsZero() && r.Origin == "" && r.Active() {
+			m.Start(r.Request)
+		}
+	}
+}
+
+/*
+follow : Runs the workload of a subscription again whenever one of the IoT resources it consumes is recorded in the
+catalog while it waits, and ends the subscription once it expires.
+Input: context whose cancellation stops the function, client request of the subscription
+Output: Nil
+*/
+func (m *Manager) follow(ctx context.Context, request string) {
+	defer func() {
+		m.mux.Lock()
+		delete(m.following, request)
+		m.mux.Unlock()
+	}()
+	pending := false
+	for {
+		r, ok := m.records.Get(request)
+		if !ok || !r.Active() {
+			return //the subscription failed or was ended otherwise
+		}
+		if !r.Live() {
+			m.expire(request)
+			return
+		}
+		if pending && r.State == requestrecord.Subscribed {
+			pending = false
+			if m.available(r) {
+				m.run(request)
+				continue
+			}
+		}
+
+		resources, types := watched(r)
+		ch := m.catalog.Watch(resources, types)
+		var recheck <-chan time.Time
+		if pending {
+			recheck = time.After(recheckinterval)
+		}
+		select {
+		case <-ctx.Done():
+			m.catalog.Unwatch(ch)
+			return
+		case <-time.After(time.Until(r.Expires)):
+			m.catalog.Unwatch(ch)
+		case <-recheck:
+			m.catalog.Unwatch(ch)
+		case <-ch:
+			//the workload runs again once the current run is over
+			pending = true
+		}
+	}
+}
+
+//run : hands a waiting subscription to the pipeline again
+func (m *Manager) run(request string) {
+	m.records.Update(request, func(r *requestrecord.Record) {
+		r.State = requestrecord.Submitted
+		r.Runs++
+	})
+	if err := m.rerun(request); err != nil {
+		m.records.Update(request, func(r *requestrecord.Record) {
+			r.State = requestrecord.Subscribed
+			r.Runs--
+		})
+		m.logger.Warn("could not run subscription again", logging.Request, request, "err", err)
+		return
+	}
+	m.logger.Info("running subscription again", logging.Request, request)
+}
+
+//expire : stops the workload of a subscription whose lifetime ended and completes its request
+func (m *Manager) expire(request string) {
+	stopped := m.stop(request)
+	m.records.Update(request, func(r *requestrecord.Record) {
+		if r.Active() {
+			r.State, r.Finished = requestrecord.Completed, time.Now()
+		}
+	})
+	m.logger.Info("subscription expired", logging.Request, request, "stopped", stopped)
+}
+
+//available : returns whether an IoT resource the workload of a subscription consumes is available to run it again
+func (m *Manager) available(r requestrecord.Record) bool {
+	if r.Query != nil {
+		return len(m.catalog.Find(*r.Query)) > 0
+	}
+	if resource := library.ApptoResource[library.RequesttoApp[r.Request]]; resource != "" {
+		return m.catalog.Has(resource)
+	}
+	return true //the inputs of the application are located by resource discovery
+}
+
+//watched : returns the names and the types of the IoT resources the workload of a subscription consumes
+func watched(r requestrecord.Record) ([]string, []string) {
+	var resources, types []string
+	application := library.RequesttoApp[r.Request]
+	if resource := library.ApptoResource[application]; resource != "" {
+		resources = append(resources, resource)
+	}
+	if r.Query != nil && r.Query.Type != "" {
+		types = append(types, r.Query.Type)
+	}
+	for _, in := range library.ApptoInputs[application] {
+		if in.Type != "" {
+			types = append(types, in.Type)
+		} else {
+			resources = append(resources, in.Resource)
+		}
+	}
+	return resources, types
+}
@@ -0,0 +1,128 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/geo"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+//eventually : waits for a condition to hold, failing the test after a while
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("%s did not happen", what)
+}
+
+func TestFollow(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifetime  time.Duration
+		announced string //IoT resource recorded in the catalog while the subscription waits, none if empty
+		rerunerr  error
+		//wantreruns : number of times the subscription is handed to the pipeline again
+		wantreruns int
+		wantstate  string
+		wantruns   int
+		//wantstopped : whether the workload of the subscription is stopped
+		wantstopped bool
+	}{
+		{name: "new version of the resource", lifetime: time.Minute, announced: "IoT_resource_1", wantreruns: 1,
+			wantstate: requestrecord.Submitted, wantruns: 1},
+		{name: "other resource", lifetime: time.Minute, announced: "IoT_resource_2",
+			wantstate: requestrecord.Subscribed},
+		{name: "run refused", lifetime: time.Minute, announced: "IoT_resource_1", rerunerr: errors.New("stopping"),
+			wantreruns: 1, wantstate: requestrecord.Subscribed},
+		{name: "expired", lifetime: 100 * time.Millisecond, wantstate: requestrecord.Completed, wantstopped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			catalog, records := resourcemanager.NewCatalog(logger), requestrecord.New()
+			records.Add(requestrecord.Record{Request: "client_request_1", State: requestrecord.Subscribed,
+				Expires: time.Now().Add(tt.lifetime)})
+			var mux sync.Mutex
+			var reruns []string
+			stopped := false
+			m := New(catalog, records, func(request string) error {
+				mux.Lock()
+				defer mux.Unlock()
+				reruns = append(reruns, request)
+				return tt.rerunerr
+			}, func(request string) bool {
+				mux.Lock()
+				defer mux.Unlock()
+				stopped = true
+				return true
+			}, logger)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			m.Resume(ctx)
+			eventually(t, "the following of the subscription", func() bool {
+				m.mux.Lock()
+				defer m.mux.Unlock()
+				return m.following["client_request_1"]
+			})
+			if tt.announced != "" {
+				//the watch of the catalog is set up once the subscription is followed
+				time.Sleep(50 * time.Millisecond)
+				catalog.Add(tt.announced, "n2", resourcemanager.Provenance{})
+				catalog.Describe(tt.announced, "n2", resourcemanager.Descriptor{})
+			}
+			eventually(t, "the runs", func() bool {
+				mux.Lock()
+				defer mux.Unlock()
+				return len(reruns) == tt.wantreruns
+			})
+			eventually(t, "the state "+tt.wantstate, func() bool {
+				r, _ := records.Get("client_request_1")
+				return r.State == tt.wantstate
+			})
+			time.Sleep(50 * time.Millisecond)
+			r, _ := records.Get("client_request_1")
+			mux.Lock()
+			defer mux.Unlock()
+			if r.State != tt.wantstate || r.Runs != tt.wantruns || stopped != tt.wantstopped {
+				t.Errorf("subscription %s after %d runs, stopped %v, want %s after %d runs, stopped %v", r.State,
+					r.Runs, stopped, tt.wantstate, tt.wantruns, tt.wantstopped)
+			}
+		})
+	}
+}
+
+func TestWatched(t *testing.T) {
+	tests := []struct {
+		name          string
+		record        requestrecord.Record
+		wantresources []string
+		wanttypes     []string
+	}{
+		{name: "resource of the application", record: requestrecord.Record{Request: "client_request_1"},
+			wantresources: []string{"IoT_resource_1"}},
+		{name: "location query", record: requestrecord.Record{Request: "client_request_1",
+			Query: &geo.Query{Type: "hd_map"}}, wantresources: []string{"IoT_resource_1"},
+			wanttypes: []string{"hd_map"}},
+		{name: "inputs of the application", record: requestrecord.Record{Request: "client_request_4"},
+			wanttypes: []string{"hd_map", "camera_frame", "lidar"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, types := watched(tt.record)
+			if !reflect.DeepEqual(resources, tt.wantresources) || !reflect.DeepEqual(types, tt.wanttypes) {
+				t.Errorf("watched %v and %v, want %v and %v", resources, types, tt.wantresources, tt.wanttypes)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+/*
+This file implements the dispatch of the workloads. The client requests whose IoT resource has been located wait in an
+admission queue ordered by their priority class and, within a class, by their arrival. A request is admitted as soon as
+the edge node holding its resource runs fewer workloads than its capacity. When a critical request finds that edge node
+saturated, the running workload of the lowest priority class on it is preempted: its service is removed and its request
+is requeued, on another edge node holding the IoT resource if there is one. Requests whose deadline passes while they
+wait, and requests cancelled on their way through the pipeline, are dropped from the queue. The workloads of an edge
+node being drained may be migrated to other edge nodes holding their IoT resource, see Migrate.
+*/
+
+package taskinitiator
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/admission"
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcediscovery"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//errpreempted : cause of the cancellation of the context of a preempted workload
+var errpreempted = errors.New("workload preempted")
+
+//errmigrated : cause of the cancellation of the context of a workload moved off an edge node being drained
+var errmigrated = errors.New("workload migrated")
+
+//errstopped : cause of the cancellation of the context of a workload stopped before it finished, see Stop
+var errstopped = errors.New("workload stopped")
+
+//shedinterval : interval at which the queue is checked for requests whose deadline passed
+const shedinterval = time.Second
+
+//queued : A client request waiting in the admission queue
+type queued struct {
+	c        resourcediscovery.Resourcediscoveryoutput
+	rank     int
+	sequence uint64
+}
+
+//admissionqueue : heap of the queued client requests, the most urgent first
+type admissionqueue []*queued
+
+func (q admissionqueue) Len() int { return len(q) }
+func (q admissionqueue) Less(i, j int) bool {
+	if q[i].rank != q[j].rank {
+		return q[i].rank > q[j].rank
+	}
+	return q[i].sequence < q[j].sequence
+}
+func (q admissionqueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *admissionqueue) Push(x interface{}) { *q = append(*q, x.(*queued)) }
+func (q *admissionqueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+//workload : A workload admitted on an edge node
+type workload struct {
+	c        resourcediscovery.Resourcediscoveryoutput
+	rank     int
+	launched time.Time
+	//cancel : stops the tracking and the resource monitoring of the workload
+	cancel context.CancelCauseFunc
+	//created : closed once the launch created the service of the workload or gave up on it. A workload cancelled while
+	//its service is being created has it removed only then, so that the service is not left in the swarm
+	created chan struct{}
+}
+
+//enqueue : adds a client request to the admission queue with the priority class recorded for it
+func (rt *Runtime) enqueue(c resourcediscovery.Resourcediscoveryoutput) {
+	record, _ := rt.records.Get(c.Request)
+	rt.dispatchmux.Lock()
+	rt.sequence++
+	heap.Push(&rt.queue, &queued{c: c, rank: library.Priorityrank[library.Priorityof(c.Request, record.Priority)],
+		sequence: rt.sequence})
+	rt.dispatchmux.Unlock()
+	rt.signal()
+}
+
+//signal : wakes the dispatcher up, a new request is queued or a workload finished
+func (rt *Runtime) signal() {
+	select {
+	case rt.wake <- struct{}{}:
+	default:
+	}
+}
+
+/*
+dispatch : Admits the queued client requests whenever a request is queued or a workload finishes, and drops the
+requests whose deadline passed.
+Input: context whose cancellation stops the dispatch and the tracking of the admitted workloads
+Output: Nil
+*/
+func (rt *Runtime) dispatch(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rt.wake:
+		case <-time.After(shedinterval):
+		}
+
+		rt.dispatchmux.Lock()
+		var waiting []*queued
+		for rt.queue.Len() > 0 {
+			item := heap.Pop(&rt.queue).(*queued)
+			if rt.shed(item) || rt.cancelled(item) {
+				continue
+			}
+			node := item.c.Locationtolaunch
+			if !rt.hascapacity(node) {
+				victim := rt.victim(node, item.rank)
+				if victim == nil {
+					waiting = append(waiting, item)
+					continue
+				}
+				rt.preempt(victim, item.c.Request)
+			}
+			rt.admit(ctx, item)
+		}
+		for _, item := range waiting {
+			heap.Push(&rt.queue, item)
+		}
+		rt.dispatchmux.Unlock()
+	}
+}
+
+//shed : drops a queued request whose deadline passed, returns whether it was dropped
+func (rt *Runtime) shed(item *queued) bool {
+	record, _ := rt.records.Get(item.c.Request)
+	if record.Deadline.IsZero() || time.Now().Before(record.Deadline) {
+		return false
+	}
+	rt.records.Update(item.c.Request, func(r *requestrecord.Record) {
+		r.State, r.Reason, r.Finished = requestrecord.Failed, admission.Deadlinemissed, time.Now()
+	})
+	span := trace.SpanFromContext(item.c.Ctx)
+	span.SetStatus(codes.Error, admission.Deadlinemissed)
+	span.End()
+	rt.logger.Warn("dropped queued request whose deadline passed", logging.Request, item.c.Request,
+		"deadline", record.Deadline)
+	return true
+}
+
+//cancelled : drops a queued request cancelled on its way through the pipeline, returns whether it was dropped
+func (rt *Runtime) cancelled(item *queued) bool {
+	if record, _ := rt.records.Get(item.c.Request); record.State != requestrecord.Cancelled {
+		return false
+	}
+	rt.unclaim(item.c)
+	trace.SpanFromContext(item.c.Ctx).End()
+	rt.logger.Info("dropped cancelled request", logging.Request, item.c.Request)
+	return true
+}
+
+/*
+Ahead : Returns the number of workloads served before a new request of a given priority rank on an edge node, i.e. the
+running workloads it cannot preempt and the queued requests of the same or a higher rank.
+Input: edge node, rank of the priority class of the request
+Output: number of workloads ahead of the request
+*/
+func (rt *Runtime) Ahead(node string, rank int) int {
+	rt.dispatchmux.Lock()
+	defer rt.dispatchmux.Unlock()
+	n := 0
+	for _, w := range rt.workloads {
+		if w.c.Locationtolaunch == node && (rank < library.Priorityrank[library.Critical] || w.rank >= rank) {
+			n++
+		}
+	}
+	for _, item := range rt.queue {
+		if item.c.Locationtolaunch == node && item.rank >= rank {
+			n++
+		}
+	}
+	return n
+}
+
+//hascapacity : returns whether an edge node can run one more workload, dispatchmux must be held
+func (rt *Runtime) hascapacity(node string) bool {
+	if rt.Nodecapacity <= 0 {
+		return true
+	}
+	n := 0
+	for _, w := range rt.workloads {
+		if w.c.Locationtolaunch == node {
+			n++
+		}
+	}
+	return n < rt.Nodecapacity
+}
+
+/*
+victim : Picks the workload to preempt on a saturated edge node for a request, i.e. the most recently launched workload
+of the lowest priority class below that of the request. Only critical requests preempt and pipelines are never
+preempted. dispatchmux must be held.
+Input: edge node, rank of the priority class of the request
+Output: the workload to preempt, nil if there is none
+*/
+func (rt *Runtime) victim(node string, rank int) *workload {
+	if rank < library.Priorityrank[library.Critical] {
+		return nil
+	}
+	var victim *workload
+	for _, w := range rt.workloads {
+		if w.c.Locationtolaunch != node || w.rank >= rank {
+			continue
+		}
+		if len(w.c.Stages) > 0 {
+			continue //the stages of a pipeline are not requeued, see stages.go
+		}
+		if victim == nil || w.rank < victim.rank || (w.rank == victim.rank && w.launched.After(victim.launched)) {
+			victim = w
+		}
+	}
+	return victim
+}
+
+//admit : starts the launch of the workload of a queued request on its edge node, dispatchmux must be held
+func (rt *Runtime) admit(ctx context.Context, item *queued) {
+	wctx, cancel := context.WithCancelCause(ctx)
+	created := make(chan struct{})
+	rt.workloads[item.c.Request] = &workload{c: item.c, rank: item.rank, launched: time.Now(), cancel: cancel,
+		created: created}
+	rt.inflight.Add(1)
+	go rt.launchtask(wctx, item.c, created)
+}
+
+//release : frees the capacity taken by the workload of a request once it finished and gives the IoT resources claimed
+//for it back to the catalog
+func (rt *Runtime) release(request string) {
+	rt.dispatchmux.Lock()
+	w, ok := rt.workloads[request]
+	delete(rt.workloads, request)
+	rt.dispatchmux.Unlock()
+	if ok {
+		rt.unclaim(w.c)
+	}
+	rt.signal()
+}
+
+/*
+preempt : Stops the tracking of a workload and requeues its request once its service is removed from the swarm.
+dispatchmux must be held.
+Input: workload to preempt, request it is preempted for
+Output: Nil
+*/
+func (rt *Runtime) preempt(w *workload, by string) {
+	delete(rt.workloads, w.c.Request)
+	w.cancel(errpreempted)
+	rt.records.Update(w.c.Request, func(r *requestrecord.Record) {
+		r.Preemptions++
+	})
+	rt.logger.Warn("preempting workload for a critical request", logging.Request, w.c.Request, "by", by,
+		"node", w.c.Locationtolaunch)
+	go rt.requeue(w)
+}
+
+/*
+Migrate : Moves the workloads of an edge node being drained to the other edge nodes holding their IoT resource. The
+queued requests waiting for that edge node claim their resource again on another edge node and the running workloads
+are stopped and requeued like preempted ones. Pipelines, workloads fusing several IoT resources and workloads whose
+resource is held nowhere else are left on the edge node.
+Input: edge node being drained
+Output: number of workloads moved
+*/
+func (rt *Runtime) Migrate(node string) int {
+	rt.dispatchmux.Lock()
+	moved := 0
+	for _, item := range rt.queue {
+		c := item.c
+		if c.Locationtolaunch != node || len(c.Stages) > 0 || len(c.Inputs) > 0 {
+			continue
+		}
+		if to, provenance, ok := rt.catalog.Claim(c.Resource, ""); ok {
+			rt.catalog.Release(c.Resource, node)
+			item.c.Locationtolaunch, item.c.Provenance = to, provenance
+			rt.logger.Info("moved queued request off draining node", logging.Request, c.Request, "node", to)
+			moved++
+		}
+	}
+	for request, w := range rt.workloads {
+		if w.c.Locationtolaunch != node || len(w.c.Stages) > 0 || len(w.c.Inputs) > 0 ||
+			len(rt.catalog.Holders(w.c.Resource)) == 0 {
+			continue
+		}
+		delete(rt.workloads, request)
+		w.cancel(errmigrated)
+		rt.logger.Warn("migrating workload off draining node", logging.Request, request, "node", node)
+		go rt.requeue(w)
+		moved++
+	}
+	rt.dispatchmux.Unlock()
+	if moved > 0 {
+		rt.signal()
+	}
+	return moved
+}
+
+//requeue : removes the service of a preempted or migrated workload once it is created and queues its request again, on
+//another edge node if possible. The IoT resource claimed for the workload is given back once it is claimed again. A
+//request cancelled meanwhile is dropped and its IoT resources are given back.
+func (rt *Runtime) requeue(w *workload) {
+	<-w.created
+	c := w.c
+	servicename := c.Request
+	if record, ok := rt.records.Get(c.Request); ok && record.Service != "" {
+		servicename = record.Service
+	}
+	if out, err := exec.Command("docker", "service", "rm", servicename).CombinedOutput(); err != nil {
+		rt.logger.Warn("could not remove service of requeued workload", logging.Request, c.Request, "err", err,
+			"output", string(out))
+	}
+	cancelled := false
+	rt.records.Update(c.Request, func(r *requestrecord.Record) {
+		if cancelled = r.State == requestrecord.Cancelled; !cancelled {
+			r.State = requestrecord.Submitted
+		}
+	})
+	if cancelled {
+		rt.unclaim(c)
+		trace.SpanFromContext(c.Ctx).End()
+		rt.logger.Info("dropped cancelled request instead of requeueing it", logging.Request, c.Request)
+		return
+	}
+	//the inputs of a workload fusing several IoT resources were gathered on its edge node, it stays there
+	if len(c.Inputs) == 0 {
+		if node, provenance, ok := rt.catalog.Claim(c.Resource, ""); ok {
+			rt.unclaim(c)
+			c.Locationtolaunch, c.Provenance = node, provenance
+		}
+	}
+	rt.logger.Info("requeued request", logging.Request, c.Request, "node", c.Locationtolaunch)
+	rt.enqueue(c)
+}
+
+/*
+Stop : Stops the workload of a request, whether it waits in the admission queue or runs. The IoT resources claimed for
+it are given back to the catalog, a running workload is no longer tracked and its services are removed from the swarm.
+The state of the request is left to the caller.
+Input: client request
+Output: whether a workload of the request was queued or running
+*/
+func (rt *Runtime) Stop(request string) bool {
+	rt.dispatchmux.Lock()
+	for i, item := range rt.queue {
+		if item.c.Request == request {
+			heap.Remove(&rt.queue, i)
+			rt.dispatchmux.Unlock()
+			rt.unclaim(item.c)
+			trace.SpanFromContext(item.c.Ctx).End()
+			rt.logger.Info("stopped queued request", logging.Request, request)
+			return true
+		}
+	}
+	w, ok := rt.workloads[request]
+	if ok {
+		delete(rt.workloads, request)
+		w.cancel(errstopped)
+	}
+	rt.dispatchmux.Unlock()
+	if !ok {
+		return false
+	}
+	rt.unclaim(w.c)
+	<-w.created
+
+	record, _ := rt.records.Get(request)
+	services := []string{record.Service}
+	for _, stage := range record.Stages {
+		services = append(services, stage.Service)
+	}
+	for _, servicename := range services {
+		if servicename == "" {
+			continue
+		}
+		if out, err := exec.Command("docker", "service", "rm", servicename).CombinedOutput(); err != nil {
+			rt.logger.Warn("could not remove service of stopped workload", logging.Request, request,
+				"service", servicename, "err", err, "output", string(out))
+		}
+	}
+	rt.logger.Info("stopped workload", logging.Request, request, "node", w.c.Locationtolaunch)
+	rt.signal()
+	return true
+}
+
+//unclaim : gives the IoT resources claimed for a request whose workload is not launched, or no longer runs, back to the
+//catalog
+func (rt *Runtime) unclaim(c resourcediscovery.Resourcediscoveryoutput) {
+	var released int
+	release := func(resource string, node string) {
+		if resource != "" && rt.catalog.Release(resource, node) {
+			released++
+		}
+	}
+	switch {
+	case len(c.Stages) > 0:
+		for _, stage := range c.Stages {
+			release(stage.Resource, stage.Node)
+			for _, in := range stage.Inputs {
+				release(in.Resource, in.Node)
+			}
+		}
+	case len(c.Inputs) > 0:
+		for _, in := range c.Inputs {
+			release(in.Resource, in.Node)
+		}
+	default:
+		release(c.Resource, c.Locationtolaunch)
+	}
+	rt.logger.Debug("released resources of request", logging.Request, c.Request, "released", released)
+}
+
+//Load : The workloads running on an edge node of the swarm and the client requests waiting for it
+type Load struct {
+	Running, Queued int
+}
+
+//Loads : returns the load of the edge nodes running workloads or awaited by queued client requests
+func (rt *Runtime) Loads() map[string]Load {
+	rt.dispatchmux.Lock()
+	defer rt.dispatchmux.Unlock()
+	loads := map[string]Load{}
+	for _, w := range rt.workloads {
+		l := loads[w.c.Locationtolaunch]
+		l.Running++
+		loads[w.c.Locationtolaunch] = l
+	}
+	for _, item := range rt.queue {
+		l := loads[item.c.Locationtolaunch]
+		l.Queued++
+		loads[item.c.Locationtolaunch] = l
+	}
+	return loads
+}
+
+//Leases : returns the IoT resources claimed for the queued client requests and the running workloads, keyed by edge
+//node then resource
+func (rt *Runtime) Leases() map[string]map[string]bool {
+	rt.dispatchmux.Lock()
+	defer rt.dispatchmux.Unlock()
+	leases := map[string]map[string]bool{}
+	lease := func(resource string, node string) {
+		if resource == "" {
+			return
+		}
+		if leases[node] == nil {
+			leases[node] = map[string]bool{}
+		}
+		leases[node][resource] = true
+	}
+	claims := func(c resourcediscovery.Resourcediscoveryoutput) {
+		lease(c.Resource, c.Locationtolaunch)
+		for _, in := range c.Inputs {
+			lease(in.Resource, in.Node)
+		}
+		for _, stage := range c.Stages {
+			lease(stage.Resource, stage.Node)
+			for _, in := range stage.Inputs {
+				lease(in.Resource, in.Node)
+			}
+		}
+	}
+	for _, w := range rt.workloads {
+		claims(w.c)
+	}
+	for _, item := range rt.queue {
+		claims(item.c)
+	}
+	return leases
+}
+
+//Queued : returns the number of client requests waiting in the admission queue
+func (rt *Runtime) Queued() int {
+	rt.dispatchmux.Lock()
+	defer rt.dispatchmux.Unlock()
+	return rt.queue.Len()
+}
@@ -0,0 +1,443 @@
+package taskinitiator
+
+import (
+	"container/heap"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcediscovery"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+)
+
+func TestAdmissionqueueOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued []queued
+		want   []string
+	}{
+		{
+			name: "higher class first",
+			queued: []queued{
+				{c: resourcediscovery.Resourcediscoveryoutput{Request: "low"}, rank: 0, sequence: 1},
+				{c: resourcediscovery.Resourcediscoveryoutput{Request: "critical"}, rank: 3, sequence: 2},
+				{c: resourcediscovery.Resourcediscoveryoutput{Request: "normal"}, rank: 1, sequence: 3},
+			},
+			want: []string{"critical", "normal", "low"},
+		},
+		{
+			name: "arrival within a class",
+			queued: []queued{
+				{c: resourcediscovery.Resourcediscoveryoutput{Request: "second"}, rank: 2, sequence: 2},
+				{c: resourcediscovery.Resourcediscoveryoutput{Request: "third"}, rank: 2, sequence: 3},
+				{c: resourcediscovery.Resourcediscoveryoutput{Request: "first"}, rank: 2, sequence: 1},
+			},
+			want: []string{"first", "second", "third"},
+		},
+		{
+			name:   "empty",
+			queued: nil,
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q admissionqueue
+			for i := range tt.queued {
+				heap.Push(&q, &tt.queued[i])
+			}
+			var got []string
+			for q.Len() > 0 {
+				got = append(got, heap.Pop(&q).(*queued).c.Request)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("popped %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("popped %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+//created : returns the channel of a workload whose service was created
+func created() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}
+
+func TestVictim(t *testing.T) {
+	now := time.Now()
+	running := func(request string, node string, class string, launched time.Time) *workload {
+		return &workload{c: resourcediscovery.Resourcediscoveryoutput{Request: request, Locationtolaunch: node},
+			rank: library.Priorityrank[class], launched: launched}
+	}
+	pipeline := running("pipeline", "n1", library.Low, now.Add(time.Minute))
+	pipeline.c.Stages = []resourcediscovery.Placedstage{{Name: "stage"}}
+
+	tests := []struct {
+		name      string
+		workloads []*workload
+		node      string
+		class     string
+		want      string
+	}{
+		{
+			name:      "only critical requests preempt",
+			workloads: []*workload{running("low", "n1", library.Low, now)},
+			node:      "n1", class: library.High,
+		},
+		{
+			name: "lowest class first",
+			workloads: []*workload{running("normal", "n1", library.Normal, now.Add(time.Second)),
+				running("low", "n1", library.Low, now)},
+			node: "n1", class: library.Critical, want: "low",
+		},
+		{
+			name: "most recently launched within a class",
+			workloads: []*workload{running("older", "n1", library.Low, now),
+				running("newer", "n1", library.Low, now.Add(time.Second))},
+			node: "n1", class: library.Critical, want: "newer",
+		},
+		{
+			name:      "other edge nodes are left alone",
+			workloads: []*workload{running("low", "n2", library.Low, now)},
+			node:      "n1", class: library.Critical,
+		},
+		{
+			name:      "critical workloads are not preempted",
+			workloads: []*workload{running("critical", "n1", library.Critical, now)},
+			node:      "n1", class: library.Critical,
+		},
+		{
+			name:      "pipelines are not preempted",
+			workloads: []*workload{pipeline, running("normal", "n1", library.Normal, now)},
+			node:      "n1", class: library.Critical, want: "normal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &Runtime{workloads: map[string]*workload{}}
+			for _, w := range tt.workloads {
+				rt.workloads[w.c.Request] = w
+			}
+			got := ""
+			if victim := rt.victim(tt.node, library.Priorityrank[tt.class]); victim != nil {
+				got = victim.c.Request
+			}
+			if got != tt.want {
+				t.Errorf("victim %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+//eventually : fails the test if a condition does not hold within a few seconds
+func eventually(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !condition(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not happen", what)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	calls := fakedocker(t, map[string]string{"TASKS": "Running"})
+	rt := newruntime()
+	rt.Nodecapacity = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	go rt.dispatch(ctx)
+	defer func() {
+		cancel()
+		eventually(t, "the end of the tracking", func() bool { return rt.Running() == 0 && rt.inflight.Load() == 0 })
+	}()
+
+	//the requests are submitted one after the other on the saturated edge node n1
+	tests := []struct {
+		request, class string
+		wantlaunched   []string
+		wantqueued     int
+		wantremoved    string //service removed to preempt its workload
+	}{
+		{request: "low", class: library.Low, wantlaunched: []string{"low"}},
+		{request: "normal", class: library.Normal, wantlaunched: []string{"low"}, wantqueued: 1},
+		{request: "critical", class: library.Critical, wantlaunched: []string{"critical"}, wantqueued: 2,
+			wantremoved: "low"},
+	}
+	for _, tt := range tests {
+		rt.records.Submit(requestrecord.Record{Request: tt.request, Priority: tt.class})
+		rt.enqueue(resourcediscovery.Resourcediscoveryoutput{Request: tt.request, Applicationtolaunch: "app",
+			Locationtolaunch: "n1", Ctx: context.Background()})
+		eventually(t, "the dispatch of "+tt.request, func() bool {
+			var launched []string
+			for _, r := range rt.records.List() {
+				if r.State == requestrecord.Launched && r.Node == "n1" {
+					launched = append(launched, r.Request)
+				}
+			}
+			return rt.Queued() == tt.wantqueued && reflect.DeepEqual(launched, tt.wantlaunched)
+		})
+		if tt.wantremoved != "" {
+			eventually(t, "the removal of "+tt.wantremoved, func() bool {
+				r, _ := rt.records.Get(tt.wantremoved)
+				return r.Preemptions == 1 && r.State == requestrecord.Submitted
+			})
+			if !reflect.DeepEqual(removed(calls()), []string{tt.wantremoved}) {
+				t.Errorf("services removed %v, want %s", removed(calls()), tt.wantremoved)
+			}
+		}
+	}
+}
+
+//removed : returns the services removed by the calls made to docker
+func removed(calls []string) []string {
+	var services []string
+	for _, call := range calls {
+		if service, ok := strings.CutPrefix(call, "service rm "); ok {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+func TestStop(t *testing.T) {
+	tests := []struct {
+		name        string
+		queued      bool //whether the request waits in the admission queue
+		running     bool //whether the workload of the request runs
+		want        bool
+		wantremoved []string
+	}{
+		{name: "queued request", queued: true, want: true},
+		{name: "running workload", running: true, want: true, wantremoved: []string{"req-run2", "req-stage"}},
+		{name: "request not served"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, nil)
+			rt := newruntime()
+			rt.records.Add(requestrecord.Record{Request: "req", Service: "req-run2", State: requestrecord.Launched,
+				Stages: []requestrecord.Stage{{Name: "stage", Service: "req-stage"}, {Name: "skipped"}}})
+			rt.catalog.Add("r", "n1", resourcemanager.Provenance{})
+			rt.catalog.Claim("r", "n1")
+			c := resourcediscovery.Resourcediscoveryoutput{Request: "req", Resource: "r", Locationtolaunch: "n1",
+				Ctx: context.Background()}
+			if tt.queued {
+				rt.enqueue(c)
+			}
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			if tt.running {
+				rt.workloads["req"] = &workload{c: c, cancel: cancel, created: created()}
+			}
+			if stopped := rt.Stop("req"); stopped != tt.want {
+				t.Errorf("Stop = %v, want %v", stopped, tt.want)
+			}
+			if rt.Queued() != 0 || len(rt.workloads) != 0 {
+				t.Errorf("%d requests queued and %d workloads left", rt.Queued(), len(rt.workloads))
+			}
+			if tt.running && context.Cause(ctx) != errstopped {
+				t.Errorf("workload cancelled with %v, want %v", context.Cause(ctx), errstopped)
+			}
+			if !reflect.DeepEqual(removed(calls()), tt.wantremoved) {
+				t.Errorf("services removed %v, want %v", removed(calls()), tt.wantremoved)
+			}
+			//a stopped request gives its resource back, whether its workload was launched or not
+			if released := rt.catalog.Has("r"); released != tt.want {
+				t.Errorf("resource released %v, want %v", released, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopWhileCreated(t *testing.T) {
+	calls := fakedocker(t, nil)
+	rt := newruntime()
+	rt.records.Add(requestrecord.Record{Request: "req", Service: "req", State: requestrecord.Launched})
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	creating := make(chan struct{})
+	rt.workloads["req"] = &workload{c: resourcediscovery.Resourcediscoveryoutput{Request: "req"}, cancel: cancel,
+		created: creating}
+	stopped := make(chan bool)
+	go func() { stopped <- rt.Stop("req") }()
+	time.Sleep(20 * time.Millisecond)
+	if context.Cause(ctx) != errstopped || len(removed(calls())) != 0 {
+		t.Fatalf("workload cancelled with %v, services removed %v before the service was created",
+			context.Cause(ctx), removed(calls()))
+	}
+	close(creating)
+	if !<-stopped {
+		t.Error("Stop = false, want true")
+	}
+	if !reflect.DeepEqual(removed(calls()), []string{"req"}) {
+		t.Errorf("services removed %v, want the service created meanwhile", removed(calls()))
+	}
+}
+
+func TestLoads(t *testing.T) {
+	tests := []struct {
+		name    string
+		running []string //edge node of each running workload
+		queued  []string //edge node awaited by each queued request
+		want    map[string]Load
+	}{
+		{name: "idle swarm", want: map[string]Load{}},
+		{name: "running and queued", running: []string{"n1", "n1", "n2"}, queued: []string{"n1", "n3"},
+			want: map[string]Load{"n1": {Running: 2, Queued: 1}, "n2": {Running: 1}, "n3": {Queued: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newruntime()
+			for i, node := range tt.running {
+				request := "run" + string(rune('1'+i))
+				rt.workloads[request] = &workload{c: resourcediscovery.Resourcediscoveryoutput{Request: request,
+					Locationtolaunch: node}}
+			}
+			for i, node := range tt.queued {
+				rt.enqueue(resourcediscovery.Resourcediscoveryoutput{Request: "queued" + string(rune('1'+i)),
+					Locationtolaunch: node, Ctx: context.Background()})
+			}
+			if loads := rt.Loads(); !reflect.DeepEqual(loads, tt.want) {
+				t.Errorf("Loads = %v, want %v", loads, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		running bool //whether the workload runs, it waits in the admission queue otherwise
+		//elsewhere : whether another edge node holds the resource, pipeline : whether the request is a pipeline
+		elsewhere, pipeline bool
+		wantmoved           int
+		wantnode            string //edge node awaited by the queued request afterwards
+	}{
+		{name: "queued request", elsewhere: true, wantmoved: 1, wantnode: "n1"},
+		{name: "queued request of a resource held nowhere else", wantnode: "n2"},
+		{name: "queued pipeline", elsewhere: true, pipeline: true, wantnode: "n2"},
+		{name: "running workload", running: true, elsewhere: true, wantmoved: 1, wantnode: "n1"},
+		{name: "running workload of a resource held nowhere else", running: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t, nil)
+			rt := newruntime()
+			if tt.elsewhere {
+				rt.catalog.Add("r", "n1", resourcemanager.Provenance{})
+			}
+			rt.catalog.Add("r", "n2", resourcemanager.Provenance{})
+			rt.catalog.Claim("r", "n2")
+			rt.catalog.Setstate("n2", resourcemanager.Draining)
+			c := resourcediscovery.Resourcediscoveryoutput{Request: "req", Resource: "r", Locationtolaunch: "n2",
+				Ctx: context.Background()}
+			if tt.pipeline {
+				c.Stages = []resourcediscovery.Placedstage{{Name: "stage", Resource: "r", Node: "n2"}}
+			}
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+			if tt.running {
+				rt.workloads["req"] = &workload{c: c, cancel: cancel, created: created()}
+			} else {
+				rt.enqueue(c)
+			}
+			if moved := rt.Migrate("n2"); moved != tt.wantmoved {
+				t.Errorf("Migrate = %d, want %d", moved, tt.wantmoved)
+			}
+			if tt.running && (context.Cause(ctx) == errmigrated) != (tt.wantmoved == 1) {
+				t.Errorf("workload cancelled with %v", context.Cause(ctx))
+			}
+			if tt.wantnode == "" {
+				return
+			}
+			eventually(t, "the request queued for "+tt.wantnode, func() bool {
+				return rt.Loads()[tt.wantnode].Queued == 1
+			})
+		})
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       string //state of the request when its workload is requeued
+		wantstate   string
+		wantqueued  int
+		wantclaimed int //claims of the IoT resource afterwards
+	}{
+		{name: "running request", state: requestrecord.Launched, wantstate: requestrecord.Submitted, wantqueued: 1,
+			wantclaimed: 1},
+		{name: "request cancelled meanwhile", state: requestrecord.Cancelled, wantstate: requestrecord.Cancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, nil)
+			rt := newruntime()
+			rt.catalog.Add("r", "n1", resourcemanager.Provenance{})
+			rt.catalog.Claim("r", "n1")
+			rt.records.Add(requestrecord.Record{Request: "req", Service: "req", State: tt.state})
+			c := resourcediscovery.Resourcediscoveryoutput{Request: "req", Resource: "r", Locationtolaunch: "n1",
+				Ctx: context.Background()}
+			rt.requeue(&workload{c: c, cancel: func(error) {}, created: created()})
+
+			if record, _ := rt.records.Get("req"); record.State != tt.wantstate {
+				t.Errorf("state %q, want %q", record.State, tt.wantstate)
+			}
+			if queued := rt.Loads()["n1"].Queued; queued != tt.wantqueued {
+				t.Errorf("%d requests queued, want %d", queued, tt.wantqueued)
+			}
+			claimed := 0
+			for _, e := range rt.catalog.Entries() {
+				if e.Claimed {
+					claimed++
+				}
+			}
+			if claimed != tt.wantclaimed {
+				t.Errorf("%d claims of the resource, want %d", claimed, tt.wantclaimed)
+			}
+			if !reflect.DeepEqual(removed(calls()), []string{"req"}) {
+				t.Errorf("services removed %v, want the service of the workload", removed(calls()))
+			}
+		})
+	}
+}
+
+func TestLeases(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued resourcediscovery.Resourcediscoveryoutput
+		want   map[string]map[string]bool
+	}{
+		{name: "resource of the request", queued: resourcediscovery.Resourcediscoveryoutput{Request: "queued",
+			Resource: "r", Locationtolaunch: "n2"},
+			want: map[string]map[string]bool{"n1": {"a": true}, "n2": {"r": true}}},
+		{name: "inputs", queued: resourcediscovery.Resourcediscoveryoutput{Request: "queued",
+			Locationtolaunch: "n2", Inputs: []resourcediscovery.Placedinput{{Resource: "b", Node: "n3"}}},
+			want: map[string]map[string]bool{"n1": {"a": true}, "n3": {"b": true}}},
+		{name: "stages", queued: resourcediscovery.Resourcediscoveryoutput{Request: "queued",
+			Locationtolaunch: "n2", Stages: []resourcediscovery.Placedstage{{Name: "s", Resource: "c", Node: "n2",
+				Inputs: []resourcediscovery.Placedinput{{Resource: "d", Node: "n1"}}}}},
+			want: map[string]map[string]bool{"n1": {"a": true, "d": true}, "n2": {"c": true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newruntime()
+			rt.workloads["running"] = &workload{c: resourcediscovery.Resourcediscoveryoutput{Request: "running",
+				Resource: "a", Locationtolaunch: "n1"}}
+			tt.queued.Ctx = context.Background()
+			rt.enqueue(tt.queued)
+			if leases := rt.Leases(); !reflect.DeepEqual(leases, tt.want) {
+				t.Errorf("Leases = %v, want %v", leases, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+This file implements the garbage collection of finished workloads. Services are created with '--restart-condition none'
+and therefore stay in the swarm after they complete, which makes 'docker service ls' grow without bound and blocks the
+service name of a request from being used again. The reaper removes such services once they have been finished for
+longer than the retention period, after collecting their logs and exit status into the request record. The scratch
+volumes created for the requests are removed along with them.
+*/
+
+package taskinitiator
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+)
+
+//logtail : number of log lines of a finished service kept in its request record
+const logtail = "500"
+
+/*
+Reapservices : Periodically removes the services of finished requests that are older than the retention period.
+Input: context whose cancellation stops the reaper, retention period after which a finished service is removed,
+interval between two passes of the reaper
+Output: Nil
+*/
+func (rt *Runtime) Reapservices(ctx context.Context, retention time.Duration, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		for _, r := range rt.records.List() {
+			if r.State != requestrecord.Completed && r.State != requestrecord.Failed {
+				continue
+			}
+			if r.Origin != "" {
+				continue //the service is reaped by the edge node serving the request
+			}
+			if time.Since(r.Finished) < retention {
+				continue
+			}
+			if len(r.Stages) > 0 {
+				rt.reapstages(r)
+				continue
+			}
+			logs, exitstatus, ok := rt.reapservice(r.Service)
+			if !ok {
+				continue
+			}
+			rt.records.Update(r.Request, func(r *requestrecord.Record) {
+				r.State = requestrecord.Reaped
+				r.ExitStatus = exitstatus
+				r.Logs = logs
+				r.Reaped = time.Now()
+			})
+		}
+
+		rt.reapvolumes()
+	}
+}
+
+/*
+reapservice : Collects the logs and exit status of a finished service and removes it from the swarm.
+Input: name of the service to remove
+Output: logs of the service, its exit status, -1 if unknown, whether the service was removed
+*/
+func (rt *Runtime) reapservice(servicename string) (string, int, bool) {
+	logs, err := exec.Command("docker", "service", "logs", "--raw", "--no-task-ids", "--tail", logtail,
+		servicename).CombinedOutput()
+	if err != nil {
+		rt.logger.Warn("reaper: could not collect logs of service", logging.Request, servicename, "err", err)
+	}
+
+	exitstatus := -1
+	out, err := exec.Command("docker", "service", "ps", "--quiet", "--no-trunc", servicename).Output()
+	if err != nil {
+		rt.logger.Warn("reaper: could not list tasks of service", logging.Request, servicename, "err", err)
+	}
+	tasks := strings.Fields(string(out))
+	if len(tasks) > 0 {
+		out, err = exec.Command("docker", "inspect", "--type", "task", "--format",
+			"{{.Status.ContainerStatus.ExitCode}}", tasks[0]).Output()
+		if err != nil {
+			rt.logger.Warn("reaper: could not inspect task of service", logging.Request, servicename, "err", err)
+		} else if code, err := strconv.Atoi(strings.TrimSpace(string(out))); err == nil {
+			exitstatus = code
+		}
+	}
+
+	if _, err := exec.Command("docker", "service", "rm", servicename).Output(); err != nil {
+		rt.logger.Warn("reaper: could not remove service", logging.Request, servicename, "err", err)
+		return "", exitstatus, false
+	}
+	rt.logger.Info("reaper: removed service", logging.Request, servicename, "exitstatus", exitstatus)
+	return string(logs), exitstatus, true
+}
+
+/*
+reapstages : Removes the services of the stages of a finished pipeline. The logs of the stages are kept one after the
+other in the request record and the exit status of each in its stage.
+Input: record of the request
+Output: Nil
+*/
+func (rt *Runtime) reapstages(r requestrecord.Record) {
+	var logs strings.Builder
+	exitstatus := map[string]int{}
+	for _, stage := range r.Stages {
+		if stage.Service == "" {
+			continue //the stage was never launched
+		}
+		stagelogs, status, ok := rt.reapservice(stage.Service)
+		if !ok {
+			return
+		}
+		logs.WriteString("== stage " + stage.Name + " ==\n" + stagelogs)
+		exitstatus[stage.Name] = status
+	}
+	rt.records.Update(r.Request, func(r *requestrecord.Record) {
+		for i := range r.Stages {
+			if status, ok := exitstatus[r.Stages[i].Name]; ok {
+				r.Stages[i].ExitStatus = status
+			}
+		}
+		r.State = requestrecord.Reaped
+		r.Logs = logs.String()
+		r.Reaped = time.Now()
+	})
+}
+
+/*
+reapvolumes : Removes the scratch volumes on this edge node whose service does not exist anymore. The scratch volume of
+a request lives on the node the workload ran on, which need not be the node that removed the service, hence every
+node sweeps its own volumes.
+Input: Nil
+Output: Nil
+*/
+func (rt *Runtime) reapvolumes() {
+	out, err := exec.Command("docker", "volume", "ls", "--quiet", "--filter", "label="+requestlabel).Output()
+	if err != nil {
+		rt.logger.Warn("reaper: could not list scratch volumes", "err", err)
+		return
+	}
+	for _, volume := range strings.Fields(string(out)) {
+		label, err := exec.Command("docker", "volume", "inspect", "--format",
+			"{{index .Labels \""+requestlabel+"\"}}", volume).Output()
+		if err != nil {
+			continue
+		}
+		servicename := strings.TrimSpace(string(label))
+		if exec.Command("docker", "service", "inspect", servicename).Run() == nil {
+			continue //service still exists, its scratch volume may still be in use
+		}
+		if _, err := exec.Command("docker", "volume", "rm", volume).Output(); err != nil {
+			rt.logger.Warn("reaper: could not remove scratch volume", "volume", volume, "err", err)
+			continue
+		}
+		rt.logger.Info("reaper: removed scratch volume", "volume", volume, logging.Request, servicename)
+	}
+}
@@ -0,0 +1,170 @@
+package taskinitiator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/niketagrawal/EDIRO/requestrecord"
+)
+
+//fakedockerscript : a docker command answering the calls of EDIRO from the FAKEDOCKER_ environment variables
+const fakedockerscript = `#!/bin/sh
+echo "$*" >> "$FAKEDOCKER_LOG"
+case "$1 $2" in
+"service logs") printf '%s' "$FAKEDOCKER_LOGS" ;;
+"service ps") printf '%s\n' "$FAKEDOCKER_TASKS" ;;
+"inspect --type") printf '%s\n' "$FAKEDOCKER_EXITCODE" ;;
+"service rm") test -z "$FAKEDOCKER_RMFAIL" || exit 1 ;;
+"service create") test -z "$FAKEDOCKER_CREATEFAIL" || exit 1 ;;
+"service inspect") case " $FAKEDOCKER_SERVICES " in *" $3 "*) ;; *) exit 1 ;; esac ;;
+"volume ls") printf '%s\n' "$FAKEDOCKER_VOLUMES" ;;
+"volume inspect")
+	case "$4" in
+	*Mountpoint*) test -n "$FAKEDOCKER_MOUNTPOINT" || exit 1; echo "$FAKEDOCKER_MOUNTPOINT" ;;
+	*) echo "${5#ediro-scratch-}" ;;
+	esac ;;
+esac
+`
+
+/*
+fakedocker : Puts a fake docker command first in the PATH for the duration of a test.
+Input: the test, FAKEDOCKER_ variables the fake command answers from, e.g. TASKS for the tasks of a service
+Output: a function returning the calls made to the fake command
+*/
+func fakedocker(t *testing.T, env map[string]string) func() []string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(fakedockerscript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	log := filepath.Join(dir, "calls")
+	t.Setenv("FAKEDOCKER_LOG", log)
+	for name, value := range env {
+		t.Setenv("FAKEDOCKER_"+name, value)
+	}
+	return func() []string {
+		data, _ := os.ReadFile(log)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func TestReapservice(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantremoved    bool
+		wantexitstatus int
+		wantlogs       string
+	}{
+		{
+			name:        "completed workload",
+			env:         map[string]string{"TASKS": "task1", "EXITCODE": "0", "LOGS": "done\n"},
+			wantremoved: true, wantexitstatus: 0, wantlogs: "done\n",
+		},
+		{
+			name:        "failed workload",
+			env:         map[string]string{"TASKS": "task1", "EXITCODE": "3", "LOGS": "panic\n"},
+			wantremoved: true, wantexitstatus: 3, wantlogs: "panic\n",
+		},
+		{
+			name:        "no task left",
+			env:         map[string]string{},
+			wantremoved: true, wantexitstatus: -1,
+		},
+		{
+			name:           "service not removed",
+			env:            map[string]string{"TASKS": "task1", "EXITCODE": "0", "RMFAIL": "1"},
+			wantexitstatus: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t, tt.env)
+			logs, exitstatus, removed := newruntime().reapservice("req")
+			if removed != tt.wantremoved || exitstatus != tt.wantexitstatus || logs != tt.wantlogs {
+				t.Errorf("removed %v with exit status %d and logs %q, want %v with %d and %q", removed, exitstatus,
+					logs, tt.wantremoved, tt.wantexitstatus, tt.wantlogs)
+			}
+		})
+	}
+}
+
+func TestReapstages(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantstate string
+		wantlogs  string
+		//wantexitstatus : exit status of each stage
+		wantexitstatus []int
+	}{
+		{
+			name:      "stages removed",
+			env:       map[string]string{"TASKS": "task1", "EXITCODE": "2", "LOGS": "out\n"},
+			wantstate: requestrecord.Reaped, wantlogs: "== stage a ==\nout\n== stage b ==\nout\n",
+			wantexitstatus: []int{2, 2, 0},
+		},
+		{
+			name:           "stage not removed",
+			env:            map[string]string{"TASKS": "task1", "EXITCODE": "0", "RMFAIL": "1"},
+			wantstate:      requestrecord.Completed,
+			wantexitstatus: []int{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t, tt.env)
+			rt := newruntime()
+			//the stage c was skipped and never launched
+			record := requestrecord.Record{Request: "req", State: requestrecord.Completed,
+				Stages: []requestrecord.Stage{{Name: "a", Service: "req-a"}, {Name: "b", Service: "req-b"},
+					{Name: "c"}}}
+			rt.records.Add(record)
+			rt.reapstages(record)
+			r, _ := rt.records.Get("req")
+			var exitstatus []int
+			for _, stage := range r.Stages {
+				exitstatus = append(exitstatus, stage.ExitStatus)
+			}
+			if r.State != tt.wantstate || r.Logs != tt.wantlogs ||
+				!reflect.DeepEqual(exitstatus, tt.wantexitstatus) {
+				t.Errorf("record %s with logs %q and exit status %v, want %s with %q and %v", r.State, r.Logs,
+					exitstatus, tt.wantstate, tt.wantlogs, tt.wantexitstatus)
+			}
+		})
+	}
+}
+
+func TestReapvolumes(t *testing.T) {
+	tests := []struct {
+		name        string
+		volumes     string
+		services    string //services still in the swarm
+		wantremoved []string
+	}{
+		{name: "no volumes"},
+		{name: "service removed", volumes: "ediro-scratch-a", wantremoved: []string{"ediro-scratch-a"}},
+		{name: "service still running", volumes: "ediro-scratch-a", services: "a"},
+		{name: "some services removed", volumes: "ediro-scratch-a ediro-scratch-b ediro-scratch-c", services: "b",
+			wantremoved: []string{"ediro-scratch-a", "ediro-scratch-c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, map[string]string{"VOLUMES": strings.ReplaceAll(tt.volumes, " ", "\n"),
+				"SERVICES": tt.services})
+			newruntime().reapvolumes()
+			var removed []string
+			for _, call := range calls() {
+				if volume, ok := strings.CutPrefix(call, "volume rm "); ok {
+					removed = append(removed, volume)
+				}
+			}
+			if !reflect.DeepEqual(removed, tt.wantremoved) {
+				t.Errorf("removed %v, want %v", removed, tt.wantremoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+This file implements the collection of the results of the workloads. A workload image hands its result back to EDIRO
+in one of two ways:
+1. It writes the result to the file 'result' in the directory given by the environment variable EDIRO_OUTPUT_DIR.
+The directory is a volume on the node the workload runs on and is read by EDIRO once the workload completes. This only
+works when the workload ran on the edge node that launched it.
+2. It sends the result in the body of a POST request to the URL given by the environment variable EDIRO_CALLBACK_URL,
+which points to the client API of the edge node that launched it. This works wherever the workload ran. The URL carries
+a token drawn for the request, the client API only stores results delivered with it.
+The name of the client request is passed to the workload in the environment variable EDIRO_REQUEST.
+*/
+
+package taskinitiator
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resultstore"
+)
+
+//resultfile : name of the file in the output directory that holds the result of a workload
+const resultfile = "result"
+
+//outputvolume : name of the volume mounted as output directory of the workload of a request
+func outputvolume(servicename string) string {
+	return "ediro-output-" + servicename
+}
+
+//callbackurl : returns the URL the workload of a request delivers its result to, with the token of the request. The
+//token is drawn on the first launch of the request and kept in its record across the runs of a subscription.
+func (rt *Runtime) callbackurl(request string) string {
+	var token string
+	rt.records.Update(request, func(r *requestrecord.Record) {
+		if r.Callbacktoken == "" {
+			r.Callbacktoken = rand.Text()
+		}
+		token = r.Callbacktoken
+	})
+	return rt.Callbackaddress + "/callback/" + request + "?token=" + token
+}
+
+/*
+collectresult : Reads the result file of a completed workload from its output volume and stores it in the result
+store, unless the workload already delivered its result through the callback. A result stored before the workload was
+launched is that of a previous run of a subscription and is replaced.
+Input: client request, service launched for it, i.e. the last stage of its pipeline if it has one, time of its launch
+Output: Nil
+*/
+func (rt *Runtime) collectresult(request string, servicename string, launched time.Time) {
+	if result, ok := rt.results.Get(request); ok && !result.Stored.Before(launched) {
+		return
+	}
+	out, err := exec.Command("docker", "volume", "inspect", "--format", "{{.Mountpoint}}",
+		outputvolume(servicename)).Output()
+	if err != nil {
+		rt.logger.Debug("no local output volume, result is expected through callback", logging.Request, servicename)
+		return
+	}
+	data, err := ioutil.ReadFile(filepath.Join(strings.TrimSpace(string(out)), resultfile))
+	if err != nil {
+		rt.logger.Debug("workload did not write a result file", logging.Request, servicename, "err", err)
+		return
+	}
+	rt.results.Put(resultstore.Result{Request: request, ContentType: http.DetectContentType(data),
+		Source: resultstore.Fromfile, Data: data})
+	rt.logger.Info("collected result file", logging.Request, servicename, "bytes", len(data))
+}
@@ -0,0 +1,133 @@
+package taskinitiator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resultstore"
+)
+
+func TestCollectresult(t *testing.T) {
+	tests := []struct {
+		name       string
+		resultfile string //content of the result file, none if empty
+		volume     bool   //whether the output volume is on this edge node
+		previous   bool   //whether the result of a previous run was stored before the launch
+		delivered  bool   //whether the result was delivered through the callback after the launch
+		wantsource string
+		wantdata   string
+	}{
+		{name: "result file", resultfile: "42", volume: true, wantsource: resultstore.Fromfile, wantdata: "42"},
+		{name: "no result file", volume: true},
+		{name: "output volume on another edge node", resultfile: "42"},
+		{name: "delivered through the callback", resultfile: "42", volume: true, delivered: true,
+			wantsource: resultstore.Fromcallback, wantdata: "callback"},
+		{name: "result of a previous run", resultfile: "42", volume: true, previous: true,
+			wantsource: resultstore.Fromfile, wantdata: "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newruntime()
+			mountpoint := t.TempDir()
+			if tt.resultfile != "" {
+				if err := os.WriteFile(filepath.Join(mountpoint, resultfile), []byte(tt.resultfile), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			env := map[string]string{}
+			if tt.volume {
+				env["MOUNTPOINT"] = mountpoint
+			}
+			fakedocker(t, env)
+			if tt.previous {
+				rt.results.Put(resultstore.Result{Request: "req", Source: resultstore.Fromcallback,
+					Data: []byte("previous")})
+				time.Sleep(time.Millisecond)
+			}
+			launched := time.Now()
+			if tt.delivered {
+				time.Sleep(time.Millisecond)
+				rt.results.Put(resultstore.Result{Request: "req", Source: resultstore.Fromcallback,
+					Data: []byte("callback")})
+			}
+			rt.collectresult("req", "req", launched)
+			r, _ := rt.results.Get("req")
+			if r.Source != tt.wantsource || string(r.Data) != tt.wantdata {
+				t.Errorf("result %q from %q, want %q from %q", r.Data, r.Source, tt.wantdata, tt.wantsource)
+			}
+		})
+	}
+}
+
+func TestCollectresultRuns(t *testing.T) {
+	mountpoint := t.TempDir()
+	fakedocker(t, map[string]string{"MOUNTPOINT": mountpoint})
+	rt := newruntime()
+	watch := rt.results.Watch("sub")
+	defer rt.results.Unwatch("sub", watch)
+
+	runs := []struct {
+		name     string
+		data     string
+		callback string
+		want     string
+	}{
+		{name: "first run", data: "run 1", want: "run 1"},
+		{name: "second run", data: "run 2", want: "run 2"},
+		{name: "result delivered through callback", data: "run 3 file", callback: "run 3", want: "run 3"},
+	}
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			launched := time.Now()
+			time.Sleep(time.Millisecond)
+			if run.callback != "" {
+				rt.results.Put(resultstore.Result{Request: "sub", Source: resultstore.Fromcallback,
+					Data: []byte(run.callback)})
+				<-watch
+			}
+			err := os.WriteFile(filepath.Join(mountpoint, resultfile), []byte(run.data), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rt.collectresult("sub", "sub-run", launched)
+			if result, _ := rt.results.Get("sub"); string(result.Data) != run.want {
+				t.Errorf("stored result %q, want %q", result.Data, run.want)
+			}
+			if run.callback != "" {
+				select {
+				case r := <-watch:
+					t.Errorf("result %q published again", r.Data)
+				default:
+				}
+				return
+			}
+			select {
+			case r := <-watch:
+				if string(r.Data) != run.want {
+					t.Errorf("published result %q, want %q", r.Data, run.want)
+				}
+			default:
+				t.Error("result of the run not published")
+			}
+		})
+	}
+}
+
+func TestCallbackurl(t *testing.T) {
+	rt := newruntime()
+	rt.records.Add(requestrecord.Record{Request: "sub", State: requestrecord.Subscribed})
+	rt.records.Add(requestrecord.Record{Request: "req", State: requestrecord.Launched})
+	first, again, other := rt.callbackurl("sub"), rt.callbackurl("sub"), rt.callbackurl("req")
+	r, _ := rt.records.Get("sub")
+	if r.Callbacktoken == "" || first != "http://edge_node_1:8080/callback/sub?token="+r.Callbacktoken {
+		t.Errorf("callback URL %q with the token %q", first, r.Callbacktoken)
+	}
+	//the runs of a subscription keep the token, the requests do not share it
+	if again != first || strings.TrimPrefix(other, "http://edge_node_1:8080/callback/req?token=") == r.Callbacktoken {
+		t.Errorf("callback URLs %q, %q and %q", first, again, other)
+	}
+}
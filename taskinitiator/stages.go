@@ -0,0 +1,283 @@
+/*
+This file implements the run of the pipeline of applications serving a client request. Every stage is a service of its
+own, named after the request and the stage, launched on the edge node it was placed on once the stages it comes after
+completed. A stage hands its output to the stages after it through its output volume: the output volume of each stage it
+comes after is mounted read only under /input/<stage>, and the output volumes held by other edge nodes are first copied
+to its edge node by the transfer service (see package prefetch). A stage coming after a failed stage is skipped. The
+state of every stage is kept in the request record and the result of the request is the result of its last stage.
+*/
+
+package taskinitiator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcediscovery"
+	"github.com/niketagrawal/EDIRO/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//inputdir : directory under which the output of the stages a stage comes after is mounted
+const inputdir = "/input"
+
+//stagepollinterval : interval at which the state of the service of a stage is checked
+const stagepollinterval = time.Second
+
+//Reasons of the failure of a pipeline
+const (
+	//Stagefailed : a stage of the pipeline failed
+	Stagefailed = "stage_failed"
+	//Pipelineinterrupted : the edge node was stopped while the pipeline was running
+	Pipelineinterrupted = "pipeline_interrupted"
+	//Servicenotcreated : the service of a stage, or of the workload of a request, could not be created
+	Servicenotcreated = "service_not_created"
+)
+
+//stageservice : name of the service of a stage of the pipeline serving a request
+func stageservice(request string, stage string) string {
+	return request + "-" + stage
+}
+
+/*
+runstages : Runs the stages of the pipeline serving a client request, each once the stages it comes after are over,
+and ends the request once all the stages are over. When ctx is cancelled no more stages are launched nor tracked and the
+services running are left in the swarm.
+Input: context whose cancellation stops the pipeline, the request and its placed stages
+Output: Nil
+*/
+func (rt *Runtime) runstages(ctx context.Context, c resourcediscovery.Resourcediscoveryoutput) {
+	defer rt.running.Add(-1)
+
+	if _, ok := rt.records.Get(c.Request); !ok {
+		rt.records.Add(requestrecord.Record{Request: c.Request, Submitted: c.Arrived})
+	}
+	launched := time.Now()
+	rt.records.Update(c.Request, func(r *requestrecord.Record) {
+		r.Application, r.Node = c.Applicationtolaunch, c.Locationtolaunch
+		if r.State != requestrecord.Cancelled {
+			r.State, r.Launched = requestrecord.Launched, launched
+		}
+		r.Stages = nil
+		for _, stage := range c.Stages {
+			r.Stages = append(r.Stages, requestrecord.Stage{Name: stage.Name, Application: stage.Application,
+				Node: stage.Node, State: requestrecord.Submitted})
+		}
+	})
+
+	//the last stages are those no stage comes after, they deliver the result of the request
+	last := map[string]bool{}
+	for _, stage := range c.Stages {
+		last[stage.Name] = true
+	}
+	done := map[string]chan struct{}{}
+	for _, stage := range c.Stages {
+		done[stage.Name] = make(chan struct{})
+		for _, after := range stage.After {
+			last[after] = false
+		}
+	}
+
+	states := map[string]string{}
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+	for _, stage := range c.Stages {
+		wg.Add(1)
+		go func(stage resourcediscovery.Placedstage) {
+			defer wg.Done()
+			defer close(done[stage.Name])
+			failed := ""
+			for _, after := range stage.After {
+				select {
+				case <-ctx.Done():
+					return
+				case <-done[after]:
+				}
+				mux.Lock()
+				if states[after] != requestrecord.Completed && failed == "" {
+					failed = after
+				}
+				mux.Unlock()
+			}
+			state := requestrecord.Skipped
+			if failed != "" {
+				rt.updatestage(c.Request, stage.Name, func(s *requestrecord.Stage) {
+					s.State, s.Reason, s.Finished = requestrecord.Skipped, "stage "+failed+" did not complete", time.Now()
+				})
+			} else {
+				state = rt.runstage(ctx, c, stage, last[stage.Name])
+			}
+			mux.Lock()
+			states[stage.Name] = state
+			mux.Unlock()
+		}(stage)
+	}
+	wg.Wait()
+
+	span := trace.SpanFromContext(c.Ctx)
+	if ctx.Err() != nil {
+		if context.Cause(ctx) == errstopped {
+			span.End()
+			return
+		}
+		rt.logger.Info("stopped running pipeline, its stages are left in the swarm", logging.Request, c.Request)
+		return
+	}
+	state, reason := requestrecord.Completed, ""
+	for _, stage := range c.Stages {
+		if states[stage.Name] != requestrecord.Completed {
+			state, reason = requestrecord.Failed, Stagefailed
+			span.SetStatus(codes.Error, "stage "+stage.Name+" did not complete")
+			break
+		}
+		if last[stage.Name] {
+			rt.collectresult(c.Request, stageservice(c.Request, stage.Name), launched)
+		}
+	}
+	rt.records.Update(c.Request, func(r *requestrecord.Record) {
+		r.State, r.Reason, r.Finished = state, reason, time.Now()
+	})
+	span.End()
+	rt.logger.Info("pipeline finished", logging.Request, c.Request, "state", state, "stages", len(c.Stages))
+	rt.release(c.Request)
+}
+
+/*
+runstage : Gathers the inputs of a stage on its edge node, launches its service and waits for it to be over.
+Input: context whose cancellation stops the wait, the request, the stage, whether the stage delivers the result of the
+request
+Output: state the stage ended in, empty if ctx was cancelled
+*/
+func (rt *Runtime) runstage(ctx context.Context, c resourcediscovery.Resourcediscoveryoutput,
+	stage resourcediscovery.Placedstage, last bool) string {
+	servicename := stageservice(c.Request, stage.Name)
+	runctx, span := tracing.Tracer.Start(c.Ctx, "stage", trace.WithAttributes(attribute.String("ediro.stage", stage.Name),
+		attribute.String("ediro.node", stage.Node)))
+	defer span.End()
+	fail := func(reason string, err error) string {
+		rt.updatestage(c.Request, stage.Name, func(s *requestrecord.Stage) {
+			s.State, s.Reason, s.Finished = requestrecord.Failed, reason, time.Now()
+		})
+		span.RecordError(err)
+		span.SetStatus(codes.Error, reason)
+		rt.logger.Error("stage failed", logging.Request, c.Request, "stage", stage.Name, "node", stage.Node,
+			"reason", reason, "err", err)
+		return requestrecord.Failed
+	}
+
+	//the inputs and the outputs of the stages it comes after that are held by other edge nodes are copied first
+	var transfers []prefetch.Transfer
+	for _, in := range stage.Inputs {
+		if in.Node != stage.Node {
+			transfers = append(transfers, prefetch.Transfer{Resource: in.Resource, From: in.Node, To: stage.Node,
+				Provenance: in.Provenance})
+		}
+	}
+	for _, after := range stage.After {
+		if node := stagenode(c.Stages, after); node != stage.Node {
+			transfers = append(transfers, prefetch.Transfer{Resource: outputvolume(stageservice(c.Request, after)),
+				From: node, To: stage.Node})
+		}
+	}
+	if len(transfers) > 0 && rt.Gather != nil {
+		if err := rt.Gather(ctx, transfers); err != nil {
+			if ctx.Err() != nil {
+				return ""
+			}
+			return fail(Inputsnotgathered, err)
+		}
+	}
+
+	args := []string{"service", "create", "--name", servicename, "--restart-condition", "none", "--detach",
+		"--label", requestlabel + "=" + servicename, "--mount", scratchmount(servicename),
+		"--mount", outputmount(servicename),
+		"--env", "EDIRO_REQUEST=" + c.Request, "--env", "EDIRO_STAGE=" + stage.Name,
+		"--env", "EDIRO_OUTPUT_DIR=" + outputdir, "--env", "EDIRO_INPUT_DIR=" + inputdir}
+	for _, after := range stage.After {
+		args = append(args, "--mount", "type=volume,source="+outputvolume(stageservice(c.Request, after))+
+			",target="+inputdir+"/"+after+",readonly")
+	}
+	if last {
+		args = append(args, "--env", "EDIRO_CALLBACK_URL="+rt.callbackurl(c.Request))
+	}
+	if stage.Resource != "" {
+		args = append(args, "--env", "EDIRO_RESOURCE="+stage.Resource,
+			"--env", "EDIRO_RESOURCE_HASH="+stage.Provenance.Hash,
+			"--env", "EDIRO_CONTRIBUTOR="+stage.Provenance.Contributor)
+	}
+	args = append(args, inputsenvironment(stage.Inputs)...)
+	for _, env := range tracing.Environment(runctx) {
+		args = append(args, "--env", env)
+	}
+	args = append(args, "--constraint", stage.Node, stage.Application)
+
+	launched := time.Now()
+	if out, err := exec.Command("docker", args...).CombinedOutput(); err != nil {
+		return fail(Servicenotcreated, errors.New(strings.TrimSpace(string(out))))
+	}
+	rt.updatestage(c.Request, stage.Name, func(s *requestrecord.Stage) {
+		s.Service, s.State, s.Launched = servicename, requestrecord.Launched, launched
+	})
+	rt.logger.Info("stage launched", logging.Request, c.Request, "stage", stage.Name, "image", stage.Application,
+		"node", stage.Node)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-time.After(stagepollinterval):
+		}
+		completed, failed := rt.servicestate(servicename)
+		if !completed && !failed {
+			continue
+		}
+		state := requestrecord.Completed
+		if failed {
+			state = requestrecord.Failed
+			span.SetStatus(codes.Error, "stage failed")
+		}
+		finished := time.Now()
+		rt.updatestage(c.Request, stage.Name, func(s *requestrecord.Stage) {
+			s.State, s.Finished = state, finished
+		})
+		//the runtime of the stage is learnt like that of a workload of its application
+		if rt.Observer != nil {
+			rt.Observer(requestrecord.Record{Request: servicename, Application: stage.Application, Node: stage.Node,
+				State: state, Launched: launched, Finished: finished})
+		}
+		rt.metrics.Observestage(metrics.Run, launched)
+		rt.logger.Info("stage finished", logging.Request, c.Request, "stage", stage.Name, "state", state)
+		return state
+	}
+}
+
+//stagenode : returns the edge node a stage of a pipeline was placed on
+func stagenode(stages []resourcediscovery.Placedstage, name string) string {
+	for _, stage := range stages {
+		if stage.Name == name {
+			return stage.Node
+		}
+	}
+	return ""
+}
+
+//updatestage : applies fn to the state of a stage in the record of a request
+func (rt *Runtime) updatestage(request string, stage string, fn func(s *requestrecord.Stage)) {
+	rt.records.Update(request, func(r *requestrecord.Record) {
+		for i := range r.Stages {
+			if r.Stages[i].Name == stage {
+				fn(&r.Stages[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package taskinitiator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcediscovery"
+)
+
+func TestRunstages(t *testing.T) {
+	stages := []resourcediscovery.Placedstage{{Name: "downsample", Application: "app_5", Node: "n1"},
+		{Name: "inference", Application: "app_6", Node: "n2", After: []string{"downsample"}}}
+	tests := []struct {
+		name       string
+		tasks      string //state of the services of the stages
+		wantstate  string
+		wantreason string
+		//wantstages : state each stage ended in, wantlaunched : services launched
+		wantstages   []string
+		wantlaunched []string
+	}{
+		{name: "stages completed", tasks: "Complete", wantstate: requestrecord.Completed,
+			wantstages:   []string{requestrecord.Completed, requestrecord.Completed},
+			wantlaunched: []string{"req-downsample", "req-inference"}},
+		{name: "stage failed", tasks: "Failed", wantstate: requestrecord.Failed, wantreason: Stagefailed,
+			wantstages:   []string{requestrecord.Failed, requestrecord.Skipped},
+			wantlaunched: []string{"req-downsample"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, map[string]string{"TASKS": tt.tasks})
+			rt := newruntime()
+			var gathered []prefetch.Transfer
+			rt.Gather = func(ctx context.Context, transfers []prefetch.Transfer) error {
+				gathered = append(gathered, transfers...)
+				return nil
+			}
+			rt.running.Add(1)
+			rt.runstages(context.Background(), resourcediscovery.Resourcediscoveryoutput{Request: "req",
+				Applicationtolaunch: "pipeline", Locationtolaunch: "n1", Stages: stages, Ctx: context.Background()})
+
+			r, _ := rt.records.Get("req")
+			var states []string
+			for _, stage := range r.Stages {
+				states = append(states, stage.State)
+			}
+			if r.State != tt.wantstate || r.Reason != tt.wantreason || !reflect.DeepEqual(states, tt.wantstages) {
+				t.Errorf("request %s (%q) with stages %v, want %s (%q) with stages %v", r.State, r.Reason, states,
+					tt.wantstate, tt.wantreason, tt.wantstages)
+			}
+			var launched []string
+			for _, call := range calls() {
+				if strings.HasPrefix(call, "service create --name ") {
+					launched = append(launched, strings.Fields(call)[3])
+				}
+			}
+			if !reflect.DeepEqual(launched, tt.wantlaunched) {
+				t.Errorf("services launched %v, want %v", launched, tt.wantlaunched)
+			}
+			//the output of the first stage is copied to the edge node of the second one
+			if len(launched) == 2 && (len(gathered) != 1 || gathered[0].Resource != outputvolume("req-downsample") ||
+				gathered[0].From != "n1" || gathered[0].To != "n2") {
+				t.Errorf("transfers %+v, want the output of downsample copied from n1 to n2", gathered)
+			}
+			if rt.Running() != 0 {
+				t.Errorf("%d workloads still running", rt.Running())
+			}
+		})
+	}
+}
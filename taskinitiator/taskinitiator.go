@@ -5,6 +5,7 @@ To execut the workloads, Docker Swarm API to create and run a service are used.
 constructed from the metadata collected by the edge nodes. Other commands are used to monitor the completion of the service
 which is used to implement the resource monitoring feature.
 It also measure the pipeline execution time which is the time spent in offloading a client's request.
+The workloads are admitted by priority class on edge nodes of limited capacity, see dispatch.go.
 
 Author : Niket Agrawal
 */
@@ -12,22 +13,91 @@ Author : Niket Agrawal
 package taskinitiator
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/niketagrawal/EDIRO/library"
+	"github.com/niketagrawal/EDIRO/logging"
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/predictor"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/requestrecord"
 	"github.com/niketagrawal/EDIRO/resourcediscovery"
 	"github.com/niketagrawal/EDIRO/resourcemanager"
+	"github.com/niketagrawal/EDIRO/resultstore"
+	"github.com/niketagrawal/EDIRO/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var start time.Time
+//Labels attached to every service and scratch volume created for a client request so that they can be found again
+//by the reaper
+const (
+	requestlabel = "ediro.request"
+	scratchdir   = "/scratch"
+	outputdir    = "/output"
+)
+
+//completionpollinterval : interval at which the state of the service of a workload is checked
+const completionpollinterval = time.Second
+
+//Inputsnotgathered : reason of the failure of a request whose inputs could not be copied to the edge node of its workload
+const Inputsnotgathered = "inputs_not_gathered"
+
+//Runtime : The task initiator of an edge node, launching and tracking the workloads in the swarm
+type Runtime struct {
+	//Callbackaddress : base URL of the client API of this edge node under which the workloads deliver their results
+	Callbackaddress string
+
+	//inflight : number of client requests whose workload is being launched
+	inflight atomic.Int64
+	//running : number of launched workloads whose completion is being tracked
+	running atomic.Int64
+
+	//Nodecapacity : number of workloads an edge node runs at the same time, 0 for no limit
+	Nodecapacity int
+	//Observer : called with the record of every workload that finished, e.g. to learn the runtime of the applications
+	Observer func(r requestrecord.Record)
+	//Gather : copies the inputs of a workload held by other edge nodes to the edge node it is placed on, see package
+	//prefetch. Without it the workload is launched and fetches them itself.
+	Gather func(ctx context.Context, transfers []prefetch.Transfer) error
+	//queue : client requests waiting to be admitted, workloads : admitted workloads keyed by their request
+	queue       admissionqueue
+	sequence    uint64
+	workloads   map[string]*workload
+	wake        chan struct{}
+	dispatchmux sync.Mutex
+
+	catalog *resourcemanager.Catalog
+	records *requestrecord.Store
+	results *resultstore.Store
+	metrics *metrics.Metrics
+	logger  *slog.Logger
+}
+
+//New : creates the task initiator of an edge node
+func New(callbackaddress string, nodecapacity int, catalog *resourcemanager.Catalog, records *requestrecord.Store,
+	results *resultstore.Store, m *metrics.Metrics, logger *slog.Logger) *Runtime {
+	return &Runtime{Callbackaddress: callbackaddress, Nodecapacity: nodecapacity, workloads: map[string]*workload{},
+		wake: make(chan struct{}, 1), catalog: catalog, records: records, results: results, metrics: m, logger: logger}
+}
 
 /*
-launchtask: This function to handle the task of launch the containerized workload for each client request
+launchtask: This function to handle the task of launch the containerized workload for each client request. The
+tracking of the launched workload stops when ctx is cancelled, i.e. on shutdown or when the workload is preempted.
+created is closed once the service is created or will not be, whoever cancelled the workload then removes it.
 */
-func launchtask(c resourcediscovery.Resourcediscoveryoutput) {
+func (rt *Runtime) launchtask(ctx context.Context, c resourcediscovery.Resourcediscoveryoutput, created chan struct{}) {
+	defer rt.inflight.Add(-1)
+	defer close(created)
+
 	isComplete := make(chan bool) //making the channel here as it closes in the child gorotuine track completion, so for
 	//every nstance of this loop this channel will be created again
 
@@ -40,35 +110,170 @@ func launchtask(c resourcediscovery.Resourcediscoveryoutput) {
 
 	//fetch name of image and constraint of where to launch from channel and populate in the command below
 
-	elapsed := time.Since(start)
-	fmt.Println("pipeline execution time until execution of system command is: ", c.Request, elapsed)
+	rt.metrics.Pipelinelatency.Observe(time.Since(c.Arrived).Seconds())
+
+	if len(c.Stages) > 0 {
+		rt.running.Add(1)
+		go rt.runstages(ctx, c)
+		return
+	}
+
+	if err := rt.gather(ctx, c); err != nil {
+		if ctx.Err() != nil {
+			return //preempted, the request is requeued
+		}
+		rt.records.Update(c.Request, func(r *requestrecord.Record) {
+			r.State, r.Reason, r.Finished = requestrecord.Failed, Inputsnotgathered, time.Now()
+		})
+		span := trace.SpanFromContext(c.Ctx)
+		span.SetStatus(codes.Error, Inputsnotgathered)
+		span.End()
+		rt.logger.Error("could not gather inputs", logging.Request, c.Request, "node", targetnode, "err", err)
+		rt.release(c.Request)
+		return
+	}
+
+	//the record is updated before the launch so that a callback of a short lived workload finds it, it keeps what was
+	//recorded on submission
+	record, ok := rt.records.Get(c.Request)
+	if !ok {
+		rt.records.Add(requestrecord.Record{Request: c.Request, Submitted: c.Arrived})
+	}
+	if record.Runs > 0 {
+		//every run of a subscription gets its own service, the service of the previous run is removed
+		servicename = fmt.Sprintf("%s-run%d", c.Request, record.Runs)
+		if record.Service != "" && record.Service != servicename {
+			go exec.Command("docker", "service", "rm", record.Service).Run()
+		}
+	}
+	rt.records.Update(c.Request, func(r *requestrecord.Record) {
+		r.Application, r.Service, r.Node = image, servicename, targetnode
+		r.Resource, r.ResourceHash, r.Contributor = c.Resource, c.Provenance.Hash, c.Provenance.Contributor
+		r.Inputsize = predictor.Inputsize(c.Resource)
+		if len(c.Inputs) > 0 {
+			r.Inputs, r.Inputsize = nil, 0
+			for _, in := range c.Inputs {
+				r.Inputs = append(r.Inputs, in.Resource)
+				r.Inputsize += in.Size
+			}
+		}
+		if r.State != requestrecord.Cancelled {
+			r.State, r.Launched = requestrecord.Launched, time.Now()
+		}
+	})
 
-	out, err := exec.Command("docker", "service", "create", "--name", servicename, "--restart-condition", "none", "--detach",
-		"--constraint", targetnode, image).Output()
+	//the run span is the parent of the spans created by the workload, its trace context is handed to the container
+	runctx, run := tracing.Tracer.Start(c.Ctx, "run", trace.WithAttributes(attribute.String("ediro.node", targetnode)))
+	_, launch := tracing.Tracer.Start(c.Ctx, "launch")
+
+	args := []string{"service", "create", "--name", servicename, "--restart-condition", "none", "--detach",
+		"--label", requestlabel + "=" + servicename, "--mount", scratchmount(servicename),
+		"--mount", outputmount(servicename),
+		"--env", "EDIRO_REQUEST=" + c.Request, "--env", "EDIRO_OUTPUT_DIR=" + outputdir,
+		"--env", "EDIRO_CALLBACK_URL=" + rt.callbackurl(c.Request),
+		//provenance of the IoT resource, so that the workload can trace and check the data it consumes
+		"--env", "EDIRO_RESOURCE=" + c.Resource, "--env", "EDIRO_RESOURCE_HASH=" + c.Provenance.Hash,
+		"--env", "EDIRO_CONTRIBUTOR=" + c.Provenance.Contributor}
+	if len(c.Matches) > 0 {
+		//all the IoT resources resolved by the location query of the request
+		args = append(args, "--env", "EDIRO_RESOURCES="+strings.Join(c.Matches, ","))
+	}
+	args = append(args, inputsenvironment(c.Inputs)...)
+	if !record.Expires.IsZero() {
+		//a streaming workload delivers its results through the callback until the subscription expires
+		args = append(args, "--env", "EDIRO_EXPIRES="+record.Expires.Format(time.RFC3339))
+	}
+	for _, env := range tracing.Environment(runctx) {
+		args = append(args, "--env", env)
+	}
+	args = append(args, "--constraint", targetnode, image)
+
+	launched := time.Now()
+	out, err := exec.Command("docker", args...).Output()
 	if err != nil {
-		fmt.Printf("%s", err)
+		rt.logger.Error("could not create service", logging.Request, c.Request, "image", image, "node", targetnode, "err", err)
+		launch.RecordError(err)
+		launch.SetStatus(codes.Error, "service could not be created")
+		launch.End()
+		run.SetStatus(codes.Error, Servicenotcreated)
+		run.End()
+		if ctx.Err() != nil {
+			return //preempted, migrated or stopped, the request is handled by whoever cancelled it
+		}
+		//the workload never ran, its request fails and gives its capacity and its IoT resource back
+		rt.records.Update(c.Request, func(r *requestrecord.Record) {
+			r.State, r.Reason, r.Finished = requestrecord.Failed, Servicenotcreated, time.Now()
+		})
+		span := trace.SpanFromContext(c.Ctx)
+		span.SetStatus(codes.Error, Servicenotcreated)
+		span.End()
+		rt.release(c.Request)
+		return
 	}
-	fmt.Println("Command Successfully Executed")
+	rt.logger.Info("service created", logging.Request, c.Request, "image", image, "node", targetnode,
+		"service", strings.TrimSpace(string(out[:])))
+	launch.End()
+	rt.metrics.Observestage(metrics.Launch, launched)
 
 	//find resoruce corresponding to this service
 	resource := library.ApptoResource[image]
 
-	go trackcompletion(servicename, isComplete)
+	rt.running.Add(1)
+	go rt.trackcompletion(ctx, c.Ctx, c.Request, servicename, launched, run, isComplete)
 
-	go resourcemanager.ResourceMonitor(chti, isComplete)
+	go rt.catalog.ResourceMonitor(ctx, chti, isComplete)
 
 	// write to channel about the resource in use correspondig to this service
 	chti <- resource
+}
 
-	output := string(out[:])
-	fmt.Println(output)
+/*
+gather : Copies the inputs of a workload held by other edge nodes to the edge node it is placed on.
+Input: context whose cancellation stops the copies, the workload
+Output: error if an input could not be copied
+*/
+func (rt *Runtime) gather(ctx context.Context, c resourcediscovery.Resourcediscoveryoutput) error {
+	var transfers []prefetch.Transfer
+	for _, in := range c.Inputs {
+		if in.Node != c.Locationtolaunch {
+			transfers = append(transfers, prefetch.Transfer{Resource: in.Resource, From: in.Node,
+				To: c.Locationtolaunch, Provenance: in.Provenance})
+		}
+	}
+	if len(transfers) == 0 || rt.Gather == nil {
+		return nil
+	}
+	_, span := tracing.Tracer.Start(c.Ctx, "gather", trace.WithAttributes(attribute.Int("ediro.transfers",
+		len(transfers))))
+	defer span.End()
+	if err := rt.Gather(ctx, transfers); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "inputs could not be gathered")
+		return err
+	}
+	return nil
+}
 
-	elapsed = time.Since(start)
-	fmt.Println("pipeline execution time for request is: ", c.Request, elapsed)
+//inputsenvironment : returns the docker arguments handing the IoT resources of each input to the workload, e.g.
+//EDIRO_INPUT_CAMERA=frame_1,frame_2
+func inputsenvironment(inputs []resourcediscovery.Placedinput) []string {
+	var names []string
+	resources := map[string][]string{}
+	for _, in := range inputs {
+		if _, ok := resources[in.Name]; !ok {
+			names = append(names, in.Name)
+		}
+		resources[in.Name] = append(resources[in.Name], in.Resource)
+	}
+	var args []string
+	for _, name := range names {
+		args = append(args, "--env", "EDIRO_INPUT_"+strings.ToUpper(name)+"="+strings.Join(resources[name], ","))
+	}
+	return args
 }
 
 /*Createlaunchcommand : performs the following tasks:
-1. Constructs system commands to launch containers
+1. Queues the requests for admission and constructs system commands to launch containers once admitted
 2. Starts a go routine to track its completion
 3. Starts a go routine to monitor updates to the resource in use by this service. This go routine lasts
 until the previous go routine runs
@@ -78,42 +283,167 @@ Input : a structure encapsulating the following details:
 - Application/dockerfile to launch as container
 - target node in the cluster where this containerized application will be executed
 - client request which forms the name of the launched service
+It returns when ctx is cancelled.
 Output: Nil
 */
-func Createlaunchcommand(ch chan resourcediscovery.Resourcediscoveryoutput) {
-	start = time.Now()
+func (rt *Runtime) Createlaunchcommand(ctx context.Context, ch chan resourcediscovery.Resourcediscoveryoutput) {
+	//the dispatcher spawns a new goroutine to handle each admitted client request to avoid sequential processing
+	go rt.dispatch(ctx)
 	for {
-		c := <-ch
+		var c resourcediscovery.Resourcediscoveryoutput
+		select {
+		case <-ctx.Done():
+			return
+		case c = <-ch:
+		}
 
-		go launchtask(c) //spawning a new goroutine to handle each client request to avoid sequential
-		//processing and other requests waiting in the queue behind the current request being processed
+		rt.enqueue(c)
 
 	}
 
 }
 
-/* trackcompletion : This function tracks completion of a service
-Input : service launched
+/* trackcompletion : This function tracks completion of a service and ends the spans of the service and of its request.
+When ctx is cancelled the tracking stops and the service is left running in the swarm. The workload of a subscription
+whose lifetime has not ended leaves its request subscribed to run again, see package subscription.
+Input : context whose cancellation stops the tracking, context carrying the span of the request, request and service
+launched for it, time of its launch, span of the running service
 Output : done with service name written to a channel, check if we have channel for dedicated service then passing service
 name to channel isn't needed , just a done is fine
 */
-func trackcompletion(servicename string, isComplete chan bool) {
-	fmt.Println("tracking completion of : ", servicename)
+func (rt *Runtime) trackcompletion(ctx context.Context, spanctx context.Context, request string, servicename string,
+	launched time.Time, run trace.Span, isComplete chan bool) {
+	defer rt.running.Add(-1)
+	rt.logger.Debug("tracking completion of service", logging.Request, request, "service", servicename)
 	for {
-		out, err := exec.Command("docker", "service", "ps", servicename).Output()
-		if err != nil {
-			fmt.Printf("%s", err)
+		if ctx.Err() != nil {
+			if cause := context.Cause(ctx); cause == errpreempted || cause == errmigrated || cause == errstopped {
+				run.SetStatus(codes.Error, cause.Error())
+				run.End()
+				if cause == errstopped {
+					trace.SpanFromContext(spanctx).End()
+				}
+				return
+			}
+			rt.logger.Info("stopped tracking completion of service, it is left running", logging.Request, request)
+			return
 		}
-		output := string(out[:])
-		status := strings.Contains(output, "Complete")
-		if status {
-			fmt.Println("application completed, stopping resource monitoring by closing channel", servicename)
+		status, failed := rt.servicestate(servicename)
+		if status || failed {
+			state := requestrecord.Completed
+			if failed {
+				state = requestrecord.Failed
+			}
+			rt.records.Update(request, func(r *requestrecord.Record) {
+				r.State = state
+				r.Finished = time.Now()
+			})
+			if record, ok := rt.records.Get(request); ok && rt.Observer != nil {
+				rt.Observer(record)
+			}
+			rt.metrics.Observestage(metrics.Run, launched)
+			if failed {
+				run.SetStatus(codes.Error, "workload failed")
+			}
+			run.End()
+			trace.SpanFromContext(spanctx).End()
+			rt.logger.Info("application finished, stopping resource monitoring", logging.Request, request, "state", state)
+			rt.collectresult(request, servicename, launched)
+			//the result is stored before the subscription is ready to run again
+			rt.records.Update(request, func(r *requestrecord.Record) {
+				if r.State == requestrecord.Completed && r.Live() {
+					r.State = requestrecord.Subscribed
+				}
+			})
+			rt.release(request)
 			close(isComplete) //closing channel to signal completion of application
-			break
-		} else {
-			continue
+			return
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(completionpollinterval):
 		}
+	}
+}
+
+//servicestate : returns whether a service completed and whether it failed, according to the states of its tasks
+func (rt *Runtime) servicestate(servicename string) (bool, bool) {
+	out, err := exec.Command("docker", "service", "ps", servicename).Output()
+	if err != nil {
+		rt.logger.Warn("could not query service state", logging.Request, servicename, "err", err)
+	}
+	output := string(out[:])
+	return strings.Contains(output, "Complete"), strings.Contains(output, "Failed") || strings.Contains(output, "Rejected")
+}
+
+//scratchmount : every request gets its own scratch volume on the target node which is removed together with the service
+func scratchmount(servicename string) string {
+	return "type=volume,source=ediro-scratch-" + servicename + ",target=" + scratchdir +
+		",volume-label=" + requestlabel + "=" + servicename
+}
+
+//outputmount : the workload hands back its result either in a file in its output directory or through a callback, see
+//result.go
+func outputmount(servicename string) string {
+	return "type=volume,source=" + outputvolume(servicename) + ",target=" + outputdir +
+		",volume-label=" + requestlabel + "=" + servicename
+}
 
+/*
+Resume : Resumes tracking the completion of the workloads that were still running when this edge node was last
+stopped, as restored from the persisted request records. Requests that were still waiting in the pipeline were dropped
+and are marked as failed.
+Input: context whose cancellation stops the tracking
+Output: Nil
+*/
+func (rt *Runtime) Resume(ctx context.Context) {
+	for _, r := range rt.records.List() {
+		if r.Origin != "" {
+			continue //handed over with the session of its client, see package session
+		}
+		if r.State == requestrecord.Submitted {
+			//the request was dropped from the pipeline when this edge node was stopped
+			rt.records.Update(r.Request, func(r *requestrecord.Record) {
+				r.State = requestrecord.Failed
+				r.Finished = time.Now()
+			})
+			continue
+		}
+		if r.State != requestrecord.Launched {
+			continue
+		}
+		if len(r.Stages) > 0 {
+			//the stages of a pipeline are not followed across restarts, their services are reaped with the request
+			rt.records.Update(r.Request, func(r *requestrecord.Record) {
+				r.State, r.Reason, r.Finished = requestrecord.Failed, Pipelineinterrupted, time.Now()
+			})
+			continue
+		}
+		rt.logger.Info("resuming tracking of service", logging.Request, r.Request)
+		isComplete := make(chan bool)
+		rt.running.Add(1)
+		//the workload takes its capacity on its edge node again and can be preempted like any other
+		wctx, cancel := context.WithCancelCause(ctx)
+		created := make(chan struct{})
+		close(created) //the service of a resumed workload already exists
+		rt.dispatchmux.Lock()
+		rt.workloads[r.Request] = &workload{c: resourcediscovery.Resourcediscoveryoutput{Request: r.Request,
+			Applicationtolaunch: r.Application, Locationtolaunch: r.Node, Resource: r.Resource, Arrived: r.Submitted,
+			Ctx: context.Background()}, rank: library.Priorityrank[library.Priorityof(r.Request, r.Priority)],
+			launched: r.Launched, cancel: cancel, created: created}
+		rt.dispatchmux.Unlock()
+		//the spans of the request were lost with the previous run, a no-op span is ended instead
+		go rt.trackcompletion(wctx, context.Background(), r.Request, r.Service, r.Launched, trace.SpanFromContext(context.Background()),
+			isComplete)
 	}
-	fmt.Println("BROKE out of for loop to signal completion of :", servicename)
+}
+
+//Inflight : returns the number of client requests waiting for admission or whose workload is being launched
+func (rt *Runtime) Inflight() int64 {
+	return rt.inflight.Load() + int64(rt.Queued())
+}
+
+//Running : returns the number of launched workloads whose completion is being tracked
+func (rt *Runtime) Running() int64 {
+	return rt.running.Load()
 }
@@ -0,0 +1,227 @@
+package taskinitiator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/niketagrawal/EDIRO/metrics"
+	"github.com/niketagrawal/EDIRO/prefetch"
+	"github.com/niketagrawal/EDIRO/requestrecord"
+	"github.com/niketagrawal/EDIRO/resourcediscovery"
+	"github.com/niketagrawal/EDIRO/resourcemanager"
+	"github.com/niketagrawal/EDIRO/resultstore"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//newruntime : returns a task initiator with empty stores
+func newruntime() *Runtime {
+	return New("http://edge_node_1:8080", 0, resourcemanager.NewCatalog(slog.Default()), requestrecord.New(),
+		resultstore.New(), metrics.New(slog.Default()), slog.Default())
+}
+
+func TestTrackcompletion(t *testing.T) {
+	tests := []struct {
+		name         string
+		tasks        string        //output of docker service ps
+		expires      time.Duration //lifetime of the subscription left, none if zero
+		wantstate    string
+		wantcomplete bool
+	}{
+		{name: "completed", tasks: "req.1 Complete 1 second ago", wantstate: requestrecord.Completed,
+			wantcomplete: true},
+		{name: "failed", tasks: "req.1 Failed 1 second ago", wantstate: requestrecord.Failed, wantcomplete: true},
+		{name: "rejected", tasks: "req.1 Rejected 1 second ago", wantstate: requestrecord.Failed, wantcomplete: true},
+		{name: "still running", tasks: "req.1 Running 1 second ago", wantstate: requestrecord.Launched},
+		{name: "run of a subscription", tasks: "req.1 Complete 1 second ago", expires: time.Minute,
+			wantstate: requestrecord.Subscribed, wantcomplete: true},
+		{name: "last run of a subscription", tasks: "req.1 Complete 1 second ago", expires: -time.Second,
+			wantstate: requestrecord.Completed, wantcomplete: true},
+		{name: "failed run of a subscription", tasks: "req.1 Failed 1 second ago", expires: time.Minute,
+			wantstate: requestrecord.Failed, wantcomplete: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t, map[string]string{"TASKS": tt.tasks})
+			rt := newruntime()
+			record := requestrecord.Record{Request: "req", Service: "req-run1", State: requestrecord.Launched}
+			if tt.expires != 0 {
+				record.Expires = time.Now().Add(tt.expires)
+			}
+			rt.records.Add(record)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			isComplete := make(chan bool)
+			rt.running.Add(1)
+			rt.trackcompletion(ctx, context.Background(), "req", "req-run1", time.Now(), trace.SpanFromContext(ctx),
+				isComplete)
+			select {
+			case <-isComplete:
+				if !tt.wantcomplete {
+					t.Error("completion signalled for a running workload")
+				}
+			default:
+				if tt.wantcomplete {
+					t.Error("completion not signalled")
+				}
+			}
+			if r, _ := rt.records.Get("req"); r.State != tt.wantstate {
+				t.Errorf("state %s, want %s", r.State, tt.wantstate)
+			}
+			if n := rt.Running(); n != 0 {
+				t.Errorf("%d workloads tracked after the tracking stopped", n)
+			}
+		})
+	}
+}
+
+func TestLaunchtask(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantstate   string
+		wantreason  string
+		wantclaimed bool //whether the IoT resource of the workload is still claimed
+	}{
+		{name: "service created", env: map[string]string{"TASKS": "Running"}, wantstate: requestrecord.Launched,
+			wantclaimed: true},
+		{name: "service not created", env: map[string]string{"CREATEFAIL": "1"}, wantstate: requestrecord.Failed,
+			wantreason: Servicenotcreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakedocker(t, tt.env)
+			rt := newruntime()
+			rt.catalog.Add("r", "n1", resourcemanager.Provenance{})
+			rt.catalog.Claim("r", "n1")
+			rt.records.Add(requestrecord.Record{Request: "req", State: requestrecord.Submitted})
+			c := resourcediscovery.Resourcediscoveryoutput{Request: "req", Applicationtolaunch: "app",
+				Locationtolaunch: "n1", Resource: "r", Arrived: time.Now(), Ctx: context.Background()}
+			ctx, cancel := context.WithCancelCause(context.Background())
+			creating := make(chan struct{})
+			rt.workloads["req"] = &workload{c: c, cancel: cancel, created: creating}
+			rt.inflight.Add(1)
+			rt.launchtask(ctx, c, creating)
+			cancel(nil)
+			select {
+			case <-creating:
+			default:
+				t.Error("launch over without signalling the creation of the service")
+			}
+			for deadline := time.Now().Add(5 * time.Second); rt.Running() != 0; time.Sleep(time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("tracking did not stop once cancelled")
+				}
+			}
+			r, _ := rt.records.Get("req")
+			if r.State != tt.wantstate || r.Reason != tt.wantreason {
+				t.Errorf("state %s (%s), want %s (%s)", r.State, r.Reason, tt.wantstate, tt.wantreason)
+			}
+			if claimed := !rt.catalog.Has("r"); claimed != tt.wantclaimed {
+				t.Errorf("resource claimed %v, want %v", claimed, tt.wantclaimed)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		tasks       string
+		wantstate   string
+		wanttracked []string //services whose tasks are queried
+	}{
+		{name: "workload completed meanwhile", tasks: "Complete", wantstate: requestrecord.Completed,
+			wanttracked: []string{"launched"}},
+		{name: "workload still running", tasks: "Running", wantstate: requestrecord.Launched,
+			wanttracked: []string{"launched"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakedocker(t, map[string]string{"TASKS": tt.tasks})
+			rt := newruntime()
+			rt.records.Add(requestrecord.Record{Request: "launched", Service: "launched",
+				State: requestrecord.Launched})
+			rt.records.Add(requestrecord.Record{Request: "reaped", Service: "reaped", State: requestrecord.Reaped})
+			ctx, cancel := context.WithCancel(context.Background())
+			rt.Resume(ctx)
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			for deadline := time.Now().Add(5 * time.Second); rt.Running() != 0; time.Sleep(time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("tracking did not stop once cancelled")
+				}
+			}
+			if r, _ := rt.records.Get("launched"); r.State != tt.wantstate {
+				t.Errorf("state %s, want %s", r.State, tt.wantstate)
+			}
+			tracked := map[string]bool{}
+			for _, call := range calls() {
+				if service, ok := strings.CutPrefix(call, "service ps "); ok {
+					tracked[service] = true
+				}
+			}
+			if len(tracked) != len(tt.wanttracked) || !tracked[tt.wanttracked[0]] {
+				t.Errorf("tracked %v, want %v", tracked, tt.wanttracked)
+			}
+		})
+	}
+}
+
+func TestGather(t *testing.T) {
+	inputs := []resourcediscovery.Placedinput{{Name: "map", Resource: "map_1", Node: "n1"},
+		{Name: "camera", Resource: "frame_1", Node: "n2"}, {Name: "camera", Resource: "frame_2", Node: "n3"}}
+	tests := []struct {
+		name      string
+		gather    bool  //whether the task initiator copies the inputs
+		gathererr error //error of the copies
+		node      string
+		wanterr   bool
+		//wantfrom : edge nodes the inputs are copied from
+		wantfrom []string
+	}{
+		{name: "inputs held by other edge nodes", gather: true, node: "n1", wantfrom: []string{"n2", "n3"}},
+		{name: "inputs held by the edge node", gather: true, node: "n4", wantfrom: []string{"n1", "n2", "n3"}},
+		{name: "copy failed", gather: true, gathererr: errors.New("transfer failed"), node: "n1", wanterr: true,
+			wantfrom: []string{"n2", "n3"}},
+		{name: "workload fetching its inputs", node: "n1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newruntime()
+			var from []string
+			if tt.gather {
+				rt.Gather = func(ctx context.Context, transfers []prefetch.Transfer) error {
+					for _, transfer := range transfers {
+						if transfer.To != tt.node {
+							t.Errorf("input %s copied to %s", transfer.Resource, transfer.To)
+						}
+						from = append(from, transfer.From)
+					}
+					return tt.gathererr
+				}
+			}
+			err := rt.gather(context.Background(), resourcediscovery.Resourcediscoveryoutput{
+				Locationtolaunch: tt.node, Inputs: inputs, Ctx: context.Background()})
+			if (err != nil) != tt.wanterr || strings.Join(from, ",") != strings.Join(tt.wantfrom, ",") {
+				t.Errorf("gather = %v copying from %v, want error %v copying from %v", err, from, tt.wanterr,
+					tt.wantfrom)
+			}
+		})
+	}
+}
+
+func TestInputsenvironment(t *testing.T) {
+	inputs := []resourcediscovery.Placedinput{{Name: "map", Resource: "map_1"},
+		{Name: "camera", Resource: "frame_1"}, {Name: "camera", Resource: "frame_2"}}
+	want := []string{"--env", "EDIRO_INPUT_MAP=map_1", "--env", "EDIRO_INPUT_CAMERA=frame_1,frame_2"}
+	if got := inputsenvironment(inputs); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("inputsenvironment = %v, want %v", got, want)
+	}
+	if got := inputsenvironment(nil); len(got) != 0 {
+		t.Errorf("inputsenvironment without inputs = %v", got)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+This package implements the tracing of EDIRO with OpenTelemetry. Every client request is traced as one span with a child
+span for each stage it goes through (parse, discovery, launch, run). The trace context is propagated to other edge nodes
+over the gRPC calls of the Frontend service and to the workloads through the TRACEPARENT environment variable of their
+container, so that one trace shows the whole life of a request across the cluster.
+The spans are exported over OTLP to a collector or written to a file for offline runs.
+
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Exporters that can be selected to export the spans
+const (
+	None = "none"
+	OTLP = "otlp"
+	File = "file"
+)
+
+//Tracer : the tracer used by all modules of EDIRO to create spans
+var Tracer trace.Tracer = otel.Tracer("github.com/niketagrawal/EDIRO")
+
+/*
+Init : Sets up the exporter of the spans and the propagation of the trace context. With the exporter 'none' spans are
+created but dropped.
+Input: exporter to use, endpoint of the OTLP collector (host:port) or path of the file to write to, name of this
+edge node
+Output: function to flush and stop the exporter, error if the exporter could not be created
+*/
+func Init(exporter string, endpoint string, nodeID string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exp sdktrace.SpanExporter
+	var file *os.File //the file the spans are written to, closed once they are flushed
+	switch exporter {
+	case None:
+		return func(context.Context) error { return nil }, nil
+	case OTLP:
+		e, err := otlptracegrpc.New(context.Background(), otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithInsecure())
+		if err != nil {
+			return nil, err
+		}
+		exp = e
+	case File:
+		f, err := os.Create(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		exp, file = e, f
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName("ediro"), semconv.ServiceInstanceID(nodeID))),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+/*
+Environment : Renders the trace context of ctx as environment variables to be passed to a container, e.g.
+TRACEPARENT=00-<trace id>-<span id>-01
+Input: context carrying the span of the workload
+Output: list of environment variables of the form NAME=value
+*/
+func Environment(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	var env []string
+	for key, value := range carrier {
+		env = append(env, strings.ToUpper(key)+"="+value)
+	}
+	return env
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name      string
+		exporter  string
+		wanterr   bool
+		wantspans bool //whether the spans are written to the file
+	}{
+		{name: "no exporter", exporter: None},
+		{name: "file", exporter: File, wantspans: true},
+		{name: "unknown exporter", exporter: "jaeger", wanterr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spans.json")
+			shutdown, err := Init(tt.exporter, path, "edge_node_1")
+			if (err != nil) != tt.wanterr {
+				t.Fatalf("Init = %v, want error %v", err, tt.wanterr)
+			}
+			if err != nil {
+				return
+			}
+			_, span := Tracer.Start(context.Background(), "request")
+			span.End()
+			if err := shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := os.ReadFile(path)
+			if spans := strings.Contains(string(data), `"Name":"request"`); spans != tt.wantspans {
+				t.Errorf("spans written %v, want %v: %s", spans, tt.wantspans, data)
+			}
+			if tt.wantspans && !strings.Contains(string(data), "edge_node_1") {
+				t.Errorf("node ID missing from the resource of the spans: %s", data)
+			}
+		})
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	shutdown, err := Init(File, filepath.Join(t.TempDir(), "spans.json"), "edge_node_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+	traced, span := Tracer.Start(context.Background(), "run")
+	defer span.End()
+	traceparent := "^TRACEPARENT=00-" + span.SpanContext().TraceID().String() + "-" +
+		span.SpanContext().SpanID().String() + "-01$"
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *regexp.Regexp //nil for no environment variables
+	}{
+		{name: "span", ctx: traced, want: regexp.MustCompile(traceparent)},
+		{name: "no span", ctx: context.Background()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := Environment(tt.ctx)
+			if tt.want == nil {
+				if len(env) != 0 {
+					t.Errorf("Environment = %v, want none", env)
+				}
+				return
+			}
+			if len(env) != 1 || !tt.want.MatchString(env[0]) {
+				t.Errorf("Environment = %v, want %s", env, tt.want)
+			}
+		})
+	}
+}